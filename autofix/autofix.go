@@ -0,0 +1,184 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+// Package autofix applies the fixes attached to linting results, directly to the source of a document.
+// Edits are made to the raw text rather than by re-rendering the document, so comments, quoting and
+// formatting are preserved.
+package autofix
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/daveshanley/vacuum/model"
+)
+
+// TextEdit is a fix edit, converted into a change to the raw text of a document. Start and End are byte
+// offsets (End is exclusive), lines and characters are zero based and characters are UTF-16 code units (ready for
+// the language server).
+type TextEdit struct {
+	Start          int
+	End            int
+	NewText        string
+	StartLine      int
+	StartCharacter int
+	EndLine        int
+	EndCharacter   int
+}
+
+// SkippedFix is a fix that could not be applied, and the reason why.
+type SkippedFix struct {
+	Result *model.RuleFunctionResult
+	Reason string
+}
+
+// Result is the outcome of applying fixes to a document.
+type Result struct {
+	Fixed   []byte                      // the document, with all applied fixes.
+	Edits   []TextEdit                  // all the text edits made, ordered by position.
+	Applied []*model.RuleFunctionResult // results that were fixed.
+	Skipped []*SkippedFix               // results that have a fix that could not be applied.
+}
+
+// ResolveFix converts all the edits of a fix into text edits against the supplied document.
+func ResolveFix(spec []byte, fix *model.Fix) ([]TextEdit, error) {
+	return resolveFix(newSource(spec), fix)
+}
+
+func resolveFix(s *source, fix *model.Fix) ([]TextEdit, error) {
+	if fix == nil || len(fix.Edits) == 0 {
+		return nil, fmt.Errorf("fix has no edits")
+	}
+	var edits []TextEdit
+	for _, e := range fix.Edits {
+		te, err := s.resolveEdit(e)
+		if err != nil {
+			return nil, err
+		}
+		for _, existing := range edits {
+			if te.overlaps(existing) {
+				return nil, fmt.Errorf("fix '%s' contains overlapping edits", fix.Description)
+			}
+		}
+		edits = append(edits, te)
+	}
+	return edits, nil
+}
+
+// ApplyFixes applies the fixes of all the supplied results to a document. Each fix is applied atomically, if
+// any of its edits cannot be resolved, or they overlap with an edit of a fix already accepted, then the whole
+// fix is skipped (running again after the document has been fixed will usually pick those up). Multiple
+// results with an identical fix (for example, one collection out of order in multiple places) are only
+// applied once. All results must belong to the supplied document, see GroupByFile.
+func ApplyFixes(spec []byte, results []*model.RuleFunctionResult) *Result {
+	s := newSource(spec)
+	res := &Result{}
+
+	ordered := make([]*model.RuleFunctionResult, 0, len(results))
+	for _, r := range results {
+		if r != nil && r.Fix != nil {
+			ordered = append(ordered, r)
+		}
+	}
+	// results are generated concurrently, make sure conflicts are always resolved the same way.
+	sort.SliceStable(ordered, func(i, j int) bool {
+		li, ci := fixPosition(ordered[i])
+		lj, cj := fixPosition(ordered[j])
+		if li != lj {
+			return li < lj
+		}
+		if ci != cj {
+			return ci < cj
+		}
+		return ruleId(ordered[i]) < ruleId(ordered[j])
+	})
+
+	for _, r := range ordered {
+		edits, err := resolveFix(s, r.Fix)
+		if err != nil {
+			res.Skipped = append(res.Skipped, &SkippedFix{Result: r, Reason: err.Error()})
+			continue
+		}
+		var accepted []TextEdit
+		conflict := false
+		for _, e := range edits {
+			duplicate := false
+			for _, existing := range res.Edits {
+				if e == existing {
+					duplicate = true
+					break
+				}
+				if e.overlaps(existing) {
+					conflict = true
+					break
+				}
+			}
+			if conflict {
+				break
+			}
+			if !duplicate {
+				accepted = append(accepted, e)
+			}
+		}
+		if conflict {
+			res.Skipped = append(res.Skipped, &SkippedFix{Result: r,
+				Reason: "fix conflicts with another fix, run again to apply it"})
+			continue
+		}
+		res.Edits = append(res.Edits, accepted...)
+		res.Applied = append(res.Applied, r)
+	}
+
+	sort.Slice(res.Edits, func(i, j int) bool {
+		return res.Edits[i].Start < res.Edits[j].Start
+	})
+
+	// apply from the bottom up, so offsets stay valid.
+	fixed := append([]byte(nil), spec...)
+	for i := len(res.Edits) - 1; i >= 0; i-- {
+		e := res.Edits[i]
+		fixed = append(fixed[:e.Start], append([]byte(e.NewText), fixed[e.End:]...)...)
+	}
+	res.Fixed = fixed
+	return res
+}
+
+// GroupByFile groups all results that carry a fix, by the file they were found in. Results without an origin
+// belong to the root specification, identified by specFileName.
+func GroupByFile(specFileName string, results []*model.RuleFunctionResult) map[string][]*model.RuleFunctionResult {
+	grouped := make(map[string][]*model.RuleFunctionResult)
+	for _, r := range results {
+		if r == nil || r.Fix == nil {
+			continue
+		}
+		file := specFileName
+		if r.Origin != nil && r.Origin.AbsoluteLocation != "" {
+			file = r.Origin.AbsoluteLocation
+		}
+		grouped[file] = append(grouped[file], r)
+	}
+	return grouped
+}
+
+// overlaps checks if two edits touch the same text. Insertions conflict with any edit that touches their
+// position, as the order of the result would be ambiguous.
+func (e TextEdit) overlaps(o TextEdit) bool {
+	if e.Start == e.End || o.Start == o.End {
+		return e.Start <= o.End && o.Start <= e.End
+	}
+	return e.Start < o.End && o.Start < e.End
+}
+
+func fixPosition(r *model.RuleFunctionResult) (int, int) {
+	if len(r.Fix.Edits) == 0 {
+		return 0, 0
+	}
+	return r.Fix.Edits[0].Line, r.Fix.Edits[0].Column
+}
+
+func ruleId(r *model.RuleFunctionResult) string {
+	if r.RuleId == "" && r.Rule != nil {
+		return r.Rule.Id
+	}
+	return r.RuleId
+}
@@ -0,0 +1,270 @@
+package autofix
+
+import (
+	"testing"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func parse(t *testing.T, spec string) *yaml.Node {
+	var root yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(spec), &root))
+	return root.Content[0]
+}
+
+func value(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func resultWithFix(edits ...model.FixEdit) *model.RuleFunctionResult {
+	return &model.RuleFunctionResult{RuleId: "test", Fix: &model.Fix{Description: "test", Edits: edits}}
+}
+
+func TestApplyFixes_Reorder_YAML(t *testing.T) {
+	spec := `openapi: 3.1.0
+tags:
+  # zebras are great
+  - name: zebra
+    description: stripes
+  - name: apple # red or green
+  - name: mango
+info:
+  title: test
+`
+	root := parse(t, spec)
+	_, tags := value(root, "tags")
+
+	res := ApplyFixes([]byte(spec), []*model.RuleFunctionResult{resultWithFix(model.NewReorderEdit(tags, []int{1, 2, 0}))})
+	assert.Len(t, res.Applied, 1)
+	assert.Equal(t, `openapi: 3.1.0
+tags:
+  # zebras are great
+  - name: apple # red or green
+  - name: mango
+  - name: zebra
+    description: stripes
+info:
+  title: test
+`, string(res.Fixed))
+}
+
+func TestApplyFixes_Reorder_JSON(t *testing.T) {
+	spec := `{
+  "tags": [
+    {"name": "zebra"},
+    {"name": "apple", "description": "a, b]"}
+  ]
+}`
+	root := parse(t, spec)
+	_, tags := value(root, "tags")
+
+	res := ApplyFixes([]byte(spec), []*model.RuleFunctionResult{resultWithFix(model.NewReorderEdit(tags, []int{1, 0}))})
+	assert.Len(t, res.Applied, 1)
+	assert.Equal(t, `{
+  "tags": [
+    {"name": "apple", "description": "a, b]"},
+    {"name": "zebra"}
+  ]
+}`, string(res.Fixed))
+}
+
+func TestApplyFixes_Replace(t *testing.T) {
+	spec := `paths:
+  /pets/:
+    get: {}
+  '/cake''s/':
+    get: {}
+  "/fooBar":
+    get: {}
+`
+	root := parse(t, spec)
+	_, paths := value(root, "paths")
+
+	res := ApplyFixes([]byte(spec), []*model.RuleFunctionResult{
+		resultWithFix(model.NewReplaceEdit(paths.Content[0], "/pets")),
+		resultWithFix(model.NewReplaceEdit(paths.Content[2], "/cake's")),
+		resultWithFix(model.NewReplaceEdit(paths.Content[4], "/foo-bar")),
+	})
+	assert.Len(t, res.Applied, 3)
+	assert.Equal(t, `paths:
+  /pets:
+    get: {}
+  '/cake''s':
+    get: {}
+  "/foo-bar":
+    get: {}
+`, string(res.Fixed))
+}
+
+func TestApplyFixes_Delete(t *testing.T) {
+	spec := `a:
+  enum:
+    - one
+    - two
+    - one
+  flow: [one, two, one]
+b: true
+`
+	root := parse(t, spec)
+	_, a := value(root, "a")
+	_, enum := value(a, "enum")
+	_, flow := value(a, "flow")
+
+	res := ApplyFixes([]byte(spec), []*model.RuleFunctionResult{
+		resultWithFix(model.NewDeleteEdit(enum, enum.Content[2])),
+		resultWithFix(model.NewDeleteEdit(flow, flow.Content[2])),
+	})
+	assert.Len(t, res.Applied, 2)
+	assert.Equal(t, `a:
+  enum:
+    - one
+    - two
+  flow: [one, two]
+b: true
+`, string(res.Fixed))
+}
+
+func TestApplyFixes_Insert_YAML(t *testing.T) {
+	spec := `openapi: 3.1.0
+info:
+    title: test # the title
+    version: 1.0.0
+paths: {}
+`
+	root := parse(t, spec)
+	_, info := value(root, "info")
+	contact := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "name"}, {Kind: yaml.ScalarNode, Tag: "!!str", Value: ""},
+	}}
+
+	res := ApplyFixes([]byte(spec), []*model.RuleFunctionResult{resultWithFix(model.NewInsertEdit(info, "contact", contact))})
+	assert.Len(t, res.Applied, 1)
+	assert.Equal(t, `openapi: 3.1.0
+info:
+    title: test # the title
+    version: 1.0.0
+    contact:
+        name: ""
+paths: {}
+`, string(res.Fixed))
+}
+
+func TestApplyFixes_Insert_JSON(t *testing.T) {
+	spec := `{
+  "info": {
+    "title": "test"
+  },
+  "paths": {}
+}`
+	root := parse(t, spec)
+	_, info := value(root, "info")
+	_, paths := value(root, "paths")
+	contact := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "name"}, {Kind: yaml.ScalarNode, Tag: "!!str", Value: ""},
+	}}
+
+	res := ApplyFixes([]byte(spec), []*model.RuleFunctionResult{
+		resultWithFix(model.NewInsertEdit(info, "contact", contact)),
+		resultWithFix(model.NewInsertEdit(paths, "/pets", &yaml.Node{Kind: yaml.MappingNode})),
+	})
+	assert.Len(t, res.Applied, 2)
+	assert.Equal(t, `{
+  "info": {
+    "title": "test",
+    "contact": {
+      "name": ""
+    }
+  },
+  "paths": {"/pets": {}}
+}`, string(res.Fixed))
+}
+
+func TestApplyFixes_ConflictsAndDuplicates(t *testing.T) {
+	spec := `tags: [b, a]
+paths:
+  /fooBar/: {}
+`
+	root := parse(t, spec)
+	_, tags := value(root, "tags")
+	_, paths := value(root, "paths")
+
+	reorder := model.NewReorderEdit(tags, []int{1, 0})
+	res := ApplyFixes([]byte(spec), []*model.RuleFunctionResult{
+		resultWithFix(reorder),
+		resultWithFix(reorder),
+		resultWithFix(model.NewReplaceEdit(paths.Content[0], "/fooBar")),
+		resultWithFix(model.NewReplaceEdit(paths.Content[0], "/foo-bar/")),
+	})
+	assert.Len(t, res.Applied, 3)
+	assert.Len(t, res.Skipped, 1)
+	assert.Equal(t, `tags: [a, b]
+paths:
+  /fooBar: {}
+`, string(res.Fixed))
+}
+
+func TestApplyFixes_NodeNotInDocument(t *testing.T) {
+	spec := `info:
+  title: test
+`
+	other := parse(t, `info:
+  titles: nope
+`)
+	_, info := value(other, "info")
+
+	res := ApplyFixes([]byte(spec), []*model.RuleFunctionResult{resultWithFix(model.NewReplaceEdit(info.Content[1], "yes"))})
+	assert.Len(t, res.Applied, 0)
+	assert.Len(t, res.Skipped, 1)
+	assert.Equal(t, spec, string(res.Fixed))
+}
+
+func TestResolveFix_Position(t *testing.T) {
+	spec := `info:
+  title: test
+`
+	root := parse(t, spec)
+	_, info := value(root, "info")
+
+	edits, err := ResolveFix([]byte(spec), &model.Fix{Edits: []model.FixEdit{model.NewReplaceEdit(info.Content[1], "pizza")}})
+	assert.NoError(t, err)
+	assert.Len(t, edits, 1)
+	assert.Equal(t, 1, edits[0].StartLine)
+	assert.Equal(t, 9, edits[0].StartCharacter)
+	assert.Equal(t, 1, edits[0].EndLine)
+	assert.Equal(t, 13, edits[0].EndCharacter)
+	assert.Equal(t, "pizza", edits[0].NewText)
+}
+
+func TestResolveFix_PositionUTF16(t *testing.T) {
+	spec := `info:
+  title: "🍕 é"
+`
+	root := parse(t, spec)
+	_, info := value(root, "info")
+
+	// the emoji is two UTF-16 code units, é is one.
+	edits, err := ResolveFix([]byte(spec), &model.Fix{Edits: []model.FixEdit{model.NewReplaceEdit(info.Content[1], "pizza")}})
+	assert.NoError(t, err)
+	assert.Len(t, edits, 1)
+	assert.Equal(t, 9, edits[0].StartCharacter)
+	assert.Equal(t, 15, edits[0].EndCharacter)
+	assert.Equal(t, len(spec)-1, edits[0].End)
+}
+
+func TestGroupByFile(t *testing.T) {
+	results := []*model.RuleFunctionResult{
+		resultWithFix(),
+		{RuleId: "nofix"},
+		resultWithFix(),
+	}
+	results[2].Origin = nil
+	grouped := GroupByFile("spec.yaml", results)
+	assert.Len(t, grouped["spec.yaml"], 2)
+}
@@ -0,0 +1,334 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package autofix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/daveshanley/vacuum/model"
+	"gopkg.in/yaml.v3"
+)
+
+// resolveEdit converts a single node anchored edit into a text edit.
+func (s *source) resolveEdit(edit model.FixEdit) (TextEdit, error) {
+	if edit.Node == nil {
+		return TextEdit{}, fmt.Errorf("%s edit has no node", edit.Kind)
+	}
+	switch edit.Kind {
+	case model.FixReplace:
+		if edit.Order != nil {
+			return s.resolveReorder(edit)
+		}
+		return s.resolveReplace(edit)
+	case model.FixDelete:
+		return s.resolveDelete(edit)
+	case model.FixInsert:
+		return s.resolveInsert(edit)
+	}
+	return TextEdit{}, fmt.Errorf("unknown edit kind '%s'", edit.Kind)
+}
+
+func (s *source) resolveReplace(edit model.FixEdit) (TextEdit, error) {
+	if edit.Node.Kind != yaml.ScalarNode {
+		return TextEdit{}, fmt.Errorf("only scalars can be replaced, node at %d:%d is not a scalar",
+			edit.Node.Line, edit.Node.Column)
+	}
+	sp, err := s.scalarSpan(edit.Node)
+	if err != nil {
+		return TextEdit{}, err
+	}
+	text, err := renderScalar(edit.NewValue, s.data[sp.start])
+	if err != nil {
+		return TextEdit{}, err
+	}
+	return s.textEdit(sp.start, sp.end, text), nil
+}
+
+func (s *source) resolveReorder(edit model.FixEdit) (TextEdit, error) {
+	spans, _, err := s.entries(edit.Node)
+	if err != nil {
+		return TextEdit{}, err
+	}
+	if len(edit.Order) != len(spans) {
+		return TextEdit{}, fmt.Errorf("order has %d entries, collection at %d:%d has %d",
+			len(edit.Order), edit.Node.Line, edit.Node.Column, len(spans))
+	}
+	seen := make([]bool, len(spans))
+	for _, o := range edit.Order {
+		if o < 0 || o >= len(spans) || seen[o] {
+			return TextEdit{}, fmt.Errorf("order is not a valid permutation of the collection at %d:%d",
+				edit.Node.Line, edit.Node.Column)
+		}
+		seen[o] = true
+	}
+
+	// entries swap places, anything in between them (separators, comments) stays exactly where it is.
+	var buf bytes.Buffer
+	for i, o := range edit.Order {
+		buf.Write(s.data[spans[o].start:spans[o].end])
+		if i+1 < len(spans) {
+			buf.Write(s.data[spans[i].end:spans[i+1].start])
+		}
+	}
+	return s.textEdit(spans[0].start, spans[len(spans)-1].end, buf.String()), nil
+}
+
+func (s *source) resolveDelete(edit model.FixEdit) (TextEdit, error) {
+	parent := edit.Parent
+	if parent == nil || (parent.Kind != yaml.SequenceNode && parent.Kind != yaml.MappingNode) {
+		return TextEdit{}, fmt.Errorf("delete edit at %d:%d needs a parent collection", edit.Node.Line, edit.Node.Column)
+	}
+	step := 1
+	if parent.Kind == yaml.MappingNode {
+		step = 2
+	}
+	idx := -1
+	for i, c := range parent.Content {
+		if c == edit.Node {
+			idx = i / step
+			break
+		}
+	}
+	if idx < 0 {
+		return TextEdit{}, fmt.Errorf("node at %d:%d is not part of its parent", edit.Node.Line, edit.Node.Column)
+	}
+	spans, flow, err := s.entries(parent)
+	if err != nil {
+		return TextEdit{}, err
+	}
+	target := spans[idx]
+	if flow {
+		switch {
+		case len(spans) == 1:
+			return s.textEdit(target.start, target.end, ""), nil
+		case idx > 0:
+			return s.textEdit(spans[idx-1].end, target.end, ""), nil
+		default:
+			return s.textEdit(target.start, spans[1].start, ""), nil
+		}
+	}
+	if len(spans) == 1 {
+		return TextEdit{}, fmt.Errorf("cannot remove the only entry of the collection at %d:%d",
+			parent.Line, parent.Column)
+	}
+	// remove the entire lines, including the line break.
+	if target.end < len(s.data) {
+		return s.textEdit(target.start, target.end+1, ""), nil
+	}
+	return s.textEdit(target.start-1, target.end, ""), nil
+}
+
+func (s *source) resolveInsert(edit model.FixEdit) (TextEdit, error) {
+	node := edit.Node
+	if node.Kind != yaml.MappingNode {
+		return TextEdit{}, fmt.Errorf("can only insert into a mapping, node at %d:%d is not a mapping",
+			node.Line, node.Column)
+	}
+	if edit.Value == nil || edit.Key == "" {
+		return TextEdit{}, fmt.Errorf("insert edit at %d:%d needs a key and a value", node.Line, node.Column)
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == edit.Key {
+			return TextEdit{}, fmt.Errorf("key '%s' already exists in mapping at %d:%d", edit.Key, node.Line, node.Column)
+		}
+	}
+
+	if s.isFlow(node) {
+		start, err := s.nodeStart(node)
+		if err != nil {
+			return TextEdit{}, err
+		}
+		end, err := s.matchBracket(start)
+		if err != nil {
+			return TextEdit{}, err
+		}
+		key := jsonString(edit.Key)
+		if len(node.Content) == 0 {
+			return s.textEdit(end-1, end-1, key+": "+renderJSON(edit.Value, "", "")), nil
+		}
+		firstKey, err := s.nodeStart(node.Content[0])
+		if err != nil {
+			return TextEdit{}, err
+		}
+		lastEnd, err := s.nodeEnd(node.Content[len(node.Content)-1])
+		if err != nil {
+			return TextEdit{}, err
+		}
+		if s.lineStart(firstKey) == s.lineStart(start) {
+			return s.textEdit(lastEnd, lastEnd, ", "+key+": "+renderJSON(edit.Value, "", "")), nil
+		}
+		prefix := string(s.data[s.lineStart(firstKey):firstKey])
+		unit := strings.Repeat(" ", s.indent)
+		return s.textEdit(lastEnd, lastEnd,
+			",\n"+prefix+key+": "+renderJSON(edit.Value, prefix, unit)), nil
+	}
+
+	if len(node.Content) == 0 {
+		return TextEdit{}, fmt.Errorf("unable to insert into an empty mapping at %d:%d", node.Line, node.Column)
+	}
+	lastEnd, err := s.nodeEnd(node.Content[len(node.Content)-1])
+	if err != nil {
+		return TextEdit{}, err
+	}
+	rendered, err := s.renderYAML(edit.Key, edit.Value)
+	if err != nil {
+		return TextEdit{}, err
+	}
+	prefix := strings.Repeat(" ", node.Content[0].Column-1)
+	var buf strings.Builder
+	for _, line := range strings.Split(rendered, "\n") {
+		buf.WriteString("\n")
+		if line != "" {
+			buf.WriteString(prefix + line)
+		}
+	}
+	pos := s.lineEnd(lastEnd)
+	return s.textEdit(pos, pos, buf.String()), nil
+}
+
+// entries locates every entry (a sequence item, or a key/value pair of a mapping) of a collection. Entries of
+// block collections cover entire lines, entries of flow collections only cover the entry itself.
+func (s *source) entries(node *yaml.Node) ([]span, bool, error) {
+	if node.Kind != yaml.SequenceNode && node.Kind != yaml.MappingNode {
+		return nil, false, fmt.Errorf("node at %d:%d is not a collection", node.Line, node.Column)
+	}
+	flow := s.isFlow(node)
+	step := 1
+	if node.Kind == yaml.MappingNode {
+		step = 2
+	}
+	var spans []span
+	for i := 0; i+step-1 < len(node.Content); i += step {
+		start, err := s.nodeStart(node.Content[i])
+		if err != nil {
+			return nil, flow, err
+		}
+		end, err := s.nodeEnd(node.Content[i+step-1])
+		if err != nil {
+			return nil, flow, err
+		}
+		if !flow {
+			if node.Kind == yaml.SequenceNode {
+				dash := start - 1
+				for dash >= 0 && isSpace(s.data[dash]) {
+					dash--
+				}
+				if dash < 0 || s.data[dash] != '-' {
+					return nil, flow, fmt.Errorf("unable to locate sequence item at %d:%d",
+						node.Content[i].Line, node.Content[i].Column)
+				}
+				start = dash
+			}
+			ls := s.lineStart(start)
+			if !s.onlySpaces(ls, start) {
+				return nil, flow, fmt.Errorf("entry at %d:%d shares a line with another node",
+					node.Content[i].Line, node.Content[i].Column)
+			}
+			start, end = ls, s.lineEnd(end)
+		}
+		if len(spans) > 0 && start < spans[len(spans)-1].end {
+			return nil, flow, fmt.Errorf("entries of the collection at %d:%d overlap", node.Line, node.Column)
+		}
+		spans = append(spans, span{start, end})
+	}
+	if len(spans) == 0 {
+		return nil, flow, fmt.Errorf("collection at %d:%d is empty", node.Line, node.Column)
+	}
+	return spans, flow, nil
+}
+
+func (s *source) textEdit(start, end int, text string) TextEdit {
+	sl, sc := s.position(start)
+	el, ec := s.position(end)
+	return TextEdit{
+		Start:          start,
+		End:            end,
+		NewText:        text,
+		StartLine:      sl,
+		StartCharacter: sc,
+		EndLine:        el,
+		EndCharacter:   ec,
+	}
+}
+
+// renderYAML renders a new key and value as block YAML, using the indentation of the document.
+func (s *source) renderYAML(key string, value *yaml.Node) (string, error) {
+	doc := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value,
+	}}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(s.indent)
+	if err := enc.Encode(doc); err != nil {
+		return "", err
+	}
+	_ = enc.Close()
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// renderScalar renders a new scalar value, using the same style of quoting as the token it replaces.
+func renderScalar(value string, style byte) (string, error) {
+	switch style {
+	case '"':
+		return jsonString(value), nil
+	case '\'':
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'", nil
+	case '|', '>':
+		return "", fmt.Errorf("replacing block scalars is not supported")
+	}
+	out, err := yaml.Marshal(&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+	if err != nil {
+		return "", err
+	}
+	rendered := strings.TrimRight(string(out), "\n")
+	if strings.Contains(rendered, "\n") {
+		return "", fmt.Errorf("value '%s' cannot be written on a single line", value)
+	}
+	return rendered, nil
+}
+
+// renderJSON renders a node as JSON, keeping the order of keys. If unit is empty, then the output is compact.
+func renderJSON(node *yaml.Node, prefix, unit string) string {
+	var open, closing string
+	var parts []string
+	switch node.Kind {
+	case yaml.MappingNode:
+		open, closing = "{", "}"
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			parts = append(parts, jsonString(node.Content[i].Value)+": "+renderJSON(node.Content[i+1], prefix+unit, unit))
+		}
+	case yaml.SequenceNode:
+		open, closing = "[", "]"
+		for _, c := range node.Content {
+			parts = append(parts, renderJSON(c, prefix+unit, unit))
+		}
+	default:
+		switch node.ShortTag() {
+		case "!!int", "!!float", "!!bool":
+			return node.Value
+		case "!!null":
+			return "null"
+		}
+		return jsonString(node.Value)
+	}
+	if len(parts) == 0 {
+		return open + closing
+	}
+	if unit == "" {
+		return open + strings.Join(parts, ", ") + closing
+	}
+	inner := prefix + unit
+	return open + "\n" + inner + strings.Join(parts, ",\n"+inner) + "\n" + prefix + closing
+}
+
+func jsonString(value string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(value)
+	return strings.TrimRight(buf.String(), "\n")
+}
@@ -0,0 +1,298 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package autofix
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/pb33f/libopenapi/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// source wraps the raw bytes of a document, and knows how to find the exact location of nodes in it.
+// Nodes only carry the line and column they start at, everything else is worked out from the text, so that
+// comments and formatting are left untouched when edits are applied.
+type source struct {
+	data   []byte
+	lines  []int // offset of the first byte of each line.
+	indent int   // the indentation unit used by the document.
+}
+
+// span is a range of bytes in a document, end is exclusive.
+type span struct {
+	start, end int
+}
+
+func newSource(data []byte) *source {
+	s := &source{data: data, lines: []int{0}}
+	for i, b := range data {
+		if b == '\n' {
+			s.lines = append(s.lines, i+1)
+		}
+	}
+	s.indent = utils.DetermineWhitespaceLength(string(data))
+	if s.indent <= 0 {
+		s.indent = 2
+	}
+	return s
+}
+
+// offset converts a 1 based line and (character) column into a byte offset.
+func (s *source) offset(line, column int) (int, error) {
+	if line < 1 || line > len(s.lines) || column < 1 {
+		return 0, fmt.Errorf("position %d:%d is outside of the document", line, column)
+	}
+	pos := s.lines[line-1]
+	for c := 1; c < column; c++ {
+		if pos >= len(s.data) || s.data[pos] == '\n' {
+			return 0, fmt.Errorf("position %d:%d is outside of the document", line, column)
+		}
+		_, w := utf8.DecodeRune(s.data[pos:])
+		pos += w
+	}
+	return pos, nil
+}
+
+// position converts a byte offset back into a 0 based line and character. Characters are counted in UTF-16 code
+// units, the same way the language server protocol counts them.
+func (s *source) position(offset int) (int, int) {
+	line := 0
+	for line+1 < len(s.lines) && s.lines[line+1] <= offset {
+		line++
+	}
+	character := 0
+	for _, r := range string(s.data[s.lines[line]:offset]) {
+		character++
+		if r >= 0x10000 {
+			character++ // a surrogate pair.
+		}
+	}
+	return line, character
+}
+
+func (s *source) nodeStart(node *yaml.Node) (int, error) {
+	return s.offset(node.Line, node.Column)
+}
+
+// lineStart returns the offset of the start of the line containing offset.
+func (s *source) lineStart(offset int) int {
+	return bytes.LastIndexByte(s.data[:offset], '\n') + 1
+}
+
+// lineEnd returns the offset of the line break ending the line containing offset (or the end of the document).
+func (s *source) lineEnd(offset int) int {
+	if i := bytes.IndexByte(s.data[offset:], '\n'); i >= 0 {
+		return offset + i
+	}
+	return len(s.data)
+}
+
+// onlySpaces checks there is nothing but spaces in a range.
+func (s *source) onlySpaces(start, end int) bool {
+	for _, b := range s.data[start:end] {
+		if b != ' ' && b != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// isFlow determines if a collection node has been written using flow style (JSON, or inline YAML).
+func (s *source) isFlow(node *yaml.Node) bool {
+	start, err := s.nodeStart(node)
+	if err != nil || start >= len(s.data) {
+		return node.Style&yaml.FlowStyle != 0
+	}
+	return s.data[start] == '{' || s.data[start] == '['
+}
+
+// nodeEnd finds the offset directly after the last character of a node.
+func (s *source) nodeEnd(node *yaml.Node) (int, error) {
+	start, err := s.nodeStart(node)
+	if err != nil {
+		return 0, err
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		sp, sErr := s.scalarSpan(node)
+		if sErr != nil {
+			return 0, sErr
+		}
+		return sp.end, nil
+	case yaml.AliasNode:
+		end := start
+		for end < len(s.data) && !isTokenBoundary(s.data[end]) {
+			end++
+		}
+		return end, nil
+	case yaml.MappingNode, yaml.SequenceNode:
+		if s.isFlow(node) {
+			return s.matchBracket(start)
+		}
+		if len(node.Content) == 0 {
+			return 0, fmt.Errorf("unable to locate the end of an empty collection at %d:%d", node.Line, node.Column)
+		}
+		return s.nodeEnd(node.Content[len(node.Content)-1])
+	}
+	return 0, fmt.Errorf("unsupported node kind at %d:%d", node.Line, node.Column)
+}
+
+// scalarSpan locates the exact token of a scalar, including quotes. The token found is checked against
+// the value of the node, if they do not agree then the node does not belong to this document.
+func (s *source) scalarSpan(node *yaml.Node) (span, error) {
+	start, err := s.nodeStart(node)
+	if err != nil {
+		return span{}, err
+	}
+	if start >= len(s.data) {
+		return span{}, fmt.Errorf("position %d:%d is outside of the document", node.Line, node.Column)
+	}
+	var end int
+	switch s.data[start] {
+	case '"':
+		end = s.quotedEnd(start, '"')
+	case '\'':
+		end = s.quotedEnd(start, '\'')
+	case '|', '>':
+		end = s.blockScalarEnd(start)
+		return span{start, end}, nil
+	default:
+		// try block context first, flow context terminators are valid characters in a block scalar.
+		end = s.plainEnd(start, false)
+		if string(s.data[start:end]) != node.Value {
+			end = s.plainEnd(start, true)
+		}
+		if string(s.data[start:end]) != node.Value {
+			return span{}, fmt.Errorf("unable to locate value '%s' at %d:%d", node.Value, node.Line, node.Column)
+		}
+		return span{start, end}, nil
+	}
+	if end < 0 {
+		return span{}, fmt.Errorf("unterminated string at %d:%d", node.Line, node.Column)
+	}
+	var decoded string
+	if yaml.Unmarshal(s.data[start:end], &decoded) != nil || decoded != node.Value {
+		return span{}, fmt.Errorf("unable to locate value '%s' at %d:%d", node.Value, node.Line, node.Column)
+	}
+	return span{start, end}, nil
+}
+
+func (s *source) quotedEnd(start int, quote byte) int {
+	for i := start + 1; i < len(s.data); i++ {
+		switch s.data[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			if quote == '\'' && i+1 < len(s.data) && s.data[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func (s *source) plainEnd(start int, flow bool) int {
+	end := start
+	for end < len(s.data) {
+		c := s.data[end]
+		if c == '\n' || c == '\r' {
+			break
+		}
+		if c == ':' && (end+1 == len(s.data) || isSpace(s.data[end+1]) || (flow && isFlowIndicator(s.data[end+1]))) {
+			break
+		}
+		if c == '#' && end > start && isSpace(s.data[end-1]) {
+			break
+		}
+		if flow && isFlowIndicator(c) {
+			break
+		}
+		end++
+	}
+	for end > start && isSpace(s.data[end-1]) {
+		end--
+	}
+	return end
+}
+
+// blockScalarEnd finds the end of a literal or folded scalar, all the lines that are indented further than
+// the line holding the indicator belong to the scalar.
+func (s *source) blockScalarEnd(start int) int {
+	ls := s.lineStart(start)
+	indent := 0
+	for ls+indent < len(s.data) && s.data[ls+indent] == ' ' {
+		indent++
+	}
+	end := s.lineEnd(start)
+	pos := end + 1
+	for pos < len(s.data) {
+		le := s.lineEnd(pos)
+		if s.onlySpaces(pos, le) {
+			pos = le + 1
+			continue
+		}
+		i := 0
+		for pos+i < le && s.data[pos+i] == ' ' {
+			i++
+		}
+		if i <= indent {
+			break
+		}
+		end = le
+		pos = le + 1
+	}
+	return end
+}
+
+// matchBracket finds the end of a flow collection starting at offset.
+func (s *source) matchBracket(start int) (int, error) {
+	depth := 0
+	for i := start; i < len(s.data); i++ {
+		switch s.data[i] {
+		case '"', '\'':
+			if s.data[i] == '\'' && i > start && !isFlowSeparator(s.data[i-1]) {
+				continue // an apostrophe inside a plain scalar.
+			}
+			e := s.quotedEnd(i, s.data[i])
+			if e < 0 {
+				return 0, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			i = e - 1
+		case '#':
+			if i > start && isSpace(s.data[i-1]) {
+				i = s.lineEnd(i) - 1
+			}
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unterminated collection at offset %d", start)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isFlowIndicator(c byte) bool {
+	return c == ',' || c == ']' || c == '}' || c == '[' || c == '{'
+}
+
+func isFlowSeparator(c byte) bool {
+	return isSpace(c) || c == ',' || c == '[' || c == '{' || c == ':'
+}
+
+func isTokenBoundary(c byte) bool {
+	return isSpace(c) || isFlowIndicator(c)
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+// Package cache stores the results of rules on disk, so rules don't have to run again against specifications
+// that have not changed. It's used by the --cache-dir flag of the lint command. The cache does not know anything
+// about specifications or rules, the motor decides what goes into a key, and when results can be replayed.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/daveshanley/vacuum/model/reports"
+	"gopkg.in/yaml.v3"
+)
+
+// Cache is a directory of cached rule results, addressed by key. It's safe to use from multiple goroutines,
+// and entries are written atomically, so multiple vacuum processes can share the same directory.
+type Cache struct {
+	dir    string
+	salt   string
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// Entry is everything cached for a single rule, run against a single specification.
+type Entry struct {
+	RuleId  string   `json:"ruleId"`
+	Results []Result `json:"results"`
+}
+
+// Result is a cached model.RuleFunctionResult. Nodes can't be cached, so they are stored as references to where
+// they were found, and looked up again when the result is replayed.
+type Result struct {
+	Message      string        `json:"message"`
+	Range        reports.Range `json:"range"`
+	Path         string        `json:"path"`
+	RuleId       string        `json:"ruleId,omitempty"`
+	RuleSeverity string        `json:"ruleSeverity,omitempty"`
+	HasRule      bool          `json:"hasRule,omitempty"` // the result was linked to the rule that created it.
+	StartNode    *NodeRef      `json:"startNode,omitempty"`
+	EndNode      *NodeRef      `json:"endNode,omitempty"`
+}
+
+// NodeRef locates a node in a parsed specification. Tree and Ordinal identify the node in the trees that were
+// linted, an Ordinal of -1 means the node was created by the rule and is re-created from the rest of the fields.
+type NodeRef struct {
+	Tree    string    `json:"tree,omitempty"`
+	Ordinal int       `json:"ordinal"`
+	Line    int       `json:"line"`
+	Column  int       `json:"column"`
+	Kind    yaml.Kind `json:"kind,omitempty"`
+	Tag     string    `json:"tag,omitempty"`
+	Value   string    `json:"value,omitempty"`
+}
+
+// NewCache creates a cache in the directory, creating the directory if it does not exist. The salt is mixed into
+// every key, it should contain anything results depend on that the cache can't see, like the vacuum version.
+func NewCache(dir, salt string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir, salt: salt}, nil
+}
+
+// Dir returns the directory the cache is stored in.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Key hashes the salt and all the parts into a key. Parts are length prefixed, so moving bytes from one part
+// to the next creates a different key.
+func (c *Cache) Key(parts ...[]byte) string {
+	h := sha256.New()
+	writePart(h, []byte(c.salt))
+	for _, p := range parts {
+		writePart(h, p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the entry stored for the key. Entries that can't be read are treated as missing.
+func (c *Cache) Get(key string) (*Entry, bool) {
+	b, err := os.ReadFile(c.location(key))
+	if err != nil {
+		c.misses.Add(1)
+		return nil, false
+	}
+	var entry Entry
+	if err = json.Unmarshal(b, &entry); err != nil {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	return &entry, true
+}
+
+// Put stores the entry for the key, replacing anything stored before.
+func (c *Cache) Put(key string, entry *Entry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	location := c.location(key)
+	if err = os.MkdirAll(filepath.Dir(location), 0755); err != nil {
+		return err
+	}
+
+	// write somewhere else first, so nobody ever reads half an entry.
+	tmp, err := os.CreateTemp(filepath.Dir(location), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = os.Rename(tmp.Name(), location); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Hits returns the number of entries found since the cache was created.
+func (c *Cache) Hits() int64 {
+	return c.hits.Load()
+}
+
+// Misses returns the number of entries not found since the cache was created.
+func (c *Cache) Misses() int64 {
+	return c.misses.Load()
+}
+
+func (c *Cache) location(key string) string {
+	// spread entries over directories, a big specification with a big ruleset creates a lot of them.
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+func writePart(h hash.Hash, p []byte) {
+	_ = binary.Write(h, binary.LittleEndian, uint64(len(p)))
+	_, _ = h.Write(p)
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_Key(t *testing.T) {
+	c, err := NewCache(t.TempDir(), "v1")
+	assert.NoError(t, err)
+
+	assert.Equal(t, c.Key([]byte("a"), []byte("b")), c.Key([]byte("a"), []byte("b")))
+	assert.NotEqual(t, c.Key([]byte("a"), []byte("b")), c.Key([]byte("ab"), []byte("")))
+
+	// a different salt, like a new vacuum version, invalidates everything.
+	other, _ := NewCache(c.Dir(), "v2")
+	assert.NotEqual(t, c.Key([]byte("a")), other.Key([]byte("a")))
+}
+
+func TestCache_PutGet(t *testing.T) {
+	c, err := NewCache(filepath.Join(t.TempDir(), "nested", "cache"), "v1")
+	assert.NoError(t, err)
+
+	key := c.Key([]byte("rule"))
+	_, ok := c.Get(key)
+	assert.False(t, ok)
+
+	entry := &Entry{RuleId: "rule", Results: []Result{{
+		Message:   "oh no",
+		Path:      "$.info",
+		StartNode: &NodeRef{Tree: "unresolved", Ordinal: 4, Line: 2, Column: 3},
+	}}}
+	assert.NoError(t, c.Put(key, entry))
+
+	found, ok := c.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, entry, found)
+	assert.Equal(t, int64(1), c.Hits())
+	assert.Equal(t, int64(1), c.Misses())
+}
+
+func TestCache_Get_Corrupt(t *testing.T) {
+	c, _ := NewCache(t.TempDir(), "v1")
+	key := c.Key([]byte("rule"))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(c.location(key)), 0755))
+	assert.NoError(t, os.WriteFile(c.location(key), []byte("{not json"), 0644))
+
+	_, ok := c.Get(key)
+	assert.False(t, ok)
+}
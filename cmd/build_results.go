@@ -3,6 +3,7 @@ package cmd
 import (
 	"github.com/daveshanley/vacuum/model"
 	"github.com/daveshanley/vacuum/motor"
+	"github.com/daveshanley/vacuum/plugin"
 	"github.com/daveshanley/vacuum/rulesets"
 	"github.com/pterm/pterm"
 	"os"
@@ -62,10 +63,11 @@ func BuildResultsWithDocCheckSkip(
 		if rsErr != nil {
 			return nil, nil, rsErr
 		}
-		selectedRS, rsErr = BuildRuleSetFromUserSuppliedSet(rsBytes, defaultRuleSets)
+		selectedRS, rsErr = BuildRuleSetFromUserSuppliedLocation(rsBytes, rulesetFlag, defaultRuleSets)
 		if rsErr != nil {
 			return nil, nil, rsErr
 		}
+		defer selectedRS.CloseFunctions()
 	}
 
 	pterm.Info.Printf("Linting against %d rules: %s\n", len(selectedRS.Rules), selectedRS.DocumentationURI)
@@ -74,6 +76,7 @@ func BuildResultsWithDocCheckSkip(
 		RuleSet:           selectedRS,
 		Spec:              specBytes,
 		CustomFunctions:   customFunctions,
+		FunctionLoader:    plugin.LoadFunctionSource,
 		Base:              base,
 		SkipDocumentCheck: skipCheck,
 		AllowLookup:       true,
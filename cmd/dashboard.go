@@ -15,12 +15,13 @@ import (
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 	"net/url"
+	"os"
 	"time"
 )
 
 func GetDashboardCommand() *cobra.Command {
 
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:     "dashboard",
 		Short:   "Show vacuum dashboard for linting report",
 		Long:    "Interactive console dashboard to explore linting report in detail",
@@ -45,6 +46,9 @@ func GetDashboardCommand() *cobra.Command {
 			timeoutFlag, _ := cmd.Flags().GetInt("timeout")
 			hardModeFlag, _ := cmd.Flags().GetBool("hard-mode")
 			silent, _ := cmd.Flags().GetBool("silent")
+			watchFlag, _ := cmd.Flags().GetBool("watch")
+			functionsFlag, _ := cmd.Flags().GetString("functions")
+			rulesetFlag, _ := cmd.Flags().GetString("ruleset")
 
 			var err error
 			vacuumReport, specBytes, _ := vacuum_report.BuildVacuumReportFromFile(args[0])
@@ -58,13 +62,18 @@ func GetDashboardCommand() *cobra.Command {
 			var specIndex *index.SpecIndex
 			var specInfo *datamodel.SpecInfo
 
+			if watchFlag && vacuumReport != nil {
+				pterm.Error.Println("The --watch flag needs a specification, not a vacuum report")
+				pterm.Println()
+				return errors.New("cannot watch a vacuum report")
+			}
+
 			// if we have a pre-compiled report, jump straight to the end and collect $500
 			if vacuumReport == nil {
 
-				functionsFlag, _ := cmd.Flags().GetString("functions")
-				customFunctions, _ := LoadCustomFunctions(functionsFlag, silent)
+				customFunctions, functionsManager, _ := LoadCustomFunctions(functionsFlag, silent)
+				defer functionsManager.Close()
 
-				rulesetFlag, _ := cmd.Flags().GetString("ruleset")
 				resultSet, ruleset, err = BuildResultsWithDocCheckSkip(false, hardModeFlag, rulesetFlag, specBytes, customFunctions,
 					baseFlag, skipCheckFlag, time.Duration(timeoutFlag)*time.Second)
 				if err != nil {
@@ -110,7 +119,47 @@ func GetDashboardCommand() *cobra.Command {
 
 			dash := cui.CreateDashboard(resultSet, specIndex, specInfo)
 			dash.Version = Version
+
+			// lint again in the background, and refresh the dashboard in place with the new results.
+			if watchFlag {
+				ctx, stop := watchContext(cmd.Context())
+				defer stop()
+
+				// nothing can be printed while the dashboard owns the terminal.
+				pterm.DisableOutput()
+				defer pterm.EnableOutput()
+
+				loaded := ruleset.Files
+				first := true
+				files, dirs := watchTargets([]string{args[0]}, rulesetFlag, functionsFlag)
+				go func() {
+					_ = watchAndRun(ctx, files, dirs, true, func(changed []string) []string {
+						if first {
+							first = false
+							return loaded
+						}
+						spec, rErr := os.ReadFile(args[0])
+						if rErr != nil {
+							return loaded
+						}
+						customFunctions, functionsManager, _ := LoadCustomFunctions(functionsFlag, true)
+						defer functionsManager.Close()
+						rs, result, bErr := BuildResultsWithDocCheckSkip(true, hardModeFlag, rulesetFlag, spec,
+							customFunctions, baseFlag, skipCheckFlag, time.Duration(timeoutFlag)*time.Second)
+						if bErr != nil || result.SpecInfo == nil {
+							return loaded
+						}
+						result.SpecInfo.Generated = time.Now()
+						dash.Refresh(rs, result.Index, result.SpecInfo)
+						loaded = result.Files
+						return loaded
+					})
+				}()
+			}
+
 			return dash.Render()
 		},
 	}
+	cmd.Flags().Bool("watch", false, "Refresh the dashboard every time the specification, the files it references, the ruleset or custom functions change")
+	return cmd
 }
@@ -0,0 +1,363 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/daveshanley/vacuum/diff"
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/motor"
+	vacuum_report "github.com/daveshanley/vacuum/vacuum-report"
+	doctor "github.com/pb33f/doctor/model"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+func GetDiffCommand() *cobra.Command {
+
+	cmd := &cobra.Command{
+		SilenceUsage: true,
+		Use:          "diff <original-openapi-file.yaml> <updated-openapi-file.yaml>",
+		Short:        "Detect breaking changes between two versions of an OpenAPI specification",
+		Long: "Compare two versions of an OpenAPI 3+ specification, and report every change that matters to clients. " +
+			"Breaking changes (removed operations and responses, narrowed enums, newly required parameters, type and " +
+			"security changes) are reported as errors, everything else is informational. Either version can be read " +
+			"from git, using a revision and a path, like 'HEAD~1:openapi.yaml', the files it references are read from " +
+			"the same revision.",
+		Example: "vacuum diff HEAD~1:openapi.yaml openapi.yaml",
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return []string{"yaml", "yml", "json"}, cobra.ShellCompDirectiveFilterFileExt
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			detailsFlag, _ := cmd.Flags().GetBool("details")
+			timeFlag, _ := cmd.Flags().GetBool("time")
+			snippetsFlag, _ := cmd.Flags().GetBool("snippets")
+			errorsFlag, _ := cmd.Flags().GetBool("errors")
+			silent, _ := cmd.Flags().GetBool("silent")
+			failSeverityFlag, _ := cmd.Flags().GetString("fail-severity")
+			noStyleFlag, _ := cmd.Flags().GetBool("no-style")
+			baseFlag, _ := cmd.Flags().GetString("base")
+			remoteFlag, _ := cmd.Flags().GetBool("remote")
+			noBanner, _ := cmd.Flags().GetBool("no-banner")
+			noMessage, _ := cmd.Flags().GetBool("no-message")
+			allResults, _ := cmd.Flags().GetBool("all-results")
+			ignoreArrayCircleRef, _ := cmd.Flags().GetBool("ignore-array-circle-ref")
+			ignorePolymorphCircleRef, _ := cmd.Flags().GetBool("ignore-polymorph-circle-ref")
+			formatFlag, _ := cmd.Flags().GetString("format")
+
+			// machine-readable formats own stdout, nothing else can be printed.
+			switch formatFlag {
+			case "", lintFormatText:
+			case lintFormatSarif:
+				silent = true
+			default:
+				pterm.Error.Printf("Unknown format '%s', use '%s' or '%s'\n", formatFlag, lintFormatText, lintFormatSarif)
+				pterm.Println()
+				return fmt.Errorf("unknown format '%s'", formatFlag)
+			}
+
+			if noStyleFlag {
+				pterm.DisableColor()
+				pterm.DisableStyling()
+			}
+
+			if !silent && !noBanner {
+				PrintBanner()
+			}
+
+			if len(args) != 2 {
+				pterm.Error.Println("Please supply the original and the updated OpenAPI specifications to compare")
+				pterm.Println()
+				return errors.New("two specifications are required")
+			}
+
+			start := time.Now()
+			logger := slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError}))
+
+			build := func(location string) (*doctor.DrDocument, []byte, int64, error) {
+				spec, base, cleanup, err := readDiffSpec(location, baseFlag, remoteFlag)
+				if err != nil {
+					pterm.Error.Printf("Unable to read '%s': %s\n", location, err.Error())
+					pterm.Println()
+					return nil, nil, 0, err
+				}
+				defer cleanup()
+
+				// no rules are run, this is only to build the model the same way linting does.
+				result := motor.ApplyRulesToRuleSet(&motor.RuleSetExecution{
+					Spec:                         spec,
+					SpecFileName:                 location,
+					Base:                         base,
+					AllowLookup:                  remoteFlag,
+					SilenceLogs:                  true,
+					Logger:                       logger,
+					IgnoreCircularArrayRef:       ignoreArrayCircleRef,
+					IgnoreCircularPolymorphicRef: ignorePolymorphCircleRef,
+				})
+				if len(result.Errors) > 0 {
+					for _, e := range result.Errors {
+						pterm.Error.Printf("unable to process spec '%s', error: %s", location, e.Error())
+						pterm.Println()
+					}
+					return nil, nil, 0, fmt.Errorf("unable to process spec '%s'", location)
+				}
+				if result.DrDocument == nil {
+					pterm.Error.Printf("Unable to compare '%s', only OpenAPI 3+ specifications can be compared\n", location)
+					pterm.Println()
+					return nil, nil, 0, fmt.Errorf("'%s' is not an OpenAPI 3+ specification", location)
+				}
+				return result.DrDocument, spec, result.FileSize, nil
+			}
+
+			original, _, originalSize, err := build(args[0])
+			if err != nil {
+				return err
+			}
+			updated, updatedSpec, updatedSize, err := build(args[1])
+			if err != nil {
+				return err
+			}
+
+			resultSet := model.NewRuleResultSet(diff.CompareDocuments(original, updated))
+			resultSet.SortResultsByLineNumber()
+
+			errs := resultSet.GetErrorCount()
+			warnings := resultSet.GetWarnCount()
+			informs := resultSet.GetInfoCount()
+
+			if formatFlag == lintFormatSarif {
+				sarifReport := vacuum_report.NewSarifReport(diff.Rules(), Version)
+				sarifReport.AddResults(args[1], resultSet.Results, "")
+				fmt.Fprintln(cmd.OutOrStdout(), string(sarifReport.Render()))
+				return CheckFailureSeverity(failSeverityFlag, errs, warnings, informs)
+			}
+
+			if detailsFlag && len(resultSet.Results) > 0 {
+				location, absErr := filepath.Abs(args[1])
+				if absErr != nil || !fileExists(args[1]) {
+					location = args[1]
+				}
+				processResults(resultSet.Results, strings.Split(string(updatedSpec), "\n"), snippetsFlag,
+					errorsFlag, silent, noMessage, allResults, location, args[1])
+			}
+
+			renderDiffSummary(resultSet, silent, detailsFlag, args[0], args[1])
+			RenderTimeAndFiles(timeFlag, time.Since(start), originalSize+updatedSize, 2)
+
+			return CheckFailureSeverity(failSeverityFlag, errs, warnings, informs)
+		},
+	}
+
+	cmd.Flags().BoolP("details", "d", false, "Show full details of every change")
+	cmd.Flags().BoolP("snippets", "s", false, "Show code snippets where changes are found")
+	cmd.Flags().BoolP("errors", "e", false, "Show breaking changes only")
+	cmd.Flags().BoolP("silent", "x", false, "Show nothing except the result.")
+	cmd.Flags().BoolP("no-style", "q", false, "Disable styling and color output, just plain text (useful for CI/CD)")
+	cmd.Flags().BoolP("no-banner", "b", false, "Disable the banner / header output")
+	cmd.Flags().BoolP("no-message", "m", false, "Hide the message output when using -d to show details")
+	cmd.Flags().BoolP("all-results", "a", false, "Render out all results, regardless of the number when using -d")
+	cmd.Flags().StringP("fail-severity", "n", model.SeverityError, "Results of this level or above will trigger a failure exit code")
+	cmd.Flags().Bool("ignore-array-circle-ref", false, "Ignore circular array references")
+	cmd.Flags().Bool("ignore-polymorph-circle-ref", false, "Ignore circular polymorphic references")
+	cmd.Flags().String("format", lintFormatText, "Output format, 'text' for humans, or 'sarif' to print a SARIF 2.1.0 log")
+
+	regErr := cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{
+		lintFormatText,
+		lintFormatSarif,
+	}, cobra.ShellCompDirectiveNoFileComp))
+	if regErr != nil {
+		panic(regErr)
+	}
+	regErr = cmd.RegisterFlagCompletionFunc("fail-severity", cobra.FixedCompletions([]string{
+		model.SeverityInfo,
+		model.SeverityWarn,
+		model.SeverityError,
+	}, cobra.ShellCompDirectiveNoFileComp))
+	if regErr != nil {
+		panic(regErr)
+	}
+
+	return cmd
+}
+
+// readDiffSpec reads a specification from a file, or if there is no such file and the location looks like
+// '<revision>:<path>', from the git repository in the working directory. A specification read from git is read
+// from a copy of the whole tree at that revision, so the files it references are read at the same revision.
+// The base returned is where references are resolved from, the cleanup function removes the copy.
+func readDiffSpec(location, base string, remote bool) ([]byte, string, func(), error) {
+	if fileExists(location) || !strings.Contains(location, ":") || strings.Contains(location, "://") {
+		spec, err := os.ReadFile(location)
+		return spec, base, func() {}, err
+	}
+	revision, path, _ := strings.Cut(location, ":")
+	root, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, "", nil, err
+	}
+	prefix, err := git("rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, "", nil, err
+	}
+	tree, err := os.MkdirTemp("", "vacuum-diff-")
+	if err != nil {
+		return nil, "", nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(tree) }
+	if err = extractGitTree(root, revision, tree); err != nil {
+		cleanup()
+		return nil, "", nil, err
+	}
+
+	// paths starting with ./ or ../ are relative to the working directory, like git show.
+	if strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") {
+		path = filepath.Join(prefix, path)
+	}
+	spec, err := os.ReadFile(filepath.Join(tree, filepath.FromSlash(path)))
+	if err != nil {
+		cleanup()
+		return nil, "", nil, fmt.Errorf("'%s' does not exist at revision '%s'", path, revision)
+	}
+
+	// a base in the repository is moved into the copy, and so is the working directory used by remote lookups.
+	wd := filepath.Join(tree, filepath.FromSlash(prefix))
+	switch {
+	case base == "" && remote:
+		base = wd
+	case base != "" && !strings.HasPrefix(base, "http"):
+		abs, absErr := filepath.Abs(base)
+		if absErr != nil {
+			cleanup()
+			return nil, "", nil, absErr
+		}
+		if rel, relErr := filepath.Rel(filepath.FromSlash(root), abs); relErr == nil && !strings.HasPrefix(rel, "..") {
+			base = filepath.Join(tree, rel)
+		}
+	}
+	return spec, base, cleanup, nil
+}
+
+// git runs a git command in the working directory, and returns what it printed.
+func git(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	command := exec.Command("git", args...)
+	command.Stderr = &stderr
+	out, err := command.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git: %s", msg)
+		}
+		return "", fmt.Errorf("unable to run git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// extractGitTree writes every file of a repository at a revision into a directory.
+func extractGitTree(root, revision, dir string) error {
+	var stderr bytes.Buffer
+	command := exec.Command("git", "archive", "--format=tar", revision)
+	command.Dir = root // from anywhere else, only that part of the tree is archived.
+	command.Stderr = &stderr
+	out, err := command.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err = command.Start(); err != nil {
+		return fmt.Errorf("unable to run git archive: %w", err)
+	}
+	extractErr := extractTar(out, dir)
+	_, _ = io.Copy(io.Discard, out)
+	if err = command.Wait(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("git: %s", msg)
+		}
+		return fmt.Errorf("unable to read revision '%s' from git: %w", revision, err)
+	}
+	return extractErr
+}
+
+func extractTar(r io.Reader, dir string) error {
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(filepath.Separator)) {
+			continue
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			err = writeTarFile(archive, target)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeTarFile(r io.Reader, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func fileExists(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && !info.IsDir()
+}
+
+func renderDiffSummary(rs *model.RuleResultSet, silent, details bool, original, updated string) {
+	if silent {
+		return
+	}
+	breaking := rs.GetErrorCount()
+	other := len(rs.Results) - breaking
+	pterm.Println()
+	switch {
+	case breaking > 0:
+		pterm.DefaultHeader.WithBackgroundStyle(pterm.NewStyle(pterm.BgRed)).WithMargin(10).Printf(
+			"Found %d breaking changes (and %d other changes) between '%s' and '%s'", breaking, other, original, updated)
+	case other > 0:
+		pterm.DefaultHeader.WithBackgroundStyle(pterm.NewStyle(pterm.BgGreen)).WithMargin(10).Printf(
+			"No breaking changes, %d other changes between '%s' and '%s'", other, original, updated)
+	default:
+		pterm.DefaultHeader.WithBackgroundStyle(pterm.NewStyle(pterm.BgGreen)).WithMargin(10).Printf(
+			"No changes between '%s' and '%s'", original, updated)
+	}
+	pterm.Println()
+	if breaking+other > 0 && !details {
+		pterm.Info.Println("To see every change, use the '-d' flag.")
+		pterm.Println()
+	}
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	vacuum_report "github.com/daveshanley/vacuum/vacuum-report"
+	"github.com/stretchr/testify/assert"
+)
+
+var diffOriginal = `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      responses:
+        '200':
+          description: ok
+    delete:
+      responses:
+        '204':
+          description: gone
+`
+
+var diffUpdated = `openapi: 3.1.0
+info:
+  title: pets
+  version: 2.0.0
+paths:
+  /pets:
+    get:
+      responses:
+        '200':
+          description: ok
+`
+
+func writeDiffSpecs(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "original.yaml")
+	updated := filepath.Join(dir, "updated.yaml")
+	assert.NoError(t, os.WriteFile(original, []byte(diffOriginal), 0664))
+	assert.NoError(t, os.WriteFile(updated, []byte(diffUpdated), 0664))
+	return original, updated
+}
+
+func TestGetDiffCommand(t *testing.T) {
+	original, updated := writeDiffSpecs(t)
+	cmd := GetDiffCommand()
+	cmd.SetArgs([]string{"-d", original, updated})
+	assert.Error(t, cmd.Execute())
+
+	// nothing breaks when going the other way.
+	cmd = GetDiffCommand()
+	cmd.SetArgs([]string{updated, original})
+	assert.NoError(t, cmd.Execute())
+}
+
+func TestGetDiffCommand_Sarif(t *testing.T) {
+	original, updated := writeDiffSpecs(t)
+	cmd := GetDiffCommand()
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+	cmd.SetArgs([]string{"--format", "sarif", original, updated})
+	assert.Error(t, cmd.Execute())
+
+	var log vacuum_report.SarifLog
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &log))
+	assert.Len(t, log.Runs[0].Results, 1)
+	assert.Equal(t, "breaking-operation-removed", log.Runs[0].Results[0].RuleId)
+}
+
+func TestGetDiffCommand_MissingArgs(t *testing.T) {
+	cmd := GetDiffCommand()
+	cmd.SetArgs([]string{"../model/test_files/burgershop.openapi.yaml"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestGetDiffCommand_NotOpenAPI3(t *testing.T) {
+	cmd := GetDiffCommand()
+	cmd.SetArgs([]string{"../model/test_files/petstorev2.json", "../model/test_files/petstorev2.json"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestReadDiffSpec(t *testing.T) {
+	spec, base, _, err := readDiffSpec("../model/test_files/burgershop.openapi.yaml", "../model", false)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, spec)
+	assert.Equal(t, "../model", base)
+
+	_, _, _, err = readDiffSpec("not-a-file.yaml", "", false)
+	assert.Error(t, err)
+}
+
+func TestGetDiffCommand_GitMultipleFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	run := func(args ...string) {
+		command := exec.Command("git", append([]string{"-c", "user.name=vacuum", "-c", "user.email=vacuum@example.com"}, args...)...)
+		command.Dir = repo
+		out, err := command.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+	spec := `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+paths:
+  /pets:
+    $ref: 'paths/pets.yaml'
+`
+	pets := `get:
+  responses:
+    '200':
+      description: ok
+`
+	assert.NoError(t, os.MkdirAll(filepath.Join(repo, "api", "paths"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(repo, "api", "openapi.yaml"), []byte(spec), 0664))
+	assert.NoError(t, os.WriteFile(filepath.Join(repo, "api", "paths", "pets.yaml"),
+		[]byte(pets+"delete:\n  responses:\n    '204':\n      description: gone\n"), 0664))
+	run("init", "-q")
+	run("add", "-A")
+	run("commit", "-q", "-m", "pets")
+
+	// the operation is removed from the referenced file only.
+	assert.NoError(t, os.WriteFile(filepath.Join(repo, "api", "paths", "pets.yaml"), []byte(pets), 0664))
+
+	wd, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(filepath.Join(repo, "api")))
+	defer func() { _ = os.Chdir(wd) }()
+
+	cmd := GetDiffCommand()
+	cmd.Flags().String("base", "", "") // a persistent flag of the root command.
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+	cmd.SetArgs([]string{"--format", "sarif", "--base", ".", "HEAD:api/openapi.yaml", "openapi.yaml"})
+	assert.Error(t, cmd.Execute())
+
+	var log vacuum_report.SarifLog
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &log))
+	assert.Len(t, log.Runs[0].Results, 1)
+	assert.Equal(t, "breaking-operation-removed", log.Runs[0].Results[0].RuleId)
+
+	// relative to the working directory, like git show.
+	spec2, base, cleanup, err := readDiffSpec("HEAD:./openapi.yaml", "", true)
+	assert.NoError(t, err)
+	defer cleanup()
+	assert.Equal(t, spec, string(spec2))
+	b2, err := os.ReadFile(filepath.Join(base, "paths", "pets.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(b2), "delete")
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/daveshanley/vacuum/motor"
 	"github.com/daveshanley/vacuum/statistics"
 	vacuum_report "github.com/daveshanley/vacuum/vacuum-report"
+	"github.com/daveshanley/vacuum/watch"
 	"github.com/pb33f/libopenapi/datamodel"
 	"github.com/pb33f/libopenapi/index"
 	"github.com/pterm/pterm"
@@ -74,6 +75,10 @@ func GetHTMLReportCommand() *cobra.Command {
 				reportOutput = args[1]
 			}
 
+			watchFlag, _ := cmd.Flags().GetBool("watch")
+			functionsFlag, _ := cmd.Flags().GetString("functions")
+			rulesetFlag, _ := cmd.Flags().GetString("ruleset")
+
 			start := time.Now()
 			var err error
 			vacuumReport, specBytes, _ := vacuum_report.BuildVacuumReportFromFile(args[0])
@@ -82,6 +87,54 @@ func GetHTMLReportCommand() *cobra.Command {
 				return err
 			}
 
+			if watchFlag {
+				if vacuumReport != nil {
+					pterm.Error.Println("The --watch flag needs a specification, not a vacuum report")
+					pterm.Println()
+					return errors.New("cannot watch a vacuum report")
+				}
+				ctx, stop := watchContext(cmd.Context())
+				defer stop()
+
+				var previous []*model.RuleFunctionResult
+				first := true
+				files, dirs := watchTargets([]string{args[0]}, rulesetFlag, functionsFlag)
+				return watchAndRun(ctx, files, dirs, silent, func(changed []string) []string {
+					spec, rErr := os.ReadFile(args[0])
+					if rErr != nil {
+						pterm.Error.Printf("Failed to read specification: %v\n\n", args[0])
+						return nil
+					}
+					customFunctions, functionsManager, _ := LoadCustomFunctions(functionsFlag, true)
+					defer functionsManager.Close()
+					resultSet, ruleset, bErr := BuildResultsWithDocCheckSkip(true, hardModeFlag, rulesetFlag, spec,
+						customFunctions, baseFlag, skipCheckFlag, time.Duration(timeoutFlag)*time.Second)
+					if bErr != nil {
+						pterm.Error.Printf("Failed to generate report: %v\n\n", bErr)
+						return nil
+					}
+					ruleset.SpecInfo.Generated = time.Now()
+					stats := statistics.CreateReportStatistics(ruleset.Index, ruleset.SpecInfo, resultSet)
+					report := html_report.NewHTMLReport(ruleset.Index, ruleset.SpecInfo, resultSet, stats, disableTimestamp)
+					if wErr := os.WriteFile(reportOutput, report.GenerateReport(false, Version), 0664); wErr != nil {
+						pterm.Error.Printf("Unable to write HTML report file: '%s': %s\n", reportOutput, wErr.Error())
+						pterm.Println()
+						return ruleset.Files
+					}
+					if first {
+						pterm.Success.Printf("HTML Report generated for '%s', written to '%s'\n", args[0], reportOutput)
+					} else {
+						added, fixed := watch.Delta(previous, resultSet.Results)
+						pterm.Success.Printf("[%s] HTML Report regenerated for '%s': %d new results, %d fixed\n",
+							time.Now().Format("15:04:05"), args[0], len(added), len(fixed))
+					}
+					pterm.Println()
+					previous = resultSet.Results
+					first = false
+					return ruleset.Files
+				})
+			}
+
 			var resultSet *model.RuleResultSet
 			var ruleset *motor.RuleSetExecutionResult
 			var specIndex *index.SpecIndex
@@ -91,10 +144,9 @@ func GetHTMLReportCommand() *cobra.Command {
 			// if we have a pre-compiled report, jump straight to the end and collect $500
 			if vacuumReport == nil {
 
-				functionsFlag, _ := cmd.Flags().GetString("functions")
-				customFunctions, _ := LoadCustomFunctions(functionsFlag, silent)
+				customFunctions, functionsManager, _ := LoadCustomFunctions(functionsFlag, silent)
+				defer functionsManager.Close()
 
-				rulesetFlag, _ := cmd.Flags().GetString("ruleset")
 				resultSet, ruleset, err = BuildResultsWithDocCheckSkip(false, hardModeFlag, rulesetFlag, specBytes, customFunctions,
 					baseFlag, skipCheckFlag, time.Duration(timeoutFlag)*time.Second)
 				if err != nil {
@@ -142,6 +194,7 @@ func GetHTMLReportCommand() *cobra.Command {
 	}
 	cmd.Flags().BoolP("disableTimestamp", "d", false, "Disable timestamp in report")
 	cmd.Flags().BoolP("no-style", "q", false, "Disable styling and color output, just plain text (useful for CI/CD)")
+	cmd.Flags().Bool("watch", false, "Generate the report again every time the specification, the files it references, the ruleset or custom functions change")
 
 	return cmd
 }
@@ -40,11 +40,12 @@ IDE and start linting your OpenAPI documents in real-time.`,
 			timeoutFlag, _ := cmd.Flags().GetInt("timeout")
 			hardModeFlag, _ := cmd.Flags().GetBool("hard-mode")
 			ignoreArrayCircleRef, _ := cmd.Flags().GetBool("ignore-array-circle-ref")
-			ignorePolymorphCircleRef, _ := cmd.Flags().GetBool("ignore-array-circle-ref")
+			ignorePolymorphCircleRef, _ := cmd.Flags().GetBool("ignore-polymorph-circle-ref")
 
 			defaultRuleSets := rulesets.BuildDefaultRuleSetsWithLogger(logger)
 			selectedRS := defaultRuleSets.GenerateOpenAPIRecommendedRuleSet()
-			customFunctions, _ := LoadCustomFunctions(functionsFlag, true)
+			customFunctions, functionsManager, _ := LoadCustomFunctions(functionsFlag, true)
+			defer functionsManager.Close()
 
 			// HARD MODE
 			if hardModeFlag {
@@ -64,10 +65,11 @@ IDE and start linting your OpenAPI documents in real-time.`,
 					return rsErr
 				}
 
-				selectedRS, rsErr = BuildRuleSetFromUserSuppliedSet(rsBytes, defaultRuleSets)
+				selectedRS, rsErr = BuildRuleSetFromUserSuppliedLocation(rsBytes, rulesetFlag, defaultRuleSets)
 				if rsErr != nil {
 					return rsErr
 				}
+				defer selectedRS.CloseFunctions()
 			}
 
 			lfr := utils.LintFileRequest{
@@ -83,7 +85,19 @@ IDE and start linting your OpenAPI documents in real-time.`,
 				Logger:                   logger,
 			}
 
-			return languageserver.NewServer(Version, &lfr).Run()
+			// workspace folders can change the ruleset, and the editor the functions too. The flags are where they start.
+			var flags languageserver.Settings
+			if rulesetFlag != "" {
+				flags.Ruleset = &rulesetFlag
+			}
+			if functionsFlag != "" {
+				flags.Functions = &functionsFlag
+			}
+			if hardModeFlag {
+				flags.HardMode = &hardModeFlag
+			}
+
+			return languageserver.NewServer(Version, &lfr).WithFlags(flags).Run()
 		},
 	}
 	cmd.Flags().Bool("ignore-array-circle-ref", false, "Ignore circular array references")
@@ -6,10 +6,13 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"github.com/daveshanley/vacuum/cache"
 	"github.com/daveshanley/vacuum/model"
 	"github.com/daveshanley/vacuum/motor"
+	"github.com/daveshanley/vacuum/plugin"
 	"github.com/daveshanley/vacuum/rulesets"
 	"github.com/daveshanley/vacuum/utils"
+	vacuum_report "github.com/daveshanley/vacuum/vacuum-report"
 	"github.com/dustin/go-humanize"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
@@ -58,6 +61,35 @@ func GetLintCommand() *cobra.Command {
 			hardModeFlag, _ := cmd.Flags().GetBool("hard-mode")
 			ignoreArrayCircleRef, _ := cmd.Flags().GetBool("ignore-array-circle-ref")
 			ignorePolymorphCircleRef, _ := cmd.Flags().GetBool("ignore-polymorph-circle-ref")
+			baselineFlag, _ := cmd.Flags().GetString("baseline")
+			updateBaselineFlag, _ := cmd.Flags().GetBool("update-baseline")
+			fixFlag, _ := cmd.Flags().GetBool("fix")
+			fixDryRunFlag, _ := cmd.Flags().GetBool("fix-dry-run")
+			formatFlag, _ := cmd.Flags().GetString("format")
+			watchFlag, _ := cmd.Flags().GetBool("watch")
+			cacheDirFlag, _ := cmd.Flags().GetString("cache-dir")
+			profileFlag, _ := cmd.Flags().GetBool("profile")
+			profileJSONFlag, _ := cmd.Flags().GetString("profile-json")
+
+			// machine-readable formats own stdout, nothing else can be printed.
+			switch formatFlag {
+			case "", lintFormatText:
+			case lintFormatSarif:
+				silent = true
+			default:
+				pterm.Error.Printf("Unknown format '%s', use '%s' or '%s'\n", formatFlag, lintFormatText, lintFormatSarif)
+				pterm.Println()
+				return fmt.Errorf("unknown format '%s'", formatFlag)
+			}
+
+			// watching never ends, so there is nothing to write a fix, baseline or SARIF log to.
+			profiling := profileFlag || profileJSONFlag != ""
+			if watchFlag && (fixFlag || fixDryRunFlag || updateBaselineFlag || formatFlag == lintFormatSarif || profiling) {
+				pterm.Error.Println("The --watch flag cannot be used with --fix, --fix-dry-run, --update-baseline, " +
+					"--profile, --profile-json or --format sarif")
+				pterm.Println()
+				return fmt.Errorf("--watch cannot be combined with fixes, baseline updates, profiles or SARIF output")
+			}
 
 			// disable color and styling, for CI/CD use.
 			// https://github.com/daveshanley/vacuum/issues/234
@@ -106,7 +138,8 @@ func GetLintCommand() *cobra.Command {
 
 			defaultRuleSets := rulesets.BuildDefaultRuleSetsWithLogger(logger)
 			selectedRS := defaultRuleSets.GenerateOpenAPIRecommendedRuleSet()
-			customFunctions, _ := LoadCustomFunctions(functionsFlag, silent)
+			customFunctions, functionsManager, _ := LoadCustomFunctions(functionsFlag, silent)
+			defer functionsManager.Close()
 
 			// HARD MODE
 			if hardModeFlag {
@@ -137,14 +170,62 @@ func GetLintCommand() *cobra.Command {
 					return rsErr
 				}
 
-				selectedRS, rsErr = BuildRuleSetFromUserSuppliedSet(rsBytes, defaultRuleSets)
+				selectedRS, rsErr = BuildRuleSetFromUserSuppliedLocation(rsBytes, rulesetFlag, defaultRuleSets)
 				if rsErr != nil {
 					return rsErr
 				}
+				defer selectedRS.CloseFunctions()
+			}
+
+			// if a baseline has been supplied, only new results will be counted.
+			var baseline *vacuum_report.Baseline
+			if updateBaselineFlag && baselineFlag == "" {
+				pterm.Error.Println("The --update-baseline flag requires a baseline file, use --baseline <file>")
+				pterm.Println()
+				return fmt.Errorf("no baseline file supplied")
+			}
+			if baselineFlag != "" {
+				// when updating, the specifications linted replace their own results, the rest are kept.
+				var bErr error
+				baseline, bErr = vacuum_report.LoadBaseline(baselineFlag, updateBaselineFlag)
+				if bErr != nil {
+					pterm.Error.Printf("Unable to load baseline '%s': %s\n", baselineFlag, bErr.Error())
+					pterm.Println()
+					return bErr
+				}
+			}
+
+			// fixes need the nodes rules found, not ones replayed from the cache, so fixing never uses it.
+			var resultCache *cache.Cache
+			if cacheDirFlag != "" {
+				if fixFlag || fixDryRunFlag || profiling {
+					if !silent {
+						pterm.Warning.Println("The result cache is not used when fixing or profiling, every rule will run")
+						pterm.Println()
+					}
+				} else {
+					var cErr error
+					if resultCache, cErr = openResultCache(cacheDirFlag, functionsFlag, selectedRS); cErr != nil {
+						pterm.Error.Printf("Unable to open cache '%s': %s\n", cacheDirFlag, cErr.Error())
+						pterm.Println()
+						return cErr
+					}
+				}
+			}
+
+			var sarifReport *vacuum_report.SarifReport
+			if formatFlag == lintFormatSarif {
+				sarifReport = vacuum_report.NewSarifReport(selectedRS.Rules, Version)
 			}
 
 			var printLock sync.Mutex
 
+			// profiles are collected from every file, and reported once they are all done.
+			var profiles *[]model.FileProfile
+			if profiling {
+				profiles = &[]model.FileProfile{}
+			}
+
 			doneChan := make(chan bool)
 
 			if len(args) <= 1 {
@@ -163,6 +244,35 @@ func GetLintCommand() *cobra.Command {
 				}
 			}
 
+			if watchFlag {
+				ctx, stop := watchContext(cmd.Context())
+				defer stop()
+				return watchLint(ctx, utils.LintFileRequest{
+					BaseFlag:                 baseFlag,
+					Remote:                   remoteFlag,
+					MultiFile:                mf,
+					SkipCheckFlag:            skipCheckFlag,
+					Silent:                   silent,
+					DetailsFlag:              detailsFlag,
+					FailSeverityFlag:         failSeverityFlag,
+					CategoryFlag:             categoryFlag,
+					SnippetsFlag:             snippetsFlag,
+					ErrorsFlag:               errorsFlag,
+					NoMessageFlag:            noMessage,
+					AllResultsFlag:           allResults,
+					TotalFiles:               len(args),
+					DefaultRuleSets:          defaultRuleSets,
+					SelectedRS:               selectedRS,
+					Functions:                customFunctions,
+					Logger:                   logger,
+					TimeoutFlag:              timeoutFlag,
+					IgnoreArrayCircleRef:     ignoreArrayCircleRef,
+					IgnorePolymorphCircleRef: ignorePolymorphCircleRef,
+					Baseline:                 baseline,
+					Cache:                    resultCache,
+				}, args, rulesetFlag, functionsFlag, functionsManager)
+			}
+
 			start := time.Now()
 
 			var filesProcessedSize int64
@@ -203,6 +313,13 @@ func GetLintCommand() *cobra.Command {
 						TimeoutFlag:              timeoutFlag,
 						IgnoreArrayCircleRef:     ignoreArrayCircleRef,
 						IgnorePolymorphCircleRef: ignorePolymorphCircleRef,
+						Baseline:                 baseline,
+						UpdateBaselineFlag:       updateBaselineFlag,
+						FixFlag:                  fixFlag,
+						FixDryRunFlag:            fixDryRunFlag,
+						Sarif:                    sarifReport,
+						Cache:                    resultCache,
+						Profiles:                 profiles,
 					}
 					fs, fp, err := lintFile(lfr)
 
@@ -220,7 +337,9 @@ func GetLintCommand() *cobra.Command {
 				completed++
 			}
 
-			if !detailsFlag {
+			if sarifReport != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), string(sarifReport.Render()))
+			} else if !detailsFlag {
 				pterm.Println()
 				pterm.Info.Println("To see full details of linting report, use the '-d' flag.")
 				pterm.Println()
@@ -228,6 +347,31 @@ func GetLintCommand() *cobra.Command {
 
 			duration := time.Since(start)
 
+			if updateBaselineFlag {
+				if bErr := baseline.Write(baselineFlag); bErr != nil {
+					pterm.Error.Printf("Unable to write baseline '%s': %s\n", baselineFlag, bErr.Error())
+					pterm.Println()
+					return bErr
+				}
+				if !silent {
+					pterm.Success.Printf("Baseline updated with %d results, written to '%s'\n", baseline.Len(), baselineFlag)
+					pterm.Println()
+				}
+			}
+
+			renderCacheStats(resultCache, silent)
+			if profiles != nil {
+				if profileFlag && !silent {
+					renderProfiles(*profiles)
+				}
+				if profileJSONFlag != "" {
+					if pErr := writeProfiles(profileJSONFlag, *profiles); pErr != nil {
+						pterm.Error.Printf("Unable to write profile '%s': %s\n", profileJSONFlag, pErr.Error())
+						pterm.Println()
+						return pErr
+					}
+				}
+			}
 			RenderTimeAndFiles(timeFlag, duration, filesProcessedSize, filesProcessed)
 
 			if len(errs) > 0 {
@@ -250,6 +394,15 @@ func GetLintCommand() *cobra.Command {
 	cmd.Flags().StringP("fail-severity", "n", model.SeverityError, "Results of this level or above will trigger a failure exit code")
 	cmd.Flags().Bool("ignore-array-circle-ref", false, "Ignore circular array references")
 	cmd.Flags().Bool("ignore-polymorph-circle-ref", false, "Ignore circular polymorphic references")
+	cmd.Flags().String("baseline", "", "Path to a baseline (vacuum report), only results not in the baseline will trigger a failure")
+	cmd.Flags().Bool("update-baseline", false, "Record the current results of the linted specifications into the baseline file, instead of checking against it")
+	cmd.Flags().Bool("fix", false, "Automatically fix results that have a fix available, files are updated in place")
+	cmd.Flags().Bool("fix-dry-run", false, "Show the fixes that would be applied by --fix, without changing any files")
+	cmd.Flags().String("format", lintFormatText, "Output format, 'text' for humans, or 'sarif' to print a SARIF 2.1.0 log")
+	cmd.Flags().String("cache-dir", "", "Cache rule results in this directory, rules are only run again when the files they depend on change")
+	cmd.Flags().Bool("profile", false, "Profile every rule, and show which rules take the most time (rules run one at a time)")
+	cmd.Flags().String("profile-json", "", "Profile every rule, and write the profile as JSON to this file")
+	cmd.Flags().Bool("watch", false, "Lint again every time the specification, the files it references, the ruleset or custom functions change")
 
 	regErr := cmd.RegisterFlagCompletionFunc("category", cobra.FixedCompletions([]string{
 		model.CategoryAll,
@@ -265,6 +418,13 @@ func GetLintCommand() *cobra.Command {
 	if regErr != nil {
 		panic(regErr)
 	}
+	regErr = cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{
+		lintFormatText,
+		lintFormatSarif,
+	}, cobra.ShellCompDirectiveNoFileComp))
+	if regErr != nil {
+		panic(regErr)
+	}
 	regErr = cmd.RegisterFlagCompletionFunc("fail-severity", cobra.FixedCompletions([]string{
 		model.SeverityInfo,
 		model.SeverityWarn,
@@ -277,6 +437,11 @@ func GetLintCommand() *cobra.Command {
 	return cmd
 }
 
+const (
+	lintFormatText  = "text"
+	lintFormatSarif = "sarif"
+)
+
 func lintFile(req utils.LintFileRequest) (int64, int, error) {
 	// read file.
 	specBytes, ferr := os.ReadFile(req.FileName)
@@ -292,19 +457,38 @@ func lintFile(req utils.LintFileRequest) (int64, int, error) {
 
 	}
 
-	result := motor.ApplyRulesToRuleSet(&motor.RuleSetExecution{
-		RuleSet:                      req.SelectedRS,
-		Spec:                         specBytes,
-		SpecFileName:                 req.FileName,
-		CustomFunctions:              req.Functions,
-		Base:                         req.BaseFlag,
-		AllowLookup:                  req.Remote,
-		SkipDocumentCheck:            req.SkipCheckFlag,
-		Logger:                       req.Logger,
-		Timeout:                      time.Duration(req.TimeoutFlag) * time.Second,
-		IgnoreCircularArrayRef:       req.IgnoreArrayCircleRef,
-		IgnoreCircularPolymorphicRef: req.IgnorePolymorphCircleRef,
-	})
+	lint := func(spec []byte) *motor.RuleSetExecutionResult {
+		return motor.ApplyRulesToRuleSet(&motor.RuleSetExecution{
+			RuleSet:                      req.SelectedRS,
+			Spec:                         spec,
+			SpecFileName:                 req.FileName,
+			CustomFunctions:              req.Functions,
+			FunctionLoader:               plugin.LoadFunctionSource,
+			Base:                         req.BaseFlag,
+			AllowLookup:                  req.Remote,
+			SkipDocumentCheck:            req.SkipCheckFlag,
+			Logger:                       req.Logger,
+			Timeout:                      time.Duration(req.TimeoutFlag) * time.Second,
+			IgnoreCircularArrayRef:       req.IgnoreArrayCircleRef,
+			IgnoreCircularPolymorphicRef: req.IgnorePolymorphCircleRef,
+			Cache:                        req.Cache,
+			Profile:                      req.Profiles != nil,
+		})
+	}
+
+	result := lint(specBytes)
+	if req.Profiles != nil {
+		req.Lock.Lock()
+		*req.Profiles = append(*req.Profiles, model.FileProfile{File: req.FileName, Rules: result.Profiles})
+		req.Lock.Unlock()
+	}
+
+	// apply any fixes, then carry on with whatever is left.
+	var fixReport *specFixReport
+	if len(result.Errors) == 0 && (req.FixFlag || req.FixDryRunFlag) {
+		specBytes, result, fixReport = fixSpecification(req, specBytes, result, lint)
+		specStringData = strings.Split(string(specBytes), "\n")
+	}
 
 	results := result.Results
 
@@ -318,12 +502,49 @@ func lintFile(req utils.LintFileRequest) (int64, int, error) {
 
 	resultSet := model.NewRuleResultSet(results)
 	resultSet.SortResultsByLineNumber()
+
+	// remove any results already known to the baseline, they don't count.
+	var knownResults []*model.RuleFunctionResult
+	if req.Baseline != nil {
+		if req.UpdateBaselineFlag {
+			resultSet.PrepareForSerialization(result.SpecInfo)
+			req.Baseline.Add(req.FileName, resultSet.Results)
+		}
+		var newResults []*model.RuleFunctionResult
+		newResults, knownResults = req.Baseline.Filter(req.FileName, resultSet.Results)
+		resultSet = model.NewRuleResultSetPointer(newResults)
+	}
+
 	warnings := resultSet.GetWarnCount()
 	errs := resultSet.GetErrorCount()
 	informs := resultSet.GetInfoCount()
+
+	// SARIF is rendered once all files are done, results are only collected.
+	if req.Sarif != nil {
+		baselineState := ""
+		if req.Baseline != nil {
+			baselineState = vacuum_report.SarifBaselineNew
+			req.Sarif.AddResults(req.FileName, knownResults, vacuum_report.SarifBaselineUnchanged)
+		}
+		req.Sarif.AddResults(req.FileName, resultSet.Results, baselineState)
+		suppressed := make([]*model.RuleFunctionResult, len(result.Suppressed))
+		for i := range result.Suppressed {
+			suppressed[i] = &result.Suppressed[i]
+		}
+		req.Sarif.AddResults(req.FileName, suppressed, "")
+		return result.FileSize, result.FilesProcessed, CheckFailureSeverity(req.FailSeverityFlag, errs, warnings, informs)
+	}
+
 	req.Lock.Lock()
 	defer req.Lock.Unlock()
+	fixReport.render(req.Silent, req.DetailsFlag)
+	if len(knownResults) > 0 && !req.Silent {
+		pterm.Info.Printf("%s results for '%s' match the baseline and have been ignored\n",
+			humanize.Comma(int64(len(knownResults))), req.FileName)
+		pterm.Println()
+	}
 	if !req.DetailsFlag {
+		RenderSuppressed(result.Suppressed, req.Silent, false)
 		RenderSummary(resultSet, req.Silent, req.TotalFiles, req.FileIndex, req.FileName, req.FailSeverityFlag)
 		return result.FileSize, result.FilesProcessed, CheckFailureSeverity(req.FailSeverityFlag, errs, warnings, informs)
 	}
@@ -343,6 +564,7 @@ func lintFile(req utils.LintFileRequest) (int64, int, error) {
 			req.FileName)
 	}
 
+	RenderSuppressed(result.Suppressed, req.Silent, true)
 	RenderSummary(resultSet, req.Silent, req.TotalFiles, req.FileIndex, req.FileName, req.FailSeverityFlag)
 
 	return result.FileSize, result.FilesProcessed, CheckFailureSeverity(req.FailSeverityFlag, errs, warnings, informs)
@@ -485,6 +707,31 @@ func renderCodeSnippet(r *model.RuleFunctionResult, specData []string) {
 	}
 }
 
+// RenderSuppressed will render out how many results were waived by comments or extensions, and when details
+// are requested, a table of each suppressed result and the reason it was suppressed.
+func RenderSuppressed(suppressed []model.RuleFunctionResult, silent, details bool) {
+	if silent || len(suppressed) == 0 {
+		return
+	}
+	pterm.Info.Printf("%s results suppressed by vacuum-ignore comments or %s extensions\n",
+		humanize.Comma(int64(len(suppressed))), model.SuppressionExtension)
+	if details {
+		tableData := [][]string{{"Location", "Rule", "Suppressed By", "Reason"}}
+		for _, r := range suppressed {
+			location := ""
+			if r.StartNode != nil {
+				location = fmt.Sprintf("%d:%d", r.StartNode.Line, r.StartNode.Column)
+			}
+			if r.Origin != nil {
+				location = fmt.Sprintf("%s:%d:%d", r.Origin.AbsoluteLocation, r.Origin.Line, r.Origin.Column)
+			}
+			tableData = append(tableData, []string{location, r.RuleId, r.Suppression.Kind, r.Suppression.Reason})
+		}
+		_ = pterm.DefaultTable.WithHasHeader().WithData(tableData).Render()
+	}
+	pterm.Println()
+}
+
 func RenderSummary(rs *model.RuleResultSet, silent bool, totalFiles, fileIndex int, filename, sev string) {
 
 	tableData := [][]string{{"Category", pterm.LightRed("Errors"), pterm.LightYellow("Warnings"),
@@ -0,0 +1,76 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/daveshanley/vacuum/cache"
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/pterm/pterm"
+)
+
+// openResultCache opens (or creates) the result cache in the directory. Cached results are only valid for this
+// version of vacuum, and the custom functions that are loaded (from the functions flag, or declared by the
+// ruleset), so they are all mixed into every key. Remote functions are known by where they are downloaded from.
+func openResultCache(cacheDir, functionsFlag string, rs *rulesets.RuleSet) (*cache.Cache, error) {
+	salt := sha256.New()
+	salt.Write([]byte(Version))
+	if functionsFlag != "" {
+		if err := saltDir(salt, functionsFlag); err != nil {
+			return nil, err
+		}
+	}
+	if rs != nil {
+		for _, source := range rs.FunctionSources {
+			salt.Write([]byte(source.Namespace))
+			salt.Write([]byte(source.Location))
+			salt.Write([]byte(strings.Join(source.Functions, ",")))
+			if source.Remote {
+				continue
+			}
+			// functions that can't be read can't be loaded either, linting reports the problem.
+			if err := saltDir(salt, source.Location); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+		}
+	}
+	return cache.NewCache(cacheDir, hex.EncodeToString(salt.Sum(nil)))
+}
+
+// saltDir adds the name and content of every file in a directory to the salt.
+func saltDir(salt hash.Hash, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, rErr := os.ReadFile(path)
+		if rErr != nil {
+			return rErr
+		}
+		salt.Write([]byte(path))
+		salt.Write(b)
+		return nil
+	})
+}
+
+// renderCacheStats prints how many rules were replayed from the cache, instead of being run.
+func renderCacheStats(c *cache.Cache, silent bool) {
+	if c == nil || silent {
+		return
+	}
+	total := c.Hits() + c.Misses()
+	if total == 0 {
+		return
+	}
+	pterm.Info.Printf("%d of %d rules were replayed from the cache in '%s'\n", c.Hits(), total, c.Dir())
+	pterm.Println()
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/daveshanley/vacuum/autofix"
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/motor"
+	"github.com/daveshanley/vacuum/utils"
+	"github.com/dustin/go-humanize"
+	"github.com/pterm/pterm"
+)
+
+// fixes can uncover or unblock other fixes (conflicting fixes are skipped), so they are applied in passes,
+// re-linting in between. This is how many passes are made before giving up.
+const maxFixPasses = 10
+
+type appliedFix struct {
+	file   string
+	result *model.RuleFunctionResult
+}
+
+type specFixReport struct {
+	fileName string
+	dryRun   bool
+	applied  []appliedFix
+	skipped  []*autofix.SkippedFix
+	errors   []error
+}
+
+// fixSpecification applies all the fixes available for the results of a specification (and any local files it
+// references), then lints it again, until there is nothing left to fix. In dry run mode, nothing is written,
+// the fixes that would be applied are reported.
+func fixSpecification(req utils.LintFileRequest, spec []byte, result *motor.RuleSetExecutionResult,
+	lint func([]byte) *motor.RuleSetExecutionResult) ([]byte, *motor.RuleSetExecutionResult, *specFixReport) {
+
+	report := &specFixReport{fileName: req.FileName, dryRun: req.FixDryRunFlag}
+	specAbs, _ := filepath.Abs(req.FileName)
+
+	for pass := 0; pass < maxFixPasses; pass++ {
+		results := make([]*model.RuleFunctionResult, len(result.Results))
+		for i := range result.Results {
+			results[i] = &result.Results[i]
+		}
+		grouped := autofix.GroupByFile(req.FileName, results)
+		files := make([]string, 0, len(grouped))
+		for f := range grouped {
+			files = append(files, f)
+		}
+		sort.Strings(files)
+
+		report.skipped = nil
+		appliedInPass := 0
+		for _, file := range files {
+			isSpec := file == req.FileName
+			if abs, _ := filepath.Abs(file); abs == specAbs {
+				isSpec = true
+			}
+			data := spec
+			if !isSpec {
+				var err error
+				if data, err = os.ReadFile(file); err != nil {
+					continue // remote, or something we can't touch.
+				}
+			}
+
+			fixed := autofix.ApplyFixes(data, grouped[file])
+			report.skipped = append(report.skipped, fixed.Skipped...)
+			for _, r := range fixed.Applied {
+				report.applied = append(report.applied, appliedFix{file: file, result: r})
+			}
+			if len(fixed.Applied) == 0 || req.FixDryRunFlag {
+				appliedInPass += len(fixed.Applied)
+				continue
+			}
+			if isSpec {
+				spec = fixed.Fixed
+				file = req.FileName
+			}
+			if err := writeFixedFile(file, fixed.Fixed); err != nil {
+				report.errors = append(report.errors, err)
+				continue
+			}
+			appliedInPass += len(fixed.Applied)
+		}
+
+		if req.FixDryRunFlag || appliedInPass == 0 || len(report.errors) > 0 {
+			break
+		}
+		result = lint(spec)
+		if len(result.Errors) > 0 {
+			break
+		}
+	}
+	return spec, result, report
+}
+
+func writeFixedFile(file string, data []byte) error {
+	mode := os.FileMode(0664)
+	if info, err := os.Stat(file); err == nil {
+		mode = info.Mode()
+	}
+	if err := os.WriteFile(file, data, mode); err != nil {
+		return fmt.Errorf("unable to write fixes to '%s': %w", file, err)
+	}
+	return nil
+}
+
+func (r *specFixReport) render(silent, details bool) {
+	if r == nil {
+		return
+	}
+	for _, err := range r.errors {
+		pterm.Error.Println(err.Error())
+	}
+	if silent {
+		return
+	}
+	if len(r.applied) == 0 && len(r.skipped) == 0 {
+		pterm.Info.Printf("No fixes available for '%s'\n", r.fileName)
+		pterm.Println()
+		return
+	}
+	if r.dryRun {
+		pterm.Info.Printf("Dry run: %s fixes would be applied to '%s'\n",
+			humanize.Comma(int64(len(r.applied))), r.fileName)
+	} else {
+		pterm.Success.Printf("Applied %s fixes to '%s'\n", humanize.Comma(int64(len(r.applied))), r.fileName)
+	}
+	for _, a := range r.applied {
+		pterm.Printf(" %s %s\n", pterm.Gray(fixLocation(a.file, a.result)), a.result.Fix.Description)
+	}
+	if len(r.skipped) > 0 {
+		pterm.Warning.Printf("%s fixes could not be applied\n", humanize.Comma(int64(len(r.skipped))))
+		if details {
+			for _, s := range r.skipped {
+				pterm.Printf(" %s %s: %s\n", pterm.Gray(fixLocation(r.fileName, s.Result)),
+					s.Result.Fix.Description, s.Reason)
+			}
+		}
+	}
+	pterm.Println()
+}
+
+func fixLocation(file string, result *model.RuleFunctionResult) string {
+	ruleId := result.RuleId
+	if ruleId == "" && result.Rule != nil {
+		ruleId = result.Rule.Id
+	}
+	if result.StartNode != nil {
+		return fmt.Sprintf("%s:%d:%d [%s]", file, result.StartNode.Line, result.StartNode.Column, ruleId)
+	}
+	return fmt.Sprintf("%s [%s]", file, ruleId)
+}
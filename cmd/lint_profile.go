@@ -0,0 +1,69 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/dustin/go-humanize"
+	"github.com/pterm/pterm"
+)
+
+// renderProfiles renders a table for each file, of the rules that ran against it, slowest first.
+func renderProfiles(profiles []model.FileProfile) {
+	sortFileProfiles(profiles)
+	for _, fp := range profiles {
+		pterm.Info.Printf("Rule profile for '%s' (%d rules)\n", fp.File, len(fp.Rules))
+		pterm.Println()
+		if len(fp.Rules) == 0 {
+			continue
+		}
+		tableData := [][]string{{"Rule", "Total", "Lookup", "Function", "Nodes", "Results", "Allocs", "Alloc Bytes"}}
+		for _, rp := range fp.Rules {
+			total := profileDuration(rp.Total)
+			if rp.TimedOut {
+				total = pterm.LightRed(total + " (timed out)")
+			}
+			tableData = append(tableData, []string{
+				rp.RuleId,
+				total,
+				profileDuration(rp.Lookup),
+				profileDuration(rp.Function),
+				humanize.Comma(int64(rp.Nodes)),
+				humanize.Comma(int64(rp.Results)),
+				humanize.Comma(int64(rp.Allocations)),
+				humanize.IBytes(rp.AllocatedBytes),
+			})
+		}
+		_ = pterm.DefaultTable.WithHasHeader().WithData(tableData).Render()
+		pterm.Println()
+	}
+}
+
+// writeProfiles writes the profiles of every file as JSON.
+func writeProfiles(location string, profiles []model.FileProfile) error {
+	sortFileProfiles(profiles)
+	b, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(location, b, 0664)
+}
+
+// files are linted concurrently, so profiles are sorted by file to make them stable.
+func sortFileProfiles(profiles []model.FileProfile) {
+	sort.SliceStable(profiles, func(i, j int) bool { return profiles[i].File < profiles[j].File })
+}
+
+func profileDuration(d time.Duration) string {
+	if d < time.Millisecond {
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	}
+	return fmt.Sprintf("%.1fms", float64(d.Microseconds())/1000)
+}
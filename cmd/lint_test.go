@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/rulesets"
+	vacuum_report "github.com/daveshanley/vacuum/vacuum-report"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -507,3 +511,173 @@ rules:
 	assert.NoError(t, err)
 	assert.NotNil(t, outBytes)
 }
+
+func TestGetLintCommand_Baseline(t *testing.T) {
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+
+	// no baseline, warnings fail the build.
+	cmd := GetLintCommand()
+	cmd.SetArgs([]string{"-x", "-n", "warn", "../model/test_files/burgershop.openapi.yaml"})
+	assert.Error(t, cmd.Execute())
+
+	// record the baseline
+	cmd = GetLintCommand()
+	cmd.SetArgs([]string{"-x", "-n", "warn", "--baseline", baseline, "--update-baseline",
+		"../model/test_files/burgershop.openapi.yaml"})
+	assert.NoError(t, cmd.Execute())
+	assert.FileExists(t, baseline)
+
+	// nothing new, so nothing fails.
+	cmd = GetLintCommand()
+	cmd.SetArgs([]string{"-x", "-n", "warn", "--baseline", baseline, "../model/test_files/burgershop.openapi.yaml"})
+	assert.NoError(t, cmd.Execute())
+}
+
+func TestGetLintCommand_BaselineSpecs(t *testing.T) {
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+	spec, _ := os.ReadFile("../model/test_files/burgershop.openapi.yaml")
+	copied := filepath.Join(t.TempDir(), "copied.openapi.yaml")
+	assert.NoError(t, os.WriteFile(copied, spec, 0664))
+
+	cmd := GetLintCommand()
+	cmd.SetArgs([]string{"-x", "-n", "warn", "--baseline", baseline, "--update-baseline",
+		"../model/test_files/burgershop.openapi.yaml"})
+	assert.NoError(t, cmd.Execute())
+
+	// the same results in another specification are new.
+	cmd = GetLintCommand()
+	cmd.SetArgs([]string{"-x", "-n", "warn", "--baseline", baseline,
+		"../model/test_files/burgershop.openapi.yaml", copied})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestGetLintCommand_UpdateBaselineMissingFile(t *testing.T) {
+	cmd := GetLintCommand()
+	cmd.SetArgs([]string{"-x", "--update-baseline", "../model/test_files/burgershop.openapi.yaml"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestGetLintCommand_Fix(t *testing.T) {
+	spec := `openapi: 3.1.0
+info:
+  title: fix me # keep this comment
+  version: 1.0.0
+paths:
+  /pets/:
+    get:
+      operationId: getPets
+      responses:
+        '200':
+          description: ok
+`
+	file := filepath.Join(t.TempDir(), "spec.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(spec), 0664))
+
+	// dry run leaves the file alone.
+	cmd := GetLintCommand()
+	cmd.SetArgs([]string{"-x", "--fix-dry-run", file})
+	_ = cmd.Execute()
+	data, _ := os.ReadFile(file)
+	assert.Equal(t, spec, string(data))
+
+	cmd = GetLintCommand()
+	cmd.SetArgs([]string{"-x", "--fix", file})
+	_ = cmd.Execute()
+	data, _ = os.ReadFile(file)
+	assert.Contains(t, string(data), "  /pets:\n")
+	assert.Contains(t, string(data), "# keep this comment")
+}
+
+func TestGetLintCommand_FormatSarif(t *testing.T) {
+	cmd := GetLintCommand()
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+	cmd.SetArgs([]string{"--format", "sarif", "../model/test_files/burgershop.openapi.yaml"})
+	exErr := cmd.Execute()
+	assert.NoError(t, exErr)
+
+	var log vacuum_report.SarifLog
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &log))
+	assert.Equal(t, vacuum_report.SarifVersion, log.Version)
+	assert.NotEmpty(t, log.Runs[0].Tool.Driver.Rules)
+	assert.NotEmpty(t, log.Runs[0].Results)
+}
+
+func TestGetLintCommand_FormatUnknown(t *testing.T) {
+	cmd := GetLintCommand()
+	cmd.SetArgs([]string{"--format", "xml", "../model/test_files/burgershop.openapi.yaml"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestGetLintCommand_CacheDir(t *testing.T) {
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+
+	lint := func() vacuum_report.SarifLog {
+		cmd := GetLintCommand()
+		b := bytes.NewBufferString("")
+		cmd.SetOut(b)
+		cmd.SetArgs([]string{"--format", "sarif", "--cache-dir", cacheDir,
+			"../model/test_files/burgershop.openapi.yaml"})
+		assert.NoError(t, cmd.Execute())
+		var log vacuum_report.SarifLog
+		assert.NoError(t, json.Unmarshal(b.Bytes(), &log))
+		return log
+	}
+
+	cold := lint()
+	entries, _ := os.ReadDir(cacheDir)
+	assert.NotEmpty(t, entries)
+
+	// replayed results are the same results.
+	warm := lint()
+	assert.Len(t, warm.Runs[0].Results, len(cold.Runs[0].Results))
+}
+
+func TestOpenResultCache_RuleSetFunctions(t *testing.T) {
+	functions := t.TempDir()
+	location := filepath.Join(functions, "check.js")
+	assert.NoError(t, os.WriteFile(location, []byte("function runRule(input) { return []; }"), 0644))
+	rs := &rulesets.RuleSet{FunctionSources: []*rulesets.FunctionSource{
+		{Namespace: "ruleset.yaml", Location: functions, Functions: []string{"check"}},
+	}}
+
+	key := func(rs *rulesets.RuleSet) string {
+		c, err := openResultCache(t.TempDir(), "", rs)
+		assert.NoError(t, err)
+		return c.Key([]byte("rule"))
+	}
+	before := key(rs)
+	assert.NotEqual(t, key(nil), before)
+	assert.Equal(t, before, key(rs))
+
+	// changing a function declared by the ruleset means cached results can't be used.
+	assert.NoError(t, os.WriteFile(location, []byte("function runRule(input) { return [{}]; }"), 0644))
+	assert.NotEqual(t, before, key(rs))
+}
+
+func TestGetLintCommand_ProfileJSON(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), "profile.json")
+
+	cmd := GetLintCommand()
+	cmd.SetOut(bytes.NewBufferString(""))
+	cmd.SetArgs([]string{"-x", "--profile", "--profile-json", profile, "../model/test_files/burgershop.openapi.yaml"})
+	assert.NoError(t, cmd.Execute())
+
+	b, err := os.ReadFile(profile)
+	assert.NoError(t, err)
+	var profiles []model.FileProfile
+	assert.NoError(t, json.Unmarshal(b, &profiles))
+	assert.Len(t, profiles, 1)
+	assert.Equal(t, "../model/test_files/burgershop.openapi.yaml", profiles[0].File)
+	assert.NotEmpty(t, profiles[0].Rules)
+	for i := 1; i < len(profiles[0].Rules); i++ {
+		assert.GreaterOrEqual(t, profiles[0].Rules[i-1].Total, profiles[0].Rules[i].Total)
+	}
+}
+
+func TestGetLintCommand_ProfileWatch(t *testing.T) {
+	cmd := GetLintCommand()
+	cmd.SetOut(bytes.NewBufferString(""))
+	cmd.SetArgs([]string{"-x", "--profile", "--watch", "../model/test_files/burgershop.openapi.yaml"})
+	assert.ErrorContains(t, cmd.Execute(), "--watch cannot be combined")
+}
@@ -0,0 +1,231 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/motor"
+	"github.com/daveshanley/vacuum/plugin"
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/daveshanley/vacuum/utils"
+	"github.com/daveshanley/vacuum/watch"
+	"github.com/dustin/go-humanize"
+	"github.com/pterm/pterm"
+)
+
+type watchedLint struct {
+	resultSet  *model.RuleResultSet
+	suppressed []model.RuleFunctionResult
+	known      int
+	specLines  []string
+	files      []string
+}
+
+// watchLint lints the specifications, then lints them again every time they (or anything they reference, the
+// ruleset or the custom functions) change. The first run renders like a normal lint, after that only the results
+// that are new, or have been fixed, are rendered. functions holds the custom functions of the request, it's
+// closed when they are reloaded.
+func watchLint(ctx context.Context, req utils.LintFileRequest, specs []string, rulesetFlag, functionsFlag string,
+	functions *plugin.Manager) error {
+	previous := make(map[string][]*model.RuleFunctionResult)
+	first := true
+	defer func() {
+		functions.Close()
+		req.SelectedRS.CloseFunctions()
+	}()
+
+	run := func(changed []string) []string {
+		if !first {
+			if !req.Silent {
+				pterm.Println()
+				pterm.Info.Printf("[%s] %s changed, linting again\n", time.Now().Format("15:04:05"),
+					describeChanged(changed))
+				pterm.Println()
+			}
+			selected, customFunctions, reloaded := reloadLintRules(req, changed, rulesetFlag, functionsFlag)
+			if selected != req.SelectedRS {
+				req.SelectedRS.CloseFunctions()
+			}
+			if reloaded != nil {
+				functions.Close()
+				functions = reloaded
+			}
+			req.SelectedRS, req.Functions = selected, customFunctions
+
+			// new custom functions (or a ruleset declaring different ones) mean new results, even if nothing else
+			// changed.
+			if req.Cache != nil && (changedIn(changed, functionsFlag) || changedIn(changed, rulesetFlag)) {
+				if c, err := openResultCache(req.Cache.Dir(), functionsFlag, req.SelectedRS); err == nil {
+					req.Cache = c
+				}
+			}
+		}
+
+		var loaded []string
+		for i, spec := range specs {
+			req.FileName = spec
+			req.FileIndex = i
+			lint, err := lintWatchedFile(req)
+			if err != nil {
+				continue
+			}
+			loaded = append(loaded, lint.files...)
+			if first {
+				renderWatchedLint(req, lint)
+			} else {
+				renderWatchedDelta(req, lint, previous[spec])
+			}
+			previous[spec] = lint.resultSet.Results
+		}
+		first = false
+		return loaded
+	}
+
+	files, dirs := watchTargets(specs, rulesetFlag, functionsFlag)
+	return watchAndRun(ctx, files, dirs, req.Silent, run)
+}
+
+// reloadLintRules rebuilds the ruleset and custom functions, if they changed. If they can't be loaded, the
+// previous ones are kept, so a half-written ruleset does not stop the watch. The manager of the custom functions
+// is only returned when they are reloaded.
+func reloadLintRules(req utils.LintFileRequest, changed []string, rulesetFlag, functionsFlag string) (
+	selected *rulesets.RuleSet, functions map[string]model.RuleFunction, manager *plugin.Manager) {
+
+	selected, functions = req.SelectedRS, req.Functions
+	if changedIn(changed, rulesetFlag) {
+		rsBytes, err := os.ReadFile(rulesetFlag)
+		if err == nil {
+			if rs, rsErr := BuildRuleSetFromUserSuppliedLocation(rsBytes, rulesetFlag, req.DefaultRuleSets); rsErr == nil {
+				selected = rs
+			}
+		} else {
+			pterm.Error.Printf("Unable to read ruleset file '%s': %s\n", rulesetFlag, err.Error())
+			pterm.Println()
+		}
+	}
+	if changedIn(changed, functionsFlag) {
+		if cf, pm, err := LoadCustomFunctions(functionsFlag, true); err == nil {
+			functions, manager = cf, pm
+		}
+	}
+	return selected, functions, manager
+}
+
+// lintWatchedFile lints a single specification, without rendering anything but errors.
+func lintWatchedFile(req utils.LintFileRequest) (*watchedLint, error) {
+	specBytes, err := os.ReadFile(req.FileName)
+	if err != nil {
+		pterm.Error.Printf("Unable to read file '%s': %s\n", req.FileName, err.Error())
+		pterm.Println()
+		return nil, err
+	}
+
+	result := motor.ApplyRulesToRuleSet(&motor.RuleSetExecution{
+		RuleSet:                      req.SelectedRS,
+		Spec:                         specBytes,
+		SpecFileName:                 req.FileName,
+		CustomFunctions:              req.Functions,
+		FunctionLoader:               plugin.LoadFunctionSource,
+		Base:                         req.BaseFlag,
+		AllowLookup:                  req.Remote,
+		SkipDocumentCheck:            req.SkipCheckFlag,
+		Logger:                       req.Logger,
+		Timeout:                      time.Duration(req.TimeoutFlag) * time.Second,
+		IgnoreCircularArrayRef:       req.IgnoreArrayCircleRef,
+		IgnoreCircularPolymorphicRef: req.IgnorePolymorphCircleRef,
+		Cache:                        req.Cache,
+	})
+
+	if len(result.Errors) > 0 {
+		for _, e := range result.Errors {
+			pterm.Error.Printf("unable to process spec '%s', error: %s", req.FileName, e.Error())
+			pterm.Println()
+		}
+		return nil, fmt.Errorf("linting failed due to %d issues", len(result.Errors))
+	}
+
+	resultSet := model.NewRuleResultSet(result.Results)
+	resultSet.SortResultsByLineNumber()
+
+	lint := &watchedLint{
+		resultSet:  resultSet,
+		suppressed: result.Suppressed,
+		specLines:  strings.Split(string(specBytes), "\n"),
+		files:      result.Files,
+	}
+	if req.Baseline != nil {
+		newResults, knownResults := req.Baseline.Filter(req.FileName, resultSet.Results)
+		lint.resultSet = model.NewRuleResultSetPointer(newResults)
+		lint.known = len(knownResults)
+	}
+	return lint, nil
+}
+
+func renderWatchedLint(req utils.LintFileRequest, lint *watchedLint) {
+	if lint.known > 0 && !req.Silent {
+		pterm.Info.Printf("%s results for '%s' match the baseline and have been ignored\n",
+			humanize.Comma(int64(lint.known)), req.FileName)
+		pterm.Println()
+	}
+	if req.DetailsFlag && len(lint.resultSet.Results) > 0 {
+		abs, _ := filepath.Abs(req.FileName)
+		processResults(lint.resultSet.Results, lint.specLines, req.SnippetsFlag, req.ErrorsFlag, req.Silent,
+			req.NoMessageFlag, req.AllResultsFlag, abs, req.FileName)
+	}
+	RenderSuppressed(lint.suppressed, req.Silent, req.DetailsFlag)
+	RenderSummary(lint.resultSet, req.Silent, req.TotalFiles, req.FileIndex, req.FileName, req.FailSeverityFlag)
+	pterm.Println()
+}
+
+// renderWatchedDelta renders the results that are new since the previous run, the results that have been fixed,
+// and then the summary of everything.
+func renderWatchedDelta(req utils.LintFileRequest, lint *watchedLint, previous []*model.RuleFunctionResult) {
+	if req.Silent {
+		return
+	}
+	added, fixed := watch.Delta(previous, lint.resultSet.Results)
+	if len(added) == 0 && len(fixed) == 0 {
+		pterm.Info.Printf("No new or fixed results for '%s'\n", req.FileName)
+		pterm.Println()
+		return
+	}
+
+	pterm.Info.Printf("'%s': %s new results, %s fixed\n", req.FileName,
+		humanize.Comma(int64(len(added))), humanize.Comma(int64(len(fixed))))
+
+	if len(added) > 0 {
+		abs, _ := filepath.Abs(req.FileName)
+		processResults(added, lint.specLines, req.SnippetsFlag, req.ErrorsFlag, req.Silent,
+			req.NoMessageFlag, req.AllResultsFlag, abs, req.FileName)
+	}
+	if len(fixed) > 0 {
+		tableData := [][]string{{"Fixed", "Rule", "Message", "Path"}}
+		for _, r := range fixed {
+			ruleId := r.RuleId
+			if r.Rule != nil {
+				ruleId = r.Rule.Id
+			}
+			tableData = append(tableData, []string{pterm.LightGreen("✓"), ruleId, r.Message, r.Path})
+		}
+		pterm.Println()
+		_ = pterm.DefaultTable.WithHasHeader().WithData(tableData).Render()
+	}
+	pterm.Println()
+	RenderSummary(lint.resultSet, req.Silent, req.TotalFiles, req.FileIndex, req.FileName, req.FailSeverityFlag)
+	pterm.Println()
+}
+
+func describeChanged(changed []string) string {
+	if len(changed) == 1 {
+		return fmt.Sprintf("'%s'", filepath.Base(changed[0]))
+	}
+	return fmt.Sprintf("%d files", len(changed))
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/daveshanley/vacuum/plugin"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -25,6 +26,7 @@ var (
 
 func Execute(version, commit, date string) {
 	Version = version
+	plugin.VacuumVersion = version
 	Commit = commit
 	Date = date
 	if err := GetRootCommand().Execute(); err != nil {
@@ -87,6 +89,10 @@ func GetRootCommand() *cobra.Command {
 	rootCmd.AddCommand(GetGenerateVersionCommand())
 	rootCmd.AddCommand(GetLanguageServerCommand())
 	rootCmd.AddCommand(GetBundleCommand())
+	rootCmd.AddCommand(GetDiffCommand())
+	rootCmd.AddCommand(GetServeCommand())
+	rootCmd.AddCommand(GetTestRulesCommand())
+	rootCmd.AddCommand(GetRulesetCommand())
 
 	return rootCmd
 }
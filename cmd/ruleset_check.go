@@ -0,0 +1,158 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/daveshanley/vacuum/functions"
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/plugin"
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// GetRulesetCommand returns the 'ruleset' command, for working with rulesets.
+func GetRulesetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ruleset",
+		Short: "Work with rulesets",
+	}
+	cmd.AddCommand(GetRulesetCheckCommand())
+	return cmd
+}
+
+// GetRulesetCheckCommand returns the 'ruleset check' command, which validates a ruleset without linting anything.
+func GetRulesetCheckCommand() *cobra.Command {
+
+	cmd := &cobra.Command{
+		SilenceUsage: true,
+		Use:          "check <ruleset.yaml>",
+		Short:        "Check a ruleset for problems, before using it",
+		Long: "Check the rules of a ruleset (and the rulesets it extends) can run. Rules that use functions that " +
+			"don't exist, give functions invalid options, or have a 'given' path that is not valid JSONPath are " +
+			"errors. Unknown formats and categories are warnings. Each problem is reported with its line and column " +
+			"in the ruleset.",
+		Example: "vacuum ruleset check my-ruleset.yaml -f ./functions",
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return []string{"yaml", "yml", "json"}, cobra.ShellCompDirectiveFilterFileExt
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			functionsFlag, _ := cmd.Flags().GetString("functions")
+			jsonFlag, _ := cmd.Flags().GetBool("json")
+			noStyleFlag, _ := cmd.Flags().GetBool("no-style")
+
+			if noStyleFlag {
+				pterm.DisableColor()
+				pterm.DisableStyling()
+			}
+
+			if len(args) < 1 {
+				pterm.Error.Println("Please supply a ruleset to check")
+				pterm.Println()
+				return fmt.Errorf("no ruleset supplied")
+			}
+			location := args[0]
+
+			rsBytes, err := os.ReadFile(location)
+			if err != nil {
+				pterm.Error.Printf("Unable to read ruleset file '%s': %s\n", location, err.Error())
+				pterm.Println()
+				return err
+			}
+			rs, err := BuildRuleSetFromUserSuppliedLocation(rsBytes, location, rulesets.BuildDefaultRuleSets())
+			if err != nil {
+				return err
+			}
+			defer rs.CloseFunctions()
+
+			customFunctions, functionsManager, err := LoadCustomFunctions(functionsFlag, true)
+			if err != nil {
+				return err
+			}
+			defer functionsManager.Close()
+			diagnostics := CheckRuleSet(rs, customFunctions)
+
+			var root yaml.Node
+			if yaml.Unmarshal(rsBytes, &root) == nil {
+				rulesets.Locate(&root, diagnostics)
+			}
+
+			if jsonFlag {
+				b, _ := json.MarshalIndent(diagnostics, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(b))
+			} else {
+				for _, d := range diagnostics {
+					fmt.Fprintln(cmd.OutOrStdout(), renderDiagnostic(location, d))
+				}
+			}
+
+			errs := 0
+			for _, d := range diagnostics {
+				if d.Severity == model.SeverityError {
+					errs++
+				}
+			}
+			if errs > 0 {
+				return fmt.Errorf("ruleset has %d errors", errs)
+			}
+			if !jsonFlag {
+				pterm.Success.Printf("Ruleset '%s' has no errors (%d warnings)\n", location, len(diagnostics))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool("json", false, "Print the problems as JSON")
+	cmd.Flags().BoolP("no-style", "q", false, "Disable styling and color output, just plain text (useful for CI/CD)")
+	return cmd
+}
+
+// CheckRuleSet validates a ruleset, using the builtin functions, the supplied custom functions and the functions
+// declared by the ruleset itself. Functions declared by the ruleset that can't be loaded are errors.
+func CheckRuleSet(rs *rulesets.RuleSet, customFunctions map[string]model.RuleFunction) []*rulesets.Diagnostic {
+	var diagnostics []*rulesets.Diagnostic
+	available := make(map[string]model.RuleFunction, len(customFunctions))
+	for name, function := range customFunctions {
+		available[name] = function
+	}
+	for _, fs := range rs.FunctionSources {
+		loaded, err := fs.Load(plugin.LoadFunctionSource)
+		if err != nil {
+			diagnostics = append(diagnostics, &rulesets.Diagnostic{
+				Severity: model.SeverityError,
+				Message:  err.Error(),
+				Path:     []string{"functions"},
+			})
+			continue
+		}
+		for name, function := range loaded {
+			available[name] = function
+		}
+	}
+
+	builtin := functions.MapBuiltinFunctions()
+	return append(diagnostics, rs.Validate(func(name string) model.RuleFunction {
+		if f := builtin.FindFunction(name); f != nil {
+			return f
+		}
+		return available[name]
+	})...)
+}
+
+func renderDiagnostic(location string, d *rulesets.Diagnostic) string {
+	at := location
+	if d.Line > 0 {
+		at = fmt.Sprintf("%s:%d:%d", location, d.Line, d.Column)
+	}
+	rule := ""
+	if d.RuleId != "" {
+		rule = fmt.Sprintf(" [%s]", d.RuleId)
+	}
+	return fmt.Sprintf("%s %s%s %s", at, d.Severity, rule, d.Message)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeRuleSet(t *testing.T, ruleset string) string {
+	location := filepath.Join(t.TempDir(), "ruleset.yaml")
+	assert.NoError(t, os.WriteFile(location, []byte(ruleset), 0644))
+	return location
+}
+
+func TestGetRulesetCheckCommand(t *testing.T) {
+	location := writeRuleSet(t, `rules:
+  info-contact:
+    given: $.info
+    then:
+      field: contact
+      function: truthy
+`)
+	cmd := GetRulesetCommand()
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+	cmd.SetArgs([]string{"check", "-q", location})
+	assert.NoError(t, cmd.Execute())
+	assert.Empty(t, b.String())
+}
+
+func TestGetRulesetCheckCommand_Errors(t *testing.T) {
+	location := writeRuleSet(t, `rules:
+  info-contact:
+    given: $.info
+    then:
+      field: contact
+      function: truthful
+  info-length:
+    given: $.info.title
+    then:
+      function: length
+`)
+	cmd := GetRulesetCommand()
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+	cmd.SetArgs([]string{"check", "-q", location})
+	assert.EqualError(t, cmd.Execute(), "ruleset has 2 errors")
+	assert.Contains(t, b.String(), location+":6:7 error [info-contact] unknown function 'truthful'")
+	assert.Contains(t, b.String(), location+":9:5 error [info-length] invalid options for function 'length'")
+}
+
+func TestGetRulesetCheckCommand_JSON(t *testing.T) {
+	location := writeRuleSet(t, `rules:
+  info-contact:
+    given: $.info[
+    then:
+      function: truthy
+`)
+	cmd := GetRulesetCommand()
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+	cmd.SetArgs([]string{"check", "--json", location})
+	assert.Error(t, cmd.Execute())
+
+	var diagnostics []*rulesets.Diagnostic
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &diagnostics))
+	assert.Len(t, diagnostics, 1)
+	assert.Equal(t, "info-contact", diagnostics[0].RuleId)
+	assert.Equal(t, []string{"rules", "info-contact", "given"}, diagnostics[0].Path)
+	assert.Equal(t, 3, diagnostics[0].Line)
+}
+
+func TestGetRulesetCheckCommand_NoRuleset(t *testing.T) {
+	cmd := GetRulesetCommand()
+	cmd.SetOut(bytes.NewBufferString(""))
+	cmd.SetArgs([]string{"check"})
+	assert.Error(t, cmd.Execute())
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+// https://pb33f.io
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"syscall"
+
+	lintserver "github.com/daveshanley/vacuum/lint-server"
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/daveshanley/vacuum/utils"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+func GetServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		SilenceUsage: true,
+		Use:          "serve",
+		Short:        "Run a long-running HTTP server that lints OpenAPI specifications",
+		Long: "Start an HTTP server that keeps rulesets and custom functions loaded, so specifications can be linted " +
+			"without paying the startup cost every time. POST a specification to /lint (optionally in a JSON envelope " +
+			"with an inline ruleset) to get a spectral, vacuum or SARIF report back. Inline rulesets can only use the " +
+			"functions the server was started with, and only extend the built-in rulesets. The rules and functions available " +
+			"are listed by /rules and /functions, and /health reports the state of the server.",
+		Example: "vacuum serve --port 9090 -r my-ruleset.yaml",
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			rulesetFlag, _ := cmd.Flags().GetString("ruleset")
+			functionsFlag, _ := cmd.Flags().GetString("functions")
+			baseFlag, _ := cmd.Flags().GetString("base")
+			skipCheckFlag, _ := cmd.Flags().GetBool("skip-check")
+			remoteFlag, _ := cmd.Flags().GetBool("remote")
+			timeoutFlag, _ := cmd.Flags().GetInt("timeout")
+			hardModeFlag, _ := cmd.Flags().GetBool("hard-mode")
+			hostFlag, _ := cmd.Flags().GetString("host")
+			portFlag, _ := cmd.Flags().GetInt("port")
+			maxConcurrentFlag, _ := cmd.Flags().GetInt("max-concurrent")
+			noStyleFlag, _ := cmd.Flags().GetBool("no-style")
+			noBanner, _ := cmd.Flags().GetBool("no-banner")
+			ignoreArrayCircleRef, _ := cmd.Flags().GetBool("ignore-array-circle-ref")
+			ignorePolymorphCircleRef, _ := cmd.Flags().GetBool("ignore-polymorph-circle-ref")
+
+			if noStyleFlag {
+				pterm.DisableColor()
+				pterm.DisableStyling()
+			}
+
+			if !noBanner {
+				PrintBanner()
+			}
+
+			// rule logs would be printed for every request, nobody is watching them.
+			logger := slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError}))
+
+			defaultRuleSets := rulesets.BuildDefaultRuleSetsWithLogger(logger)
+			selectedRS := defaultRuleSets.GenerateOpenAPIRecommendedRuleSet()
+			customFunctions, functionsManager, err := LoadCustomFunctions(functionsFlag, false)
+			if err != nil {
+				return err
+			}
+			defer functionsManager.Close()
+
+			// HARD MODE
+			if hardModeFlag {
+				selectedRS = defaultRuleSets.GenerateOpenAPIDefaultRuleSet()
+
+				// extract all OWASP Rules
+				owaspRules := rulesets.GetAllOWASPRules()
+				allRules := selectedRS.Rules
+				for k, v := range owaspRules {
+					allRules[k] = v
+				}
+			}
+
+			if rulesetFlag != "" {
+				rsBytes, rsErr := os.ReadFile(rulesetFlag)
+				if rsErr != nil {
+					pterm.Error.Printf("Unable to read ruleset file '%s': %s\n", rulesetFlag, rsErr.Error())
+					pterm.Println()
+					return rsErr
+				}
+				selectedRS, rsErr = BuildRuleSetFromUserSuppliedLocation(rsBytes, rulesetFlag, defaultRuleSets)
+				if rsErr != nil {
+					return rsErr
+				}
+				defer selectedRS.CloseFunctions()
+			}
+
+			if maxConcurrentFlag < 1 {
+				maxConcurrentFlag = runtime.NumCPU()
+			}
+
+			lfr := utils.LintFileRequest{
+				BaseFlag:                 baseFlag,
+				Remote:                   remoteFlag,
+				SkipCheckFlag:            skipCheckFlag,
+				DefaultRuleSets:          defaultRuleSets,
+				SelectedRS:               selectedRS,
+				Functions:                customFunctions,
+				TimeoutFlag:              timeoutFlag,
+				IgnoreArrayCircleRef:     ignoreArrayCircleRef,
+				IgnorePolymorphCircleRef: ignorePolymorphCircleRef,
+				Logger:                   logger,
+			}
+
+			addr := net.JoinHostPort(hostFlag, strconv.Itoa(portFlag))
+			pterm.Info.Printf("Serving %d rules: %s\n", len(selectedRS.Rules), selectedRS.DocumentationURI)
+			pterm.Info.Printf("Linting up to %d specifications at a time, on http://%s\n", maxConcurrentFlag, addr)
+			pterm.Println()
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if err = lintserver.NewServer(Version, &lfr, maxConcurrentFlag).Run(ctx, addr); err != nil {
+				pterm.Error.Printf("Unable to serve on '%s': %s\n", addr, err.Error())
+				pterm.Println()
+				return fmt.Errorf("unable to serve: %w", err)
+			}
+			pterm.Info.Println("vacuum server stopped")
+			return nil
+		},
+	}
+	cmd.Flags().String("host", "localhost", "Host or address to listen on")
+	cmd.Flags().Int("port", 9090, "Port to listen on")
+	cmd.Flags().Int("max-concurrent", runtime.NumCPU(), "Maximum number of specifications linted at the same time")
+	cmd.Flags().BoolP("no-style", "q", false, "Disable styling and color output, just plain text (useful for CI/CD)")
+	cmd.Flags().BoolP("no-banner", "b", false, "Disable the banner / header output")
+	cmd.Flags().Bool("ignore-array-circle-ref", false, "Ignore circular array references")
+	cmd.Flags().Bool("ignore-polymorph-circle-ref", false, "Ignore circular polymorphic references")
+	return cmd
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetServeCommand_BadRuleset(t *testing.T) {
+	cmd := GetServeCommand()
+	cmd.PersistentFlags().StringP("ruleset", "r", "", "")
+	cmd.SetArgs([]string{"-r", "nowhere.yaml", "-b"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestGetServeCommand_PortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	cmd := GetServeCommand()
+	cmd.SetArgs([]string{"-b", "--host", "127.0.0.1",
+		"--port", strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)})
+	err = cmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to serve")
+}
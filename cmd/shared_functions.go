@@ -17,6 +17,12 @@ import (
 // BuildRuleSetFromUserSuppliedSet creates a ready to run ruleset, augmented or provided by a user
 // configured ruleset. This ruleset could be lifted directly from a Spectral configuration.
 func BuildRuleSetFromUserSuppliedSet(rsBytes []byte, rs rulesets.RuleSets) (*rulesets.RuleSet, error) {
+	return BuildRuleSetFromUserSuppliedLocation(rsBytes, "", rs)
+}
+
+// BuildRuleSetFromUserSuppliedLocation is BuildRuleSetFromUserSuppliedSet for a ruleset read from a file, functions
+// declared by the ruleset are found relative to that file.
+func BuildRuleSetFromUserSuppliedLocation(rsBytes []byte, location string, rs rulesets.RuleSets) (*rulesets.RuleSet, error) {
 
 	// load in our user supplied ruleset and try to validate it.
 	userRS, userErr := rulesets.CreateRuleSetFromData(rsBytes)
@@ -26,6 +32,7 @@ func BuildRuleSetFromUserSuppliedSet(rsBytes []byte, rs rulesets.RuleSets) (*rul
 		return nil, userErr
 
 	}
+	userRS.Location = location
 	return rs.GenerateRuleSetFromSuppliedRuleSet(userRS), nil
 }
 
@@ -81,20 +88,21 @@ func PrintBanner() {
 	pterm.Println()
 }
 
-// LoadCustomFunctions will scan for (and load) custom functions defined as vacuum plugins.
-func LoadCustomFunctions(functionsFlag string, silence bool) (map[string]model.RuleFunction, error) {
+// LoadCustomFunctions will scan for (and load) custom functions defined as vacuum plugins. The returned manager
+// holds what the functions need to run (like plugin processes), it should be closed once they are no longer needed.
+func LoadCustomFunctions(functionsFlag string, silence bool) (map[string]model.RuleFunction, *plugin.Manager, error) {
 	// check custom functions
 	if functionsFlag != "" {
 		pm, err := plugin.LoadFunctions(functionsFlag, silence)
 		if err != nil {
 			pterm.Error.Printf("Unable to open custom functions: %v\n", err)
 			pterm.Println()
-			return nil, err
+			return nil, nil, err
 		}
 		pterm.Info.Printf("Loaded %d custom function(s) successfully.\n", pm.LoadedFunctionCount())
-		return pm.GetCustomFunctions(), nil
+		return pm.GetCustomFunctions(), pm, nil
 	}
-	return nil, nil
+	return nil, nil, nil
 }
 
 func CheckFailureSeverity(failSeverityFlag string, errors int, warnings int, informs int) error {
@@ -10,10 +10,13 @@ import (
 	"fmt"
 	"github.com/daveshanley/vacuum/model"
 	"github.com/daveshanley/vacuum/motor"
+	"github.com/daveshanley/vacuum/plugin"
 	"github.com/daveshanley/vacuum/rulesets"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -133,17 +136,20 @@ func GetSpectralReportCommand() *cobra.Command {
 			// and see if it's valid. If so - let's go!
 			if rulesetFlag != "" {
 
-				customFunctions, _ = LoadCustomFunctions(functionsFlag, true)
+				var functionsManager *plugin.Manager
+				customFunctions, functionsManager, _ = LoadCustomFunctions(functionsFlag, true)
+				defer functionsManager.Close()
 				rsBytes, rsErr := os.ReadFile(rulesetFlag)
 				if rsErr != nil {
 					pterm.Error.Printf("Unable to read ruleset file '%s': %s\n", rulesetFlag, rsErr.Error())
 					pterm.Println()
 					return rsErr
 				}
-				selectedRS, rsErr = BuildRuleSetFromUserSuppliedSet(rsBytes, defaultRuleSets)
+				selectedRS, rsErr = BuildRuleSetFromUserSuppliedLocation(rsBytes, rulesetFlag, defaultRuleSets)
 				if rsErr != nil {
 					return rsErr
 				}
+				defer selectedRS.CloseFunctions()
 			}
 
 			if !stdIn && !stdOut {
@@ -154,6 +160,7 @@ func GetSpectralReportCommand() *cobra.Command {
 				RuleSet:           selectedRS,
 				Spec:              specBytes,
 				CustomFunctions:   customFunctions,
+				FunctionLoader:    plugin.LoadFunctionSource,
 				SilenceLogs:       true,
 				Base:              baseFlag,
 				SkipDocumentCheck: skipCheckFlag,
@@ -174,13 +181,27 @@ func GetSpectralReportCommand() *cobra.Command {
 			// serialize
 			spectralReport := resultSet.GenerateSpectralReport(source)
 
-			var data []byte
-			if noPretty {
-				data, _ = json.Marshal(spectralReport)
-			} else {
-				data, _ = json.MarshalIndent(spectralReport, "", "    ")
+			// suppressed results are left out of the report, spectral consumers would count them as issues.
+			// They are written next to it instead, with the reason each one was waived.
+			suppressedOutput, _ := cmd.Flags().GetString("suppressed-output")
+			if suppressedOutput == "" && !stdOut && len(ruleset.Suppressed) > 0 {
+				suppressedOutput = strings.TrimSuffix(reportOutput, filepath.Ext(reportOutput)) + "-suppressed.json"
+			}
+			if suppressedOutput != "" {
+				suppressedSet := model.NewRuleResultSet(ruleset.Suppressed)
+				suppressedSet.SortResultsByLineNumber()
+				if err := os.WriteFile(suppressedOutput, marshalReport(suppressedSet.GenerateSuppressedReport(source), noPretty), 0664); err != nil {
+					pterm.Error.Printf("Unable to write suppressed results file: '%s': %s\n", suppressedOutput, err.Error())
+					pterm.Println()
+					return err
+				}
+				if !stdOut {
+					pterm.Info.Printf("%d results suppressed, written to '%s'\n", len(ruleset.Suppressed), suppressedOutput)
+				}
 			}
 
+			data := marshalReport(spectralReport, noPretty)
+
 			if stdOut {
 				fmt.Print(string(data))
 				return nil
@@ -207,6 +228,17 @@ func GetSpectralReportCommand() *cobra.Command {
 	cmd.Flags().BoolP("stdout", "o", false, "Use stdout as output, instead of a file")
 	cmd.Flags().BoolP("no-pretty", "n", false, "Render JSON with no formatting")
 	cmd.Flags().BoolP("no-style", "q", false, "Disable styling and color output, just plain text (useful for CI/CD)")
+	cmd.Flags().String("suppressed-output", "", "File suppressed results are written to, with their reasons "+
+		"(defaults to '<report>-suppressed.json', when results are suppressed)")
 	return cmd
 
 }
+
+func marshalReport(report any, noPretty bool) []byte {
+	if noPretty {
+		data, _ := json.Marshal(report)
+		return data
+	}
+	data, _ := json.MarshalIndent(report, "", "    ")
+	return data
+}
@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
+	"github.com/daveshanley/vacuum/model/reports"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -42,6 +45,39 @@ func TestGetSpectralReportCommand_CustomName(t *testing.T) {
 	defer os.Remove("blue-shoes.json")
 }
 
+func TestGetSpectralReportCommand_Suppressed(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "spec.yaml")
+	assert.NoError(t, os.WriteFile(spec, []byte(`openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+  x-lint-ignore:
+    info-description: described elsewhere
+paths: {}
+`), 0644))
+
+	cmd := GetSpectralReportCommand()
+	cmd.SetArgs([]string{spec, filepath.Join(dir, "report.json")})
+	assert.NoError(t, cmd.Execute())
+
+	// the report is what spectral would report, suppressed results are written next to it.
+	var report []reports.SpectralReport
+	b, _ := os.ReadFile(filepath.Join(dir, "report.json"))
+	assert.NoError(t, json.Unmarshal(b, &report))
+	for _, r := range report {
+		assert.NotEqual(t, "info-description", r.Code)
+	}
+
+	var suppressed reports.SuppressedReport
+	b, _ = os.ReadFile(filepath.Join(dir, "report-suppressed.json"))
+	assert.NoError(t, json.Unmarshal(b, &suppressed))
+	assert.Equal(t, 1, suppressed.Count)
+	assert.Equal(t, "info-description", suppressed.Results[0].Code)
+	assert.Equal(t, []string{"info"}, suppressed.Results[0].Path)
+	assert.Equal(t, "described elsewhere", suppressed.Results[0].Suppression.Reason)
+}
+
 func TestGetSpectralReportCommand_StdInOut(t *testing.T) {
 	cmd := GetSpectralReportCommand()
 	b := bytes.NewBufferString("")
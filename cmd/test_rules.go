@@ -0,0 +1,142 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/daveshanley/vacuum/ruletest"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+func GetTestRulesCommand() *cobra.Command {
+
+	cmd := &cobra.Command{
+		SilenceUsage: true,
+		Use:          "test-rules <test-manifest.yaml>",
+		Short:        "Test custom rules against fixture specifications",
+		Long: "Run the rules of a ruleset against fixture specifications, and check that exactly the expected results " +
+			"are found. A test manifest names the ruleset (and any custom functions it uses), the fixtures, and the " +
+			"results expected for each fixture by rule id, path, severity, line and message. Results that are missing " +
+			"or unexpected are reported as a diff, and can be written as a JUnit report for CI.",
+		Example: "vacuum test-rules rule-tests.yaml --junit rule-tests.xml",
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return []string{"yaml", "yml"}, cobra.ShellCompDirectiveFilterFileExt
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			functionsFlag, _ := cmd.Flags().GetString("functions")
+			timeoutFlag, _ := cmd.Flags().GetInt("timeout")
+			junitFlag, _ := cmd.Flags().GetString("junit")
+			silent, _ := cmd.Flags().GetBool("silent")
+			noStyleFlag, _ := cmd.Flags().GetBool("no-style")
+			noBanner, _ := cmd.Flags().GetBool("no-banner")
+
+			if noStyleFlag {
+				pterm.DisableColor()
+				pterm.DisableStyling()
+			}
+
+			if !silent && !noBanner {
+				PrintBanner()
+			}
+
+			if len(args) < 1 {
+				pterm.Error.Println("Please supply a test manifest")
+				pterm.Println()
+				return fmt.Errorf("no test manifest supplied")
+			}
+
+			// functions supplied with -f are used by manifests that don't load their own.
+			customFunctions, functionsManager, err := LoadCustomFunctions(functionsFlag, true)
+			if err != nil {
+				return err
+			}
+			defer functionsManager.Close()
+
+			var suites []*ruletest.SuiteResult
+			for _, arg := range args {
+				m, mErr := ruletest.LoadManifest(arg)
+				if mErr != nil {
+					pterm.Error.Println(mErr.Error())
+					pterm.Println()
+					return mErr
+				}
+				suite := ruletest.Run(m, ruletest.Options{
+					Functions: customFunctions,
+					Timeout:   time.Duration(timeoutFlag) * time.Second,
+				})
+				suites = append(suites, suite)
+				if !silent {
+					renderRuleTestSuite(suite)
+				}
+			}
+
+			if junitFlag != "" {
+				if wErr := os.WriteFile(junitFlag, ruletest.BuildJUnitReport(suites), 0664); wErr != nil {
+					pterm.Error.Printf("Unable to write JUnit report '%s': %s\n", junitFlag, wErr.Error())
+					pterm.Println()
+					return wErr
+				}
+				if !silent {
+					pterm.Info.Printf("JUnit report written to '%s'\n", junitFlag)
+					pterm.Println()
+				}
+			}
+
+			tests, failures := 0, 0
+			for _, s := range suites {
+				tests += len(s.Tests)
+				failures += s.Failures()
+			}
+			if failures > 0 {
+				if !silent {
+					pterm.Error.Printf("%d of %d rule tests failed\n", failures, tests)
+					pterm.Println()
+				}
+				return fmt.Errorf("%d rule tests failed", failures)
+			}
+			if !silent {
+				pterm.Success.Printf("All %d rule tests passed\n", tests)
+				pterm.Println()
+			}
+			return nil
+		},
+	}
+	cmd.Flags().String("junit", "", "Write the results as a JUnit XML report to this file")
+	cmd.Flags().BoolP("silent", "x", false, "Show nothing except the result.")
+	cmd.Flags().BoolP("no-style", "q", false, "Disable styling and color output, just plain text (useful for CI/CD)")
+	cmd.Flags().BoolP("no-banner", "b", false, "Disable the banner / header output")
+	return cmd
+}
+
+func renderRuleTestSuite(suite *ruletest.SuiteResult) {
+	pterm.Info.Printf("Testing rules with '%s'\n", suite.Manifest)
+	pterm.Println()
+	if suite.Error != nil {
+		pterm.Error.Println(suite.Error.Error())
+		pterm.Println()
+		return
+	}
+	for _, t := range suite.Tests {
+		if t.Passed() {
+			pterm.Printf("%s %s %s\n", pterm.LightGreen("✓"), t.Name, pterm.Gray(t.Duration.Round(time.Millisecond)))
+			continue
+		}
+		pterm.Printf("%s %s: %s\n", pterm.LightRed("✗"), t.Name, ruletest.Summary(t))
+		for _, e := range t.Errors {
+			pterm.Printf("    %s\n", pterm.LightRed("! "+e.Error()))
+		}
+		for _, e := range t.Missing {
+			pterm.Printf("    %s\n", pterm.LightRed("- expected   "+e.String()))
+		}
+		for _, e := range t.Unexpected {
+			pterm.Printf("    %s\n", pterm.LightGreen("+ unexpected "+e.String()))
+		}
+	}
+	pterm.Println()
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeRuleTestManifest(t *testing.T, expect string) string {
+	dir := t.TempDir()
+	ruleset := `rules:
+  info-contact:
+    description: Info must have a contact
+    severity: warn
+    given: $.info
+    then:
+      field: contact
+      function: truthy`
+	spec := `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+paths: {}`
+	manifest := `ruleset: ruleset.yaml
+rules: [info-contact]
+tests:
+  - name: contact is missing
+    spec: spec.yaml
+    expect:
+` + expect
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ruleset.yaml"), []byte(ruleset), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "spec.yaml"), []byte(spec), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "tests.yaml"), []byte(manifest), 0644))
+	return filepath.Join(dir, "tests.yaml")
+}
+
+func TestGetTestRulesCommand(t *testing.T) {
+	manifest := writeRuleTestManifest(t, "      - rule: info-contact\n        severity: warn\n        path: $.info")
+	junit := filepath.Join(t.TempDir(), "junit.xml")
+
+	cmd := GetTestRulesCommand()
+	cmd.SetOut(bytes.NewBufferString(""))
+	cmd.SetArgs([]string{"--junit", junit, manifest})
+	assert.NoError(t, cmd.Execute())
+
+	report, err := os.ReadFile(junit)
+	assert.NoError(t, err)
+	assert.Contains(t, string(report), `name="contact is missing"`)
+	assert.NotContains(t, string(report), "<failure")
+}
+
+func TestGetTestRulesCommand_Fail(t *testing.T) {
+	manifest := writeRuleTestManifest(t, "      - rule: info-contact\n        severity: error")
+	junit := filepath.Join(t.TempDir(), "junit.xml")
+
+	cmd := GetTestRulesCommand()
+	cmd.SetOut(bytes.NewBufferString(""))
+	cmd.SetArgs([]string{"-q", "--junit", junit, manifest})
+	assert.ErrorContains(t, cmd.Execute(), "1 rule tests failed")
+
+	report, err := os.ReadFile(junit)
+	assert.NoError(t, err)
+	assert.Contains(t, string(report), "<failure")
+}
+
+func TestGetTestRulesCommand_NoManifest(t *testing.T) {
+	cmd := GetTestRulesCommand()
+	cmd.SetOut(bytes.NewBufferString(""))
+	cmd.SetArgs([]string{"-x"})
+	assert.Error(t, cmd.Execute())
+}
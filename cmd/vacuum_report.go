@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"github.com/daveshanley/vacuum/model"
 	"github.com/daveshanley/vacuum/motor"
+	"github.com/daveshanley/vacuum/plugin"
 	"github.com/daveshanley/vacuum/rulesets"
 	"github.com/daveshanley/vacuum/statistics"
 	vacuum_report "github.com/daveshanley/vacuum/vacuum-report"
@@ -43,6 +44,7 @@ func GetVacuumReportCommand() *cobra.Command {
 			noStyleFlag, _ := cmd.Flags().GetBool("no-style")
 			baseFlag, _ := cmd.Flags().GetString("base")
 			junitFlag, _ := cmd.Flags().GetBool("junit")
+			sarifFlag, _ := cmd.Flags().GetBool("sarif")
 			skipCheckFlag, _ := cmd.Flags().GetBool("skip-check")
 			timeoutFlag, _ := cmd.Flags().GetInt("timeout")
 			hardModeFlag, _ := cmd.Flags().GetBool("hard-mode")
@@ -134,7 +136,9 @@ func GetVacuumReportCommand() *cobra.Command {
 			// and see if it's valid. If so - let's go!
 			if rulesetFlag != "" {
 
-				customFunctions, _ = LoadCustomFunctions(functionsFlag, true)
+				var functionsManager *plugin.Manager
+				customFunctions, functionsManager, _ = LoadCustomFunctions(functionsFlag, true)
+				defer functionsManager.Close()
 
 				rsBytes, rsErr := os.ReadFile(rulesetFlag)
 				if rsErr != nil {
@@ -142,10 +146,11 @@ func GetVacuumReportCommand() *cobra.Command {
 					pterm.Println()
 					return rsErr
 				}
-				selectedRS, rsErr = BuildRuleSetFromUserSuppliedSet(rsBytes, defaultRuleSets)
+				selectedRS, rsErr = BuildRuleSetFromUserSuppliedLocation(rsBytes, rulesetFlag, defaultRuleSets)
 				if rsErr != nil {
 					return rsErr
 				}
+				defer selectedRS.CloseFunctions()
 			}
 
 			if !stdIn && !stdOut {
@@ -156,6 +161,7 @@ func GetVacuumReportCommand() *cobra.Command {
 				RuleSet:           selectedRS,
 				Spec:              specBytes,
 				CustomFunctions:   customFunctions,
+				FunctionLoader:    plugin.LoadFunctionSource,
 				SilenceLogs:       true,
 				Base:              baseFlag,
 				SkipDocumentCheck: skipCheckFlag,
@@ -191,6 +197,37 @@ func GetVacuumReportCommand() *cobra.Command {
 				}
 			}
 
+			// SARIF output is also a complete report on its own.
+			if sarifFlag {
+				specFileName := ""
+				if !stdIn {
+					specFileName = args[0]
+				}
+				suppressed := make([]*model.RuleFunctionResult, len(ruleset.Suppressed))
+				for i := range ruleset.Suppressed {
+					suppressed[i] = &ruleset.Suppressed[i]
+				}
+				sarif := vacuum_report.BuildSarifReport(resultSet, suppressed, selectedRS.Rules, specFileName, Version)
+				if stdOut {
+					fmt.Print(string(sarif))
+					return nil
+				}
+
+				reportOutputName := fmt.Sprintf("%s-%s%s",
+					reportOutput, time.Now().Format("01-02-06-15_04_05"), ".sarif")
+
+				err := os.WriteFile(reportOutputName, sarif, 0664)
+				if err != nil {
+					pterm.Error.Printf("Unable to write SARIF report file: '%s': %s\n", reportOutputName, err.Error())
+					pterm.Println()
+					return err
+				}
+
+				pterm.Success.Printf("SARIF Report generated for '%s', written to '%s'\n", args[0], reportOutputName)
+				pterm.Println()
+				return nil
+			}
+
 			// pre-render
 			resultSet.PrepareForSerialization(ruleset.SpecInfo)
 
@@ -200,12 +237,22 @@ func GetVacuumReportCommand() *cobra.Command {
 			// generate statistics
 			stats := statistics.CreateReportStatistics(ruleset.Index, ruleset.SpecInfo, resultSet)
 
+			// suppressed results are kept separate, so they can be audited.
+			var suppressedSet *model.RuleResultSet
+			if len(ruleset.Suppressed) > 0 {
+				suppressedSet = model.NewRuleResultSet(ruleset.Suppressed)
+				suppressedSet.SortResultsByLineNumber()
+				suppressedSet.PrepareForSerialization(ruleset.SpecInfo)
+				stats.TotalSuppressed = len(suppressedSet.Results)
+			}
+
 			// create vacuum report
 			vr := vacuum_report.VacuumReport{
 				Generated:  time.Now(),
 				SpecInfo:   ruleset.SpecInfo,
 				ResultSet:  resultSet,
 				Statistics: stats,
+				Suppressed: suppressedSet,
 			}
 
 			if noPretty || compress {
@@ -259,6 +306,7 @@ func GetVacuumReportCommand() *cobra.Command {
 	cmd.Flags().BoolP("stdin", "i", false, "Use stdin as input, instead of a file")
 	cmd.Flags().BoolP("stdout", "o", false, "Use stdout as output, instead of a file")
 	cmd.Flags().BoolP("junit", "j", false, "Generate report in JUnit format (cannot be compressed)")
+	cmd.Flags().Bool("sarif", false, "Generate report in SARIF 2.1.0 format (cannot be compressed)")
 	cmd.Flags().BoolP("compress", "c", false, "Compress results using gzip")
 	cmd.Flags().BoolP("no-pretty", "n", false, "Render JSON with no formatting")
 	cmd.Flags().BoolP("no-style", "q", false, "Disable styling and color output, just plain text (useful for CI/CD)")
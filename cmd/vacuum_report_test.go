@@ -2,10 +2,13 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	vacuum_report "github.com/daveshanley/vacuum/vacuum-report"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -190,3 +193,29 @@ func TestGetVacuumReportCommand_BadFile(t *testing.T) {
 	assert.Error(t, cmdErr)
 
 }
+
+func TestGetVacuumReportCommand_Sarif(t *testing.T) {
+	dir := t.TempDir()
+	cmd := GetVacuumReportCommand()
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+	cmd.SetArgs([]string{
+		"--sarif",
+		"../model/test_files/petstorev3.json",
+		filepath.Join(dir, "sarif-report"),
+	})
+	cmdErr := cmd.Execute()
+	assert.NoError(t, cmdErr)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "sarif-report-*.sarif"))
+	assert.Len(t, files, 1)
+	if len(files) == 1 {
+		data, _ := os.ReadFile(files[0])
+		var log vacuum_report.SarifLog
+		assert.NoError(t, json.Unmarshal(data, &log))
+		assert.Equal(t, vacuum_report.SarifVersion, log.Version)
+		assert.NotEmpty(t, log.Runs[0].Results)
+		assert.Equal(t, "../model/test_files/petstorev3.json",
+			log.Runs[0].Results[0].Locations[0].PhysicalLocation.ArtifactLocation.Uri)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/daveshanley/vacuum/watch"
+	"github.com/pterm/pterm"
+)
+
+// watchTargets returns the files and directories that are watched, as well as every file loaded by a lint:
+// the specifications themselves, a local ruleset and the custom functions directory.
+func watchTargets(specs []string, rulesetFlag, functionsFlag string) (files, dirs []string) {
+	files = append(files, specs...)
+	if rulesetFlag != "" && !strings.HasPrefix(rulesetFlag, "http://") && !strings.HasPrefix(rulesetFlag, "https://") {
+		files = append(files, rulesetFlag)
+	}
+	if functionsFlag != "" {
+		dirs = append(dirs, functionsFlag)
+	}
+	return files, dirs
+}
+
+// watchContext returns a context that is cancelled when vacuum is interrupted, which is how watching stops.
+func watchContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	return signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+}
+
+// watchAndRun calls run straight away, and then every time a watched file changes, until the context is done.
+// run is given the files that changed (nothing on the first run) and returns every file it loaded, like the
+// files the rolodex indexed for a specification, so new references are watched as soon as they are added.
+func watchAndRun(ctx context.Context, files, dirs []string, silent bool, run func(changed []string) []string) error {
+	w, err := watch.NewWatcher(watch.DefaultDebounce)
+	if err != nil {
+		pterm.Error.Printf("Unable to watch for changes: %s\n", err.Error())
+		pterm.Println()
+		return err
+	}
+	defer w.Close()
+
+	update := func(loaded []string) error {
+		all := make([]string, 0, len(files)+len(loaded))
+		all = append(all, files...)
+		all = append(all, loaded...)
+		return w.Watch(all, dirs)
+	}
+
+	if err = update(run(nil)); err != nil {
+		pterm.Error.Printf("Unable to watch for changes: %s\n", err.Error())
+		pterm.Println()
+		return err
+	}
+	if !silent {
+		pterm.Info.Println("Watching for changes, press Ctrl+C to stop")
+		pterm.Println()
+	}
+
+	return w.Run(ctx, func(changed []string) {
+		if uErr := update(run(changed)); uErr != nil && !silent {
+			pterm.Warning.Printf("Unable to watch every file: %s\n", uErr.Error())
+			pterm.Println()
+		}
+	})
+}
+
+// changedIn returns true if any of the changed files is the file, or is inside the directory.
+func changedIn(changed []string, location string) bool {
+	if location == "" {
+		return false
+	}
+	abs, err := filepath.Abs(location)
+	if err != nil {
+		return false
+	}
+	for _, c := range changed {
+		if c == abs || strings.HasPrefix(c, abs+string(os.PathSeparator)) {
+			return true
+		}
+	}
+	return false
+}
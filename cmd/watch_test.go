@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/daveshanley/vacuum/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchTargets(t *testing.T) {
+	files, dirs := watchTargets([]string{"a.yaml", "b.yaml"}, "rules.yaml", "functions")
+	assert.Equal(t, []string{"a.yaml", "b.yaml", "rules.yaml"}, files)
+	assert.Equal(t, []string{"functions"}, dirs)
+
+	files, dirs = watchTargets([]string{"a.yaml"}, "https://pb33f.io/rules.yaml", "")
+	assert.Equal(t, []string{"a.yaml"}, files)
+	assert.Empty(t, dirs)
+}
+
+func TestChangedIn(t *testing.T) {
+	functions, _ := filepath.Abs("functions")
+	changed := []string{filepath.Join(functions, "check.js")}
+	assert.True(t, changedIn(changed, "functions"))
+	assert.False(t, changedIn(changed, "rules.yaml"))
+	assert.False(t, changedIn(changed, ""))
+	assert.False(t, changedIn([]string{functions + "-old"}, "functions"))
+}
+
+func TestReloadLintRules_RuleTurnedBackOn(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "rules.yaml")
+	assert.NoError(t, os.WriteFile(location, []byte("extends: [[spectral:oas, all]]\nrules:\n  info-contact: off\n  info-description: hint\n"), 0644))
+
+	defaults := rulesets.BuildDefaultRuleSets()
+	req := utils.LintFileRequest{DefaultRuleSets: defaults}
+	selected, _, _ := reloadLintRules(req, []string{location}, location, "")
+	assert.Nil(t, selected.Rules["info-contact"])
+	assert.Equal(t, "hint", selected.Rules["info-description"].Severity)
+
+	// editing the ruleset brings the rule back as it was.
+	assert.NoError(t, os.WriteFile(location, []byte("extends: [[spectral:oas, all]]\n"), 0644))
+	req.SelectedRS = selected
+	selected, _, _ = reloadLintRules(req, []string{location}, location, "")
+	assert.NotNil(t, selected.Rules["info-contact"])
+	assert.Equal(t, "error", selected.Rules["info-description"].Severity)
+}
+
+func TestGetLintCommand_WatchIncompatible(t *testing.T) {
+	cmd := GetLintCommand()
+	cmd.SetArgs([]string{"--watch", "--fix", "-b", "../model/test_files/burgershop.openapi.yaml"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestGetLintCommand_Watch(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "spec.yaml")
+	assert.NoError(t, os.WriteFile(spec, []byte(`openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+paths: {}`), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	cmd := GetLintCommand()
+	cmd.PersistentFlags().StringP("ruleset", "r", "", "")
+	cmd.PersistentFlags().StringP("functions", "f", "", "")
+	cmd.SetArgs([]string{"--watch", "-b", spec})
+	go func() {
+		done <- cmd.ExecuteContext(ctx)
+	}()
+
+	// give the first lint a moment, then change the spec, and stop watching.
+	time.Sleep(500 * time.Millisecond)
+	assert.NoError(t, os.WriteFile(spec, []byte(`openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+  description: all the pets
+paths: {}`), 0644))
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("watching did not stop")
+	}
+}
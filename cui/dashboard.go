@@ -32,17 +32,45 @@ type Dashboard struct {
 	violationViewActive    bool
 	helpViewActive         bool
 	uiEvents               <-chan ui.Event
+	refresh                chan *dashboardUpdate
 	Version                string
 }
 
+// dashboardUpdate carries new results to a rendering dashboard.
+type dashboardUpdate struct {
+	resultSet *model.RuleResultSet
+	index     *index.SpecIndex
+	info      *datamodel.SpecInfo
+}
+
 func CreateDashboard(resultSet *model.RuleResultSet, index *index.SpecIndex, info *datamodel.SpecInfo) *Dashboard {
 	db := new(Dashboard)
 	db.resultSet = resultSet
 	db.index = index
 	db.info = info
+	db.refresh = make(chan *dashboardUpdate, 1)
 	return db
 }
 
+// Refresh replaces the results being explored, the dashboard is redrawn in place and the selected category is
+// kept, if it still has results. It's safe to call from any goroutine while the dashboard is rendering, only the
+// latest update is applied if several arrive at once.
+func (dash *Dashboard) Refresh(resultSet *model.RuleResultSet, index *index.SpecIndex, info *datamodel.SpecInfo) {
+	update := &dashboardUpdate{resultSet: resultSet, index: index, info: info}
+	for {
+		select {
+		case dash.refresh <- update:
+			return
+		default:
+			// drop the stale update that has not been picked up yet.
+			select {
+			case <-dash.refresh:
+			default:
+			}
+		}
+	}
+}
+
 // GenerateTabbedView generates tabs
 func (dash *Dashboard) GenerateTabbedView() {
 	var labels []string
@@ -90,8 +118,16 @@ func (dash *Dashboard) Render() error {
 	dash.run = true
 	defer ui.Close()
 
-	// extract categories and calculate coverage.
+	termWidth, termHeight := ui.TerminalDimensions()
+	dash.prepare(termWidth, termHeight)
+
+	ui.Render(dash.grid, dash.title)
+	dash.eventLoop()
+	return nil
+}
 
+// prepare extracts categories from the results, calculates coverage and builds all the views.
+func (dash *Dashboard) prepare(termWidth, termHeight int) {
 	var gauges []CategoryGauge
 	cats := model.RuleCategoriesOrdered
 
@@ -117,24 +153,43 @@ func (dash *Dashboard) Render() error {
 	dash.ruleCategories = catsFiltered
 
 	dash.grid = ui.NewGrid()
-	termWidth, termHeight := ui.TerminalDimensions()
 	dash.grid.SetRect(0, 0, termWidth, termHeight)
 
 	dash.helpGrid = ui.NewGrid()
 	dash.helpGrid.SetRect(0, 0, termWidth, termHeight)
 
-	dash.GenerateTabbedView()
+	dash.tabs = TabbedView{}
+	if len(dash.ruleCategories) > 0 {
+		dash.GenerateTabbedView()
+	}
 	dash.ComposeGauges()
 
 	dash.setGrid()
-	//dash.tabs.setActiveCategoryIndex(dash.tabs.tv.ActiveTabIndex)
+}
 
-	ui.Render(dash.grid, dash.title)
-	dash.eventLoop(cats)
-	return nil
+// applyUpdate swaps in new results, and rebuilds the views, keeping the selected category if possible.
+func (dash *Dashboard) applyUpdate(update *dashboardUpdate, termWidth, termHeight int) {
+	selected := dash.selectedCategory
+	dash.resultSet = update.resultSet
+	dash.index = update.index
+	dash.info = update.info
+	dash.violationViewActive = false
+	dash.prepare(termWidth, termHeight)
+
+	if selected == nil {
+		return
+	}
+	for i, cat := range dash.ruleCategories {
+		if cat.Id == selected.Id && i > 0 {
+			dash.tabs.tv.ActiveTabIndex = i
+			dash.tabs.setActiveCategoryIndex(i)
+			dash.generateViewsAfterEvent()
+			return
+		}
+	}
 }
 
-func (dash *Dashboard) eventLoop(cats []*model.RuleCategory) {
+func (dash *Dashboard) eventLoop() {
 	var uiEvents <-chan ui.Event
 	if dash.uiEvents == nil {
 		uiEvents = ui.PollEvents()
@@ -144,7 +199,22 @@ func (dash *Dashboard) eventLoop(cats []*model.RuleCategory) {
 	}
 	// TODO: clean this damn mess up.
 	for {
-		e := <-uiEvents
+		var e ui.Event
+		select {
+		case e = <-uiEvents:
+		case update := <-dash.refresh:
+			termWidth, termHeight := ui.TerminalDimensions()
+			dash.applyUpdate(update, termWidth, termHeight)
+			ui.Clear()
+			ui.Render(dash.grid, dash.title)
+			continue
+		}
+
+		// there is nothing to navigate without results.
+		if len(dash.ruleCategories) == 0 && e.ID != "q" && e.ID != "<C-c>" {
+			continue
+		}
+
 		switch e.ID {
 		case "q", "<C-c>":
 			return
@@ -152,7 +222,7 @@ func (dash *Dashboard) eventLoop(cats []*model.RuleCategory) {
 			dash.helpViewActive = true
 		case "<Tab>":
 			dash.violationViewActive = false
-			if dash.tabs.tv.ActiveTabIndex == len(cats)-1 { // loop around and around.
+			if dash.tabs.tv.ActiveTabIndex == len(dash.ruleCategories)-1 { // loop around and around.
 				dash.tabs.tv.ActiveTabIndex = 0
 			} else {
 				dash.tabs.tv.FocusRight()
@@ -258,6 +328,15 @@ func (dash *Dashboard) setGrid() {
 					),
 				),
 			)
+		} else {
+			// nothing to explore, all the results have gone.
+			empty := widgets.NewParagraph()
+			empty.Text = "[No results, a perfect score! well done!](fg:green,mod:bold)"
+			empty.Border = false
+			dash.grid.Set(
+				ui.NewRow(0.07, p),
+				ui.NewRow(0.93, empty),
+			)
 		}
 	}
 
@@ -138,3 +138,43 @@ func testBootDashboard() (*model.RuleResultSet, *index.SpecIndex, *datamodel.Spe
 	resultSet := model.NewRuleResultSet(applied.Results)
 	return resultSet, specIndex, info
 }
+
+func TestDashboard_Refresh(t *testing.T) {
+
+	_, idx, info := testBootDashboard()
+	var results []model.RuleFunctionResult
+	for _, cat := range []string{model.CategoryDescriptions, model.CategoryTags} {
+		results = append(results, model.RuleFunctionResult{
+			Message:   "oh no",
+			StartNode: &yaml.Node{Line: 1},
+			EndNode:   &yaml.Node{Line: 1},
+			Rule: &model.Rule{
+				Id:           cat + "-rule",
+				Severity:     model.SeverityError,
+				RuleCategory: model.RuleCategories[cat],
+			},
+		})
+	}
+	resultSet := model.NewRuleResultSet(results)
+	dash := CreateDashboard(resultSet, idx, info)
+	dash.prepare(200, 100)
+	assert.Len(t, dash.ruleCategories, 2)
+
+	// select the second category, it's kept when the results are refreshed.
+	dash.tabs.tv.ActiveTabIndex = 1
+	dash.tabs.setActiveCategoryIndex(1)
+	selected := dash.selectedCategory
+
+	// only the latest refresh is applied.
+	dash.Refresh(model.NewRuleResultSet(nil), idx, info)
+	dash.Refresh(resultSet, idx, info)
+	dash.applyUpdate(<-dash.refresh, 200, 100)
+	assert.Equal(t, selected.Id, dash.selectedCategory.Id)
+	assert.Equal(t, 1, dash.tabs.tv.ActiveTabIndex)
+
+	// everything has been fixed.
+	dash.Refresh(model.NewRuleResultSet(nil), idx, info)
+	dash.applyUpdate(<-dash.refresh, 200, 100)
+	assert.Empty(t, dash.ruleCategories)
+	assert.NotNil(t, dash.grid)
+}
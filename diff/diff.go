@@ -0,0 +1,580 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+// Package diff compares two versions of an OpenAPI specification, and classifies the changes that matter to
+// clients as breaking, or not. Changes are reported as regular rule results, so they can be rendered, reported and
+// used to fail a build, just like linting results.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/daveshanley/vacuum/model"
+	vacuumUtils "github.com/daveshanley/vacuum/utils"
+	doctor "github.com/pb33f/doctor/model"
+	drBase "github.com/pb33f/doctor/model/high/base"
+	drV3 "github.com/pb33f/doctor/model/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+// schemas can be deeply nested (and recursive), this is as deep as a comparison will go.
+const maxSchemaDepth = 64
+
+// direction determines which way data flows through a schema. A change that is safe for a request, is often
+// breaking for a response, and vice versa.
+type direction int
+
+const (
+	request direction = iota
+	response
+)
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+type comparison struct {
+	original *drV3.Document
+	updated  *drV3.Document
+	rules    map[string]*model.Rule
+	results  []model.RuleFunctionResult
+	seen     map[[2]*drBase.Schema]bool
+}
+
+// CompareDocuments compares the original and updated versions of an OpenAPI 3+ specification. Every change
+// found is returned as a result, breaking changes are errors, all other changes are informational. Changes are
+// located in the updated document, anything that has been removed points to the closest parent that remains.
+func CompareDocuments(original, updated *doctor.DrDocument) []model.RuleFunctionResult {
+	if original == nil || updated == nil || original.V3Document == nil || updated.V3Document == nil {
+		return nil
+	}
+	c := &comparison{
+		original: original.V3Document,
+		updated:  updated.V3Document,
+		rules:    Rules(),
+		seen:     make(map[[2]*drBase.Schema]bool),
+	}
+	c.comparePaths()
+	return c.results
+}
+
+func (c *comparison) add(ruleId, message, path string, node *yaml.Node) {
+	c.results = append(c.results, model.RuleFunctionResult{
+		Message:   message,
+		StartNode: node,
+		EndNode:   vacuumUtils.BuildEndNode(node),
+		Path:      path,
+		Rule:      c.rules[ruleId],
+		RuleId:    ruleId,
+	})
+}
+
+func (c *comparison) comparePaths() {
+	oldPaths := pathItems(c.original)
+	newPaths := pathItems(c.updated)
+
+	for pair := orderedmap.First(oldPaths); pair != nil; pair = pair.Next() {
+		path, oldItem := pair.Key(), pair.Value()
+		newItem, _ := newPaths.Get(path)
+		anchor := pathsNode(c.updated)
+		if newItem != nil {
+			anchor = pathNode(c.updated, path)
+		}
+		oldOps, newOps := operations(oldItem), operations(newItem)
+		for _, method := range methods {
+			oldOp := oldOps[method]
+			if oldOp == nil {
+				continue
+			}
+			newOp := newOps[method]
+			if newOp == nil {
+				c.add(BreakingOperationRemoved,
+					fmt.Sprintf("operation `%s` has been removed", operationName(method, path)),
+					oldOp.GenerateJSONPath(), anchor)
+				continue
+			}
+			c.compareOperation(method, path, oldItem, newItem, oldOp, newOp)
+		}
+	}
+
+	for pair := orderedmap.First(newPaths); pair != nil; pair = pair.Next() {
+		path, newItem := pair.Key(), pair.Value()
+		oldItem, _ := oldPaths.Get(path)
+		oldOps, newOps := operations(oldItem), operations(newItem)
+		for _, method := range methods {
+			newOp := newOps[method]
+			if newOp == nil || oldOps[method] != nil {
+				continue
+			}
+			c.add(OperationAdded,
+				fmt.Sprintf("operation `%s` has been added", operationName(method, path)),
+				newOp.GenerateJSONPath(), operationNode(newOp, pathNode(c.updated, path)))
+		}
+	}
+}
+
+func (c *comparison) compareOperation(method, path string, oldItem, newItem *drV3.PathItem, oldOp, newOp *drV3.Operation) {
+	name := operationName(method, path)
+	opNode := operationNode(newOp, pathNode(c.updated, path))
+
+	// parameters
+	oldParams := parameters(oldItem, oldOp)
+	newParams := parameters(newItem, newOp)
+	for _, key := range sortedKeys(newParams) {
+		newParam := newParams[key]
+		newRequired := newParam.Value.Required != nil && *newParam.Value.Required
+		oldParam := oldParams[key]
+		if oldParam == nil {
+			if newRequired {
+				c.add(BreakingParameterRequired,
+					fmt.Sprintf("required %s parameter `%s` has been added to `%s`", newParam.Value.In,
+						newParam.Value.Name, name), newParam.GenerateJSONPath(), parameterNode(newParam, opNode))
+			} else {
+				c.add(ParameterOptional,
+					fmt.Sprintf("optional %s parameter `%s` has been added to `%s`", newParam.Value.In,
+						newParam.Value.Name, name), newParam.GenerateJSONPath(), parameterNode(newParam, opNode))
+			}
+			continue
+		}
+		oldRequired := oldParam.Value.Required != nil && *oldParam.Value.Required
+		if newRequired && !oldRequired {
+			c.add(BreakingParameterRequired,
+				fmt.Sprintf("%s parameter `%s` of `%s` is now required", newParam.Value.In, newParam.Value.Name, name),
+				newParam.GenerateJSONPath(), parameterNode(newParam, opNode))
+		}
+		if oldRequired && !newRequired {
+			c.add(ParameterOptional,
+				fmt.Sprintf("%s parameter `%s` of `%s` is now optional", newParam.Value.In, newParam.Value.Name, name),
+				newParam.GenerateJSONPath(), parameterNode(newParam, opNode))
+		}
+		if oldParam.SchemaProxy != nil && newParam.SchemaProxy != nil {
+			c.compareSchemas(oldParam.SchemaProxy.Schema, newParam.SchemaProxy.Schema, request, 0)
+		}
+		c.compareContent(oldParam.Content, newParam.Content, request)
+	}
+
+	// request body
+	if newOp.RequestBody != nil {
+		newRequired := newOp.RequestBody.Value.Required != nil && *newOp.RequestBody.Value.Required
+		oldRequired := oldOp.RequestBody != nil && oldOp.RequestBody.Value.Required != nil &&
+			*oldOp.RequestBody.Value.Required
+		bodyNode := opNode
+		if low := newOp.RequestBody.Value.GoLow(); low != nil && low.KeyNode != nil {
+			bodyNode = low.KeyNode
+		}
+		if newRequired && !oldRequired {
+			c.add(BreakingParameterRequired, fmt.Sprintf("request body of `%s` is now required", name),
+				newOp.RequestBody.GenerateJSONPath(), bodyNode)
+		}
+		if oldRequired && !newRequired {
+			c.add(ParameterOptional, fmt.Sprintf("request body of `%s` is now optional", name),
+				newOp.RequestBody.GenerateJSONPath(), bodyNode)
+		}
+		if oldOp.RequestBody != nil {
+			c.compareContent(oldOp.RequestBody.Content, newOp.RequestBody.Content, request)
+		}
+	}
+
+	// responses
+	oldResponses := responses(oldOp)
+	newResponses := responses(newOp)
+	for pair := orderedmap.First(oldResponses); pair != nil; pair = pair.Next() {
+		code, oldResponse := pair.Key(), pair.Value()
+		newResponse, _ := newResponses.Get(code)
+		if newResponse == nil {
+			c.add(BreakingResponseRemoved,
+				fmt.Sprintf("response `%s` has been removed from `%s`", code, name),
+				oldResponse.GenerateJSONPath(), responsesNode(newOp, opNode))
+			continue
+		}
+		c.compareContent(oldResponse.Content, newResponse.Content, response)
+	}
+	for pair := orderedmap.First(newResponses); pair != nil; pair = pair.Next() {
+		code, newResponse := pair.Key(), pair.Value()
+		if old, _ := oldResponses.Get(code); old == nil {
+			c.add(ResponseAdded, fmt.Sprintf("response `%s` has been added to `%s`", code, name),
+				newResponse.GenerateJSONPath(), responseNode(newOp, code, opNode))
+		}
+	}
+
+	c.compareSecurity(name, oldOp, newOp, opNode)
+}
+
+// compareContent compares the schemas of media types that exist in both versions.
+func (c *comparison) compareContent(oldContent, newContent *orderedmap.Map[string, *drV3.MediaType], dir direction) {
+	for pair := orderedmap.First(oldContent); pair != nil; pair = pair.Next() {
+		newMediaType, _ := newContent.Get(pair.Key())
+		if newMediaType == nil || pair.Value().SchemaProxy == nil || newMediaType.SchemaProxy == nil {
+			continue
+		}
+		c.compareSchemas(pair.Value().SchemaProxy.Schema, newMediaType.SchemaProxy.Schema, dir, 0)
+	}
+}
+
+func (c *comparison) compareSchemas(oldSchema, newSchema *drBase.Schema, dir direction, depth int) {
+	if oldSchema == nil || newSchema == nil || oldSchema.Value == nil || newSchema.Value == nil ||
+		depth > maxSchemaDepth {
+		return
+	}
+	pair := [2]*drBase.Schema{oldSchema, newSchema}
+	if c.seen[pair] {
+		return
+	}
+	c.seen[pair] = true
+
+	c.compareTypes(oldSchema, newSchema, dir)
+	c.compareEnums(oldSchema, newSchema, dir)
+	if dir == request {
+		c.compareRequired(oldSchema, newSchema)
+	}
+
+	for prop := orderedmap.First(oldSchema.Properties); prop != nil; prop = prop.Next() {
+		newProp, _ := newSchema.Properties.Get(prop.Key())
+		if newProp != nil && prop.Value() != nil {
+			c.compareSchemas(prop.Value().Schema, newProp.Schema, dir, depth+1)
+		}
+	}
+	if oldItems, newItems := items(oldSchema), items(newSchema); oldItems != nil && newItems != nil {
+		c.compareSchemas(oldItems, newItems, dir, depth+1)
+	}
+	for _, poly := range [][2][]*drBase.SchemaProxy{
+		{oldSchema.AllOf, newSchema.AllOf},
+		{oldSchema.OneOf, newSchema.OneOf},
+		{oldSchema.AnyOf, newSchema.AnyOf},
+	} {
+		for i := 0; i < len(poly[0]) && i < len(poly[1]); i++ {
+			if poly[0][i] != nil && poly[1][i] != nil {
+				c.compareSchemas(poly[0][i].Schema, poly[1][i].Schema, dir, depth+1)
+			}
+		}
+	}
+}
+
+func (c *comparison) compareTypes(oldSchema, newSchema *drBase.Schema, dir direction) {
+	oldTypes, newTypes := oldSchema.Value.Type, newSchema.Value.Type
+	if len(oldTypes) == 0 || len(newTypes) == 0 {
+		return
+	}
+	added, removed := difference(oldTypes, newTypes)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+
+	// a request can accept more types, a response can return fewer, anything else breaks someone.
+	ruleId := BreakingTypeChanged
+	if (dir == request && len(removed) == 0) || (dir == response && len(added) == 0) {
+		ruleId = TypeChanged
+	}
+	node := newSchema.Value.GoLow().Type.KeyNode
+	c.add(ruleId, fmt.Sprintf("type changed from `%s` to `%s`", strings.Join(oldTypes, ", "),
+		strings.Join(newTypes, ", ")), newSchema.GenerateJSONPath()+".type", schemaNode(newSchema, node))
+}
+
+func (c *comparison) compareEnums(oldSchema, newSchema *drBase.Schema, dir direction) {
+	oldEnum, newEnum := enumValues(oldSchema), enumValues(newSchema)
+	if oldEnum == nil && newEnum == nil {
+		return
+	}
+	node := schemaNode(newSchema, newSchema.Value.GoLow().Enum.KeyNode)
+	path := newSchema.GenerateJSONPath() + ".enum"
+
+	// no enum allows any value, adding one narrows things, removing one widens them.
+	switch {
+	case oldEnum == nil:
+		ruleId := EnumChanged
+		if dir == request {
+			ruleId = BreakingEnumChanged
+		}
+		c.add(ruleId, fmt.Sprintf("values are now restricted to `%s`", strings.Join(newEnum, "`, `")), path, node)
+		return
+	case newEnum == nil:
+		ruleId := EnumChanged
+		if dir == response {
+			ruleId = BreakingEnumChanged
+		}
+		c.add(ruleId, "values are no longer restricted by an enum", path, node)
+		return
+	}
+
+	added, removed := difference(oldEnum, newEnum)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	var changes []string
+	if len(removed) > 0 {
+		changes = append(changes, fmt.Sprintf("removed `%s`", strings.Join(removed, "`, `")))
+	}
+	if len(added) > 0 {
+		changes = append(changes, fmt.Sprintf("added `%s`", strings.Join(added, "`, `")))
+	}
+	ruleId := EnumChanged
+	if (dir == request && len(removed) > 0) || (dir == response && len(added) > 0) {
+		ruleId = BreakingEnumChanged
+	}
+	c.add(ruleId, fmt.Sprintf("enum values have changed: %s", strings.Join(changes, ", ")), path, node)
+}
+
+func (c *comparison) compareRequired(oldSchema, newSchema *drBase.Schema) {
+	added, removed := difference(oldSchema.Value.Required, newSchema.Value.Required)
+	node := schemaNode(newSchema, newSchema.Value.GoLow().Required.KeyNode)
+	path := newSchema.GenerateJSONPath() + ".required"
+	for _, prop := range added {
+		c.add(BreakingParameterRequired, fmt.Sprintf("property `%s` is now required", prop), path, node)
+	}
+	for _, prop := range removed {
+		c.add(ParameterOptional, fmt.Sprintf("property `%s` is now optional", prop), path, node)
+	}
+}
+
+// compareSecurity compares the security requirements that apply to an operation. Requirements are alternatives,
+// a client only needs to satisfy one of them, so removing (or changing) any requirement is breaking, adding one is not.
+func (c *comparison) compareSecurity(name string, oldOp, newOp *drV3.Operation, opNode *yaml.Node) {
+	oldReqs, oldAnonymous := security(c.original, oldOp)
+	newReqs, newAnonymous := security(c.updated, newOp)
+	added, removed := difference(oldReqs, newReqs)
+	if len(added) == 0 && len(removed) == 0 && oldAnonymous == newAnonymous {
+		return
+	}
+
+	node := opNode
+	if low := newOp.Value.GoLow(); low != nil && low.Security.KeyNode != nil {
+		node = low.Security.KeyNode
+	} else if low := c.updated.Document.GoLow(); low != nil && low.Security.KeyNode != nil {
+		node = low.Security.KeyNode
+	}
+	path := newOp.GenerateJSONPath() + ".security"
+
+	switch {
+	case newAnonymous:
+		c.add(SecurityChanged, fmt.Sprintf("`%s` no longer requires authentication", name), path, node)
+	case oldAnonymous:
+		c.add(BreakingSecurityChanged, fmt.Sprintf("`%s` now requires authentication with `%s`", name,
+			strings.Join(newReqs, "` or `")), path, node)
+	case len(removed) > 0:
+		c.add(BreakingSecurityChanged, fmt.Sprintf("security requirement `%s` has been removed from `%s`",
+			strings.Join(removed, "`, `"), name), path, node)
+	default:
+		c.add(SecurityChanged, fmt.Sprintf("security requirement `%s` has been added to `%s`",
+			strings.Join(added, "`, `"), name), path, node)
+	}
+}
+
+func pathItems(doc *drV3.Document) *orderedmap.Map[string, *drV3.PathItem] {
+	if doc.Paths == nil || doc.Paths.PathItems == nil {
+		return orderedmap.New[string, *drV3.PathItem]()
+	}
+	return doc.Paths.PathItems
+}
+
+func operations(item *drV3.PathItem) map[string]*drV3.Operation {
+	ops := make(map[string]*drV3.Operation)
+	if item == nil {
+		return ops
+	}
+	for method, op := range map[string]*drV3.Operation{
+		"get": item.Get, "put": item.Put, "post": item.Post, "delete": item.Delete,
+		"options": item.Options, "head": item.Head, "patch": item.Patch, "trace": item.Trace,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+func operationName(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+// parameters returns the parameters that apply to an operation, keyed by location and name. Operation
+// parameters override path item parameters.
+func parameters(item *drV3.PathItem, op *drV3.Operation) map[string]*drV3.Parameter {
+	params := make(map[string]*drV3.Parameter)
+	for _, list := range [][]*drV3.Parameter{item.Parameters, op.Parameters} {
+		for _, p := range list {
+			if p == nil || p.Value == nil {
+				continue
+			}
+			name := p.Value.Name
+			if p.Value.In == "header" {
+				name = strings.ToLower(name) // headers are case-insensitive.
+			}
+			params[p.Value.In+":"+name] = p
+		}
+	}
+	return params
+}
+
+func responses(op *drV3.Operation) *orderedmap.Map[string, *drV3.Response] {
+	codes := orderedmap.New[string, *drV3.Response]()
+	if op.Responses == nil {
+		return codes
+	}
+	for pair := orderedmap.First(op.Responses.Codes); pair != nil; pair = pair.Next() {
+		codes.Set(pair.Key(), pair.Value())
+	}
+	if op.Responses.Default != nil {
+		codes.Set("default", op.Responses.Default)
+	}
+	return codes
+}
+
+func items(schema *drBase.Schema) *drBase.Schema {
+	if schema.Items == nil || schema.Items.Value == nil || !schema.Items.Value.IsA() || schema.Items.A == nil {
+		return nil
+	}
+	return schema.Items.A.Schema
+}
+
+func enumValues(schema *drBase.Schema) []string {
+	if schema.Value.Enum == nil {
+		return nil
+	}
+	values := make([]string, 0, len(schema.Value.Enum))
+	for _, node := range schema.Value.Enum {
+		if node == nil {
+			continue
+		}
+		if node.Kind == yaml.ScalarNode {
+			values = append(values, node.Value)
+			continue
+		}
+		rendered, _ := yaml.Marshal(node)
+		values = append(values, strings.TrimSpace(string(rendered)))
+	}
+	return values
+}
+
+// security returns the requirements that apply to an operation, rendered so they can be compared, and if
+// the operation can be called without authenticating at all.
+func security(doc *drV3.Document, op *drV3.Operation) ([]string, bool) {
+	reqs := doc.Document.Security
+	if low := op.Value.GoLow(); low != nil && low.Security.ValueNode != nil {
+		reqs = op.Value.Security // an operation can override (or clear) the global requirements.
+	}
+	if len(reqs) == 0 {
+		return nil, true
+	}
+	var rendered []string
+	anonymous := false
+	for _, req := range reqs {
+		if req == nil || req.Requirements == nil || req.Requirements.Len() == 0 || req.ContainsEmptyRequirement {
+			anonymous = true
+			continue
+		}
+		var schemes []string
+		for pair := orderedmap.First(req.Requirements); pair != nil; pair = pair.Next() {
+			scopes := append([]string(nil), pair.Value()...)
+			sort.Strings(scopes)
+			if len(scopes) > 0 {
+				schemes = append(schemes, fmt.Sprintf("%s[%s]", pair.Key(), strings.Join(scopes, ", ")))
+			} else {
+				schemes = append(schemes, pair.Key())
+			}
+		}
+		sort.Strings(schemes)
+		rendered = append(rendered, strings.Join(schemes, " + "))
+	}
+	return rendered, anonymous
+}
+
+// difference returns the values only found in updated (added) and only found in original (removed).
+func difference(original, updated []string) (added, removed []string) {
+	inOriginal := make(map[string]bool, len(original))
+	for _, v := range original {
+		inOriginal[v] = true
+	}
+	inUpdated := make(map[string]bool, len(updated))
+	for _, v := range updated {
+		inUpdated[v] = true
+		if !inOriginal[v] {
+			added = append(added, v)
+		}
+	}
+	for _, v := range original {
+		if !inUpdated[v] {
+			removed = append(removed, v)
+		}
+	}
+	return added, removed
+}
+
+func sortedKeys(params map[string]*drV3.Parameter) []string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func pathsNode(doc *drV3.Document) *yaml.Node {
+	if low := doc.Document.GoLow(); low != nil {
+		return low.Paths.KeyNode
+	}
+	return nil
+}
+
+func pathNode(doc *drV3.Document, path string) *yaml.Node {
+	if doc.Paths == nil || doc.Paths.Value == nil || doc.Paths.Value.GoLow() == nil {
+		return pathsNode(doc)
+	}
+	if key, _ := doc.Paths.Value.GoLow().FindPathAndKey(path); key != nil && key.KeyNode != nil {
+		return key.KeyNode
+	}
+	return pathsNode(doc)
+}
+
+func operationNode(op *drV3.Operation, fallback *yaml.Node) *yaml.Node {
+	if low := op.Value.GoLow(); low != nil && low.KeyNode != nil {
+		return low.KeyNode
+	}
+	return fallback
+}
+
+func parameterNode(param *drV3.Parameter, fallback *yaml.Node) *yaml.Node {
+	if low := param.Value.GoLow(); low != nil {
+		if low.Required.KeyNode != nil {
+			return low.Required.KeyNode
+		}
+		if low.Name.KeyNode != nil {
+			return low.Name.KeyNode
+		}
+	}
+	return fallback
+}
+
+func responsesNode(op *drV3.Operation, fallback *yaml.Node) *yaml.Node {
+	if low := op.Value.GoLow(); low != nil && low.Responses.KeyNode != nil {
+		return low.Responses.KeyNode
+	}
+	return fallback
+}
+
+func responseNode(op *drV3.Operation, code string, fallback *yaml.Node) *yaml.Node {
+	low := op.Value.GoLow()
+	if low == nil || low.Responses.Value == nil {
+		return fallback
+	}
+	if code == "default" && low.Responses.Value.Default.KeyNode != nil {
+		return low.Responses.Value.Default.KeyNode
+	}
+	for pair := orderedmap.First(low.Responses.Value.Codes); pair != nil; pair = pair.Next() {
+		if pair.Key().Value == code && pair.Key().KeyNode != nil {
+			return pair.Key().KeyNode
+		}
+	}
+	return responsesNode(op, fallback)
+}
+
+// schemaNode returns the node if it exists, otherwise the node of the schema itself.
+func schemaNode(schema *drBase.Schema, node *yaml.Node) *yaml.Node {
+	if node != nil {
+		return node
+	}
+	if proxy, ok := schema.Parent.(*drBase.SchemaProxy); ok && proxy.Value != nil && proxy.Value.GoLow() != nil {
+		return proxy.Value.GoLow().GetKeyNode()
+	}
+	return nil
+}
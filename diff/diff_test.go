@@ -0,0 +1,237 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/daveshanley/vacuum/model"
+	drModel "github.com/pb33f/doctor/model"
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func buildDrDocument(t *testing.T, spec string) *drModel.DrDocument {
+	document, err := libopenapi.NewDocument([]byte(spec))
+	assert.NoError(t, err)
+	m, _ := document.BuildV3Model()
+	return drModel.NewDrDocument(m)
+}
+
+func resultsByRule(results []model.RuleFunctionResult) map[string][]model.RuleFunctionResult {
+	byRule := make(map[string][]model.RuleFunctionResult)
+	for _, r := range results {
+		byRule[r.RuleId] = append(byRule[r.RuleId], r)
+	}
+	return byRule
+}
+
+var original = `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+security:
+  - apiKey: []
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+        - name: kind
+          in: query
+          schema:
+            type: string
+            enum: [cat, dog, bird]
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+        '404':
+          description: nope
+    delete:
+      responses:
+        '204':
+          description: gone
+    post:
+      security:
+        - apiKey: []
+        - oauth: [write]
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+      responses:
+        '201':
+          description: created
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id:
+          type: integer
+        status:
+          type: string
+          enum: [available, sold]
+`
+
+var updated = `openapi: 3.1.0
+info:
+  title: pets
+  version: 2.0.0
+security:
+  - apiKey: []
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+        - name: kind
+          in: query
+          schema:
+            type: string
+            enum: [cat, dog, fish]
+        - name: page
+          in: query
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+        '400':
+          description: bad
+    post:
+      security:
+        - oauth: [write]
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string
+      responses:
+        '201':
+          description: created
+  /cats:
+    get:
+      security: []
+      responses:
+        '200':
+          description: ok
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id:
+          type: string
+        status:
+          type: string
+          enum: [available, sold, pending]
+`
+
+func TestCompareDocuments(t *testing.T) {
+	results := CompareDocuments(buildDrDocument(t, original), buildDrDocument(t, updated))
+	byRule := resultsByRule(results)
+
+	assert.Len(t, byRule[BreakingOperationRemoved], 1)
+	assert.Equal(t, "operation `DELETE /pets` has been removed", byRule[BreakingOperationRemoved][0].Message)
+	assert.Equal(t, "$.paths['/pets'].delete", byRule[BreakingOperationRemoved][0].Path)
+	assert.Equal(t, 8, byRule[BreakingOperationRemoved][0].StartNode.Line)
+
+	assert.Len(t, byRule[OperationAdded], 1)
+	assert.Equal(t, "operation `GET /cats` has been added", byRule[OperationAdded][0].Message)
+
+	// limit is now required, the request body is now required and so is the name property.
+	assert.Len(t, byRule[BreakingParameterRequired], 3)
+	assert.Len(t, byRule[ParameterOptional], 1)
+	assert.Equal(t, "optional query parameter `page` has been added to `GET /pets`", byRule[ParameterOptional][0].Message)
+
+	// removing 'bird' from a request breaks, adding 'pending' to a response breaks.
+	assert.Len(t, byRule[BreakingEnumChanged], 2)
+	assert.Len(t, byRule[EnumChanged], 0)
+
+	assert.Len(t, byRule[BreakingTypeChanged], 1)
+	assert.Equal(t, "type changed from `integer` to `string`", byRule[BreakingTypeChanged][0].Message)
+	assert.Equal(t,
+		"$.paths['/pets'].get.responses['200'].content['application/json'].schema.properties['id'].type",
+		byRule[BreakingTypeChanged][0].Path)
+
+	assert.Len(t, byRule[BreakingResponseRemoved], 1)
+	assert.Equal(t, "response `404` has been removed from `GET /pets`", byRule[BreakingResponseRemoved][0].Message)
+	assert.Len(t, byRule[ResponseAdded], 1)
+
+	assert.Len(t, byRule[BreakingSecurityChanged], 1)
+	assert.Equal(t, "security requirement `apiKey` has been removed from `POST /pets`",
+		byRule[BreakingSecurityChanged][0].Message)
+
+	for _, r := range results {
+		assert.NotNil(t, r.Rule)
+		assert.NotNil(t, r.StartNode, r.Message)
+	}
+}
+
+func TestCompareDocuments_NoChanges(t *testing.T) {
+	results := CompareDocuments(buildDrDocument(t, original), buildDrDocument(t, original))
+	assert.Empty(t, results)
+}
+
+func TestCompareDocuments_Security(t *testing.T) {
+	open := `openapi: 3.1.0
+paths:
+  /pets:
+    get:
+      responses:
+        '200':
+          description: ok`
+	secured := `openapi: 3.1.0
+security:
+  - apiKey: []
+paths:
+  /pets:
+    get:
+      responses:
+        '200':
+          description: ok`
+
+	results := CompareDocuments(buildDrDocument(t, open), buildDrDocument(t, secured))
+	assert.Len(t, results, 1)
+	assert.Equal(t, BreakingSecurityChanged, results[0].RuleId)
+	assert.Equal(t, "`GET /pets` now requires authentication with `apiKey`", results[0].Message)
+
+	results = CompareDocuments(buildDrDocument(t, secured), buildDrDocument(t, open))
+	assert.Len(t, results, 1)
+	assert.Equal(t, SecurityChanged, results[0].RuleId)
+}
+
+func TestCompareDocuments_Nil(t *testing.T) {
+	assert.Nil(t, CompareDocuments(nil, nil))
+}
+
+func TestRules(t *testing.T) {
+	for id, rule := range Rules() {
+		assert.Equal(t, id, rule.Id)
+		assert.NotNil(t, rule.RuleCategory)
+		assert.NotEmpty(t, rule.HowToFix)
+	}
+}
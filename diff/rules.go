@@ -0,0 +1,98 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package diff
+
+import (
+	"github.com/daveshanley/vacuum/model"
+)
+
+// rule IDs for changes that break existing clients, all of them share the 'breaking-' prefix.
+const (
+	BreakingOperationRemoved  = "breaking-operation-removed"
+	BreakingEnumChanged       = "breaking-enum-changed"
+	BreakingParameterRequired = "breaking-parameter-required"
+	BreakingTypeChanged       = "breaking-type-changed"
+	BreakingResponseRemoved   = "breaking-response-removed"
+	BreakingSecurityChanged   = "breaking-security-changed"
+)
+
+// rule IDs for changes that are safe for existing clients.
+const (
+	OperationAdded    = "operation-added"
+	EnumChanged       = "enum-changed"
+	ParameterOptional = "parameter-optional"
+	TypeChanged       = "type-changed"
+	ResponseAdded     = "response-added"
+	SecurityChanged   = "security-changed"
+)
+
+// Rules returns all the rules used to classify changes between two versions of a specification. The rules are
+// never run by the motor, they only describe the results created by CompareDocuments, so they can be rendered
+// and reported like any other rule.
+func Rules() map[string]*model.Rule {
+	rules := []*model.Rule{
+		changeRule(BreakingOperationRemoved, "Check for removed operations", model.SeverityError,
+			"An operation has been removed, clients calling it will break.", model.CategoryOperations,
+			"Deprecate the operation first, and remove it in a new major version of the API."),
+		changeRule(OperationAdded, "Check for added operations", model.SeverityInfo,
+			"An operation has been added.", model.CategoryOperations, ""),
+		changeRule(BreakingEnumChanged, "Check for breaking enum changes", model.SeverityError,
+			"Enum values have been removed from a request, or added to a response, clients may send or receive "+
+				"values that are no longer understood.", model.CategorySchemas,
+			"Only add enum values to requests, and only remove them from responses. If clients must handle new "+
+				"response values, document how unknown values should be treated before adding them."),
+		changeRule(EnumChanged, "Check for enum changes", model.SeverityInfo,
+			"Enum values have been changed in a way that existing clients can handle.", model.CategorySchemas, ""),
+		changeRule(BreakingParameterRequired, "Check for newly required parameters", model.SeverityError,
+			"A parameter, request body or request property is now required, clients that don't send it will break.",
+			model.CategoryOperations,
+			"New parameters and properties must be optional, give them a sensible default on the server instead."),
+		changeRule(ParameterOptional, "Check for newly optional parameters", model.SeverityInfo,
+			"A parameter, request body or request property has been added or made optional.",
+			model.CategoryOperations, ""),
+		changeRule(BreakingTypeChanged, "Check for breaking type changes", model.SeverityError,
+			"A schema type has been changed, clients will send or receive data they don't understand.",
+			model.CategorySchemas,
+			"Types cannot be changed without breaking clients, add a new property with the new type instead."),
+		changeRule(TypeChanged, "Check for type changes", model.SeverityInfo,
+			"A schema type has been widened in a request, or narrowed in a response.", model.CategorySchemas, ""),
+		changeRule(BreakingResponseRemoved, "Check for removed responses", model.SeverityError,
+			"A response code has been removed, clients may rely on it.", model.CategoryOperations,
+			"Keep the response code documented, until a new major version of the API."),
+		changeRule(ResponseAdded, "Check for added responses", model.SeverityInfo,
+			"A response code has been added.", model.CategoryOperations, ""),
+		changeRule(BreakingSecurityChanged, "Check for breaking security changes", model.SeverityError,
+			"Security requirements have been added or changed, clients will not be able to authenticate.",
+			model.CategorySecurity,
+			"Add new security requirements as alternatives to the existing ones, instead of replacing them."),
+		changeRule(SecurityChanged, "Check for security changes", model.SeverityInfo,
+			"Security requirements have been relaxed, or alternatives have been added.", model.CategorySecurity, ""),
+	}
+	mapped := make(map[string]*model.Rule, len(rules))
+	for _, r := range rules {
+		mapped[r.Id] = r
+	}
+	return mapped
+}
+
+func changeRule(id, name, severity, description, category, howToFix string) *model.Rule {
+	if howToFix == "" {
+		howToFix = "This change is safe for existing clients, nothing needs to be done."
+	}
+	return &model.Rule{
+		Name:         name,
+		Id:           id,
+		Formats:      model.OAS3AllFormat,
+		Description:  description,
+		Given:        "$",
+		Recommended:  true,
+		RuleCategory: model.RuleCategories[category],
+		Type:         "validation",
+		Severity:     severity,
+		Then: model.RuleAction{
+			Function: "blank",
+		},
+		HowToFix: howToFix,
+	}
+}
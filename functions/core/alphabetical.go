@@ -61,9 +61,10 @@ func (a Alphabetical) RunRule(nodes []*yaml.Node, context model.RuleFunctionCont
 		if utils.IsNodeMap(node) {
 
 			if keyedBy == "" {
+				errMsg := fmt.Sprintf("`%s` is a map/object. %s", node.Value, a.GetSchema().ErrorMessage)
 				results = append(results, model.RuleFunctionResult{
-					Message: vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: `%s` is a map/object. %s", context.Rule.Description,
-						node.Value, a.GetSchema().ErrorMessage)),
+					Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", context.Rule.Description, errMsg)),
+					Error:     errMsg,
 					StartNode: node,
 					EndNode:   vacuumUtils.BuildEndNode(node),
 					Path:      pathValue,
@@ -96,6 +97,15 @@ func (a Alphabetical) RunRule(nodes []*yaml.Node, context model.RuleFunctionCont
 				}
 				results = model.MapPathAndNodesToResults(pathValue, node, node, results)
 
+				// every result points at the same array, sorting it fixes all of them.
+				if fix := a.buildSortFix(node, keyedBy); fix != nil {
+					for i := range results {
+						if results[i].StartNode == node {
+							results[i].Fix = fix
+						}
+					}
+				}
+
 			}
 			continue
 		}
@@ -127,6 +137,63 @@ func (a Alphabetical) processMap(node *yaml.Node, keyedBy string, context model.
 	return resultsFromKey
 }
 
+// buildSortFix creates a fix that sorts the items of an array, using the same ordering as the checks. Arrays
+// of objects are sorted by the value of the 'keyedBy' property, every object must have it.
+func (a Alphabetical) buildSortFix(arr *yaml.Node, keyedBy string) *model.Fix {
+	if len(arr.Content) < 2 {
+		return nil
+	}
+	numeric := a.isValidNumberArray(arr)
+	keys := make([]string, len(arr.Content))
+	values := make([]float64, len(arr.Content))
+	for i, n := range arr.Content {
+		switch {
+		case n.Tag == "!!map" && keyedBy != "":
+			_, v := utils.FindKeyNodeTop(keyedBy, n.Content)
+			if v == nil {
+				return nil
+			}
+			keys[i] = v.Value
+		case numeric && (n.Tag == "!!int" || n.Tag == "!!float"):
+			f, err := strconv.ParseFloat(n.Value, 64)
+			if err != nil {
+				return nil
+			}
+			values[i] = f
+		case !numeric && n.Tag == "!!str":
+			keys[i] = n.Value
+		default:
+			return nil
+		}
+	}
+
+	order := make([]int, len(arr.Content))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if numeric {
+			return values[order[i]] < values[order[j]]
+		}
+		return strings.Compare(keys[order[i]], keys[order[j]]) < 0
+	})
+	if sort.IntsAreSorted(order) {
+		return nil
+	}
+
+	desc := "sort items alphabetically"
+	if numeric {
+		desc = "sort items numerically"
+	}
+	if keyedBy != "" && !numeric && arr.Content[0].Tag == "!!map" {
+		desc = fmt.Sprintf("sort items alphabetically by `%s`", keyedBy)
+	}
+	return &model.Fix{
+		Description: desc,
+		Edits:       []model.FixEdit{model.NewReorderEdit(arr, order)},
+	}
+}
+
 func (a Alphabetical) isValidArray(arr *yaml.Node) bool {
 	for _, n := range arr.Content {
 		switch n.Tag {
@@ -179,13 +246,13 @@ func compareStringArray(node *yaml.Node, strArr []string, context model.RuleFunc
 		if x+1 < len(strArr) {
 			s := strings.Compare(strArr[x], strArr[x+1])
 			if s > 0 {
+				errMsg := fmt.Sprintf("`%s` must be placed before `%s` (alphabetical)", strArr[x+1], strArr[x])
 				results = append(results, model.RuleFunctionResult{
 					Rule:      context.Rule,
 					StartNode: node,
 					EndNode:   vacuumUtils.BuildEndNode(node),
-					Message: vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: `%s` must be placed before `%s` (alphabetical)",
-						context.Rule.Description,
-						strArr[x+1], strArr[x])),
+					Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", context.Rule.Description, errMsg)),
+					Error:     errMsg,
 				})
 			}
 		}
@@ -209,7 +276,7 @@ func (a Alphabetical) checkNumberArrayIsSorted(arr *yaml.Node, context model.Rul
 		}
 	}
 
-	errmsg := "%s: `%v` is less than `%v`, they need to be swapped (numerical ordering)"
+	errmsg := "`%v` is less than `%v`, they need to be swapped (numerical ordering)"
 
 	if len(floatArray) > 0 {
 		if !sort.Float64sAreSorted(floatArray) {
@@ -231,11 +298,13 @@ func (a Alphabetical) evaluateIntArray(node *yaml.Node, intArray []int, errmsg s
 	message := context.Rule.Message
 	for x, n := range intArray {
 		if x+1 < len(intArray) && n > intArray[x+1] {
+			errMsg := fmt.Sprintf(errmsg, intArray[x+1], intArray[x])
 			results = append(results, model.RuleFunctionResult{
 				Rule:      context.Rule,
 				StartNode: node,
 				EndNode:   vacuumUtils.BuildEndNode(node),
-				Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf(errmsg, context.Rule.Description, intArray[x+1], intArray[x])),
+				Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", context.Rule.Description, errMsg)),
+				Error:     errMsg,
 			})
 		}
 	}
@@ -248,11 +317,13 @@ func (a Alphabetical) evaluateFloatArray(node *yaml.Node, floatArray []float64,
 
 	for x, n := range floatArray {
 		if x+1 < len(floatArray) && n > floatArray[x+1] {
+			errMsg := fmt.Sprintf(errmsg, floatArray[x+1], floatArray[x])
 			results = append(results, model.RuleFunctionResult{
 				Rule:      context.Rule,
 				StartNode: node,
 				EndNode:   vacuumUtils.BuildEndNode(node),
-				Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf(errmsg, context.Rule.Description, floatArray[x+1], floatArray[x])),
+				Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", context.Rule.Description, errMsg)),
+				Error:     errMsg,
 			})
 		}
 	}
@@ -299,3 +299,50 @@ func TestAlphabetical_RunRule_ObjectFailNoKeyedBy(t *testing.T) {
 
 	assert.Len(t, res, 1)
 }
+
+func TestAlphabetical_RunRule_Fix(t *testing.T) {
+
+	sampleYaml := `tags:
+ - name: zebra
+ - name: apple
+ - name: mango`
+
+	path := "$.tags"
+	nodes, _ := utils.FindNodes([]byte(sampleYaml), path)
+	assert.Len(t, nodes, 1)
+
+	opts := make(map[string]string)
+	opts["keyedBy"] = "name"
+
+	rule := buildCoreTestRule(path, model.SeverityError, "alphabetical", "", opts)
+	ctx := buildCoreTestContextFromRule(model.CastToRuleAction(rule.Then), rule)
+	ctx.Given = path
+	ctx.Rule = &rule
+
+	def := &Alphabetical{}
+	res := def.RunRule(nodes, ctx)
+
+	assert.Len(t, res, 1)
+	assert.NotNil(t, res[0].Fix)
+	assert.Equal(t, model.FixReplace, res[0].Fix.Edits[0].Kind)
+	assert.Equal(t, []int{1, 2, 0}, res[0].Fix.Edits[0].Order)
+}
+
+func TestAlphabetical_RunRule_FixIntegerArray(t *testing.T) {
+
+	sampleYaml := `numbers: [3, 1, 2]`
+
+	path := "$.numbers"
+	nodes, _ := utils.FindNodes([]byte(sampleYaml), path)
+
+	rule := buildCoreTestRule(path, model.SeverityError, "alphabetical", "", nil)
+	ctx := buildCoreTestContextFromRule(model.CastToRuleAction(rule.Then), rule)
+	ctx.Given = path
+	ctx.Rule = &rule
+
+	def := &Alphabetical{}
+	res := def.RunRule(nodes, ctx)
+
+	assert.NotEmpty(t, res)
+	assert.Equal(t, []int{1, 2, 0}, res[0].Fix.Edits[0].Order)
+}
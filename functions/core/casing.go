@@ -154,8 +154,10 @@ func (c Casing) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext) [
 		}
 
 		if !rx.MatchString(node.Value) {
+			errMsg := fmt.Sprintf("`%s` is not %s case", node.Value, casingType)
 			results = append(results, model.RuleFunctionResult{
-				Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: `%s` is not %s case", ruleMessage, node.Value, casingType)),
+				Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage, errMsg)),
+				Error:     errMsg,
 				StartNode: node,
 				EndNode:   vacuumUtils.BuildEndNode(node),
 				Path:      pathValue,
@@ -176,9 +178,10 @@ func (c Casing) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext) [
 
 		rx := regexp.MustCompile(leadingPattern)
 		if !rx.MatchString(nodes[0].Value) {
+			errMsg := fmt.Sprintf("`%s` is not `%s` case", nodes[0].Value, casingType)
 			results = append(results, model.RuleFunctionResult{
-				Message: vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: `%s` is not `%s` case", ruleMessage,
-					nodes[0].Value, casingType)),
+				Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage, errMsg)),
+				Error:     errMsg,
 				StartNode: nodes[0],
 				EndNode:   vacuumUtils.BuildEndNode(nodes[0]),
 				Path:      pathValue,
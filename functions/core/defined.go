@@ -47,9 +47,10 @@ func (d Defined) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext)
 	for _, node := range nodes {
 		fieldNode, _ := utils.FindKeyNode(context.RuleAction.Field, node.Content)
 		if fieldNode == nil {
+			errMsg := fmt.Sprintf("`%s` must be defined", context.RuleAction.Field)
 			results = append(results, model.RuleFunctionResult{
-				Message: vacuumUtils.SuppliedOrDefault(message,
-					fmt.Sprintf("%s: `%s` must be defined", ruleMessage, context.RuleAction.Field)),
+				Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage, errMsg)),
+				Error:     errMsg,
 				StartNode: node,
 				EndNode:   vacuumUtils.BuildEndNode(node),
 				Path:      pathValue,
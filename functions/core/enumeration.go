@@ -63,10 +63,10 @@ func (e Enumeration) RunRule(nodes []*yaml.Node, context model.RuleFunctionConte
 
 	for _, node := range nodes {
 		if !e.checkValueAgainstAllowedValues(node.Value, values) {
+			errMsg := fmt.Sprintf("`%s` must equal to one of: %v", node.Value, values)
 			results = append(results, model.RuleFunctionResult{
-				Message: vacuumUtils.SuppliedOrDefault(message,
-					fmt.Sprintf("%s: `%s` must equal to one of: %v", ruleMessage,
-						node.Value, values)),
+				Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage, errMsg)),
+				Error:     errMsg,
 				StartNode: node,
 				EndNode:   vacuumUtils.BuildEndNode(node),
 				Path:      pathValue,
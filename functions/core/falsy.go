@@ -47,8 +47,10 @@ func (f Falsy) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext) []
 		fieldNode, fieldNodeValue := utils.FindKeyNode(context.RuleAction.Field, node.Content)
 		if (fieldNode != nil && fieldNodeValue != nil) &&
 			(fieldNodeValue.Value != "" && fieldNodeValue.Value != "false" && fieldNodeValue.Value != "0" || (fieldNodeValue.Value == "" && fieldNodeValue.Content != nil)) {
+			errMsg := fmt.Sprintf("`%s` must be falsy", context.RuleAction.Field)
 			results = append(results, model.RuleFunctionResult{
-				Message:   fmt.Sprintf("%s: `%s` must be falsy", ruleMessage, context.RuleAction.Field),
+				Message:   fmt.Sprintf("%s: %s", ruleMessage, errMsg),
+				Error:     errMsg,
 				StartNode: node,
 				EndNode:   vacuumUtils.BuildEndNode(node),
 				Path:      pathValue,
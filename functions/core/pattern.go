@@ -101,10 +101,10 @@ func (p Pattern) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext)
 			rx, err := p.getPatternFromCache(p.match, context.Rule)
 			expPath := fmt.Sprintf("%s['%s']", pathValue, currentField)
 			if err != nil {
+				errMsg := fmt.Sprintf("`%s` cannot be compiled into a regular expression [`%s`]", p.match, err.Error())
 				results = append(results, model.RuleFunctionResult{
-					Message: vacuumUtils.SuppliedOrDefault(message,
-						fmt.Sprintf("%s: `%s` cannot be compiled into a regular expression [`%s`]",
-							ruleMessage, p.match, err.Error())),
+					Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage, errMsg)),
+					Error:     errMsg,
 					StartNode: node,
 					EndNode:   vacuumUtils.BuildEndNode(node),
 					Path:      expPath,
@@ -112,10 +112,10 @@ func (p Pattern) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext)
 				})
 			} else {
 				if !rx.MatchString(node.Value) {
+					errMsg := fmt.Sprintf("`%s` does not match the expression `%s`", node.Value, p.match)
 					results = append(results, model.RuleFunctionResult{
-						Message: vacuumUtils.SuppliedOrDefault(message,
-							fmt.Sprintf("%s: `%s` does not match the expression `%s`", ruleMessage,
-								node.Value, p.match)),
+						Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage, errMsg)),
+						Error:     errMsg,
 						StartNode: node,
 						EndNode:   vacuumUtils.BuildEndNode(node),
 						Path:      expPath,
@@ -130,10 +130,10 @@ func (p Pattern) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext)
 			rx, err := p.getPatternFromCache(p.notMatch, context.Rule)
 			expPath := fmt.Sprintf("%s['%s']", pathValue, currentField)
 			if err != nil {
+				errMsg := fmt.Sprintf("cannot be compiled into a regular expression [`%s`]", err.Error())
 				results = append(results, model.RuleFunctionResult{
-					Message: vacuumUtils.SuppliedOrDefault(message,
-						fmt.Sprintf("%s: cannot be compiled into a regular expression [`%s`]",
-							ruleMessage, err.Error())),
+					Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage, errMsg)),
+					Error:     errMsg,
 					StartNode: node,
 					EndNode:   vacuumUtils.BuildEndNode(node),
 					Path:      expPath,
@@ -141,9 +141,10 @@ func (p Pattern) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext)
 				})
 			} else {
 				if rx.MatchString(node.Value) {
+					errMsg := fmt.Sprintf("matches the expression `%s`", p.notMatch)
 					results = append(results, model.RuleFunctionResult{
-						Message: vacuumUtils.SuppliedOrDefault(message,
-							fmt.Sprintf("%s: matches the expression `%s`", ruleMessage, p.notMatch)),
+						Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage, errMsg)),
+						Error:     errMsg,
 						StartNode: node,
 						EndNode:   vacuumUtils.BuildEndNode(node),
 						Path:      expPath,
@@ -64,8 +64,9 @@ func (sch Schema) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext)
 		err := on.Encode(&s)
 
 		if err != nil {
-			r := model.BuildFunctionResultString(
-				vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("unable to parse function options: %s", err.Error())))
+			errMsg := fmt.Sprintf("unable to parse function options: %s", err.Error())
+			r := model.BuildFunctionResultString(vacuumUtils.SuppliedOrDefault(message, errMsg))
+			r.Error = errMsg
 			r.Rule = context.Rule
 			results = append(results, r)
 			return results
@@ -74,9 +75,9 @@ func (sch Schema) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext)
 		// first, run the model builder on the schema
 		err = low.BuildModel(&on, &lowSchema)
 		if err != nil {
-			r := model.BuildFunctionResultString(
-				vacuumUtils.SuppliedOrDefault(message,
-					fmt.Sprintf("unable to build low schema from function options: %s", err.Error())))
+			errMsg := fmt.Sprintf("unable to build low schema from function options: %s", err.Error())
+			r := model.BuildFunctionResultString(vacuumUtils.SuppliedOrDefault(message, errMsg))
+			r.Error = errMsg
 			r.Rule = context.Rule
 			results = append(results, r)
 			return results
@@ -85,9 +86,9 @@ func (sch Schema) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext)
 		// now build out the low level schema.
 		err = lowSchema.Build(ctx.Background(), &on, context.Index)
 		if err != nil {
-			r := model.BuildFunctionResultString(
-				vacuumUtils.SuppliedOrDefault(message,
-					fmt.Sprintf("unable to build high schema from function options: %s", err.Error())))
+			errMsg := fmt.Sprintf("unable to build high schema from function options: %s", err.Error())
+			r := model.BuildFunctionResultString(vacuumUtils.SuppliedOrDefault(message, errMsg))
+			r.Error = errMsg
 			r.Rule = context.Rule
 			results = append(results, r)
 			return results
@@ -128,9 +129,10 @@ func (sch Schema) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext)
 			forceValidation := utils.ExtractValueFromInterfaceMap("forceValidation", context.Options)
 			if _, ko := forceValidation.(bool); ko {
 
+				errMsg := fmt.Sprintf("`%s`, is missing and is required", context.RuleAction.Field)
 				r := model.BuildFunctionResultString(
-					vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage,
-						fmt.Sprintf("`%s`, is missing and is required", context.RuleAction.Field))))
+					vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage, errMsg)))
+				r.Error = errMsg
 				r.StartNode = node
 				r.EndNode = vacuumUtils.BuildEndNode(node)
 				r.Rule = context.Rule
@@ -174,6 +176,7 @@ func validateNodeAgainstSchema(ctx *model.RuleFunctionContext, schema *highBase.
 
 		r := model.BuildFunctionResultString(vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage,
 			schemaErrors[c].Reason)))
+		r.Error = schemaErrors[c].Reason
 		r.StartNode = field
 		r.EndNode = vacuumUtils.BuildEndNode(field)
 		r.Rule = context.Rule
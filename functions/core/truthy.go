@@ -65,9 +65,10 @@ func (t *Truthy) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext)
 				} else {
 					endNode = node
 				}
+				errMsg := fmt.Sprintf("`%s` must be set", context.RuleAction.Field)
 				results = append(results, model.RuleFunctionResult{
-					Message: vacuumUtils.SuppliedOrDefault(message,
-						fmt.Sprintf("%s: `%s` must be set", ruleMessage, context.RuleAction.Field)),
+					Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage, errMsg)),
+					Error:     errMsg,
 					StartNode: node,
 					EndNode:   vacuumUtils.BuildEndNode(endNode),
 					Path:      pathValue,
@@ -48,9 +48,10 @@ func (u Undefined) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext
 			if context.RuleAction.Field != "" {
 				val = fmt.Sprintf("'%s' ", context.RuleAction.Field)
 			}
+			errMsg := fmt.Sprintf("`%s` must be undefined]", val)
 			results = append(results, model.RuleFunctionResult{
-				Message: vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: `%s` must be undefined]",
-					ruleMessage, val)),
+				Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage, errMsg)),
+				Error:     errMsg,
 				StartNode: fieldNode,
 				EndNode:   vacuumUtils.BuildEndNode(fieldNode),
 				Path:      pathValue,
@@ -78,9 +78,10 @@ func (x Xor) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext) []mo
 		}
 
 		if seenCount != 1 {
+			errMsg := fmt.Sprintf("`%s` and `%s` must not be both defined or undefined", properties[0], properties[1])
 			results = append(results, model.RuleFunctionResult{
-				Message: vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: `%s` and `%s` must not be both defined or undefined",
-					ruleMessage, properties[0], properties[1])),
+				Message:   vacuumUtils.SuppliedOrDefault(message, fmt.Sprintf("%s: %s", ruleMessage, errMsg)),
+				Error:     errMsg,
 				StartNode: node,
 				EndNode:   vacuumUtils.BuildEndNode(node),
 				Path:      pathValue,
@@ -92,7 +92,8 @@ func MapBuiltinFunctions() Functions {
 		funcs["oasPolymorphicOneOf"] = openapi_functions.PolymorphicOneOf{}
 		funcs["oasDocumentSchema"] = openapi_functions.OASSchema{}
 		funcs["oasAPIServers"] = openapi_functions.APIServers{}
-		funcs["noAmbiguousPaths"] = openapi_functions.AmbiguousPaths{}
+		funcs["ambiguousPaths"] = openapi_functions.AmbiguousPaths{}
+		funcs["noAmbiguousPaths"] = openapi_functions.AmbiguousPaths{} // the name it was first registered as.
 		funcs["noVerbsInPath"] = openapi_functions.VerbsInPaths{}
 		funcs["pathsKebabCase"] = openapi_functions.PathsKebabCase{}
 		funcs["oasOpErrorResponse"] = openapi_functions.Operation4xResponse{}
@@ -8,5 +8,9 @@ import (
 
 func TestMapBuiltinFunctions(t *testing.T) {
 	funcs := MapBuiltinFunctions()
-	assert.Len(t, funcs.GetAllFunctions(), 65)
+	assert.Len(t, funcs.GetAllFunctions(), 66)
+
+	// rulesets written for either name of the function still work.
+	assert.NotNil(t, funcs.FindFunction("ambiguousPaths"))
+	assert.NotNil(t, funcs.FindFunction("noAmbiguousPaths"))
 }
@@ -49,6 +49,7 @@ func (de DuplicatedEnum) RunRule(_ []*yaml.Node, context model.RuleFunctionConte
 					EndNode:   vacuumUtils.BuildEndNode(node),
 					Path:      fmt.Sprintf("%s.%s", schema.GenerateJSONPath(), "enum"),
 					Rule:      context.Rule,
+					Fix:       buildDuplicateEnumFix(schema.Value.GoLow().Enum.ValueNode, res),
 				}
 				schema.AddRuleFunctionResult(base.ConvertRuleResult(&result))
 				results = append(results, result)
@@ -58,3 +59,23 @@ func (de DuplicatedEnum) RunRule(_ []*yaml.Node, context model.RuleFunctionConte
 
 	return results
 }
+
+// buildDuplicateEnumFix creates a fix that removes a duplicate from an enum. Values are only considered
+// duplicates if they are the same type, a string '1' is not the same as the number 1.
+func buildDuplicateEnumFix(enum, duplicate *yaml.Node) *model.Fix {
+	if enum == nil || duplicate == nil {
+		return nil
+	}
+	for _, n := range enum.Content {
+		if n == duplicate {
+			return &model.Fix{
+				Description: fmt.Sprintf("remove duplicate enum value `%s`", duplicate.Value),
+				Edits:       []model.FixEdit{model.NewDeleteEdit(enum, duplicate)},
+			}
+		}
+		if n.Value == duplicate.Value && n.ShortTag() != duplicate.ShortTag() {
+			return nil
+		}
+	}
+	return nil
+}
@@ -113,3 +113,48 @@ components:
 
 	assert.Len(t, res, 5)
 }
+
+func TestDuplicatedEnum_RunRule_DuplicationFix(t *testing.T) {
+
+	yml := `openapi: 3.0
+components:
+  schemas:
+    YesNo:
+      type: string
+      enum: [yes, no, yes]
+    Mixed:
+      type: string
+      enum: ["1", 1]`
+
+	document, err := libopenapi.NewDocument([]byte(yml))
+	if err != nil {
+		panic(fmt.Sprintf("cannot create new document: %e", err))
+	}
+
+	m, _ := document.BuildV3Model()
+	path := "$"
+
+	drDocument := drModel.NewDrDocument(m)
+
+	rule := buildOpenApiTestRuleAction(path, "duplicated_enum", "", nil)
+	ctx := buildOpenApiTestContext(model.CastToRuleAction(rule.Then), nil)
+
+	ctx.Document = document
+	ctx.DrDocument = drDocument
+	ctx.Rule = &rule
+
+	def := DuplicatedEnum{}
+	res := def.RunRule(nil, ctx)
+
+	assert.Len(t, res, 2)
+	for _, r := range res {
+		if r.Path == "$.components.schemas['YesNo'].enum" {
+			assert.NotNil(t, r.Fix)
+			assert.Equal(t, model.FixDelete, r.Fix.Edits[0].Kind)
+			assert.Equal(t, 6, r.Fix.Edits[0].Line)
+			assert.Equal(t, 23, r.Fix.Edits[0].Column)
+		} else {
+			assert.Nil(t, r.Fix) // a string and a number are not really duplicates.
+		}
+	}
+}
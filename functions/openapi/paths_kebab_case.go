@@ -10,6 +10,7 @@ import (
 	"gopkg.in/yaml.v3"
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 // PathsKebabCase Checks to ensure each segment of a path is using kebab case.
@@ -52,6 +53,7 @@ func (vp PathsKebabCase) RunRule(nodes []*yaml.Node, context model.RuleFunctionC
 					EndNode:   vacuumUtils.BuildEndNode(op),
 					Path:      path,
 					Rule:      context.Rule,
+					Fix:       buildKebabCaseFix(ops, ops.Content[i-1]),
 				})
 			}
 		}
@@ -62,6 +64,59 @@ func (vp PathsKebabCase) RunRule(nodes []*yaml.Node, context model.RuleFunctionC
 var pathKebabCaseRegex, _ = regexp.Compile(`^[{}a-z\d-.]+$`)
 var variableRegex, _ = regexp.Compile(`^\{(\w.*)}\.?.*$`)
 
+// buildKebabCaseFix creates a fix that renames a path to use kebab-case, variables are left alone. No fix is
+// created if the new path cannot be made kebab-case, or already exists.
+func buildKebabCaseFix(paths, pathKey *yaml.Node) *model.Fix {
+	segs := strings.Split(pathKey.Value, "/")
+	for i, seg := range segs {
+		if seg == "" || variableRegex.MatchString(seg) {
+			continue
+		}
+		segs[i] = toKebabCase(seg)
+	}
+	fixed := strings.Join(segs, "/")
+	if notKebab, _ := checkPathCase(fixed); notKebab || fixed == pathKey.Value {
+		return nil
+	}
+	for i := 0; i < len(paths.Content); i += 2 {
+		if paths.Content[i].Value == fixed {
+			return nil
+		}
+	}
+	return &model.Fix{
+		Description: fmt.Sprintf("rename path to `%s`", fixed),
+		Edits:       []model.FixEdit{model.NewReplaceEdit(pathKey, fixed)},
+	}
+}
+
+// toKebabCase converts camelCase, PascalCase, snake_case and space separated words into kebab-case.
+func toKebabCase(seg string) string {
+	runes := []rune(seg)
+	var sb strings.Builder
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == ' ':
+			sb.WriteRune('-')
+		case unicode.IsUpper(r):
+			if i > 0 {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					sb.WriteRune('-')
+				}
+			}
+			sb.WriteRune(unicode.ToLower(r))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	kebab := sb.String()
+	for strings.Contains(kebab, "--") {
+		kebab = strings.ReplaceAll(kebab, "--", "-")
+	}
+	return kebab
+}
+
 func checkPathCase(path string) (bool, []string) {
 	segs := strings.Split(path, "/")[1:]
 	var found []string
@@ -138,3 +138,43 @@ paths:
 	assert.Len(t, res, 0)
 
 }
+
+func TestPathsKebabCase_Fix(t *testing.T) {
+
+	yml := `openapi: 3.0.0
+paths:
+  '/youHave/{someId}/the_morning last':
+    get:
+      summary: bad path
+  '/HTTPServer/status':
+    get:
+      summary: bad path
+  '/taken/Already':
+    get:
+      summary: would collide
+  '/taken/already':
+    get:
+      summary: fine`
+
+	path := "$"
+
+	var rootNode yaml.Node
+	err := yaml.Unmarshal([]byte(yml), &rootNode)
+
+	assert.NoError(t, err)
+	nodes, _ := utils.FindNodes([]byte(yml), path)
+
+	rule := buildOpenApiTestRuleAction(path, "pathsKebabCase", "", nil)
+	ctx := buildOpenApiTestContext(model.CastToRuleAction(rule.Then), nil)
+	ctx.Rule = &rule
+	config := index.CreateOpenAPIIndexConfig()
+	ctx.Index = index.NewSpecIndexWithConfig(&rootNode, config)
+
+	def := PathsKebabCase{}
+	res := def.RunRule(nodes, ctx)
+
+	assert.Len(t, res, 3)
+	assert.Equal(t, "/you-have/{someId}/the-morning-last", res[0].Fix.Edits[0].NewValue)
+	assert.Equal(t, "/http-server/status", res[1].Fix.Edits[0].NewValue)
+	assert.Nil(t, res[2].Fix)
+}
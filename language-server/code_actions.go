@@ -0,0 +1,226 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package languageserver
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/daveshanley/vacuum/autofix"
+	"github.com/daveshanley/vacuum/model"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+	"gopkg.in/yaml.v3"
+)
+
+// not defined by the 3.16 protocol, but understood by all clients that support 'source' actions.
+const codeActionKindSourceFixAll = protocol.CodeActionKind("source.fixAll")
+
+// buildCodeActions creates a quick fix for every result with a fix that falls in the requested range, and
+// a 'fix all' action that applies every fix in the document at once. Every result in the range can also be
+// disabled, for the node it was found on (with a suppression comment), or everywhere (by turning the rule off
+// in the ruleset, if there is a ruleset file).
+func buildCodeActions(doc *Document, params *protocol.CodeActionParams, rulesetLocation string) []protocol.CodeAction {
+	content, results, ok := doc.getLintResults()
+	if !ok {
+		return nil // results are stale, the document is being linted again.
+	}
+	spec := []byte(content)
+	quickFix := protocol.CodeActionKindQuickFix
+	fixAll := codeActionKindSourceFixAll
+
+	var actions, disables []protocol.CodeAction
+	var fixable []*model.RuleFunctionResult
+	suppressed := make(map[string]bool)
+	for i := range results {
+		r := &results[i]
+		if r.StartNode == nil || r.EndNode == nil || r.Rule == nil {
+			continue
+		}
+		// the result might belong to another file, that the client doesn't have open.
+		if r.Origin != nil && r.Origin.AbsoluteLocation != "" {
+			continue
+		}
+		if r.Fix != nil {
+			fixable = append(fixable, r)
+		}
+
+		diagnostic := buildDiagnostic(*r)
+		if !rangesIntersect(diagnostic.Range, params.Range) || !wantsKind(params, quickFix) {
+			continue
+		}
+
+		// disable actions come after the fixes, and are only offered once for a rule on a line.
+		key := fmt.Sprintf("%s:%d", r.Rule.Id, r.StartNode.Line)
+		if !suppressed[key] {
+			suppressed[key] = true
+			if edit, ok := suppressionEdit(content, r); ok {
+				disables = append(disables, protocol.CodeAction{
+					Title:       fmt.Sprintf("vacuum: disable '%s' for this node", r.Rule.Id),
+					Kind:        &quickFix,
+					Diagnostics: []protocol.Diagnostic{diagnostic},
+					Edit:        &protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{doc.URI: {edit}}},
+				})
+			}
+		}
+		if !suppressed[r.Rule.Id] && rulesetLocation != "" {
+			suppressed[r.Rule.Id] = true
+			if edits, err := disableRuleEdits(rulesetLocation, r.Rule.Id); err == nil {
+				disables = append(disables, protocol.CodeAction{
+					Title:       fmt.Sprintf("vacuum: disable '%s' in ruleset", r.Rule.Id),
+					Kind:        &quickFix,
+					Diagnostics: []protocol.Diagnostic{diagnostic},
+					Edit: &protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+						fileURI(rulesetLocation): convertEdits(edits)}},
+				})
+			}
+		}
+
+		if r.Fix == nil {
+			continue
+		}
+		edits, err := autofix.ResolveFix(spec, r.Fix)
+		if err != nil {
+			continue
+		}
+		actions = append(actions, protocol.CodeAction{
+			Title:       "vacuum: " + r.Fix.Description,
+			Kind:        &quickFix,
+			Diagnostics: []protocol.Diagnostic{diagnostic},
+			Edit:        &protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{doc.URI: convertEdits(edits)}},
+		})
+	}
+	actions = append(actions, disables...)
+
+	if len(fixable) > 1 && wantsKind(params, fixAll) {
+		if res := autofix.ApplyFixes(spec, fixable); len(res.Applied) > 0 {
+			actions = append(actions, protocol.CodeAction{
+				Title: "vacuum: fix all auto-fixable problems",
+				Kind:  &fixAll,
+				Edit:  &protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{doc.URI: convertEdits(res.Edits)}},
+			})
+		}
+	}
+	return actions
+}
+
+// suppressionEdit adds a 'vacuum-ignore' comment for the rule of a result, on the line above the node it was found
+// on. JSON has no comments, so JSON documents can't be suppressed this way.
+func suppressionEdit(content string, r *model.RuleFunctionResult) (protocol.TextEdit, bool) {
+	if strings.HasPrefix(strings.TrimSpace(content), "{") {
+		return protocol.TextEdit{}, false
+	}
+	lines := strings.Split(content, "\n")
+	line := r.StartNode.Line - 1
+	if line < 0 || line >= len(lines) {
+		return protocol.TextEdit{}, false
+	}
+	text := lines[line]
+	indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+	at := protocol.Position{Line: protocol.UInteger(line)}
+	return protocol.TextEdit{
+		Range:   protocol.Range{Start: at, End: at},
+		NewText: fmt.Sprintf("%s# %s %s\n", indent, model.SuppressionComment, r.Rule.Id),
+	}, true
+}
+
+// disableRuleEdits turns a rule off in a ruleset file. Rules the ruleset defines itself are not turned off, the
+// definition would be lost (they can be deleted instead).
+func disableRuleEdits(rulesetLocation, ruleId string) ([]autofix.TextEdit, error) {
+	b, err := os.ReadFile(rulesetLocation)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err = yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("ruleset '%s' is not a mapping", rulesetLocation)
+	}
+	root := doc.Content[0]
+	off := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "off"}
+
+	var edit model.FixEdit
+	rules := valueOf(root, "rules")
+	switch {
+	case rules == nil:
+		edit = model.NewInsertEdit(root, "rules", &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: ruleId}, off}})
+	case rules.Kind != yaml.MappingNode:
+		return nil, fmt.Errorf("rules of ruleset '%s' are not a mapping", rulesetLocation)
+	default:
+		existing := valueOf(rules, ruleId)
+		switch {
+		case existing == nil:
+			edit = model.NewInsertEdit(rules, ruleId, off)
+		case existing.Kind == yaml.ScalarNode:
+			edit = model.NewReplaceEdit(existing, "off")
+		default:
+			return nil, fmt.Errorf("rule '%s' is defined by ruleset '%s'", ruleId, rulesetLocation)
+		}
+	}
+	return autofix.ResolveFix(b, &model.Fix{Description: "disable rule", Edits: []model.FixEdit{edit}})
+}
+
+func valueOf(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// fileURI returns the file URI of a path, percent-encoded, the reverse of uriLocation.
+func fileURI(location string) protocol.DocumentUri {
+	if abs, err := filepath.Abs(location); err == nil {
+		location = abs
+	}
+	location = filepath.ToSlash(location)
+	u := &url.URL{Scheme: "file"}
+	if strings.HasPrefix(location, "//") {
+		// a UNC path, the server is the host of the URI.
+		host, path, _ := strings.Cut(location[2:], "/")
+		u.Host, u.Path = host, "/"+path
+	} else if strings.HasPrefix(location, "/") {
+		u.Path = location
+	} else {
+		u.Path = "/" + location
+	}
+	return u.String()
+}
+
+func convertEdits(edits []autofix.TextEdit) []protocol.TextEdit {
+	converted := make([]protocol.TextEdit, len(edits))
+	for i, e := range edits {
+		converted[i] = protocol.TextEdit{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: protocol.UInteger(e.StartLine), Character: protocol.UInteger(e.StartCharacter)},
+				End:   protocol.Position{Line: protocol.UInteger(e.EndLine), Character: protocol.UInteger(e.EndCharacter)},
+			},
+			NewText: e.NewText,
+		}
+	}
+	return converted
+}
+
+func rangesIntersect(a, b protocol.Range) bool {
+	return a.Start.Line <= b.End.Line && b.Start.Line <= a.End.Line
+}
+
+// wantsKind checks if the client asked for a specific kind of action, no filter means everything.
+func wantsKind(params *protocol.CodeActionParams, kind protocol.CodeActionKind) bool {
+	if len(params.Context.Only) == 0 {
+		return true
+	}
+	for _, only := range params.Context.Only {
+		if only == kind || only == protocol.CodeActionKindSource && kind == codeActionKindSourceFixAll {
+			return true
+		}
+	}
+	return false
+}
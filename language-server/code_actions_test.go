@@ -0,0 +1,140 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package languageserver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/daveshanley/vacuum/motor"
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const testSpec = `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: ok
+`
+
+func lintedDocument(t *testing.T, spec string) *Document {
+	doc := &Document{URI: "file:///tmp/spec.yaml", Content: spec}
+	result := motor.ApplyRulesToRuleSet(&motor.RuleSetExecution{
+		RuleSet: rulesets.BuildDefaultRuleSets().GenerateOpenAPIRecommendedRuleSet(),
+		Spec:    []byte(spec),
+	})
+	assert.Empty(t, result.Errors)
+	doc.setLintResults(spec, result.Results, result.Index)
+	return doc
+}
+
+func lineRange(line int) protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{Line: protocol.UInteger(line)},
+		End:   protocol.Position{Line: protocol.UInteger(line), Character: 100},
+	}
+}
+
+func actionTitles(actions []protocol.CodeAction) []string {
+	var titles []string
+	for _, a := range actions {
+		titles = append(titles, a.Title)
+	}
+	return titles
+}
+
+func TestBuildCodeActions_DisableForNode(t *testing.T) {
+	doc := lintedDocument(t, testSpec)
+
+	// info is missing a description.
+	actions := buildCodeActions(doc, &protocol.CodeActionParams{Range: lineRange(2)}, "")
+	assert.Equal(t, []string{"vacuum: disable 'info-description' for this node"}, actionTitles(actions))
+
+	edits := actions[0].Edit.Changes[doc.URI]
+	assert.Len(t, edits, 1)
+	assert.Equal(t, "  # vacuum-ignore info-description\n", edits[0].NewText)
+	assert.Equal(t, protocol.UInteger(2), edits[0].Range.Start.Line)
+}
+
+func TestBuildCodeActions_DisableInRuleset(t *testing.T) {
+	doc := lintedDocument(t, testSpec)
+	ruleset := filepath.Join(t.TempDir(), "ruleset.yaml")
+	assert.NoError(t, os.WriteFile(ruleset, []byte("extends: [[spectral:oas, recommended]]\nrules:\n  operation-tags: warn\n"), 0644))
+
+	actions := buildCodeActions(doc, &protocol.CodeActionParams{Range: protocol.Range{
+		Start: protocol.Position{Line: 2},
+		End:   protocol.Position{Line: 6},
+	}}, ruleset)
+	edits := make(map[string]string)
+	for _, a := range actions {
+		for uri, changes := range a.Edit.Changes {
+			if uri == fileURI(ruleset) {
+				edits[a.Title] = changes[0].NewText
+			}
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"vacuum: disable 'info-description' in ruleset": "\n  info-description: off",
+		"vacuum: disable 'operation-tags' in ruleset":   "off",
+	}, edits)
+}
+
+func TestDisableRuleEdits(t *testing.T) {
+	dir := t.TempDir()
+	write := func(ruleset string) string {
+		location := filepath.Join(dir, "ruleset.yaml")
+		assert.NoError(t, os.WriteFile(location, []byte(ruleset), 0644))
+		return location
+	}
+
+	edits, err := disableRuleEdits(write("extends: [[spectral:oas, recommended]]\n"), "info-contact")
+	assert.NoError(t, err)
+	assert.Equal(t, "\nrules:\n  info-contact: off", edits[0].NewText)
+
+	edits, err = disableRuleEdits(write(`{"rules": {}}`), "info-contact")
+	assert.NoError(t, err)
+	assert.Equal(t, `"info-contact": "off"`, edits[0].NewText)
+
+	_, err = disableRuleEdits(write("rules:\n  info-contact:\n    given: $\n"), "info-contact")
+	assert.ErrorContains(t, err, "rule 'info-contact' is defined by ruleset")
+}
+
+func TestSuppressionEdit_JSON(t *testing.T) {
+	doc := lintedDocument(t, `{"openapi": "3.1.0", "info": {"title": "pets", "version": "1.0.0"}, "paths": {}}`)
+	ruleset := filepath.Join(t.TempDir(), "ruleset.yaml")
+	assert.NoError(t, os.WriteFile(ruleset, []byte("rules: {}\n"), 0644))
+
+	actions := buildCodeActions(doc, &protocol.CodeActionParams{Range: lineRange(0)}, ruleset)
+	assert.NotEmpty(t, actions)
+	for _, title := range actionTitles(actions) {
+		assert.False(t, strings.HasSuffix(title, "for this node"), title)
+	}
+}
+
+func TestBuildHover(t *testing.T) {
+	doc := lintedDocument(t, testSpec)
+
+	hover := buildHover(doc, &protocol.HoverParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+		Position: protocol.Position{Line: 2, Character: 4},
+	}})
+	assert.NotNil(t, hover)
+	content := hover.Contents.(protocol.MarkupContent)
+	assert.Equal(t, protocol.MarkupKindMarkdown, content.Kind)
+	assert.Contains(t, content.Value, "**info-description** (Contract Information)")
+	assert.Contains(t, content.Value, "**How to fix:**")
+
+	assert.Nil(t, buildHover(doc, &protocol.HoverParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+		Position: protocol.Position{Line: 9, Character: 1},
+	}}))
+}
@@ -0,0 +1,302 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package languageserver
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pb33f/libopenapi/index"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// statusCodes are offered wherever the schema allows response codes as keys.
+var statusCodes = []string{
+	"200", "201", "202", "204", "301", "302", "304", "400", "401", "403", "404", "405", "409", "410", "415", "422",
+	"429", "500", "501", "502", "503", "504", "default",
+}
+
+// mediaTypes are offered as the keys of content, and the items of consumes and produces.
+var mediaTypes = []string{
+	"application/json", "application/xml", "application/x-www-form-urlencoded", "application/octet-stream",
+	"application/problem+json", "multipart/form-data", "text/plain", "text/html", "text/csv",
+}
+
+// componentKinds maps the keys a reference can be used under, to the kind of component it can reference.
+var componentKinds = map[string]string{
+	"schema":               "schemas",
+	"schemas":              "schemas",
+	"definitions":          "schemas",
+	"items":                "schemas",
+	"not":                  "schemas",
+	"allOf":                "schemas",
+	"anyOf":                "schemas",
+	"oneOf":                "schemas",
+	"additionalProperties": "schemas",
+	"parameters":           "parameters",
+	"requestBody":          "requestBodies",
+	"requestBodies":        "requestBodies",
+	"headers":              "headers",
+	"examples":             "examples",
+	"links":                "links",
+	"callbacks":            "callbacks",
+	"securitySchemes":      "securitySchemes",
+}
+
+var codePattern = regexp.MustCompile(`^[1-5]\d\d$|^[1-5]XX$|^default$`)
+
+// buildCompletion offers completions at a position of a document. Keys and values come from the JSON schema of
+// the document's OpenAPI version, references come from the components of the document (and any files it
+// references) found when it was last linted.
+func buildCompletion(doc *Document, params *protocol.CompletionParams) []protocol.CompletionItem {
+	content, _ := doc.text()
+	schema := documentSchema(content)
+	c := findCursor(content, params.Position)
+	if c == nil {
+		return nil
+	}
+	b := &completionBuilder{cursor: c, end: params.Position, seen: make(map[string]bool)}
+
+	switch {
+	case c.value && c.key == "$ref":
+		b.references(doc)
+	case c.either:
+		if schema != nil {
+			w := &schemaWalker{root: schema}
+			b.keys(w)
+			b.values(w, c.path[:len(c.path)-1], sequenceItem)
+		}
+	case c.value:
+		if schema != nil {
+			b.values(&schemaWalker{root: schema}, c.path, c.key)
+		}
+	default:
+		if schema != nil {
+			b.keys(&schemaWalker{root: schema})
+		}
+	}
+	sort.SliceStable(b.items, func(i, j int) bool { return b.items[i].Label < b.items[j].Label })
+	return b.items
+}
+
+type completionBuilder struct {
+	cursor *cursor
+	end    protocol.Position
+	items  []protocol.CompletionItem
+	seen   map[string]bool
+}
+
+// keys offers the keys allowed in the mapping the cursor is in, that are not there already.
+func (b *completionBuilder) keys(w *schemaWalker) {
+	schemas := w.at(b.cursor.path)
+	for _, s := range schemas {
+		if props, ok := s["properties"].(map[string]any); ok {
+			for key := range props {
+				b.key(key, "")
+			}
+		}
+		if patterns, ok := s["patternProperties"].(map[string]any); ok {
+			for pattern := range patterns {
+				if key, ok := literalPattern(pattern); ok {
+					b.key(key, "")
+					continue
+				}
+				re, err := regexp.Compile(pattern)
+				if err != nil || !re.MatchString("200") {
+					continue
+				}
+				for _, code := range statusCodes {
+					if re.MatchString(code) {
+						b.key(code, http.StatusText(statusCode(code)))
+					}
+				}
+			}
+		}
+	}
+	path := b.cursor.path
+	if len(path) > 0 && path[len(path)-1] == "content" && (len(path) < 2 || path[len(path)-2] != "properties") {
+		for _, mediaType := range mediaTypes {
+			b.key(mediaType, "")
+		}
+	}
+}
+
+// values offers the values allowed for a key: enums, constants and booleans.
+func (b *completionBuilder) values(w *schemaWalker, path []string, key string) {
+	if len(path) > 0 && key == sequenceItem && (path[len(path)-1] == "consumes" || path[len(path)-1] == "produces") {
+		for _, mediaType := range mediaTypes {
+			b.value(mediaType, "", protocol.CompletionItemKindValue, true)
+		}
+	}
+	for _, s := range w.at(append(append([]string{}, path...), key)) {
+		if enum, ok := s["enum"].([]any); ok {
+			for _, v := range enum {
+				b.literal(v)
+			}
+		}
+		if v, ok := s["const"]; ok {
+			b.literal(v)
+		}
+		if s["type"] == "boolean" {
+			b.literal(true)
+			b.literal(false)
+		}
+	}
+}
+
+// references offers every component that can be referenced at the cursor, from the document and from the files
+// in its rolodex.
+func (b *completionBuilder) references(doc *Document) {
+	idx := doc.getIndex()
+	if idx == nil {
+		return
+	}
+	kind := referenceKind(b.cursor.path)
+	b.componentReferences(idx, "", kind)
+
+	rolodex := idx.GetRolodex()
+	if rolodex == nil {
+		return
+	}
+	docDir := filepath.Dir(uriLocation(doc.URI))
+	for _, fileIdx := range rolodex.GetIndexes() {
+		location := fileIdx.GetSpecAbsolutePath()
+		if fileIdx == idx || location == "" || location == idx.GetSpecAbsolutePath() {
+			continue
+		}
+		rel, err := filepath.Rel(docDir, location)
+		if err != nil {
+			rel = location
+		}
+		b.componentReferences(fileIdx, filepath.ToSlash(rel), kind)
+	}
+}
+
+func (b *completionBuilder) componentReferences(idx *index.SpecIndex, file, kind string) {
+	components := map[string]map[string]*index.Reference{
+		"schemas":         idx.GetAllComponentSchemas(),
+		"parameters":      idx.GetAllParameters(),
+		"responses":       idx.GetAllResponses(),
+		"requestBodies":   idx.GetAllRequestBodies(),
+		"headers":         idx.GetAllHeaders(),
+		"examples":        idx.GetAllExamples(),
+		"links":           idx.GetAllLinks(),
+		"callbacks":       idx.GetAllCallbacks(),
+		"securitySchemes": idx.GetAllSecuritySchemes(),
+	}
+	for k, refs := range components {
+		if kind != "" && k != kind {
+			continue
+		}
+		for _, ref := range refs {
+			definition := ref.Definition
+			if i := strings.Index(definition, "#/"); i >= 0 {
+				definition = definition[i:]
+			}
+			if !strings.HasPrefix(definition, "#/") {
+				continue
+			}
+			detail := k
+			if file != "" {
+				detail = fmt.Sprintf("%s in %s", k, file)
+			}
+			b.value(file+definition, detail, protocol.CompletionItemKindReference, true)
+		}
+	}
+}
+
+// referenceKind works out what kind of component can be referenced at a path, it's empty if it could be anything.
+func referenceKind(path []string) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		segment := path[i]
+		if segment == sequenceItem {
+			continue
+		}
+		if i > 0 {
+			switch path[i-1] {
+			case "properties", "patternProperties", "definitions", "schemas":
+				return "schemas"
+			case "responses":
+				if codePattern.MatchString(segment) {
+					return "responses"
+				}
+			}
+		}
+		if kind, ok := componentKinds[segment]; ok {
+			return kind
+		}
+		return ""
+	}
+	return ""
+}
+
+func (b *completionBuilder) key(key, detail string) {
+	if b.cursor.siblings[key] || b.seen[key] {
+		return
+	}
+	b.seen[key] = true
+	var text string
+	switch {
+	case b.cursor.quoted:
+		text = key
+	case b.cursor.json:
+		text = fmt.Sprintf("%q: ", key)
+	case codePattern.MatchString(key) && key != "default":
+		text = fmt.Sprintf("%q: ", key)
+	default:
+		text = key + ": "
+	}
+	b.add(key, detail, protocol.CompletionItemKindProperty, text)
+}
+
+func (b *completionBuilder) literal(v any) {
+	switch v := v.(type) {
+	case string:
+		b.value(v, "", protocol.CompletionItemKindEnumMember, b.cursor.json || strings.HasPrefix(v, "#"))
+	case bool, float64:
+		label := fmt.Sprint(v)
+		if b.cursor.quoted {
+			return
+		}
+		b.add(label, "", protocol.CompletionItemKindValue, label)
+	}
+}
+
+// value adds a string value, quoted if it has to be and the cursor isn't in quotes already.
+func (b *completionBuilder) value(v, detail string, kind protocol.CompletionItemKind, quote bool) {
+	text := v
+	if quote && !b.cursor.quoted {
+		text = fmt.Sprintf("%q", v)
+	}
+	b.add(v, detail, kind, text)
+}
+
+func (b *completionBuilder) add(label, detail string, kind protocol.CompletionItemKind, text string) {
+	if b.seen["value:"+label] {
+		return
+	}
+	b.seen["value:"+label] = true
+	item := protocol.CompletionItem{
+		Label: label,
+		Kind:  &kind,
+		TextEdit: protocol.TextEdit{
+			Range:   protocol.Range{Start: b.cursor.start, End: b.end},
+			NewText: text,
+		},
+	}
+	if detail != "" {
+		item.Detail = &detail
+	}
+	b.items = append(b.items, item)
+}
+
+func statusCode(code string) int {
+	var c int
+	_, _ = fmt.Sscanf(code, "%d", &c)
+	return c
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package languageserver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/daveshanley/vacuum/motor"
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// complete returns the completions where the pipe is in a document.
+func complete(t *testing.T, doc *Document, spec string) []protocol.CompletionItem {
+	i := strings.Index(spec, "|")
+	assert.GreaterOrEqual(t, i, 0)
+	before := spec[:i]
+	line := strings.Count(before, "\n")
+	character := len(before) - strings.LastIndex(before, "\n") - 1
+	doc.Content = spec[:i] + spec[i+1:]
+	return buildCompletion(doc, &protocol.CompletionParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+		Position: protocol.Position{Line: protocol.UInteger(line), Character: protocol.UInteger(character)},
+	}})
+}
+
+func labels(items []protocol.CompletionItem) []string {
+	var l []string
+	for _, item := range items {
+		l = append(l, item.Label)
+	}
+	return l
+}
+
+func newText(items []protocol.CompletionItem, label string) string {
+	for _, item := range items {
+		if item.Label == label {
+			return item.TextEdit.(protocol.TextEdit).NewText
+		}
+	}
+	return ""
+}
+
+func TestFindCursor_YAML(t *testing.T) {
+	spec := `openapi: 3.0.3
+info:
+  title: pets
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: limit
+          in: query
+        - na
+      responses:
+        "200":
+          description: ok
+`
+	c := findCursor(spec, protocol.Position{Line: 9, Character: 12})
+	assert.Equal(t, []string{"paths", "/pets", "get", "parameters", "-"}, c.path)
+	assert.True(t, c.either)
+	assert.Equal(t, "na", c.prefix)
+	assert.Equal(t, protocol.Position{Line: 9, Character: 10}, c.start)
+
+	c = findCursor(spec, protocol.Position{Line: 8, Character: 14})
+	assert.Equal(t, []string{"paths", "/pets", "get", "parameters", "-"}, c.path)
+	assert.Equal(t, "in", c.key)
+	assert.True(t, c.value)
+	assert.True(t, c.siblings["name"])
+
+	c = findCursor(spec, protocol.Position{Line: 2, Character: 2})
+	assert.Equal(t, []string{"info"}, c.path)
+	assert.False(t, c.value)
+
+	c = findCursor(spec, protocol.Position{Line: 12, Character: 10})
+	assert.Equal(t, []string{"paths", "/pets", "get", "responses", "200"}, c.path)
+}
+
+func TestFindCursor_JSON(t *testing.T) {
+	spec := `{"openapi": "3.1.0", "info": {"title": "pets", "ver`
+	c := findCursor(spec, protocol.Position{Character: protocol.UInteger(len(spec))})
+	assert.Equal(t, []string{"info"}, c.path)
+	assert.False(t, c.value)
+	assert.True(t, c.quoted)
+	assert.Equal(t, "ver", c.prefix)
+	assert.True(t, c.siblings["title"])
+
+	spec = `{"openapi": "3.1.0", "paths": {"/pets": {"get": {"tags": [`
+	c = findCursor(spec, protocol.Position{Character: protocol.UInteger(len(spec))})
+	assert.Equal(t, []string{"paths", "/pets", "get", "tags"}, c.path)
+	assert.Equal(t, sequenceItem, c.key)
+	assert.True(t, c.value)
+}
+
+func TestUTF16Offset(t *testing.T) {
+	// the pizza is two UTF-16 code units, and four bytes.
+	line := "a🍕é: b"
+	assert.Equal(t, 1, utf16Offset(line, 1))
+	assert.Equal(t, 5, utf16Offset(line, 3))
+	assert.Equal(t, 7, utf16Offset(line, 4))
+	assert.Equal(t, len(line), utf16Offset(line, 100))
+	assert.Equal(t, 7, utf16Length(line))
+}
+
+func TestBuildCompletion_Keys(t *testing.T) {
+	doc := &Document{URI: "file:///tmp/spec.yaml"}
+	items := complete(t, doc, "openapi: 3.0.3\ninfo:\n  title: pets\n  |\n")
+	assert.Contains(t, labels(items), "version")
+	assert.Contains(t, labels(items), "license")
+	assert.NotContains(t, labels(items), "title")
+	assert.Equal(t, "version: ", newText(items, "version"))
+
+	// the keys of a parameter, which is a sequence item.
+	items = complete(t, doc, "openapi: 3.1.0\npaths:\n  /pets:\n    get:\n      parameters:\n        - |\n")
+	assert.Contains(t, labels(items), "name")
+	assert.Contains(t, labels(items), "in")
+}
+
+func TestBuildCompletion_Values(t *testing.T) {
+	doc := &Document{URI: "file:///tmp/spec.yaml"}
+	items := complete(t, doc, "openapi: 3.0.3\npaths:\n  /pets:\n    get:\n      parameters:\n        - name: limit\n          in: |\n")
+	assert.Equal(t, []string{"cookie", "header", "path", "query"}, labels(items))
+
+	items = complete(t, doc, "openapi: 3.0.3\npaths:\n  /pets:\n    get:\n      deprecated: |\n")
+	assert.Equal(t, []string{"false", "true"}, labels(items))
+
+	items = complete(t, doc, "swagger: \"2.0\"\nproduces:\n  - |\n")
+	assert.Contains(t, labels(items), "application/json")
+	assert.Equal(t, `"application/json"`, newText(items, "application/json"))
+}
+
+func TestBuildCompletion_StatusCodesAndMediaTypes(t *testing.T) {
+	doc := &Document{URI: "file:///tmp/spec.yaml"}
+	items := complete(t, doc, "openapi: 3.0.3\npaths:\n  /pets:\n    get:\n      responses:\n        \"200\":\n          description: ok\n        |\n")
+	assert.Contains(t, labels(items), "404")
+	assert.Contains(t, labels(items), "default")
+	assert.NotContains(t, labels(items), "200")
+	assert.Equal(t, `"404": `, newText(items, "404"))
+
+	items = complete(t, doc, "openapi: 3.1.0\npaths:\n  /pets:\n    get:\n      responses:\n        \"200\":\n          content:\n            |\n")
+	assert.Contains(t, labels(items), "application/json")
+}
+
+func TestBuildCompletion_JSON(t *testing.T) {
+	doc := &Document{URI: "file:///tmp/spec.json"}
+	items := complete(t, doc, `{"openapi": "3.1.0", "info": {"title": "pets", |}}`)
+	assert.Contains(t, labels(items), "version")
+	assert.Equal(t, `"version": `, newText(items, "version"))
+
+	items = complete(t, doc, `{"openapi": "3.1.0", "info": {"title": "pets", "ver|"}}`)
+	assert.Equal(t, "version", newText(items, "version"))
+}
+
+func TestBuildCompletion_References(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "shared.yaml"), []byte(`openapi: 3.1.0
+components:
+  schemas:
+    Error:
+      type: object
+`), 0644))
+	spec := `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      parameters:
+        - $ref: '#/components/parameters/Limit'
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+        default:
+          description: error
+          content:
+            application/json:
+              schema:
+                $ref: 'shared.yaml#/components/schemas/Error'
+components:
+  parameters:
+    Limit:
+      name: limit
+      in: query
+  schemas:
+    Pet:
+      type: object
+`
+	result := motor.ApplyRulesToRuleSet(&motor.RuleSetExecution{
+		RuleSet:     rulesets.BuildDefaultRuleSets().GenerateOpenAPIRecommendedRuleSet(),
+		Spec:        []byte(spec),
+		Base:        dir,
+		AllowLookup: true,
+	})
+	doc := &Document{URI: fileURI(filepath.Join(dir, "spec.yaml"))}
+	doc.setLintResults(spec, result.Results, result.Index)
+
+	items := complete(t, doc, strings.Replace(spec, "'#/components/schemas/Pet'", "|", 1))
+	assert.Equal(t, []string{"#/components/schemas/Pet", "shared.yaml#/components/schemas/Error"}, labels(items))
+	assert.Equal(t, `"#/components/schemas/Pet"`, newText(items, "#/components/schemas/Pet"))
+
+	items = complete(t, doc, strings.Replace(spec, "$ref: '#/components/parameters/Limit'", "$ref: '|", 1))
+	assert.Equal(t, []string{"#/components/parameters/Limit"}, labels(items))
+	assert.Equal(t, "#/components/parameters/Limit", newText(items, "#/components/parameters/Limit"))
+}
@@ -0,0 +1,437 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package languageserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/plugin"
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/daveshanley/vacuum/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the vacuum configuration file, the same one the CLI reads from the working directory.
+const configFile = "vacuum.conf.yaml"
+
+// rulesetFiles are the rulesets found in a workspace folder, when no ruleset is configured.
+var rulesetFiles = []string{".spectral.yaml", ".spectral.yml", ".spectral.json"}
+
+// Settings configure how documents are linted. Settings come from the command line (and the vacuum.conf.yaml
+// next to where the server was started), then from the vacuum.conf.yaml of a workspace folder, and then from the
+// editor, with workspace/didChangeConfiguration. Anything that's not set is left as it was.
+//
+// Custom functions run code, so they are only loaded when the command line or the editor asks for them. Functions
+// set by the vacuum.conf.yaml of a workspace folder are ignored, and so are the functions declared by a ruleset
+// the workspace picked (found in the folder, or set by its vacuum.conf.yaml): opening a folder does not run
+// anything in it. Rules using functions that are not loaded are skipped.
+type Settings struct {
+	Ruleset                  *string `json:"ruleset,omitempty" yaml:"ruleset"`
+	Functions                *string `json:"functions,omitempty" yaml:"functions"`
+	HardMode                 *bool   `json:"hardMode,omitempty" yaml:"hard-mode"`
+	Timeout                  *int    `json:"timeout,omitempty" yaml:"timeout"`
+	IgnoreArrayCircleRef     *bool   `json:"ignoreArrayCircleRef,omitempty" yaml:"ignore-array-circle-ref"`
+	IgnorePolymorphCircleRef *bool   `json:"ignorePolymorphCircleRef,omitempty" yaml:"ignore-polymorph-circle-ref"`
+}
+
+// merge returns the settings, with anything set by other replacing them.
+func (s Settings) merge(other Settings) Settings {
+	if other.Ruleset != nil {
+		s.Ruleset = other.Ruleset
+	}
+	if other.Functions != nil {
+		s.Functions = other.Functions
+	}
+	if other.HardMode != nil {
+		s.HardMode = other.HardMode
+	}
+	if other.Timeout != nil {
+		s.Timeout = other.Timeout
+	}
+	if other.IgnoreArrayCircleRef != nil {
+		s.IgnoreArrayCircleRef = other.IgnoreArrayCircleRef
+	}
+	if other.IgnorePolymorphCircleRef != nil {
+		s.IgnorePolymorphCircleRef = other.IgnorePolymorphCircleRef
+	}
+	return s
+}
+
+// resolve makes the paths of the settings absolute, relative paths are relative to a directory.
+func (s Settings) resolve(dir string) Settings {
+	abs := func(p *string) *string {
+		if p == nil || *p == "" || filepath.IsAbs(*p) || dir == "" {
+			return p
+		}
+		joined := filepath.Join(dir, *p)
+		return &joined
+	}
+	s.Ruleset = abs(s.Ruleset)
+	s.Functions = abs(s.Functions)
+	return s
+}
+
+// parseSettings reads the settings sent by an editor. Settings can be sent as they are, or in a vacuum section.
+func parseSettings(raw any) (Settings, error) {
+	var settings Settings
+	if raw == nil {
+		return settings, nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return settings, err
+	}
+	var section struct {
+		Vacuum *Settings `json:"vacuum"`
+	}
+	if err = json.Unmarshal(b, &section); err == nil && section.Vacuum != nil {
+		return *section.Vacuum, nil
+	}
+	err = json.Unmarshal(b, &settings)
+	return settings, err
+}
+
+// readConfigFile reads the settings from the vacuum.conf.yaml in a directory. Like the CLI, global flags are at
+// the root, and flags for the language-server command are under language-server.
+func readConfigFile(dir string) (Settings, bool, error) {
+	b, err := os.ReadFile(filepath.Join(dir, configFile))
+	if os.IsNotExist(err) {
+		return Settings{}, false, nil
+	}
+	if err != nil {
+		return Settings{}, false, err
+	}
+	var conf struct {
+		Settings       `yaml:",inline"`
+		LanguageServer Settings `yaml:"language-server"`
+	}
+	if err = yaml.Unmarshal(b, &conf); err != nil {
+		return Settings{}, true, fmt.Errorf("unable to parse '%s': %w", filepath.Join(dir, configFile), err)
+	}
+	return conf.Settings.merge(conf.LanguageServer).resolve(dir), true, nil
+}
+
+// folderConfig is how documents in a workspace folder are linted.
+type folderConfig struct {
+	folder    string
+	request   *utils.LintFileRequest
+	watched   []string          // files (and directories) the configuration was built from.
+	ruleset   *rulesets.RuleSet // the ruleset loaded for the folder, nil if it uses the ruleset of the server.
+	functions *plugin.Manager   // the custom functions of the folder, shared with the folders loading the same ones.
+}
+
+// close releases the functions of the ruleset loaded for the folder, once the configuration is no longer used.
+// Custom functions are shared between folders, they are released by reload.
+func (c *folderConfig) close() {
+	c.ruleset.CloseFunctions()
+}
+
+// functionsLoader loads the custom functions in a directory, once for every reload whatever the number of folders
+// using them. Managers are closed by reload once no configuration uses them.
+type functionsLoader struct {
+	loaded map[string]*plugin.Manager
+	failed map[string]error
+}
+
+func newFunctionsLoader() *functionsLoader {
+	return &functionsLoader{loaded: make(map[string]*plugin.Manager), failed: make(map[string]error)}
+}
+
+func (l *functionsLoader) load(location string) (*plugin.Manager, error) {
+	if pm, ok := l.loaded[location]; ok {
+		return pm, nil
+	}
+	if err, ok := l.failed[location]; ok {
+		return nil, err
+	}
+	pm, err := loadFunctions(location)
+	if err != nil {
+		l.failed[location] = err
+		return nil, err
+	}
+	l.loaded[location] = pm
+	return pm, nil
+}
+
+// buildConfig builds the lint configuration of a workspace folder, an empty folder is the configuration of
+// documents that are not in any folder. A ruleset found in the folder is used if no ruleset is configured.
+func (s *ServerState) buildConfig(folder string, flags, editor Settings, functions *functionsLoader) (*folderConfig, error) {
+	settings := flags
+	var watched []string
+	// the ruleset comes from the workspace folder, rather than the command line or the editor.
+	workspaceRuleset := false
+	if folder != "" {
+		conf, found, err := readConfigFile(folder)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			watched = append(watched, filepath.Join(folder, configFile))
+		}
+		conf.Functions = nil
+		editor = editor.resolve(folder)
+		workspaceRuleset = conf.Ruleset != nil && editor.Ruleset == nil
+		settings = settings.merge(conf).merge(editor)
+	} else {
+		settings = settings.merge(editor)
+	}
+
+	// a ruleset in the workspace folder is used when nothing else is configured.
+	var ruleset string
+	if settings.Ruleset != nil {
+		ruleset = *settings.Ruleset
+	}
+	if ruleset == "" && folder != "" {
+		for _, name := range rulesetFiles {
+			if _, err := os.Stat(filepath.Join(folder, name)); err == nil {
+				ruleset = filepath.Join(folder, name)
+				workspaceRuleset = true
+				break
+			}
+		}
+	}
+
+	config := &folderConfig{folder: folder, watched: watched}
+	request := *s.lintRequest
+	defaults := request.DefaultRuleSets
+	if defaults == nil {
+		defaults = rulesets.BuildDefaultRuleSetsWithLogger(request.Logger)
+	}
+	switch {
+	case ruleset != "":
+		rs, err := loadRuleSet(ruleset, defaults)
+		if err != nil {
+			return nil, err
+		}
+		if workspaceRuleset {
+			rs.FunctionSources = nil
+		}
+		request.SelectedRS = rs
+		config.ruleset = rs
+		config.watched = append(config.watched, ruleset)
+	case settings.HardMode != nil && *settings.HardMode:
+		request.SelectedRS = hardModeRuleSet(defaults)
+	case settings.Ruleset != nil || settings.HardMode != nil:
+		// the ruleset set on the command line has been unset.
+		request.SelectedRS = defaults.GenerateOpenAPIRecommendedRuleSet()
+	}
+
+	if settings.Functions != nil {
+		request.Functions = nil
+		if *settings.Functions != "" {
+			pm, err := functions.load(*settings.Functions)
+			if err != nil {
+				config.close()
+				return nil, err
+			}
+			request.Functions = pm.GetCustomFunctions()
+			config.functions = pm
+			config.watched = append(config.watched, *settings.Functions)
+		}
+	}
+	if settings.Timeout != nil {
+		request.TimeoutFlag = *settings.Timeout
+	}
+	if settings.IgnoreArrayCircleRef != nil {
+		request.IgnoreArrayCircleRef = *settings.IgnoreArrayCircleRef
+	}
+	if settings.IgnorePolymorphCircleRef != nil {
+		request.IgnorePolymorphCircleRef = *settings.IgnorePolymorphCircleRef
+	}
+	config.request = &request
+	return config, nil
+}
+
+// loadRuleSet reads a ruleset, and builds it on top of the default rulesets (downloading any remote extends).
+func loadRuleSet(location string, defaults rulesets.RuleSets) (*rulesets.RuleSet, error) {
+	b, err := os.ReadFile(location)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read ruleset '%s': %w", location, err)
+	}
+	rs, err := rulesets.CreateRuleSetFromData(b)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse ruleset '%s': %w", location, err)
+	}
+	rs.Location = location
+	return defaults.GenerateRuleSetFromSuppliedRuleSet(rs), nil
+}
+
+// hardModeRuleSet is every built-in rule, OWASP rules included.
+func hardModeRuleSet(defaults rulesets.RuleSets) *rulesets.RuleSet {
+	rs := defaults.GenerateOpenAPIDefaultRuleSet()
+	rules := make(map[string]*model.Rule, len(rs.Rules))
+	for k, v := range rs.Rules {
+		rules[k] = v
+	}
+	for k, v := range rulesets.GetAllOWASPRules() {
+		rules[k] = v
+	}
+	hard := *rs
+	hard.Rules = rules
+	return &hard
+}
+
+// loadFunctions loads the custom functions in a directory, the manager has to be closed once they are not used.
+func loadFunctions(location string) (*plugin.Manager, error) {
+	pm, err := plugin.LoadFunctions(location, true)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load custom functions from '%s': %w", location, err)
+	}
+	return pm, nil
+}
+
+// reload builds the configuration of every workspace folder again, and lints every open document with it. A
+// folder that can't be configured keeps its previous configuration, the problem is shown in the editor.
+func (s *ServerState) reload(notify func(method string, params any)) {
+	s.reloadLock.Lock()
+	s.configLock.RLock()
+	folders := append([]string{""}, s.folders...)
+	previous, flags, editor := s.configs, s.flags, s.editorSettings
+	s.configLock.RUnlock()
+
+	configs := make(map[string]*folderConfig)
+	functions := newFunctionsLoader()
+	for _, folder := range folders {
+		config, err := s.buildConfig(folder, flags, editor, functions)
+		if err != nil {
+			if notify != nil {
+				notify(protocol.ServerWindowShowMessage, protocol.ShowMessageParams{
+					Type:    protocol.MessageTypeError,
+					Message: fmt.Sprintf("vacuum: %s", err.Error()),
+				})
+			}
+			if config = previous[folder]; config == nil {
+				continue
+			}
+		}
+		configs[folder] = config
+	}
+
+	// custom functions are released once no configuration uses them, a folder keeping its previous
+	// configuration keeps its functions.
+	used := make(map[*plugin.Manager]bool)
+	for _, config := range configs {
+		if config.functions != nil {
+			used[config.functions] = true
+		}
+	}
+
+	s.configLock.Lock()
+	s.configs = configs
+	previousFunctions := s.functions
+	s.functions = used
+	s.configLock.Unlock()
+	s.reloadLock.Unlock()
+
+	// configurations that have been replaced release their functions.
+	for folder, config := range previous {
+		if configs[folder] != config {
+			config.close()
+		}
+	}
+	for pm := range previousFunctions {
+		if !used[pm] {
+			pm.Close()
+		}
+	}
+	for _, pm := range functions.loaded {
+		if !used[pm] {
+			pm.Close()
+		}
+	}
+
+	if notify != nil {
+		for _, doc := range s.documentStore.All() {
+			s.runDiagnostic(doc, notify, false)
+		}
+	}
+}
+
+// closeConfigs releases the functions of every folder, when the server stops.
+func (s *ServerState) closeConfigs() {
+	s.reloadLock.Lock()
+	defer s.reloadLock.Unlock()
+	s.configLock.Lock()
+	defer s.configLock.Unlock()
+	for _, config := range s.configs {
+		config.close()
+	}
+	for pm := range s.functions {
+		pm.Close()
+	}
+	s.configs, s.functions = nil, nil
+}
+
+// requestFor returns how a document is linted, using the configuration of the innermost workspace folder the
+// document is in.
+func (s *ServerState) requestFor(uri protocol.DocumentUri) *utils.LintFileRequest {
+	location := uriLocation(uri)
+	s.configLock.RLock()
+	defer s.configLock.RUnlock()
+	var best *folderConfig
+	for folder, config := range s.configs {
+		if folder != "" && !strings.HasPrefix(location, folder+string(filepath.Separator)) {
+			continue
+		}
+		if best == nil || len(folder) > len(best.folder) {
+			best = config
+		}
+	}
+	if best == nil {
+		return s.lintRequest
+	}
+	return best.request
+}
+
+// isConfigFile returns true if a file changing means the configuration has to be built again.
+func (s *ServerState) isConfigFile(location string) bool {
+	name := filepath.Base(location)
+	if name == configFile {
+		return true
+	}
+	for _, r := range rulesetFiles {
+		if name == r {
+			return true
+		}
+	}
+	s.configLock.RLock()
+	defer s.configLock.RUnlock()
+	for _, config := range s.configs {
+		for _, w := range config.watched {
+			if location == w || strings.HasPrefix(location, w+string(filepath.Separator)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// watchPatterns are the glob patterns of the files the configuration is built from.
+func (s *ServerState) watchPatterns() []string {
+	patterns := map[string]bool{"**/" + configFile: true, "**/.spectral.{yaml,yml,json}": true}
+	s.configLock.RLock()
+	for _, config := range s.configs {
+		for _, w := range config.watched {
+			if info, err := os.Stat(w); err == nil && info.IsDir() {
+				patterns[filepath.ToSlash(w)+"/**"] = true
+			} else {
+				patterns[filepath.ToSlash(w)] = true
+			}
+		}
+	}
+	s.configLock.RUnlock()
+	list := make([]string, 0, len(patterns))
+	for p := range patterns {
+		list = append(list, p)
+	}
+	sort.Strings(list)
+	return list
+}
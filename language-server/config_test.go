@@ -0,0 +1,242 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package languageserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/daveshanley/vacuum/utils"
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const pathsRuleSet = `rules:
+  paths-description:
+    description: paths must have a description
+    given: $.paths[*]
+    severity: warn
+    then:
+      field: description
+      function: truthy
+`
+
+func configServer(t *testing.T, folders ...string) *ServerState {
+	defaults := rulesets.BuildDefaultRuleSets()
+	return &ServerState{
+		documentStore: newDocumentStore(),
+		lintRequest: &utils.LintFileRequest{
+			DefaultRuleSets: defaults,
+			SelectedRS:      defaults.GenerateOpenAPIRecommendedRuleSet(),
+			TimeoutFlag:     5,
+		},
+		folders: folders,
+	}
+}
+
+func hasRule(request *utils.LintFileRequest, id string) bool {
+	return request.SelectedRS != nil && request.SelectedRS.Rules[id] != nil
+}
+
+func TestParseSettings(t *testing.T) {
+	settings, err := parseSettings(map[string]any{"vacuum": map[string]any{"ruleset": "rules.yaml", "hardMode": true}})
+	assert.NoError(t, err)
+	assert.Equal(t, "rules.yaml", *settings.Ruleset)
+	assert.True(t, *settings.HardMode)
+	assert.Nil(t, settings.Timeout)
+
+	settings, err = parseSettings(map[string]any{"timeout": 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, *settings.Timeout)
+
+	settings, err = parseSettings(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Settings{}, settings)
+}
+
+func TestReload_DiscoversRuleSets(t *testing.T) {
+	spectral, conf, plain := t.TempDir(), t.TempDir(), t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(spectral, ".spectral.yaml"), []byte(titleRuleSet), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(conf, "paths.yaml"), []byte(pathsRuleSet), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(conf, configFile),
+		[]byte("ruleset: paths.yaml\nlanguage-server:\n  timeout: 9\n  ignore-array-circle-ref: true\n"), 0644))
+
+	state := configServer(t, spectral, conf, plain)
+	state.reload(nil)
+
+	request := state.requestFor(fileURI(filepath.Join(spectral, "api", "spec.yaml")))
+	assert.True(t, hasRule(request, "info-title"))
+	assert.Equal(t, filepath.Join(spectral, ".spectral.yaml"), state.rulesetLocation(fileURI(filepath.Join(spectral, "spec.yaml"))))
+
+	request = state.requestFor(fileURI(filepath.Join(conf, "spec.yaml")))
+	assert.True(t, hasRule(request, "paths-description"))
+	assert.False(t, hasRule(request, "info-title"))
+	assert.Equal(t, 9, request.TimeoutFlag)
+	assert.True(t, request.IgnoreArrayCircleRef)
+
+	// nothing configured, the ruleset the server started with is used.
+	request = state.requestFor(fileURI(filepath.Join(plain, "spec.yaml")))
+	assert.Equal(t, state.lintRequest.SelectedRS, request.SelectedRS)
+	assert.Equal(t, 5, request.TimeoutFlag)
+
+	assert.True(t, state.isConfigFile(filepath.Join(conf, "paths.yaml")))
+	assert.False(t, state.isConfigFile(filepath.Join(conf, "spec.yaml")))
+	assert.Contains(t, state.watchPatterns(), filepath.ToSlash(filepath.Join(conf, "paths.yaml")))
+}
+
+func TestReload_EditorSettings(t *testing.T) {
+	folder := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, ".spectral.yaml"), []byte(titleRuleSet), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "paths.yaml"), []byte(pathsRuleSet), 0644))
+
+	state := configServer(t, folder)
+	ruleset, timeout := "paths.yaml", 2
+	state.editorSettings = Settings{Ruleset: &ruleset, Timeout: &timeout}
+	state.reload(nil)
+
+	request := state.requestFor(fileURI(filepath.Join(folder, "spec.yaml")))
+	assert.True(t, hasRule(request, "paths-description"))
+	assert.Equal(t, 2, request.TimeoutFlag)
+
+	hardMode := true
+	state.editorSettings = Settings{HardMode: &hardMode}
+	state.reload(nil)
+	request = state.requestFor(fileURI(filepath.Join(t.TempDir(), "spec.yaml")))
+	assert.True(t, hasRule(request, "owasp-no-http-basic"))
+}
+
+func TestReload_RuleTurnedBackOn(t *testing.T) {
+	off, on := t.TempDir(), t.TempDir()
+	location := filepath.Join(off, ".spectral.yaml")
+	assert.NoError(t, os.WriteFile(location, []byte("extends: [[spectral:oas, all]]\nrules:\n  info-contact: off\n  info-description: hint\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(on, ".spectral.yaml"), []byte("extends: [[spectral:oas, all]]\n"), 0644))
+
+	state := configServer(t, off, on)
+	state.reload(nil)
+	assert.False(t, hasRule(state.requestFor(fileURI(filepath.Join(off, "spec.yaml"))), "info-contact"))
+
+	// one folder turning a rule off does not turn it off for the others.
+	request := state.requestFor(fileURI(filepath.Join(on, "spec.yaml")))
+	assert.True(t, hasRule(request, "info-contact"))
+	assert.Equal(t, "error", request.SelectedRS.Rules["info-description"].Severity)
+
+	assert.NoError(t, os.WriteFile(location, []byte("extends: [[spectral:oas, all]]\n"), 0644))
+	state.reload(nil)
+	request = state.requestFor(fileURI(filepath.Join(off, "spec.yaml")))
+	assert.True(t, hasRule(request, "info-contact"))
+	assert.Equal(t, "error", request.SelectedRS.Rules["info-description"].Severity)
+}
+
+func TestReload_BrokenRuleSet(t *testing.T) {
+	folder := t.TempDir()
+	location := filepath.Join(folder, ".spectral.yaml")
+	assert.NoError(t, os.WriteFile(location, []byte(titleRuleSet), 0644))
+
+	state := configServer(t, folder)
+	state.reload(nil)
+
+	var messages []protocol.ShowMessageParams
+	notify := func(method string, params any) {
+		if method == protocol.ServerWindowShowMessage {
+			messages = append(messages, params.(protocol.ShowMessageParams))
+		}
+	}
+	assert.NoError(t, os.WriteFile(location, []byte("rules: [not a ruleset"), 0644))
+	assert.True(t, state.filesChanged([]protocol.FileEvent{{URI: fileURI(location)}}, notify))
+
+	// the previous configuration is kept.
+	assert.Len(t, messages, 1)
+	assert.Contains(t, messages[0].Message, "unable to parse ruleset")
+	assert.True(t, hasRule(state.requestFor(fileURI(filepath.Join(folder, "spec.yaml"))), "info-title"))
+
+	assert.False(t, state.filesChanged([]protocol.FileEvent{{URI: fileURI(filepath.Join(folder, "spec.yaml"))}}, notify))
+}
+
+func TestFilesChanged_Relints(t *testing.T) {
+	folder := t.TempDir()
+	location := filepath.Join(folder, ".spectral.yaml")
+	assert.NoError(t, os.WriteFile(location, []byte(titleRuleSet), 0644))
+
+	state := configServer(t, folder)
+	state.lintRequest.SkipCheckFlag = true
+	state.reload(nil)
+
+	published := make(chan protocol.PublishDiagnosticsParams, 10)
+	notify := func(method string, params any) {
+		if p, ok := params.(protocol.PublishDiagnosticsParams); ok {
+			published <- p
+		}
+	}
+	doc := state.documentStore.Add(fileURI(filepath.Join(folder, "spec.yaml")),
+		"openapi: 3.1.0\ninfo:\n  version: 1.0.0\npaths:\n  /pets: {}\n")
+	state.runDiagnostic(doc, notify, false)
+	p := receive(t, published)
+	assert.Len(t, p.Diagnostics, 1)
+	assert.Equal(t, "info-title", p.Diagnostics[0].Code.Value)
+
+	// the ruleset changes, the open document is linted with the new one.
+	assert.NoError(t, os.WriteFile(location, []byte(pathsRuleSet), 0644))
+	assert.True(t, state.filesChanged([]protocol.FileEvent{{URI: fileURI(location)}}, notify))
+	p = receive(t, published)
+	assert.Len(t, p.Diagnostics, 1)
+	assert.Equal(t, "paths-description", p.Diagnostics[0].Code.Value)
+}
+
+func TestReload_WorkspaceFunctions(t *testing.T) {
+	functionsRuleSet := "functions: [check]\nrules:\n  checked:\n    given: $\n    then:\n      function: check\n"
+	discovered, configured := t.TempDir(), t.TempDir()
+	for _, folder := range []string{discovered, configured} {
+		assert.NoError(t, os.Mkdir(filepath.Join(folder, "functions"), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(folder, "functions", "check.js"), []byte("//"), 0644))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(discovered, ".spectral.yaml"), []byte(functionsRuleSet), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(configured, "rules.yaml"), []byte(functionsRuleSet), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(configured, configFile),
+		[]byte("ruleset: rules.yaml\nfunctions: functions\n"), 0644))
+
+	state := configServer(t, discovered, configured)
+	state.reload(nil)
+
+	// opening a folder does not load the functions it holds.
+	for _, folder := range []string{discovered, configured} {
+		request := state.requestFor(fileURI(filepath.Join(folder, "spec.yaml")))
+		assert.True(t, hasRule(request, "checked"))
+		assert.Empty(t, request.SelectedRS.FunctionSources)
+		assert.Nil(t, request.Functions)
+	}
+	assert.False(t, state.isConfigFile(filepath.Join(configured, "functions", "check.js")))
+
+	// the editor can ask for them.
+	ruleset := "rules.yaml"
+	state.editorSettings = Settings{Ruleset: &ruleset}
+	state.reload(nil)
+	request := state.requestFor(fileURI(filepath.Join(configured, "spec.yaml")))
+	assert.Len(t, request.SelectedRS.FunctionSources, 1)
+	state.closeConfigs()
+}
+
+func TestReload_FunctionsLoadedOnce(t *testing.T) {
+	functions := filepath.Join(t.TempDir(), "functions")
+	assert.NoError(t, os.Mkdir(functions, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(functions, "check.js"), []byte("//"), 0644))
+
+	state := configServer(t, t.TempDir(), t.TempDir())
+	state.flags = Settings{Functions: &functions}
+	state.reload(nil)
+
+	// every folder shares the same functions.
+	assert.Len(t, state.functions, 1)
+	for _, config := range state.configs {
+		assert.True(t, state.functions[config.functions])
+	}
+
+	previous := state.functions
+	state.reload(nil)
+	assert.Len(t, state.functions, 1)
+	assert.NotEqual(t, previous, state.functions)
+	state.closeConfigs()
+	assert.Nil(t, state.functions)
+}
@@ -0,0 +1,293 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package languageserver
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// sequenceItem is used in cursor paths for the items of a sequence, the position of an item never matters.
+const sequenceItem = "-"
+
+// cursor describes what is being typed at a position in a document. Documents are usually broken while they are
+// being typed, so the cursor is worked out from the text, not by parsing the document.
+type cursor struct {
+	path     []string          // keys (and sequence items) from the root to the mapping or sequence the cursor is in.
+	key      string            // the key of the value being typed, or sequenceItem for an item of a sequence.
+	value    bool              // a value is being typed, not a key.
+	either   bool              // a YAML sequence item without a colon, could be a key of a mapping item or a value.
+	prefix   string            // what has been typed so far.
+	start    protocol.Position // where the prefix starts, it's replaced by the completion.
+	quoted   bool              // the prefix is inside quotes (which are not part of the prefix).
+	siblings map[string]bool   // keys that are already in the mapping.
+	json     bool
+}
+
+var yamlKey = regexp.MustCompile(`^(?:"([^"]*)"|'([^']*)'|([^\s"'#\-?:][^#]*?|-[^\s#][^#]*?))\s*:(?:\s|$)`)
+
+// parseYAMLKey returns the key at the start of a line (after indentation), and where its value starts.
+func parseYAMLKey(text string) (string, int, bool) {
+	m := yamlKey.FindStringSubmatchIndex(text)
+	if m == nil {
+		return "", 0, false
+	}
+	for g := 1; g <= 3; g++ {
+		if m[g*2] >= 0 {
+			return text[m[g*2]:m[g*2+1]], m[1], true
+		}
+	}
+	return "", 0, false
+}
+
+// yamlLine splits a line of YAML into its indentation, and the indentation of its content once any sequence
+// dashes are skipped.
+func yamlLine(line string) (indent, contentIndent int, dash bool, content string) {
+	indent = len(line) - len(strings.TrimLeft(line, " "))
+	contentIndent = indent
+	content = line[indent:]
+	for content == "-" || strings.HasPrefix(content, "- ") {
+		dash = true
+		trimmed := strings.TrimLeft(content[1:], " ")
+		contentIndent += len(content) - len(trimmed)
+		content = trimmed
+	}
+	return indent, contentIndent, dash, content
+}
+
+func isYAMLNoise(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---"
+}
+
+// findCursor works out what is being typed at a position.
+func findCursor(content string, position protocol.Position) *cursor {
+	lines := strings.Split(content, "\n")
+	if int(position.Line) >= len(lines) {
+		return nil
+	}
+	if strings.HasPrefix(strings.TrimSpace(content), "{") {
+		return findJSONCursor(lines, position)
+	}
+	return findYAMLCursor(lines, position)
+}
+
+func findYAMLCursor(lines []string, position protocol.Position) *cursor {
+	line := lines[position.Line]
+	before := line[:utf16Offset(line, int(position.Character))]
+	c := &cursor{siblings: make(map[string]bool)}
+
+	indent, target, dash, rest := yamlLine(before)
+	offset := len(before) - len(rest)
+	if key, valueStart, ok := parseYAMLKey(rest); ok {
+		c.key, c.value = key, true
+		valueStart += len(rest[valueStart:]) - len(strings.TrimLeft(rest[valueStart:], " "))
+		offset += valueStart
+	} else if dash {
+		c.either = true
+	}
+	if strings.HasPrefix(before[offset:], `"`) || strings.HasPrefix(before[offset:], `'`) {
+		c.quoted = true
+		offset++
+	}
+	c.prefix = before[offset:]
+	c.start = protocol.Position{Line: position.Line, Character: protocol.UInteger(utf16Length(before[:offset]))}
+
+	// keys of the same mapping below the cursor.
+	for i, mapIndent := int(position.Line)+1, target; i < len(lines); i++ {
+		if isYAMLNoise(lines[i]) {
+			continue
+		}
+		ind, keyInd, _, text := yamlLine(lines[i])
+		if ind < mapIndent {
+			break
+		}
+		if key, _, ok := parseYAMLKey(text); ok && ind == mapIndent && keyInd == mapIndent {
+			c.siblings[key] = true
+		}
+	}
+
+	// the mapping the cursor is in started on this line, as an item of a sequence.
+	var path []string
+	inSeq := false
+	if dash {
+		path = append(path, sequenceItem)
+		target, inSeq = indent, true
+	}
+	level := !dash
+
+	// walk up, collecting the keys that own the mapping, and keys of the same mapping above the cursor.
+	for i := int(position.Line) - 1; i >= 0; i-- {
+		if isYAMLNoise(lines[i]) {
+			continue
+		}
+		ind, keyInd, d, text := yamlLine(lines[i])
+		key, _, isKey := parseYAMLKey(text)
+		switch {
+		case d && keyInd == target && !inSeq:
+			// the first line of the sequence item the mapping belongs to.
+			if isKey && level {
+				c.siblings[key] = true
+			}
+			path = append(path, sequenceItem)
+			target, inSeq, level = ind, true, false
+		case d && keyInd < target && !inSeq:
+			// a key on the first line of a sequence item, that owns the mapping.
+			if isKey {
+				path = append(path, key)
+			}
+			path = append(path, sequenceItem)
+			target, inSeq, level = ind, true, false
+		case d:
+			// another item of the sequence, or something deeper.
+		case isKey && (ind < target || inSeq && ind == target):
+			path = append(path, key)
+			target, inSeq, level = ind, false, false
+		case isKey && ind == target && level:
+			c.siblings[key] = true
+		}
+		if target == 0 && !inSeq && !level {
+			break
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	c.path = path
+	return c
+}
+
+type jsonFrame struct {
+	object    bool
+	key       string
+	expectKey bool
+	keys      map[string]bool
+}
+
+func findJSONCursor(lines []string, position protocol.Position) *cursor {
+	var b strings.Builder
+	for i := 0; i < int(position.Line); i++ {
+		b.WriteString(lines[i])
+		b.WriteByte('\n')
+	}
+	line := lines[position.Line]
+	b.WriteString(line[:utf16Offset(line, int(position.Character))])
+	text := b.String()
+
+	var stack []*jsonFrame
+	c := &cursor{json: true}
+	partialStart := -1
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		switch {
+		case ch == '"':
+			j := i + 1
+			for j < len(text) && text[j] != '"' {
+				if text[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(text) {
+				partialStart = i + 1
+				c.quoted = true
+				i = len(text)
+				break
+			}
+			if top != nil && top.object && top.expectKey {
+				top.key = text[i+1 : j]
+				top.keys[top.key] = true
+			}
+			i = j
+		case ch == '{':
+			stack = append(stack, &jsonFrame{object: true, expectKey: true, keys: make(map[string]bool)})
+		case ch == '[':
+			stack = append(stack, &jsonFrame{})
+		case ch == '}' || ch == ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case ch == ':' && top != nil && top.object:
+			top.expectKey = false
+		case ch == ',' && top != nil && top.object:
+			top.expectKey = true
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == ',' || ch == ':':
+		default:
+			// a number, or true / false / null.
+			j := i
+			for j < len(text) && !strings.ContainsRune(" \t\r\n,:{}[]\"", rune(text[j])) {
+				j++
+			}
+			if j >= len(text) {
+				partialStart = i
+			}
+			i = j - 1
+		}
+	}
+	if len(stack) == 0 {
+		return nil
+	}
+
+	top := stack[len(stack)-1]
+	for _, f := range stack[:len(stack)-1] {
+		if f.object {
+			c.path = append(c.path, f.key)
+		} else {
+			c.path = append(c.path, sequenceItem)
+		}
+	}
+	switch {
+	case top.object && top.expectKey:
+		c.siblings = top.keys
+	case top.object:
+		c.key, c.value = top.key, true
+	default:
+		c.key, c.value = sequenceItem, true
+	}
+	if partialStart >= 0 {
+		c.prefix = text[partialStart:]
+	}
+	lineStart := len(text) - len(line[:utf16Offset(line, int(position.Character))])
+	start := len(text) - len(c.prefix)
+	if start < lineStart {
+		start = lineStart // multi-line strings are not a thing in JSON, this is just in case.
+	}
+	c.start = protocol.Position{Line: position.Line,
+		Character: protocol.UInteger(utf16Length(text[lineStart:start]))}
+	return c
+}
+
+// utf16Offset converts a character position in a line into a byte offset. Positions are counted in UTF-16 code
+// units, like editors count them, so characters outside the basic multilingual plane (emoji) count twice.
+func utf16Offset(line string, character int) int {
+	offset := 0
+	for units := 0; units < character && offset < len(line); {
+		r, size := utf8.DecodeRuneInString(line[offset:])
+		offset += size
+		units++
+		if r >= 0x10000 {
+			units++
+		}
+	}
+	return offset
+}
+
+// utf16Length returns the length of a string in UTF-16 code units, which is how editors count characters.
+func utf16Length(s string) int {
+	length := 0
+	for _, r := range s {
+		length++
+		if r >= 0x10000 {
+			length++
+		}
+	}
+	return length
+}
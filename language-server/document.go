@@ -15,16 +15,125 @@
 package languageserver
 
 import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/pb33f/libopenapi/index"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
+// DocumentStore holds the documents open in the editor. Handlers and lint runs use it at the same time, so it's
+// locked.
 type DocumentStore struct {
 	documents map[string]*Document
+	lock      sync.RWMutex
 }
+
+// Document is a document open in the editor. The content changes while it's being linted, so everything is
+// locked, and lint runs take a copy of the content with text().
 type Document struct {
 	URI               protocol.DocumentUri
 	RunningDiagnostic bool
 	Content           string
+	Version           protocol.Integer
+
+	// the content that was last linted, and the results it produced. Code actions use these, the results
+	// are only valid for as long as the content has not changed.
+	lintedContent string
+	lintResults   []model.RuleFunctionResult
+	lintIndex     *index.SpecIndex
+	lock          sync.Mutex
+
+	// the pending (debounced) lint, and the cancel func of the lint that's running.
+	lintTimer  *time.Timer
+	lintCancel context.CancelFunc
+}
+
+// text returns the content of the document, and its version.
+func (d *Document) text() (string, protocol.Integer) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.Content, d.Version
+}
+
+// applyChanges applies the changes sent by the editor, and moves the document to a new version.
+func (d *Document) applyChanges(version protocol.Integer, changes []any) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for _, change := range changes {
+		switch c := change.(type) {
+		case protocol.TextDocumentContentChangeEvent:
+			startIndex, endIndex := c.Range.IndexesIn(d.Content)
+			d.Content = d.Content[:startIndex] + c.Text + d.Content[endIndex:]
+		case protocol.TextDocumentContentChangeEventWhole:
+			d.Content = c.Text
+		}
+	}
+	d.Version = version
+}
+
+// scheduleLint runs a lint after a delay. A lint that's pending is replaced, and a lint that's running is
+// cancelled, its results are for content that has changed.
+func (d *Document) scheduleLint(delay time.Duration, lint func(ctx context.Context)) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.stopLint()
+	ctx, cancel := context.WithCancel(context.Background())
+	d.lintCancel = cancel
+	d.lintTimer = time.AfterFunc(delay, func() {
+		defer cancel()
+		lint(ctx)
+	})
+}
+
+// cancelLint stops any pending or running lint, when the document is closed.
+func (d *Document) cancelLint() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.stopLint()
+}
+
+func (d *Document) stopLint() {
+	if d.lintTimer != nil {
+		d.lintTimer.Stop()
+	}
+	if d.lintCancel != nil {
+		d.lintCancel()
+	}
+}
+
+// setLintResults records the results of a lint. False is returned if the document has changed since the content
+// was linted, the results are stale and should not be published.
+func (d *Document) setLintResults(content string, results []model.RuleFunctionResult, idx *index.SpecIndex) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.lintedContent = content
+	d.lintResults = results
+	if idx != nil {
+		d.lintIndex = idx
+	}
+	return d.Content == content
+}
+
+// getIndex returns the index built the last time the document was linted. Unlike the results, the index is
+// still useful once the document has changed (components rarely change while typing), and it's kept when a
+// broken document can't be indexed.
+func (d *Document) getIndex() *index.SpecIndex {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.lintIndex
+}
+
+// getLintResults returns the last lint results for the document, as long as they are still current.
+func (d *Document) getLintResults() (string, []model.RuleFunctionResult, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.lintedContent != d.Content {
+		return "", nil, false
+	}
+	return d.lintedContent, d.lintResults, true
 }
 
 func newDocumentStore() *DocumentStore {
@@ -37,13 +146,34 @@ func (s *DocumentStore) Add(uri string, content string) *Document {
 		URI:     uri,
 		Content: content,
 	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if old, ok := s.documents[uri]; ok {
+		old.cancelLint()
+	}
 	s.documents[uri] = doc
 	return doc
 }
 func (s *DocumentStore) Get(uri string) (*Document, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	d, ok := s.documents[uri]
 	return d, ok
 }
+func (s *DocumentStore) All() []*Document {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	docs := make([]*Document, 0, len(s.documents))
+	for _, d := range s.documents {
+		docs = append(docs, d)
+	}
+	return docs
+}
 func (s *DocumentStore) Remove(uri string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if doc, ok := s.documents[uri]; ok {
+		doc.cancelLint()
+	}
 	delete(s.documents, uri)
 }
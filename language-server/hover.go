@@ -0,0 +1,77 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package languageserver
+
+import (
+	"fmt"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// buildHover describes the rules of every result under the cursor: what the rule checks, which category it
+// belongs to, and how to fix it. Nothing is returned if there are no results under the cursor.
+func buildHover(doc *Document, params *protocol.HoverParams) *protocol.Hover {
+	_, results, ok := doc.getLintResults()
+	if !ok {
+		return nil
+	}
+	var sections []string
+	seen := make(map[string]bool)
+	for i := range results {
+		r := &results[i]
+		if r.StartNode == nil || r.EndNode == nil || r.Rule == nil || seen[r.Rule.Id] {
+			continue
+		}
+		if r.Origin != nil && r.Origin.AbsoluteLocation != "" {
+			continue
+		}
+		diagnostic := buildDiagnostic(*r)
+		if !rangeContains(diagnostic.Range, params.Position) {
+			continue
+		}
+		seen[r.Rule.Id] = true
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "**%s**", r.Rule.Id)
+		if r.Rule.RuleCategory != nil && r.Rule.RuleCategory.Name != "" {
+			fmt.Fprintf(&b, " (%s)", r.Rule.RuleCategory.Name)
+		}
+		if r.Rule.Severity != "" {
+			fmt.Fprintf(&b, " · %s", r.Rule.Severity)
+		}
+		if r.Rule.Description != "" {
+			fmt.Fprintf(&b, "\n\n%s", r.Rule.Description)
+		}
+		if r.Rule.HowToFix != "" {
+			fmt.Fprintf(&b, "\n\n**How to fix:** %s", r.Rule.HowToFix)
+		}
+		if diagnostic.CodeDescription != nil {
+			fmt.Fprintf(&b, "\n\n[Documentation](%s)", diagnostic.CodeDescription.HRef)
+		}
+		sections = append(sections, b.String())
+	}
+	if len(sections) == 0 {
+		return nil
+	}
+	return &protocol.Hover{
+		Contents: protocol.MarkupContent{
+			Kind:  protocol.MarkupKindMarkdown,
+			Value: strings.Join(sections, "\n\n---\n\n"),
+		},
+	}
+}
+
+func rangeContains(r protocol.Range, p protocol.Position) bool {
+	if p.Line < r.Start.Line || p.Line > r.End.Line {
+		return false
+	}
+	if p.Line == r.Start.Line && p.Character < r.Start.Character {
+		return false
+	}
+	if p.Line == r.End.Line && p.Character > r.End.Character {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,422 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package languageserver
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+	"gopkg.in/yaml.v3"
+)
+
+// componentName is what OpenAPI 3 allows component names to be.
+var componentName = regexp.MustCompile(`^[a-zA-Z0-9.\-_]+$`)
+
+// specFile is a file that navigation works across, parsed so nodes can be found by position.
+type specFile struct {
+	location string
+	uri      protocol.DocumentUri
+	content  string
+	lines    []string
+	root     *yaml.Node
+}
+
+// workspace is the set of files navigation works across: the documents open in the editor, the files in the
+// rolodex of each of them (the files they reference, directly or not), and the YAML and JSON files in the
+// workspace folders, which may reference them without being open. Files are loaded and parsed once.
+type workspace struct {
+	store   *DocumentStore
+	folders []string
+	files   map[string]*specFile
+}
+
+func newWorkspace(store *DocumentStore) *workspace {
+	return &workspace{store: store, files: make(map[string]*specFile)}
+}
+
+// uriLocation returns the file path of a file URI, percent-decoded. Drive letters of Windows URIs
+// (file:///C:/api/spec.yaml) lose the slash in front of them, and a host other than localhost makes a UNC path.
+func uriLocation(uri protocol.DocumentUri) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return filepath.Clean(filepath.FromSlash(strings.TrimPrefix(uri, "file://")))
+	}
+	location := u.Path
+	if len(location) >= 3 && location[0] == '/' && location[2] == ':' && isDriveLetter(location[1]) {
+		location = location[1:]
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		location = "//" + u.Host + location
+	}
+	return filepath.Clean(filepath.FromSlash(location))
+}
+
+func isDriveLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// file returns a file of the workspace, the content of an open document is used over what's on disk.
+func (w *workspace) file(location string) *specFile {
+	location = filepath.Clean(location)
+	if f, ok := w.files[location]; ok {
+		return f
+	}
+	f := &specFile{location: location, uri: fileURI(location)}
+	if doc := w.document(location); doc != nil {
+		// editors encode URIs their own way, the URI of the document is the one they know it by.
+		f.uri = doc.URI
+		f.content, _ = doc.text()
+	} else if b, err := os.ReadFile(location); err == nil {
+		f.content = string(b)
+	}
+	f.lines = strings.Split(f.content, "\n")
+	var root yaml.Node
+	if f.content != "" && yaml.Unmarshal([]byte(f.content), &root) == nil && len(root.Content) > 0 {
+		f.root = root.Content[0]
+	}
+	w.files[location] = f
+	return f
+}
+
+// document returns the open document of a location, if there is one.
+func (w *workspace) document(location string) *Document {
+	if doc, ok := w.store.Get(fileURI(location)); ok {
+		return doc
+	}
+	for _, doc := range w.store.All() {
+		if uriLocation(doc.URI) == location {
+			return doc
+		}
+	}
+	return nil
+}
+
+// all returns every file of the workspace, sorted by location.
+func (w *workspace) all() []*specFile {
+	locations := make(map[string]bool)
+	for _, folder := range w.folders {
+		_ = filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != folder && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".yaml", ".yml", ".json":
+				locations[filepath.Clean(path)] = true
+			}
+			return nil
+		})
+	}
+	for _, doc := range w.store.All() {
+		locations[uriLocation(doc.URI)] = true
+		idx := doc.getIndex()
+		if idx == nil || idx.GetRolodex() == nil {
+			continue
+		}
+		for _, fileIdx := range idx.GetRolodex().GetIndexes() {
+			if location := fileIdx.GetSpecAbsolutePath(); location != "" && !strings.HasPrefix(location, "http") {
+				locations[filepath.Clean(location)] = true
+			}
+		}
+	}
+	var files []*specFile
+	for location := range locations {
+		if f := w.file(location); f.root != nil {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].location < files[j].location })
+	return files
+}
+
+// target is something a reference points to: a file, and a JSON pointer into it.
+type target struct {
+	location string
+	pointer  string
+}
+
+// resolveRef works out what a reference in a file points to, remote references are not followed.
+func resolveRef(from, ref string) (target, bool) {
+	file, pointer, _ := strings.Cut(ref, "#")
+	if strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://") {
+		return target{}, false
+	}
+	location := from
+	if file != "" {
+		location = file
+		if !filepath.IsAbs(file) {
+			location = filepath.Join(filepath.Dir(from), file)
+		}
+	}
+	return target{location: filepath.Clean(location), pointer: pointer}, true
+}
+
+// ref is a $ref found in a file.
+type ref struct {
+	file  *specFile
+	value *yaml.Node
+}
+
+// walkNodes calls fn with every key / value pair of every mapping, and the path to the pair.
+func walkNodes(node *yaml.Node, path []string, fn func(path []string, key, value *yaml.Node)) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fn(path, key, value)
+			walkNodes(value, append(path[:len(path):len(path)], key.Value), fn)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			walkNodes(item, append(path[:len(path):len(path)], strconv.Itoa(i)), fn)
+		}
+	}
+}
+
+// lookupPointer returns the node a JSON pointer points to, and the key node that names it (if it has one).
+func lookupPointer(root *yaml.Node, pointer string) (key, value *yaml.Node) {
+	value = root
+	if pointer == "" || pointer == "/" {
+		return nil, root
+	}
+	for _, segment := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		found := false
+		switch value.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(value.Content); i += 2 {
+				if value.Content[i].Value == segment {
+					key, value, found = value.Content[i], value.Content[i+1], true
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(value.Content) {
+				key, value, found = nil, value.Content[i], true
+			}
+		}
+		if !found {
+			return nil, nil
+		}
+	}
+	return key, value
+}
+
+// isComponent returns true if a path (of keys from the root) is where a component is defined. Components live
+// under components in OpenAPI 3, and at the root in OpenAPI 2.
+func isComponent(path []string) bool {
+	switch len(path) {
+	case 2:
+		switch path[0] {
+		case "definitions", "parameters", "responses", "securityDefinitions":
+			return true
+		}
+	case 3:
+		return path[0] == "components"
+	}
+	return false
+}
+
+func escapePointer(path []string) string {
+	var b strings.Builder
+	for _, segment := range path {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(segment, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// targetAt works out what is at a position of a file: the target of a $ref value, or the component whose name is
+// under the cursor.
+func targetAt(f *specFile, position protocol.Position) (target, bool) {
+	if f.root == nil {
+		return target{}, false
+	}
+	var found target
+	ok := false
+	walkNodes(f.root, nil, func(path []string, key, value *yaml.Node) {
+		if ok {
+			return
+		}
+		if key.Value == "$ref" && value.Kind == yaml.ScalarNode && rangeContains(nodeRange(f.lines, value), position) {
+			found, ok = resolveRef(f.location, value.Value)
+			return
+		}
+		p := append(path[:len(path):len(path)], key.Value)
+		if isComponent(p) && rangeContains(nodeRange(f.lines, key), position) {
+			found, ok = target{location: f.location, pointer: escapePointer(p)}, true
+		}
+	})
+	return found, ok
+}
+
+// refsTo returns every $ref in the workspace that points to a target, or into it.
+func (w *workspace) refsTo(t target) []ref {
+	var refs []ref
+	for _, f := range w.all() {
+		walkNodes(f.root, nil, func(_ []string, key, value *yaml.Node) {
+			if key.Value != "$ref" || value.Kind != yaml.ScalarNode {
+				return
+			}
+			rt, ok := resolveRef(f.location, value.Value)
+			if ok && rt.location == t.location && (rt.pointer == t.pointer || strings.HasPrefix(rt.pointer, t.pointer+"/")) {
+				refs = append(refs, ref{file: f, value: value})
+			}
+		})
+	}
+	return refs
+}
+
+// buildDefinition returns the location a $ref under the cursor points to.
+func buildDefinition(w *workspace, doc *Document, position protocol.Position) []protocol.Location {
+	t, ok := targetAt(w.file(uriLocation(doc.URI)), position)
+	if !ok {
+		return nil
+	}
+	f := w.file(t.location)
+	if f.root == nil {
+		return nil
+	}
+	key, value := lookupPointer(f.root, t.pointer)
+	switch {
+	case key != nil:
+		return []protocol.Location{{URI: f.uri, Range: nodeRange(f.lines, key)}}
+	case value != nil:
+		return []protocol.Location{{URI: f.uri, Range: nodeRange(f.lines, value)}}
+	}
+	return nil
+}
+
+// buildReferences returns every $ref in the workspace that points to the component (or $ref) under the cursor.
+func buildReferences(w *workspace, doc *Document, params *protocol.ReferenceParams) []protocol.Location {
+	t, ok := targetAt(w.file(uriLocation(doc.URI)), params.Position)
+	if !ok {
+		return nil
+	}
+	var locations []protocol.Location
+	if params.Context.IncludeDeclaration {
+		if f := w.file(t.location); f.root != nil {
+			if key, _ := lookupPointer(f.root, t.pointer); key != nil {
+				locations = append(locations, protocol.Location{URI: f.uri, Range: nodeRange(f.lines, key)})
+			}
+		}
+	}
+	for _, r := range w.refsTo(t) {
+		locations = append(locations, protocol.Location{URI: r.file.uri, Range: nodeRange(r.file.lines, r.value)})
+	}
+	return locations
+}
+
+// buildRename renames the component under the cursor (or the component a $ref under the cursor points to), and
+// rewrites every $ref to it across the workspace.
+func buildRename(w *workspace, doc *Document, params *protocol.RenameParams) (*protocol.WorkspaceEdit, error) {
+	t, ok := targetAt(w.file(uriLocation(doc.URI)), params.Position)
+	if !ok {
+		return nil, nil
+	}
+	segments := strings.Split(strings.TrimPrefix(t.pointer, "/"), "/")
+	if !isComponent(unescapeAll(segments)) {
+		return nil, fmt.Errorf("only components can be renamed, '%s' is not a component", t.pointer)
+	}
+	if !componentName.MatchString(params.NewName) {
+		return nil, fmt.Errorf("'%s' is not a valid component name, names can only use letters, digits, '.', '-' and '_'",
+			params.NewName)
+	}
+	f := w.file(t.location)
+	if f.root == nil {
+		return nil, fmt.Errorf("unable to read '%s'", t.location)
+	}
+	key, _ := lookupPointer(f.root, t.pointer)
+	if key == nil {
+		return nil, fmt.Errorf("unable to find component '%s' in '%s'", t.pointer, t.location)
+	}
+	parent := escapePointer(unescapeAll(segments[:len(segments)-1]))
+	if existing, _ := lookupPointer(f.root, parent+"/"+params.NewName); existing != nil {
+		return nil, fmt.Errorf("component '%s/%s' already exists", parent, params.NewName)
+	}
+
+	changes := make(map[protocol.DocumentUri][]protocol.TextEdit)
+	changes[f.uri] = append(changes[f.uri], protocol.TextEdit{Range: valueRange(f.lines, key), NewText: params.NewName})
+
+	for _, r := range w.refsTo(t) {
+		// the name is always the last segment of the target, find it in the $ref as it is written.
+		file, fragment, _ := strings.Cut(r.value.Value, "#")
+		raw := strings.Split(strings.TrimPrefix(fragment, "/"), "/")
+		offset := len(file) + 1
+		for _, s := range raw[:len(segments)-1] {
+			offset += len(s) + 1
+		}
+		offset++
+		start := valueRange(r.file.lines, r.value).Start
+		start.Character += protocol.UInteger(utf16Length(r.value.Value[:offset]))
+		end := start
+		end.Character += protocol.UInteger(utf16Length(raw[len(segments)-1]))
+		changes[r.file.uri] = append(changes[r.file.uri], protocol.TextEdit{
+			Range:   protocol.Range{Start: start, End: end},
+			NewText: params.NewName,
+		})
+	}
+	return &protocol.WorkspaceEdit{Changes: changes}, nil
+}
+
+func unescapeAll(segments []string) []string {
+	unescaped := make([]string, len(segments))
+	for i, s := range segments {
+		unescaped[i] = strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+	}
+	return unescaped
+}
+
+// nodeRange is the range of a node as it's written, quotes included. Only scalars are measured, other nodes
+// are empty ranges at their start. Characters are counted in UTF-16 code units, like editors count them.
+func nodeRange(lines []string, node *yaml.Node) protocol.Range {
+	start := protocol.Position{Line: protocol.UInteger(node.Line - 1), Character: utf16Column(lines, node.Line, node.Column)}
+	end := start
+	if node.Kind == yaml.ScalarNode {
+		end.Character += protocol.UInteger(utf16Length(node.Value))
+		if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+			end.Character += 2
+		}
+	}
+	return protocol.Range{Start: start, End: end}
+}
+
+// valueRange is the range of the value of a scalar, without its quotes.
+func valueRange(lines []string, node *yaml.Node) protocol.Range {
+	r := nodeRange(lines, node)
+	if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		r.Start.Character++
+		r.End.Character--
+	}
+	return r
+}
+
+// utf16Column converts the column of a node (which counts characters from one) into the character position an
+// editor uses for it, which counts UTF-16 code units from zero.
+func utf16Column(lines []string, line, column int) protocol.UInteger {
+	if line < 1 || line > len(lines) || column < 1 {
+		return protocol.UInteger(max(column-1, 0))
+	}
+	text := lines[line-1]
+	offset, characters := 0, 1
+	for ; characters < column && offset < len(text); characters++ {
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+	return protocol.UInteger(utf16Length(text[:offset]) + column - characters)
+}
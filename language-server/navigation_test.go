@@ -0,0 +1,249 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package languageserver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/daveshanley/vacuum/motor"
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+	"gopkg.in/yaml.v3"
+)
+
+const navigationSpec = `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+        default:
+          description: error
+          content:
+            application/json:
+              schema:
+                $ref: "shared.yaml#/components/schemas/Error"
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        owner:
+          $ref: '#/components/schemas/Pet/properties/name'
+        name:
+          type: string
+`
+
+const sharedSpec = `openapi: 3.1.0
+components:
+  schemas:
+    Error:
+      type: object
+      properties:
+        cause:
+          $ref: '#/components/schemas/Error'
+`
+
+// navigationWorkspace opens the navigation spec, which references a shared file, and lints it so the rolodex
+// knows about the shared file.
+func navigationWorkspace(t *testing.T) (*workspace, *Document, string) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "shared.yaml"), []byte(sharedSpec), 0644))
+	location := filepath.Join(dir, "spec.yaml")
+	assert.NoError(t, os.WriteFile(location, []byte(navigationSpec), 0644))
+
+	store := newDocumentStore()
+	doc := store.Add(fileURI(location), navigationSpec)
+	result := motor.ApplyRulesToRuleSet(&motor.RuleSetExecution{
+		RuleSet:     rulesets.BuildDefaultRuleSets().GenerateOpenAPIRecommendedRuleSet(),
+		Spec:        []byte(navigationSpec),
+		Base:        dir,
+		AllowLookup: true,
+	})
+	doc.setLintResults(navigationSpec, result.Results, result.Index)
+	return newWorkspace(store), doc, dir
+}
+
+func position(line, character int) protocol.Position {
+	return protocol.Position{Line: protocol.UInteger(line), Character: protocol.UInteger(character)}
+}
+
+func TestBuildDefinition(t *testing.T) {
+	w, doc, dir := navigationWorkspace(t)
+
+	locations := buildDefinition(w, doc, position(14, 25))
+	assert.Equal(t, []protocol.Location{{URI: doc.URI, Range: protocol.Range{Start: position(23, 4), End: position(23, 7)}}},
+		locations)
+
+	locations = buildDefinition(w, doc, position(20, 25))
+	assert.Equal(t, []protocol.Location{{URI: fileURI(filepath.Join(dir, "shared.yaml")),
+		Range: protocol.Range{Start: position(3, 4), End: position(3, 9)}}}, locations)
+
+	assert.Nil(t, buildDefinition(w, doc, position(7, 20)))
+}
+
+func TestBuildReferences(t *testing.T) {
+	w, doc, _ := navigationWorkspace(t)
+
+	// from the component, refs into the component count as well.
+	locations := buildReferences(w, doc, &protocol.ReferenceParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{Position: position(23, 5)},
+		Context:                    protocol.ReferenceContext{IncludeDeclaration: true},
+	})
+	var lines []protocol.UInteger
+	for _, l := range locations {
+		assert.Equal(t, doc.URI, l.URI)
+		lines = append(lines, l.Range.Start.Line)
+	}
+	assert.Equal(t, []protocol.UInteger{23, 14, 27}, lines)
+
+	// from a ref to a component in another file.
+	locations = buildReferences(w, doc, &protocol.ReferenceParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{Position: position(20, 25)},
+	})
+	assert.Len(t, locations, 2)
+}
+
+func TestBuildRename(t *testing.T) {
+	w, doc, dir := navigationWorkspace(t)
+	shared := fileURI(filepath.Join(dir, "shared.yaml"))
+
+	edit, err := buildRename(w, doc, &protocol.RenameParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{Position: position(20, 25)},
+		NewName:                    "Problem",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []protocol.TextEdit{
+		{Range: protocol.Range{Start: position(3, 4), End: position(3, 9)}, NewText: "Problem"},
+		{Range: protocol.Range{Start: position(7, 38), End: position(7, 43)}, NewText: "Problem"},
+	}, edit.Changes[shared])
+	assert.Equal(t, []protocol.TextEdit{
+		{Range: protocol.Range{Start: position(20, 55), End: position(20, 60)}, NewText: "Problem"},
+	}, edit.Changes[doc.URI])
+
+	edit, err = buildRename(w, doc, &protocol.RenameParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{Position: position(23, 5)},
+		NewName:                    "Animal",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, edit.Changes[doc.URI], 3)
+	assert.Equal(t, protocol.Range{Start: position(27, 38), End: position(27, 41)}, edit.Changes[doc.URI][2].Range)
+
+	_, err = buildRename(w, doc, &protocol.RenameParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{Position: position(23, 5)},
+		NewName:                    "not valid",
+	})
+	assert.ErrorContains(t, err, "is not a valid component name")
+
+	_, err = buildRename(w, doc, &protocol.RenameParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{Position: position(27, 30)},
+		NewName:                    "title",
+	})
+	assert.ErrorContains(t, err, "only components can be renamed")
+}
+
+func TestNodeRange_UTF16(t *testing.T) {
+	content := `pet: {emoji: "🍕", $ref: 'pets.yaml#/Pet'}`
+	var root yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(content), &root))
+	ref := root.Content[0].Content[1].Content[3]
+	lines := strings.Split(content, "\n")
+
+	// the pizza is one character to YAML, and two to an editor.
+	assert.Equal(t, protocol.Range{
+		Start: protocol.Position{Character: 25},
+		End:   protocol.Position{Character: 41},
+	}, nodeRange(lines, ref))
+	assert.Equal(t, protocol.UInteger(26), valueRange(lines, ref).Start.Character)
+}
+
+func TestURILocation(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "my api", "100% spec.yaml")
+	uri := fileURI(location)
+	assert.Contains(t, uri, "/my%20api/100%25%20spec.yaml")
+	assert.Equal(t, location, uriLocation(uri))
+
+	assert.Equal(t, filepath.FromSlash("/my api/spec.yaml"), uriLocation("file:///my%20api/spec.yaml"))
+	assert.Equal(t, filepath.FromSlash("C:/api/spec.yaml"), uriLocation("file:///C:/api/spec.yaml"))
+	assert.Equal(t, filepath.FromSlash("c:/api/spec.yaml"), uriLocation("file:///c%3A/api/spec.yaml"))
+	assert.Equal(t, filepath.Clean(filepath.FromSlash("//server/share/spec.yaml")), uriLocation("file://server/share/spec.yaml"))
+}
+
+func TestWorkspaceFile_EditorURI(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "my api", "spec.yaml")
+	store := newDocumentStore()
+	// the editor encodes more than vacuum does, the document is still found.
+	uri := strings.Replace(fileURI(location), "/my%20api/", "/my%20%61pi/", 1)
+	store.Add(uri, navigationSpec)
+
+	f := newWorkspace(store).file(location)
+	assert.Equal(t, uri, f.uri)
+	assert.Equal(t, navigationSpec, f.content)
+}
+
+func TestBuildDocumentSymbols(t *testing.T) {
+	w, doc, _ := navigationWorkspace(t)
+
+	symbols := buildDocumentSymbols(w.file(uriLocation(doc.URI)))
+	assert.Len(t, symbols, 2)
+	assert.Equal(t, "paths", symbols[0].Name)
+	assert.Equal(t, "/pets", symbols[0].Children[0].Name)
+	operation := symbols[0].Children[0].Children[0]
+	assert.Equal(t, "get", operation.Name)
+	assert.Equal(t, "listPets", *operation.Detail)
+	assert.Equal(t, protocol.SymbolKindMethod, operation.Kind)
+	assert.Equal(t, protocol.Range{Start: position(6, 4), End: position(20, 61)}, operation.Range)
+
+	assert.Equal(t, "components", symbols[1].Name)
+	assert.Equal(t, "schemas", symbols[1].Children[0].Name)
+	assert.Equal(t, "Pet", symbols[1].Children[0].Children[0].Name)
+	assert.Equal(t, protocol.SymbolKindStruct, symbols[1].Children[0].Children[0].Kind)
+}
+
+func TestBuildRename_WorkspaceFolders(t *testing.T) {
+	w, doc, dir := navigationWorkspace(t)
+
+	// a specification that isn't open, referencing the shared file.
+	closed := filepath.Join(dir, "apis", "closed.yaml")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(closed), 0755))
+	assert.NoError(t, os.WriteFile(closed, []byte(`openapi: 3.1.0
+components:
+  schemas:
+    Failure:
+      $ref: '../shared.yaml#/components/schemas/Error'
+`), 0644))
+	hidden := filepath.Join(dir, ".git", "hidden.yaml")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(hidden), 0755))
+	assert.NoError(t, os.WriteFile(hidden, []byte(`$ref: '../shared.yaml#/components/schemas/Error'`), 0644))
+
+	params := &protocol.RenameParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{Position: position(20, 25)},
+		NewName:                    "Problem",
+	}
+	edit, err := buildRename(w, doc, params)
+	assert.NoError(t, err)
+	assert.NotContains(t, edit.Changes, fileURI(closed))
+
+	w.folders = []string{dir}
+	edit, err = buildRename(w, doc, params)
+	assert.NoError(t, err)
+	assert.Equal(t, []protocol.TextEdit{
+		{Range: protocol.Range{Start: position(4, 48), End: position(4, 53)}, NewText: "Problem"},
+	}, edit.Changes[fileURI(closed)])
+	assert.NotContains(t, edit.Changes, fileURI(hidden))
+	assert.Len(t, edit.Changes[doc.URI], 1)
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package languageserver
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pb33f/libopenapi/datamodel"
+)
+
+// the maximum number of references and compositions followed when expanding a schema, the OpenAPI schemas are
+// recursive.
+const maxSchemaDepth = 16
+
+var specVersion = regexp.MustCompile(`["']?(openapi|swagger)["']?\s*:\s*["']?(\d+\.\d+)`)
+
+var documentSchemas sync.Map
+
+// documentSchema returns the parsed JSON schema for the OpenAPI version of a document, the same schemas the
+// oasDocumentSchema function validates documents against. Nil is returned if the version can't be worked out.
+func documentSchema(content string) map[string]any {
+	m := specVersion.FindStringSubmatch(content)
+	if m == nil {
+		return nil
+	}
+	var data string
+	switch {
+	case m[1] == "swagger":
+		data = datamodel.OpenAPI2SchemaData
+	case strings.HasPrefix(m[2], "3.0"):
+		data = datamodel.OpenAPI3SchemaData
+	case strings.HasPrefix(m[2], "3."):
+		data = datamodel.OpenAPI31SchemaData
+	default:
+		return nil
+	}
+	if s, ok := documentSchemas.Load(data); ok {
+		return s.(map[string]any)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(data), &schema); err != nil {
+		return nil
+	}
+	documentSchemas.Store(data, schema)
+	return schema
+}
+
+// schemaWalker finds the schemas that apply at a path of a document.
+type schemaWalker struct {
+	root map[string]any
+}
+
+// at returns every schema that applies at a path, with references and compositions expanded.
+func (w *schemaWalker) at(path []string) []map[string]any {
+	schemas := w.expand(w.root)
+	for _, segment := range path {
+		var next []map[string]any
+		for _, s := range schemas {
+			next = append(next, w.step(s, segment)...)
+		}
+		schemas = w.expandAll(next)
+		if len(schemas) == 0 {
+			return nil
+		}
+	}
+	return schemas
+}
+
+// step returns the schemas of a property, or of the items of an array when the segment is a sequence item.
+func (w *schemaWalker) step(schema map[string]any, segment string) []map[string]any {
+	if segment == sequenceItem {
+		switch items := schema["items"].(type) {
+		case map[string]any:
+			return []map[string]any{items}
+		case []any:
+			return asSchemas(items)
+		}
+		return nil
+	}
+	if props, ok := schema["properties"].(map[string]any); ok {
+		if p, ok := props[segment].(map[string]any); ok {
+			return []map[string]any{p}
+		}
+	}
+	var matched []map[string]any
+	if patterns, ok := schema["patternProperties"].(map[string]any); ok {
+		for pattern, p := range patterns {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(segment) {
+				if p, ok := p.(map[string]any); ok {
+					matched = append(matched, p)
+				}
+			}
+		}
+	}
+	if len(matched) > 0 {
+		return matched
+	}
+	if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+		return []map[string]any{additional}
+	}
+	return nil
+}
+
+func (w *schemaWalker) expandAll(schemas []map[string]any) []map[string]any {
+	var expanded []map[string]any
+	for _, s := range schemas {
+		expanded = append(expanded, w.expand(s)...)
+	}
+	return expanded
+}
+
+// expand returns a schema, and every schema it pulls in with a local reference or a composition.
+func (w *schemaWalker) expand(schema map[string]any) []map[string]any {
+	var expanded []map[string]any
+	seen := make(map[string]bool)
+	var walk func(s map[string]any, depth int)
+	walk = func(s map[string]any, depth int) {
+		if s == nil || depth > maxSchemaDepth {
+			return
+		}
+		expanded = append(expanded, s)
+		if ref, ok := s["$ref"].(string); ok && !seen[ref] {
+			seen[ref] = true
+			walk(w.resolve(ref), depth+1)
+		}
+		for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+			if list, ok := s[keyword].([]any); ok {
+				for _, sub := range asSchemas(list) {
+					walk(sub, depth+1)
+				}
+			}
+		}
+		for _, keyword := range []string{"then", "else"} {
+			if sub, ok := s[keyword].(map[string]any); ok {
+				walk(sub, depth+1)
+			}
+		}
+	}
+	walk(schema, 0)
+	return expanded
+}
+
+// resolve looks up a local reference (a JSON pointer) in the root schema.
+func (w *schemaWalker) resolve(ref string) map[string]any {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	var node any = w.root
+	for _, segment := range strings.Split(ref[2:], "/") {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		node = m[segment]
+	}
+	s, _ := node.(map[string]any)
+	return s
+}
+
+func asSchemas(list []any) []map[string]any {
+	var schemas []map[string]any
+	for _, item := range list {
+		if s, ok := item.(map[string]any); ok {
+			schemas = append(schemas, s)
+		}
+	}
+	return schemas
+}
+
+// literalPattern returns the key a pattern matches, if it only matches one (like `^\$ref$`).
+func literalPattern(pattern string) (string, bool) {
+	if !strings.HasPrefix(pattern, "^") || !strings.HasSuffix(pattern, "$") {
+		return "", false
+	}
+	re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+	if err != nil {
+		return "", false
+	}
+	literal, complete := re.LiteralPrefix()
+	return literal, complete && literal != ""
+}
@@ -16,24 +16,51 @@
 package languageserver
 
 import (
+	"context"
 	"fmt"
 	"github.com/daveshanley/vacuum/model"
 	"github.com/daveshanley/vacuum/motor"
+	"github.com/daveshanley/vacuum/plugin"
 	"github.com/daveshanley/vacuum/utils"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 	glspserv "github.com/tliron/glsp/server"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
 var serverName = "vacuum"
 
+// commandLintAll lints every open document again, for example after the ruleset has changed.
+const commandLintAll = "vacuum.lintAll"
+
+// lintDelay is how long a document has to stop changing before it's linted again.
+const lintDelay = 300 * time.Millisecond
+
+// watchRegistration is the id of the file watchers registered with the editor.
+const watchRegistration = "vacuum-config-files"
+
 type ServerState struct {
 	server        *glspserv.Server
 	documentStore *DocumentStore
 	lintRequest   *utils.LintFileRequest
+	lintDelay     time.Duration
+
+	// settings from the command line and the editor, the workspace folders, and the lint configuration of each.
+	flags          Settings
+	editorSettings Settings
+	folders        []string
+	configs        map[string]*folderConfig
+	functions      map[*plugin.Manager]bool // the custom functions used by the configurations.
+	configLock     sync.RWMutex
+	reloadLock     sync.Mutex
+
+	// file watchers can be registered with the editor.
+	canWatch  bool
+	watching  bool
+	watchLock sync.Mutex
 }
 
 func NewServer(version string, lintRequest *utils.LintFileRequest) *ServerState {
@@ -44,15 +71,46 @@ func NewServer(version string, lintRequest *utils.LintFileRequest) *ServerState
 		server:        server,
 		lintRequest:   lintRequest,
 		documentStore: newDocumentStore(),
+		lintDelay:     lintDelay,
 	}
 	handler.Initialize = func(context *glsp.Context, params *protocol.InitializeParams) (interface{}, error) {
 		if params.Trace != nil {
 			protocol.SetTraceValue(*params.Trace)
 		}
 
+		for _, folder := range params.WorkspaceFolders {
+			state.folders = append(state.folders, uriLocation(folder.URI))
+		}
+		if len(state.folders) == 0 && params.RootURI != nil {
+			state.folders = append(state.folders, uriLocation(*params.RootURI))
+		}
+		if settings, err := parseSettings(params.InitializationOptions); err == nil {
+			state.editorSettings = settings
+		}
+		if w := params.Capabilities.Workspace; w != nil && w.DidChangeWatchedFiles != nil &&
+			w.DidChangeWatchedFiles.DynamicRegistration != nil {
+			state.canWatch = *w.DidChangeWatchedFiles.DynamicRegistration
+		}
+
 		serverCapabilities := handler.CreateServerCapabilities()
 		serverCapabilities.TextDocumentSync = protocol.TextDocumentSyncKindIncremental
-		serverCapabilities.CompletionProvider = &protocol.CompletionOptions{}
+		serverCapabilities.CompletionProvider = &protocol.CompletionOptions{
+			TriggerCharacters: []string{"\"", ":", "/"},
+		}
+		serverCapabilities.CodeActionProvider = protocol.CodeActionOptions{
+			CodeActionKinds: []protocol.CodeActionKind{protocol.CodeActionKindQuickFix, codeActionKindSourceFixAll},
+		}
+		serverCapabilities.HoverProvider = true
+		supported := true
+		serverCapabilities.Workspace = &protocol.ServerCapabilitiesWorkspace{
+			WorkspaceFolders: &protocol.WorkspaceFoldersServerCapabilities{
+				Supported:           &supported,
+				ChangeNotifications: &protocol.BoolOrString{Value: true},
+			},
+		}
+		serverCapabilities.ExecuteCommandProvider = &protocol.ExecuteCommandOptions{
+			Commands: []string{commandLintAll},
+		}
 
 		return protocol.InitializeResult{
 			Capabilities: serverCapabilities,
@@ -62,12 +120,62 @@ func NewServer(version string, lintRequest *utils.LintFileRequest) *ServerState
 			},
 		}, nil
 	}
+	handler.Initialized = func(context *glsp.Context, params *protocol.InitializedParams) error {
+		state.reload(context.Notify)
+		go state.watchConfigFiles(context.Call)
+		return nil
+	}
+	handler.WorkspaceDidChangeConfiguration = func(context *glsp.Context, params *protocol.DidChangeConfigurationParams) error {
+		settings, err := parseSettings(params.Settings)
+		if err != nil {
+			return err
+		}
+		state.configLock.Lock()
+		state.editorSettings = settings
+		state.configLock.Unlock()
+		state.reload(context.Notify)
+		go state.watchConfigFiles(context.Call)
+		return nil
+	}
+	handler.WorkspaceDidChangeWorkspaceFolders = func(context *glsp.Context, params *protocol.DidChangeWorkspaceFoldersParams) error {
+		state.configLock.Lock()
+		removed := make(map[string]bool)
+		for _, folder := range params.Event.Removed {
+			removed[uriLocation(folder.URI)] = true
+		}
+		var folders []string
+		for _, folder := range state.folders {
+			if !removed[folder] {
+				folders = append(folders, folder)
+			}
+		}
+		for _, folder := range params.Event.Added {
+			folders = append(folders, uriLocation(folder.URI))
+		}
+		state.folders = folders
+		state.configLock.Unlock()
+		state.reload(context.Notify)
+		go state.watchConfigFiles(context.Call)
+		return nil
+	}
+	handler.WorkspaceDidChangeWatchedFiles = func(context *glsp.Context, params *protocol.DidChangeWatchedFilesParams) error {
+		if state.filesChanged(params.Changes, context.Notify) {
+			go state.watchConfigFiles(context.Call)
+		}
+		return nil
+	}
 	handler.SetTrace = func(context *glsp.Context, params *protocol.SetTraceParams) error {
 		protocol.SetTraceValue(params.Value)
 		return nil
 	}
+	handler.Shutdown = func(context *glsp.Context) error {
+		// plugins started for the workspace are stopped, the editor is about to exit the server.
+		state.closeConfigs()
+		return nil
+	}
 	handler.TextDocumentDidOpen = func(context *glsp.Context, params *protocol.DidOpenTextDocumentParams) error {
 		doc := state.documentStore.Add(params.TextDocument.URI, params.TextDocument.Text)
+		doc.Version = params.TextDocument.Version
 		state.runDiagnostic(doc, context.Notify, false)
 		return nil
 	}
@@ -76,15 +184,7 @@ func NewServer(version string, lintRequest *utils.LintFileRequest) *ServerState
 		if !ok {
 			return nil
 		}
-		for _, change := range params.ContentChanges {
-			switch c := change.(type) {
-			case protocol.TextDocumentContentChangeEvent:
-				startIndex, endIndex := c.Range.IndexesIn(doc.Content)
-				doc.Content = doc.Content[:startIndex] + c.Text + doc.Content[endIndex:]
-			case protocol.TextDocumentContentChangeEventWhole:
-				doc.Content = c.Text
-			}
-		}
+		doc.applyChanges(params.TextDocument.Version, params.ContentChanges)
 		state.runDiagnostic(doc, context.Notify, true)
 		return nil
 	}
@@ -92,67 +192,228 @@ func NewServer(version string, lintRequest *utils.LintFileRequest) *ServerState
 	handler.TextDocumentDidClose = func(context *glsp.Context, params *protocol.DidCloseTextDocumentParams) error {
 		state.documentStore.Remove(params.TextDocument.URI)
 
+		// the diagnostics of a closed document are cleared.
+		go context.Notify(protocol.ServerTextDocumentPublishDiagnostics, protocol.PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []protocol.Diagnostic{},
+		})
 		return nil
 	}
 
 	handler.TextDocumentCompletion = func(context *glsp.Context, params *protocol.CompletionParams) (any, error) {
-		return nil, nil
+		doc, ok := state.documentStore.Get(params.TextDocument.URI)
+		if !ok {
+			return nil, nil
+		}
+		return buildCompletion(doc, params), nil
+	}
+
+	handler.TextDocumentCodeAction = func(context *glsp.Context, params *protocol.CodeActionParams) (any, error) {
+		doc, ok := state.documentStore.Get(params.TextDocument.URI)
+		if !ok {
+			return nil, nil
+		}
+		return buildCodeActions(doc, params, state.rulesetLocation(doc.URI)), nil
+	}
+
+	handler.TextDocumentHover = func(context *glsp.Context, params *protocol.HoverParams) (*protocol.Hover, error) {
+		doc, ok := state.documentStore.Get(params.TextDocument.URI)
+		if !ok {
+			return nil, nil
+		}
+		return buildHover(doc, params), nil
+	}
+
+	handler.TextDocumentDefinition = func(context *glsp.Context, params *protocol.DefinitionParams) (any, error) {
+		doc, ok := state.documentStore.Get(params.TextDocument.URI)
+		if !ok {
+			return nil, nil
+		}
+		return buildDefinition(state.workspace(), doc, params.Position), nil
+	}
+
+	handler.TextDocumentReferences = func(context *glsp.Context, params *protocol.ReferenceParams) ([]protocol.Location, error) {
+		doc, ok := state.documentStore.Get(params.TextDocument.URI)
+		if !ok {
+			return nil, nil
+		}
+		return buildReferences(state.workspace(), doc, params), nil
+	}
+
+	handler.TextDocumentDocumentSymbol = func(context *glsp.Context, params *protocol.DocumentSymbolParams) (any, error) {
+		doc, ok := state.documentStore.Get(params.TextDocument.URI)
+		if !ok {
+			return nil, nil
+		}
+		return buildDocumentSymbols(state.workspace().file(uriLocation(doc.URI))), nil
+	}
+
+	handler.TextDocumentRename = func(context *glsp.Context, params *protocol.RenameParams) (*protocol.WorkspaceEdit, error) {
+		doc, ok := state.documentStore.Get(params.TextDocument.URI)
+		if !ok {
+			return nil, nil
+		}
+		return buildRename(state.workspace(), doc, params)
+	}
+
+	handler.WorkspaceExecuteCommand = func(context *glsp.Context, params *protocol.ExecuteCommandParams) (any, error) {
+		switch params.Command {
+		case commandLintAll:
+			for _, doc := range state.documentStore.All() {
+				state.runDiagnostic(doc, context.Notify, false)
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unknown command '%s'", params.Command)
 	}
 	return state
 }
 
+// WithFlags sets the settings the server was started with, the configuration of workspace folders and the
+// editor is layered on top of them.
+func (s *ServerState) WithFlags(flags Settings) *ServerState {
+	s.flags = flags
+	return s
+}
+
+// rulesetLocation returns the file the ruleset of a document was loaded from, empty if the ruleset is not a file.
+func (s *ServerState) rulesetLocation(uri protocol.DocumentUri) string {
+	rs := s.requestFor(uri).SelectedRS
+	if rs == nil || strings.HasPrefix(rs.Location, "http") {
+		return ""
+	}
+	return rs.Location
+}
+
+// workspace returns the files navigation works across, the open documents and the workspace folders.
+func (s *ServerState) workspace() *workspace {
+	w := newWorkspace(s.documentStore)
+	s.configLock.RLock()
+	w.folders = append(w.folders, s.folders...)
+	s.configLock.RUnlock()
+	return w
+}
+
+// filesChanged builds the configuration again if any of the files it was built from changed, true is returned
+// if it was.
+func (s *ServerState) filesChanged(changes []protocol.FileEvent, notify glsp.NotifyFunc) bool {
+	for _, change := range changes {
+		if s.isConfigFile(uriLocation(change.URI)) {
+			s.reload(notify)
+			return true
+		}
+	}
+	return false
+}
+
+// watchConfigFiles asks the editor to watch the files the configuration is built from, the editor can only be
+// asked if it supports registering watchers.
+func (s *ServerState) watchConfigFiles(call glsp.CallFunc) {
+	if !s.canWatch {
+		return
+	}
+	s.watchLock.Lock()
+	defer s.watchLock.Unlock()
+	if s.watching {
+		call(protocol.ServerClientUnregisterCapability, protocol.UnregistrationParams{
+			Unregisterations: []protocol.Unregistration{{
+				ID: watchRegistration, Method: string(protocol.MethodWorkspaceDidChangeWatchedFiles)}},
+		}, nil)
+	}
+	var watchers []protocol.FileSystemWatcher
+	for _, pattern := range s.watchPatterns() {
+		watchers = append(watchers, protocol.FileSystemWatcher{GlobPattern: pattern})
+	}
+	call(protocol.ServerClientRegisterCapability, protocol.RegistrationParams{
+		Registrations: []protocol.Registration{{
+			ID:              watchRegistration,
+			Method:          string(protocol.MethodWorkspaceDidChangeWatchedFiles),
+			RegisterOptions: protocol.DidChangeWatchedFilesRegistrationOptions{Watchers: watchers},
+		}},
+	}, nil)
+	s.watching = true
+}
+
 func (s *ServerState) Run() error {
+	defer s.closeConfigs()
 	return s.server.RunStdio()
 }
 
+// runDiagnostic lints a document and publishes the diagnostics. Edits are debounced (delay), so a document is
+// linted once it stops changing, and a lint that's still running when the document changes again is cancelled.
+// Diagnostics are always published (even when there are none) so fixed issues are cleared.
 func (s *ServerState) runDiagnostic(doc *Document, notify glsp.NotifyFunc, delay bool) {
+	var wait time.Duration
+	if delay {
+		wait = s.lintDelay
+	}
+	doc.scheduleLint(wait, func(ctx context.Context) {
+		s.lint(ctx, doc, notify)
+	})
+}
 
-	go func() {
-		var diagnostics []protocol.Diagnostic
+func (s *ServerState) lint(ctx context.Context, doc *Document, notify glsp.NotifyFunc) {
+	content, version := doc.text()
 
-		if s.lintRequest.BaseFlag == "" {
-			s.lintRequest.BaseFlag = filepath.Dir(strings.TrimPrefix(doc.URI, "file://"))
-		}
+	request := s.requestFor(doc.URI)
+	base := request.BaseFlag
+	if base == "" {
+		base = filepath.Dir(uriLocation(doc.URI))
+	}
 
-		result := motor.ApplyRulesToRuleSet(&motor.RuleSetExecution{
-			RuleSet:                      s.lintRequest.SelectedRS,
-			Timeout:                      time.Duration(s.lintRequest.TimeoutFlag) * time.Second,
-			CustomFunctions:              s.lintRequest.Functions,
-			IgnoreCircularArrayRef:       s.lintRequest.IgnoreArrayCircleRef,
-			IgnoreCircularPolymorphicRef: s.lintRequest.IgnorePolymorphCircleRef,
-			AllowLookup:                  true,
-			Base:                         s.lintRequest.BaseFlag,
-			Spec:                         []byte(doc.Content),
-			SkipDocumentCheck:            s.lintRequest.SkipCheckFlag,
-			Logger:                       s.lintRequest.Logger,
-		})
+	result := motor.ApplyRulesToRuleSet(&motor.RuleSetExecution{
+		RuleSet:                      request.SelectedRS,
+		Timeout:                      time.Duration(request.TimeoutFlag) * time.Second,
+		CustomFunctions:              request.Functions,
+		FunctionLoader:               plugin.LoadFunctionSource,
+		IgnoreCircularArrayRef:       request.IgnoreArrayCircleRef,
+		IgnoreCircularPolymorphicRef: request.IgnorePolymorphCircleRef,
+		AllowLookup:                  true,
+		Base:                         base,
+		Spec:                         []byte(content),
+		SkipDocumentCheck:            request.SkipCheckFlag,
+		Logger:                       request.Logger,
+		Context:                      ctx,
+	})
+	if ctx.Err() != nil {
+		return
+	}
+	if !doc.setLintResults(content, result.Results, result.Index) {
+		return
+	}
 
-		for _, vacuumResult := range result.Results {
-			severity := getDiagnosticSeverityFromRule(vacuumResult.Rule)
-			diagnosticErrorHref := fmt.Sprintf("%s/rules/%s/%s", model.WebsiteUrl,
-				vacuumResult.Rule.RuleCategory.Id, strings.ReplaceAll(vacuumResult.Rule.Id, "$", ""))
-			diagnostics = append(diagnostics, protocol.Diagnostic{
-				Range: protocol.Range{
-					Start: protocol.Position{Line: protocol.UInteger(vacuumResult.StartNode.Line - 1),
-						Character: protocol.UInteger(vacuumResult.StartNode.Column - 1)},
-					End: protocol.Position{Line: protocol.UInteger(vacuumResult.EndNode.Line - 1),
-						Character: protocol.UInteger(vacuumResult.EndNode.Column + len(vacuumResult.EndNode.Value) - 1)},
-				},
-				Severity:        &severity,
-				Source:          &serverName,
-				Code:            &protocol.IntegerOrString{Value: vacuumResult.Rule.Id},
-				CodeDescription: &protocol.CodeDescription{HRef: diagnosticErrorHref},
-				Message:         vacuumResult.Message,
-			})
-
-		}
-		if len(diagnostics) > 0 {
-			go notify(protocol.ServerTextDocumentPublishDiagnostics, protocol.PublishDiagnosticsParams{
-				URI:         doc.URI,
-				Diagnostics: diagnostics,
-			})
-		}
-	}()
+	diagnostics := []protocol.Diagnostic{}
+	for _, vacuumResult := range result.Results {
+		diagnostics = append(diagnostics, buildDiagnostic(vacuumResult))
+	}
+	params := protocol.PublishDiagnosticsParams{
+		URI:         doc.URI,
+		Diagnostics: diagnostics,
+	}
+	if version >= 0 {
+		v := protocol.UInteger(version)
+		params.Version = &v
+	}
+	notify(protocol.ServerTextDocumentPublishDiagnostics, params)
+}
+
+func buildDiagnostic(vacuumResult model.RuleFunctionResult) protocol.Diagnostic {
+	severity := getDiagnosticSeverityFromRule(vacuumResult.Rule)
+	diagnosticErrorHref := fmt.Sprintf("%s/rules/%s/%s", model.WebsiteUrl,
+		vacuumResult.Rule.RuleCategory.Id, strings.ReplaceAll(vacuumResult.Rule.Id, "$", ""))
+	return protocol.Diagnostic{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: protocol.UInteger(vacuumResult.StartNode.Line - 1),
+				Character: protocol.UInteger(vacuumResult.StartNode.Column - 1)},
+			End: protocol.Position{Line: protocol.UInteger(vacuumResult.EndNode.Line - 1),
+				Character: protocol.UInteger(vacuumResult.EndNode.Column + len(vacuumResult.EndNode.Value) - 1)},
+		},
+		Severity:        &severity,
+		Source:          &serverName,
+		Code:            &protocol.IntegerOrString{Value: vacuumResult.Rule.Id},
+		CodeDescription: &protocol.CodeDescription{HRef: diagnosticErrorHref},
+		Message:         vacuumResult.Message,
+	}
 }
 
 func getDiagnosticSeverityFromRule(rule *model.Rule) protocol.DiagnosticSeverity {
@@ -0,0 +1,104 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package languageserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/daveshanley/vacuum/utils"
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const titleRuleSet = `rules:
+  info-title:
+    description: info must have a title
+    given: $.info
+    severity: error
+    then:
+      field: title
+      function: truthy
+`
+
+// testServer returns a server (that isn't connected to anything) and a channel of everything it publishes.
+func testServer(t *testing.T) (*ServerState, chan protocol.PublishDiagnosticsParams) {
+	rs, err := rulesets.CreateRuleSetFromData([]byte(titleRuleSet))
+	assert.NoError(t, err)
+	state := &ServerState{
+		documentStore: newDocumentStore(),
+		lintRequest: &utils.LintFileRequest{
+			SelectedRS:    rulesets.BuildDefaultRuleSets().GenerateRuleSetFromSuppliedRuleSet(rs),
+			SkipCheckFlag: true,
+		},
+		lintDelay: 50 * time.Millisecond,
+	}
+	published := make(chan protocol.PublishDiagnosticsParams, 10)
+	return state, published
+}
+
+func receive(t *testing.T, published chan protocol.PublishDiagnosticsParams) protocol.PublishDiagnosticsParams {
+	select {
+	case p := <-published:
+		return p
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "nothing was published")
+	}
+	return protocol.PublishDiagnosticsParams{}
+}
+
+func TestRunDiagnostic_Debounced(t *testing.T) {
+	state, published := testServer(t)
+	notify := func(method string, params any) {
+		assert.Equal(t, protocol.ServerTextDocumentPublishDiagnostics, method)
+		published <- params.(protocol.PublishDiagnosticsParams)
+	}
+
+	doc := state.documentStore.Add("file:///tmp/spec.yaml", "openapi: 3.1.0\ninfo:\n  version: 1.0.0\n")
+	doc.Version = 1
+	state.runDiagnostic(doc, notify, false)
+	p := receive(t, published)
+	assert.Equal(t, protocol.UInteger(1), *p.Version)
+	assert.Len(t, p.Diagnostics, 1)
+
+	// a burst of edits is linted once, for the latest version.
+	for v := 2; v <= 5; v++ {
+		doc.applyChanges(protocol.Integer(v), []any{protocol.TextDocumentContentChangeEventWhole{
+			Text: "openapi: 3.1.0\ninfo:\n  version: 1.0.0\n  title: pets\n",
+		}})
+		state.runDiagnostic(doc, notify, true)
+	}
+	p = receive(t, published)
+	assert.Equal(t, protocol.UInteger(5), *p.Version)
+
+	// fixed issues are cleared with an empty publish.
+	assert.NotNil(t, p.Diagnostics)
+	assert.Empty(t, p.Diagnostics)
+
+	select {
+	case p = <-published:
+		assert.Fail(t, "stale diagnostics were published", "version %d", *p.Version)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestRunDiagnostic_Closed(t *testing.T) {
+	state, published := testServer(t)
+	notify := func(method string, params any) {
+		published <- params.(protocol.PublishDiagnosticsParams)
+	}
+
+	doc := state.documentStore.Add("file:///tmp/spec.yaml", "openapi: 3.1.0\ninfo:\n  version: 1.0.0\n")
+	state.runDiagnostic(doc, notify, true)
+	state.documentStore.Remove(doc.URI)
+
+	select {
+	case <-published:
+		assert.Fail(t, "a closed document was linted")
+	case <-time.After(300 * time.Millisecond):
+	}
+	_, ok := state.documentStore.Get(doc.URI)
+	assert.False(t, ok)
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package languageserver
+
+import (
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+	"gopkg.in/yaml.v3"
+)
+
+var operationMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true, "options": true, "head": true, "patch": true, "trace": true,
+}
+
+// buildDocumentSymbols returns the outline of a document: paths (and webhooks) with their operations, and
+// components by kind. OpenAPI 2 definitions, parameters and responses live at the root, and are outlined the
+// same way.
+func buildDocumentSymbols(f *specFile) []protocol.DocumentSymbol {
+	if f.root == nil || f.root.Kind != yaml.MappingNode {
+		return nil
+	}
+	lines := f.lines
+	var symbols []protocol.DocumentSymbol
+	for i := 0; i+1 < len(f.root.Content); i += 2 {
+		key, value := f.root.Content[i], f.root.Content[i+1]
+		switch key.Value {
+		case "paths", "webhooks":
+			s := newSymbol(lines, key, value, protocol.SymbolKindNamespace, "")
+			for _, path := range pairs(value) {
+				ps := newSymbol(lines, path[0], path[1], protocol.SymbolKindClass, "")
+				for _, op := range pairs(path[1]) {
+					if !operationMethods[op[0].Value] {
+						continue
+					}
+					ps.Children = append(ps.Children,
+						newSymbol(lines, op[0], op[1], protocol.SymbolKindMethod, scalarValue(op[1], "operationId")))
+				}
+				s.Children = append(s.Children, ps)
+			}
+			symbols = append(symbols, s)
+		case "components":
+			s := newSymbol(lines, key, value, protocol.SymbolKindNamespace, "")
+			for _, kind := range pairs(value) {
+				s.Children = append(s.Children, componentSymbols(lines, kind[0], kind[1]))
+			}
+			symbols = append(symbols, s)
+		case "definitions", "parameters", "responses", "securityDefinitions":
+			symbols = append(symbols, componentSymbols(lines, key, value))
+		}
+	}
+	return symbols
+}
+
+func componentSymbols(lines []string, key, value *yaml.Node) protocol.DocumentSymbol {
+	kind := protocol.SymbolKindObject
+	if key.Value == "schemas" || key.Value == "definitions" {
+		kind = protocol.SymbolKindStruct
+	}
+	s := newSymbol(lines, key, value, protocol.SymbolKindPackage, "")
+	for _, c := range pairs(value) {
+		s.Children = append(s.Children, newSymbol(lines, c[0], c[1], kind, ""))
+	}
+	return s
+}
+
+func newSymbol(lines []string, key, value *yaml.Node, kind protocol.SymbolKind, detail string) protocol.DocumentSymbol {
+	selection := nodeRange(lines, key)
+	end := lastLine(value)
+	r := protocol.Range{Start: selection.Start, End: selection.End}
+	if end > int(r.End.Line) && end < len(lines) {
+		r.End = protocol.Position{Line: protocol.UInteger(end),
+			Character: protocol.UInteger(utf16Length(strings.TrimRight(lines[end], "\r")))}
+	}
+	s := protocol.DocumentSymbol{Name: key.Value, Kind: kind, Range: r, SelectionRange: selection}
+	if s.Name == "" {
+		s.Name = "\"\""
+	}
+	if detail != "" {
+		s.Detail = &detail
+	}
+	return s
+}
+
+// lastLine returns the (zero based) last line a node is on.
+func lastLine(node *yaml.Node) int {
+	last := node.Line - 1
+	for _, c := range node.Content {
+		if l := lastLine(c); l > last {
+			last = l
+		}
+	}
+	return last
+}
+
+func pairs(node *yaml.Node) [][2]*yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	var p [][2]*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		p = append(p, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+	return p
+}
+
+func scalarValue(node *yaml.Node, key string) string {
+	for _, p := range pairs(node) {
+		if p[0].Value == key && p[1].Kind == yaml.ScalarNode {
+			return p[1].Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,336 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+// https://pb33f.io
+
+package lintserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/model/reports"
+	"github.com/daveshanley/vacuum/motor"
+	"github.com/daveshanley/vacuum/plugin"
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/daveshanley/vacuum/statistics"
+	vacuum_report "github.com/daveshanley/vacuum/vacuum-report"
+)
+
+// formats a lint can be returned in.
+const (
+	FormatSpectral = "spectral"
+	FormatVacuum   = "vacuum"
+	FormatSarif    = "sarif"
+)
+
+// LintRequest is the JSON envelope accepted by the /lint endpoint, when a ruleset or options need to be sent
+// along with the specification. A specification can also be posted on its own, as the raw request body.
+type LintRequest struct {
+	Spec     string `json:"spec"`               // The specification to lint (YAML or JSON).
+	RuleSet  string `json:"ruleset,omitempty"`  // A ruleset (YAML or JSON) to use instead of the server ruleset.
+	Format   string `json:"format,omitempty"`   // spectral (default), vacuum or sarif.
+	FileName string `json:"fileName,omitempty"` // The name used to label results.
+	Timeout  int    `json:"timeout,omitempty"`  // Rule timeout in seconds, cannot exceed the server timeout.
+
+	// Suppressed asks for suppressed results along with a spectral report, which is then returned in a
+	// SpectralResponse, rather than on its own.
+	Suppressed bool `json:"suppressed,omitempty"`
+}
+
+// SpectralResponse is returned by the /lint endpoint for the spectral format, when suppressed results are asked
+// for. Results is the spectral report, suppressed results are kept out of it.
+type SpectralResponse struct {
+	Results    []reports.SpectralReport  `json:"results"`
+	Suppressed *reports.SuppressedReport `json:"suppressed"`
+}
+
+// RuleList is returned by the /rules endpoint.
+type RuleList struct {
+	DocumentationURI string        `json:"documentationUrl,omitempty"`
+	Description      string        `json:"description,omitempty"`
+	Rules            []*model.Rule `json:"rules"`
+}
+
+// FunctionDescription is a function rules can use, returned by the /functions endpoint.
+type FunctionDescription struct {
+	Name   string                   `json:"name"`
+	Custom bool                     `json:"custom,omitempty"`
+	Schema model.RuleFunctionSchema `json:"schema"`
+}
+
+// Health is returned by the /health endpoint.
+type Health struct {
+	Status    string `json:"status"`
+	Version   string `json:"version"`
+	Uptime    string `json:"uptime"`
+	Rules     int    `json:"rules"`
+	Functions int    `json:"functions"`
+	InFlight  int64  `json:"inFlight"`
+	Capacity  int    `json:"capacity"`
+	Linted    int64  `json:"linted"`
+}
+
+type errorResponse struct {
+	Error  string   `json:"error"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+func (s *Server) handleLint(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	req, err := readLintRequest(w, r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Spec == "" {
+		writeError(w, http.StatusBadRequest, errors.New("no specification was supplied"))
+		return
+	}
+
+	switch req.Format {
+	case "":
+		req.Format = FormatSpectral
+	case FormatSpectral, FormatVacuum, FormatSarif:
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format '%s', use '%s', '%s' or '%s'",
+			req.Format, FormatSpectral, FormatVacuum, FormatSarif))
+		return
+	}
+
+	timeout := s.lintTimeout()
+	if req.Timeout > 0 && time.Duration(req.Timeout)*time.Second < timeout {
+		timeout = time.Duration(req.Timeout) * time.Second
+	}
+
+	selectedRS := s.lintRequest.SelectedRS
+	if req.RuleSet != "" {
+		if selectedRS, err = s.compileRuleSet([]byte(req.RuleSet)); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("unable to parse ruleset: %w", err))
+			return
+		}
+	}
+
+	if !s.acquire(r.Context()) {
+		writeError(w, http.StatusServiceUnavailable, errors.New("request abandoned while waiting to be linted"))
+		return
+	}
+	result := motor.ApplyRulesToRuleSet(&motor.RuleSetExecution{
+		RuleSet:                      selectedRS,
+		Spec:                         []byte(req.Spec),
+		SpecFileName:                 req.FileName,
+		CustomFunctions:              s.lintRequest.Functions,
+		FunctionLoader:               plugin.LoadFunctionSource,
+		Base:                         s.lintRequest.BaseFlag,
+		AllowLookup:                  s.lintRequest.Remote,
+		SkipDocumentCheck:            s.lintRequest.SkipCheckFlag,
+		SilenceLogs:                  true,
+		Logger:                       s.lintRequest.Logger,
+		Timeout:                      timeout,
+		IgnoreCircularArrayRef:       s.lintRequest.IgnoreArrayCircleRef,
+		IgnoreCircularPolymorphicRef: s.lintRequest.IgnorePolymorphCircleRef,
+		Context:                      r.Context(), // rules stop when the client goes away.
+	})
+	s.release()
+	if r.Context().Err() != nil {
+		return // nobody is listening.
+	}
+	s.linted.Add(1)
+
+	if len(result.Errors) > 0 {
+		resp := errorResponse{Error: fmt.Sprintf("linting failed due to %d issues", len(result.Errors))}
+		for _, e := range result.Errors {
+			resp.Errors = append(resp.Errors, e.Error())
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	resultSet := model.NewRuleResultSet(result.Results)
+	resultSet.SortResultsByLineNumber()
+
+	var suppressedSet *model.RuleResultSet
+	if len(result.Suppressed) > 0 {
+		suppressedSet = model.NewRuleResultSet(result.Suppressed)
+		suppressedSet.SortResultsByLineNumber()
+	}
+
+	switch req.Format {
+	case FormatSarif:
+		var suppressed []*model.RuleFunctionResult
+		if suppressedSet != nil {
+			suppressed = suppressedSet.Results
+		}
+		sarif := vacuum_report.BuildSarifReport(resultSet, suppressed, selectedRS.Rules, req.FileName, s.version)
+		w.Header().Set("Content-Type", "application/sarif+json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(sarif)
+
+	case FormatVacuum:
+		resultSet.PrepareForSerialization(result.SpecInfo)
+		stats := statistics.CreateReportStatistics(result.Index, result.SpecInfo, resultSet)
+		if suppressedSet != nil {
+			suppressedSet.PrepareForSerialization(result.SpecInfo)
+			stats.TotalSuppressed = len(suppressedSet.Results)
+		}
+		writeJSON(w, http.StatusOK, vacuum_report.VacuumReport{
+			Generated:  time.Now(),
+			SpecInfo:   result.SpecInfo,
+			ResultSet:  resultSet,
+			Statistics: stats,
+			Suppressed: suppressedSet,
+		})
+
+	default:
+		// suppressed results are left out, spectral consumers would count them as issues.
+		report := resultSet.GenerateSpectralReport(req.FileName)
+		if report == nil {
+			report = []reports.SpectralReport{}
+		}
+		if !req.Suppressed {
+			writeJSON(w, http.StatusOK, report)
+			return
+		}
+		suppressed := &reports.SuppressedReport{Results: []reports.SuppressedResultItem{}}
+		if suppressedSet != nil {
+			suppressed = suppressedSet.GenerateSuppressedReport(req.FileName)
+		}
+		writeJSON(w, http.StatusOK, SpectralResponse{Results: report, Suppressed: suppressed})
+	}
+}
+
+func (s *Server) handleRules(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	rs := s.lintRequest.SelectedRS
+	writeJSON(w, http.StatusOK, RuleList{
+		DocumentationURI: rs.DocumentationURI,
+		Description:      rs.Description,
+		Rules:            sortedRules(rs),
+	})
+}
+
+func (s *Server) handleFunctions(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.describeFunctions())
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, http.StatusOK, Health{
+		Status:    "ok",
+		Version:   s.version,
+		Uptime:    time.Since(s.started).Round(time.Second).String(),
+		Rules:     len(s.lintRequest.SelectedRS.Rules),
+		Functions: len(s.describeFunctions()),
+		InFlight:  s.inFlight.Load(),
+		Capacity:  cap(s.slots),
+		Linted:    s.linted.Load(),
+	})
+}
+
+// describeFunctions lists the built-in and custom functions, custom functions replace built-in functions
+// with the same name, the same way they do when rules are run.
+func (s *Server) describeFunctions() []FunctionDescription {
+	described := make(map[string]FunctionDescription)
+	for name, fn := range s.builtin.GetAllFunctions() {
+		described[name] = FunctionDescription{Name: name, Schema: fn.GetSchema()}
+	}
+	for name, fn := range s.lintRequest.Functions {
+		described[name] = FunctionDescription{Name: name, Custom: true, Schema: fn.GetSchema()}
+	}
+	list := make([]FunctionDescription, 0, len(described))
+	for _, d := range described {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// readLintRequest reads a lint request from the body. A JSON body with a 'spec' property is a LintRequest
+// envelope, anything else is the specification itself. Query parameters fill in options the body does not set.
+func readLintRequest(w http.ResponseWriter, r *http.Request) (*LintRequest, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSpecSize))
+	if err != nil {
+		return nil, err
+	}
+
+	req := &LintRequest{}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var envelope LintRequest
+		if json.Unmarshal(body, &envelope) == nil && envelope.Spec != "" {
+			req = &envelope
+		}
+	}
+	if req.Spec == "" {
+		req.Spec = string(body)
+	}
+
+	query := r.URL.Query()
+	if req.Format == "" {
+		req.Format = query.Get("format")
+	}
+	if req.FileName == "" {
+		req.FileName = query.Get("fileName")
+	}
+	if !req.Suppressed && query.Get("suppressed") != "" {
+		if req.Suppressed, err = strconv.ParseBool(query.Get("suppressed")); err != nil {
+			return nil, fmt.Errorf("suppressed must be true or false, not '%s'", query.Get("suppressed"))
+		}
+	}
+	if req.Timeout == 0 && query.Get("timeout") != "" {
+		if req.Timeout, err = strconv.Atoi(query.Get("timeout")); err != nil {
+			return nil, fmt.Errorf("timeout must be a number of seconds, not '%s'", query.Get("timeout"))
+		}
+	}
+	return req, nil
+}
+
+func sortedRules(rs *rulesets.RuleSet) []*model.Rule {
+	rules := make([]*model.Rule, 0, len(rs.Rules))
+	for _, rule := range rs.Rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Id < rules[j].Id })
+	return rules
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method || (method == http.MethodGet && r.Method == http.MethodHead) {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed, use %s", r.Method, method))
+	return false
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
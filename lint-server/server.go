@@ -0,0 +1,177 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+// https://pb33f.io
+
+package lintserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/daveshanley/vacuum/functions"
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/daveshanley/vacuum/utils"
+)
+
+const (
+	// maxSpecSize is the largest request body the server will read, specifications larger than this are rejected.
+	maxSpecSize = 50 << 20
+
+	// maxCachedRuleSets is the number of compiled inline rulesets kept warm between requests.
+	maxCachedRuleSets = 64
+)
+
+// Server is a long-running HTTP lint server. Everything that is expensive to build (the default rulesets, the
+// selected ruleset, custom functions and compiled inline rulesets) is built once and re-used by every request.
+type Server struct {
+	version     string
+	lintRequest *utils.LintFileRequest
+	builtin     functions.Functions
+	slots       chan struct{}
+	started     time.Time
+	inFlight    atomic.Int64
+	linted      atomic.Int64
+
+	ruleSets     map[string]*rulesets.RuleSet
+	ruleSetsLock sync.Mutex
+}
+
+// NewServer creates a new lint server. The lint request carries the configuration shared by every lint: the
+// default and selected rulesets, custom functions, the base path, remote lookups and the rule timeout.
+// maxConcurrent limits how many specifications are linted at the same time, if it's less than one, the number
+// of CPUs is used.
+func NewServer(version string, lintRequest *utils.LintFileRequest, maxConcurrent int) *Server {
+	if maxConcurrent < 1 {
+		maxConcurrent = runtime.NumCPU()
+	}
+	if lintRequest.DefaultRuleSets == nil {
+		lintRequest.DefaultRuleSets = rulesets.BuildDefaultRuleSetsWithLogger(lintRequest.Logger)
+	}
+	if lintRequest.SelectedRS == nil {
+		lintRequest.SelectedRS = lintRequest.DefaultRuleSets.GenerateOpenAPIRecommendedRuleSet()
+	}
+	return &Server{
+		version:     version,
+		lintRequest: lintRequest,
+		builtin:     functions.MapBuiltinFunctions(),
+		slots:       make(chan struct{}, maxConcurrent),
+		started:     time.Now(),
+		ruleSets:    make(map[string]*rulesets.RuleSet),
+	}
+}
+
+// Handler returns the HTTP handler serving all the endpoints of the server.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lint", s.handleLint)
+	mux.HandleFunc("/rules", s.handleRules)
+	mux.HandleFunc("/functions", s.handleFunctions)
+	mux.HandleFunc("/health", s.handleHealth)
+	return mux
+}
+
+// Run listens on the supplied address and serves requests until the context is cancelled, then waits for
+// in-flight requests to complete before returning.
+func (s *Server) Run(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves requests on the supplied listener until the context is cancelled.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	done := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), s.lintTimeout()+5*time.Second)
+		defer cancel()
+		done <- srv.Shutdown(shutdown)
+	}()
+	if err := srv.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return <-done
+}
+
+// acquire waits for a free lint slot, it returns false if the request was abandoned while waiting.
+func (s *Server) acquire(ctx context.Context) bool {
+	select {
+	case s.slots <- struct{}{}:
+		s.inFlight.Add(1)
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *Server) release() {
+	s.inFlight.Add(-1)
+	<-s.slots
+}
+
+// lintTimeout is the rule timeout configured for the server, clients can only ask for less.
+func (s *Server) lintTimeout() time.Duration {
+	if s.lintRequest.TimeoutFlag <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(s.lintRequest.TimeoutFlag) * time.Second
+}
+
+// compileRuleSet builds a ruleset supplied inline with a lint request. Compiled rulesets are cached by the
+// hash of their source, so clients sending the same ruleset with every request only pay for it once.
+// Inline rulesets cannot bring functions or extend anything but the built-in rulesets: loading functions runs
+// code, and extends can read files and make requests, so only the functions the server started with are used.
+func (s *Server) compileRuleSet(source []byte) (*rulesets.RuleSet, error) {
+	sum := sha256.Sum256(source)
+	key := hex.EncodeToString(sum[:])
+
+	s.ruleSetsLock.Lock()
+	rs := s.ruleSets[key]
+	s.ruleSetsLock.Unlock()
+	if rs != nil {
+		return rs, nil
+	}
+
+	userRS, err := rulesets.CreateRuleSetFromData(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(userRS.Functions) > 0 || userRS.FunctionsDir != "" {
+		return nil, errors.New("inline rulesets cannot declare functions or functionsDir, " +
+			"use the functions the server was started with")
+	}
+	for extends := range userRS.GetExtendsValue() {
+		switch extends {
+		case rulesets.SpectralOpenAPI, rulesets.SpectralOwasp, rulesets.VacuumOwasp:
+		default:
+			return nil, fmt.Errorf("inline rulesets can only extend the built-in rulesets, not '%s'", extends)
+		}
+	}
+	rs = s.lintRequest.DefaultRuleSets.GenerateRuleSetFromSuppliedRuleSet(userRS)
+
+	s.ruleSetsLock.Lock()
+	if len(s.ruleSets) >= maxCachedRuleSets {
+		for k := range s.ruleSets {
+			delete(s.ruleSets, k)
+			break
+		}
+	}
+	s.ruleSets[key] = rs
+	s.ruleSetsLock.Unlock()
+	return rs, nil
+}
@@ -0,0 +1,281 @@
+package lintserver
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/daveshanley/vacuum/model/reports"
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/daveshanley/vacuum/utils"
+	vacuum_report "github.com/daveshanley/vacuum/vacuum-report"
+	"github.com/stretchr/testify/assert"
+)
+
+var testSpec = `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      responses:
+        '200':
+          description: ok`
+
+var testRuleSet = `rules:
+  info-description:
+    description: info must have a description
+    given: $.info
+    severity: error
+    then:
+      field: description
+      function: truthy`
+
+func testServer() *Server {
+	defaultRuleSets := rulesets.BuildDefaultRuleSets()
+	return NewServer("test", &utils.LintFileRequest{
+		DefaultRuleSets: defaultRuleSets,
+		SelectedRS:      defaultRuleSets.GenerateOpenAPIRecommendedRuleSet(),
+		TimeoutFlag:     5,
+	}, 2)
+}
+
+func do(s *Server, method, target, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer_LintSpectral(t *testing.T) {
+	rec := do(testServer(), http.MethodPost, "/lint?fileName=pets.yaml", "application/yaml", testSpec)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var report []reports.SpectralReport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.NotEmpty(t, report)
+	assert.Equal(t, "pets.yaml", report[0].Source)
+}
+
+func TestServer_LintInlineRuleSet(t *testing.T) {
+	s := testServer()
+	envelope, _ := json.Marshal(LintRequest{Spec: testSpec, RuleSet: testRuleSet})
+
+	for i := 0; i < 2; i++ {
+		rec := do(s, http.MethodPost, "/lint", "application/json", string(envelope))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var report []reports.SpectralReport
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		assert.Len(t, report, 1)
+		assert.Equal(t, "info-description", report[0].Code)
+	}
+
+	// the ruleset was only compiled once.
+	assert.Len(t, s.ruleSets, 1)
+}
+
+func TestServer_LintInlineRuleSets_Isolated(t *testing.T) {
+	s := testServer()
+	lint := func(ruleSet string) map[string]int {
+		envelope, _ := json.Marshal(LintRequest{Spec: testSpec, RuleSet: ruleSet})
+		rec := do(s, http.MethodPost, "/lint", "application/json", string(envelope))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var report []reports.SpectralReport
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		severities := make(map[string]int)
+		for _, r := range report {
+			severities[r.Code] = r.Severity
+		}
+		return severities
+	}
+
+	first := lint("extends: [[spectral:oas, all]]\nrules:\n  info-contact: off\n  info-description: info\n")
+	assert.NotContains(t, first, "info-contact")
+	assert.Equal(t, 3, first["info-description"])
+
+	// a ruleset changing the built-in rules does not change them for anybody else.
+	second := lint("extends: [[spectral:oas, all]]\nrules: {}\n")
+	assert.Contains(t, second, "info-contact")
+	assert.Equal(t, 0, second["info-description"])
+	assert.NotNil(t, s.lintRequest.DefaultRuleSets.GenerateOpenAPIDefaultRuleSet().Rules["info-contact"])
+	assert.Equal(t, "error", s.lintRequest.DefaultRuleSets.GenerateOpenAPIDefaultRuleSet().Rules["info-description"].Severity)
+}
+
+func TestServer_LintSuppressed(t *testing.T) {
+	spec := strings.Replace(testSpec, "info:\n", "info:\n  x-lint-ignore:\n    info-description: not needed\n", 1)
+	envelope, _ := json.Marshal(LintRequest{Spec: spec, RuleSet: testRuleSet})
+
+	// suppressed results are not reported as issues to spectral consumers.
+	rec := do(testServer(), http.MethodPost, "/lint", "application/json", string(envelope))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var report []reports.SpectralReport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Empty(t, report)
+
+	// they can be asked for alongside the report, with their reasons.
+	rec = do(testServer(), http.MethodPost, "/lint?suppressed=true", "application/json", string(envelope))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response SpectralResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Empty(t, response.Results)
+	assert.Equal(t, 1, response.Suppressed.Count)
+	assert.Equal(t, "info-description", response.Suppressed.Results[0].Code)
+	assert.Equal(t, []string{"info"}, response.Suppressed.Results[0].Path)
+	assert.Equal(t, "not needed", response.Suppressed.Results[0].Suppression.Reason)
+
+	// they are kept in the vacuum report, so they can be audited.
+	rec = do(testServer(), http.MethodPost, "/lint?format=vacuum", "application/json", string(envelope))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var vacuumReport vacuum_report.VacuumReport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &vacuumReport))
+	assert.Len(t, vacuumReport.Suppressed.Results, 1)
+	assert.Equal(t, "not needed", vacuumReport.Suppressed.Results[0].Suppression.Reason)
+}
+
+func TestServer_LintBadRuleSet(t *testing.T) {
+	envelope, _ := json.Marshal(LintRequest{Spec: testSpec, RuleSet: "rules: [nope"})
+	rec := do(testServer(), http.MethodPost, "/lint", "application/json", string(envelope))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "unable to parse ruleset")
+}
+
+func TestServer_LintUnsafeRuleSet(t *testing.T) {
+	for _, ruleSet := range []string{
+		"functions: [evil]\n" + testRuleSet,
+		"functionsDir: /tmp\n" + testRuleSet,
+		"extends: [[/etc/rules.yaml, all]]\n" + testRuleSet,
+		"extends: https://example.com/rules.yaml\n" + testRuleSet,
+	} {
+		envelope, _ := json.Marshal(LintRequest{Spec: testSpec, RuleSet: ruleSet})
+		rec := do(testServer(), http.MethodPost, "/lint", "application/json", string(envelope))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, ruleSet)
+		assert.Contains(t, rec.Body.String(), "inline rulesets can", ruleSet)
+	}
+
+	// built-in rulesets can still be extended.
+	envelope, _ := json.Marshal(LintRequest{Spec: testSpec, RuleSet: "extends: [[spectral:oas, off], [vacuum:owasp, off]]\n" + testRuleSet})
+	rec := do(testServer(), http.MethodPost, "/lint", "application/json", string(envelope))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestServer_LintJSONSpec(t *testing.T) {
+	// a JSON specification posted on its own is not mistaken for an envelope.
+	spec := `{"openapi": "3.1.0", "info": {"title": "pets", "version": "1.0.0"}, "paths": {}}`
+	rec := do(testServer(), http.MethodPost, "/lint", "application/json", spec)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestServer_LintVacuumReport(t *testing.T) {
+	rec := do(testServer(), http.MethodPost, "/lint?format=vacuum", "", testSpec)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var report vacuum_report.VacuumReport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.NotNil(t, report.Statistics)
+	assert.NotEmpty(t, report.ResultSet.Results)
+}
+
+func TestServer_LintSarif(t *testing.T) {
+	rec := do(testServer(), http.MethodPost, "/lint?format=sarif", "", testSpec)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/sarif+json", rec.Header().Get("Content-Type"))
+
+	var log vacuum_report.SarifLog
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &log))
+	assert.Len(t, log.Runs, 1)
+	assert.NotEmpty(t, log.Runs[0].Results)
+}
+
+func TestServer_LintBadRequests(t *testing.T) {
+	s := testServer()
+	assert.Equal(t, http.StatusMethodNotAllowed, do(s, http.MethodGet, "/lint", "", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(s, http.MethodPost, "/lint", "", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(s, http.MethodPost, "/lint?format=xml", "", testSpec).Code)
+	assert.Equal(t, http.StatusBadRequest, do(s, http.MethodPost, "/lint?timeout=soon", "", testSpec).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(s, http.MethodPost, "/lint", "", "not: [a spec").Code)
+}
+
+func TestServer_Busy(t *testing.T) {
+	s := testServer()
+	for i := 0; i < cap(s.slots); i++ {
+		s.slots <- struct{}{}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	req := httptest.NewRequest(http.MethodPost, "/lint", strings.NewReader(testSpec)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestServer_Rules(t *testing.T) {
+	s := testServer()
+	rec := do(s, http.MethodGet, "/rules", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var list RuleList
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Len(t, list.Rules, len(s.lintRequest.SelectedRS.Rules))
+	assert.Less(t, list.Rules[0].Id, list.Rules[1].Id)
+}
+
+func TestServer_Functions(t *testing.T) {
+	rec := do(testServer(), http.MethodGet, "/functions", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var list []FunctionDescription
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	var found bool
+	for _, f := range list {
+		if f.Name == "pattern" {
+			found = true
+			assert.Equal(t, "pattern", f.Schema.Name)
+			assert.False(t, f.Custom)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestServer_Health(t *testing.T) {
+	rec := do(testServer(), http.MethodGet, "/health", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var health Health
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &health))
+	assert.Equal(t, "ok", health.Status)
+	assert.Equal(t, "test", health.Version)
+	assert.Equal(t, 2, health.Capacity)
+	assert.NotZero(t, health.Rules)
+}
+
+func TestServer_Serve(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- testServer().Serve(ctx, listener)
+	}()
+
+	resp, err := http.Post("http://"+listener.Addr().String()+"/lint", "application/yaml",
+		strings.NewReader(testSpec))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Error(t, testServer().Run(context.Background(), "not-an-address"))
+}
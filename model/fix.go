@@ -0,0 +1,64 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package model
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FixInsert  = "insert"  // add a new entry to a mapping
+	FixReplace = "replace" // replace a scalar value, or re-order the entries of a collection
+	FixDelete  = "delete"  // remove an entry from a sequence or a mapping
+)
+
+// AutoFixFunction builds a Fix for a result created by a rule. It is used by rules that run generic functions
+// (like 'truthy' or 'pattern') where the function itself has no idea how to repair the document.
+type AutoFixFunction func(result *RuleFunctionResult, context RuleFunctionContext) *Fix
+
+// Fix describes a set of concrete edits that will resolve a result. All edits are anchored to nodes of
+// the document the result was found in. A Fix is applied atomically, all edits are applied or none of them are.
+type Fix struct {
+	Description string    `json:"description" yaml:"description"` // what the fix will do
+	Edits       []FixEdit `json:"edits" yaml:"edits"`             // the edits that make up the fix
+}
+
+// FixEdit is a single edit to a node.
+//
+//   - FixInsert adds Key with Value to the mapping Node.
+//   - FixReplace changes the value of the scalar Node to NewValue, or if Order is set, re-orders the
+//     entries (items of a sequence, or key/value pairs of a mapping) of the collection Node.
+//   - FixDelete removes Node (an item of a sequence, or the key of a mapping entry) from Parent.
+type FixEdit struct {
+	Kind     string     `json:"kind" yaml:"kind"`
+	Key      string     `json:"key,omitempty" yaml:"key,omitempty"`
+	NewValue string     `json:"value,omitempty" yaml:"value,omitempty"`
+	Order    []int      `json:"order,omitempty" yaml:"order,omitempty"`
+	Line     int        `json:"line" yaml:"line"`
+	Column   int        `json:"column" yaml:"column"`
+	Node     *yaml.Node `json:"-" yaml:"-"`
+	Parent   *yaml.Node `json:"-" yaml:"-"`
+	Value    *yaml.Node `json:"-" yaml:"-"`
+}
+
+// NewInsertEdit creates a FixEdit that adds a new key to a mapping node.
+func NewInsertEdit(mapNode *yaml.Node, key string, value *yaml.Node) FixEdit {
+	return FixEdit{Kind: FixInsert, Node: mapNode, Key: key, Value: value, Line: mapNode.Line, Column: mapNode.Column}
+}
+
+// NewReplaceEdit creates a FixEdit that replaces the value of a scalar node.
+func NewReplaceEdit(node *yaml.Node, value string) FixEdit {
+	return FixEdit{Kind: FixReplace, Node: node, NewValue: value, Line: node.Line, Column: node.Column}
+}
+
+// NewReorderEdit creates a FixEdit that re-orders the entries of a collection. order[i] is the current
+// position of the entry that should be placed at position i.
+func NewReorderEdit(node *yaml.Node, order []int) FixEdit {
+	return FixEdit{Kind: FixReplace, Node: node, Order: order, Line: node.Line, Column: node.Column}
+}
+
+// NewDeleteEdit creates a FixEdit that removes a node from its parent collection.
+func NewDeleteEdit(parent, node *yaml.Node) FixEdit {
+	return FixEdit{Kind: FixDelete, Node: node, Parent: parent, Line: node.Line, Column: node.Column}
+}
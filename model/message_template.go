@@ -0,0 +1,108 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package model
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MaxTemplateValueLength is the longest a {{value}} can be when it's interpolated into a message, longer values
+// are truncated.
+const MaxTemplateValueLength = 80
+
+var messagePlaceholder = regexp.MustCompile(`{{\s*(\w+)\s*}}`)
+
+// IsMessageTemplate returns true if a rule message contains placeholders.
+func IsMessageTemplate(message string) bool {
+	return messagePlaceholder.MatchString(message)
+}
+
+// InterpolateMessage fills in the placeholders of a message template, the same way Spectral does. The supported
+// placeholders are:
+//
+//   - {{error}} is what the function found wrong, without the rule's description.
+//   - {{path}} is the JSON path of the result.
+//   - {{property}} is the last property (or index) of the path.
+//   - {{value}} is the value of the node the result points at, truncated if it's long.
+//   - {{description}} is the description of the rule.
+//
+// Placeholders that are not known are left alone.
+func InterpolateMessage(template string, result *RuleFunctionResult, rule *Rule) string {
+	return messagePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		switch messagePlaceholder.FindStringSubmatch(placeholder)[1] {
+		case "error":
+			if result.Error != "" {
+				return result.Error
+			}
+			return result.Message
+		case "path":
+			return result.Path
+		case "property":
+			return LastPathSegment(result.Path)
+		case "value":
+			return templateValue(result.StartNode)
+		case "description":
+			if rule != nil {
+				return rule.Description
+			}
+			return ""
+		}
+		return placeholder
+	})
+}
+
+// LastPathSegment returns the last property name, or array index, of a JSON path. For example
+// $.paths['/pets'].get returns 'get', $.tags[2] returns '2' and $.paths['/pets'] returns '/pets'.
+func LastPathSegment(path string) string {
+	path = strings.TrimSpace(path)
+	if strings.HasSuffix(path, "]") {
+		open := strings.LastIndex(path, "[")
+		if open < 0 {
+			return ""
+		}
+		segment := path[open+1 : len(path)-1]
+
+		// quoted properties can contain a '[' of their own, look for the opening quote instead.
+		if strings.HasSuffix(segment, "'") || strings.HasSuffix(segment, "\"") {
+			quote := segment[len(segment)-1:]
+			start := strings.LastIndex(path[:len(path)-2], "["+quote)
+			if start >= 0 {
+				return path[start+2 : len(path)-2]
+			}
+		}
+		return segment
+	}
+	if dot := strings.LastIndex(path, "."); dot >= 0 {
+		return path[dot+1:]
+	}
+	if path == "$" {
+		return ""
+	}
+	return path
+}
+
+func templateValue(node *yaml.Node) string {
+	if node == nil {
+		return ""
+	}
+	var value string
+	if node.Kind == yaml.ScalarNode {
+		value = node.Value
+	} else {
+		var decoded any
+		if err := node.Decode(&decoded); err == nil {
+			if b, jErr := json.Marshal(decoded); jErr == nil {
+				value = string(b)
+			}
+		}
+	}
+	if r := []rune(value); len(r) > MaxTemplateValueLength {
+		value = string(r[:MaxTemplateValueLength]) + "..."
+	}
+	return value
+}
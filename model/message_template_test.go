@@ -0,0 +1,61 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestIsMessageTemplate(t *testing.T) {
+	assert.True(t, IsMessageTemplate("{{property}} is bad"))
+	assert.True(t, IsMessageTemplate("{{ error }}"))
+	assert.False(t, IsMessageTemplate("nothing to see here"))
+	assert.False(t, IsMessageTemplate("{{}}"))
+}
+
+func TestInterpolateMessage(t *testing.T) {
+	result := &RuleFunctionResult{
+		Message:   "Pets: `pet_name` does not match the expression `^[a-z]+$`",
+		Error:     "`pet_name` does not match the expression `^[a-z]+$`",
+		Path:      "$.components.schemas['Pet'].properties.pet_name",
+		StartNode: &yaml.Node{Kind: yaml.ScalarNode, Value: "pet_name"},
+	}
+	rule := &Rule{Description: "Property names must be lowercase"}
+
+	assert.Equal(t, "pet_name: `pet_name` does not match the expression `^[a-z]+$` "+
+		"($.components.schemas['Pet'].properties.pet_name) Property names must be lowercase, {{nope}}",
+		InterpolateMessage("{{property}}: {{error}} ({{ path }}) {{description}}, {{nope}}", result, rule))
+
+	// functions without a raw error use their message.
+	result.Error = ""
+	assert.Equal(t, result.Message, InterpolateMessage("{{error}}", result, rule))
+}
+
+func TestInterpolateMessage_Value(t *testing.T) {
+	var root yaml.Node
+	_ = yaml.Unmarshal([]byte("a: 1\nb: [x, y]"), &root)
+
+	result := &RuleFunctionResult{StartNode: root.Content[0]}
+	assert.Equal(t, `{"a":1,"b":["x","y"]}`, InterpolateMessage("{{value}}", result, nil))
+
+	result.StartNode = &yaml.Node{Kind: yaml.ScalarNode, Value: strings.Repeat("a", 100)}
+	assert.Equal(t, strings.Repeat("a", MaxTemplateValueLength)+"...", InterpolateMessage("{{value}}", result, nil))
+
+	result.StartNode = nil
+	assert.Equal(t, "[]", InterpolateMessage("[{{value}}]", result, nil))
+}
+
+func TestLastPathSegment(t *testing.T) {
+	assert.Equal(t, "get", LastPathSegment("$.paths['/pets'].get"))
+	assert.Equal(t, "/pets", LastPathSegment("$.paths['/pets']"))
+	assert.Equal(t, "/pets/[id]", LastPathSegment("$.paths['/pets/[id]']"))
+	assert.Equal(t, "2", LastPathSegment("$.tags[2]"))
+	assert.Equal(t, "info", LastPathSegment("$.info"))
+	assert.Equal(t, "", LastPathSegment("$"))
+	assert.Equal(t, "", LastPathSegment(""))
+}
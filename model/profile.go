@@ -0,0 +1,38 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// RuleProfile records where a rule spent its time when it ran, and what it did. Durations are nanoseconds in JSON.
+type RuleProfile struct {
+	RuleId         string        `json:"ruleId" yaml:"ruleId"`
+	Total          time.Duration `json:"total" yaml:"total"`                   // from start to finish (or timeout).
+	Lookup         time.Duration `json:"lookup" yaml:"lookup"`                 // finding nodes with the given JSONPath.
+	Function       time.Duration `json:"function" yaml:"function"`             // running the rule function(s).
+	Nodes          int           `json:"nodes" yaml:"nodes"`                   // nodes matched by the given paths.
+	Results        int           `json:"results" yaml:"results"`               // results the rule returned.
+	TimedOut       bool          `json:"timedOut" yaml:"timedOut"`             // the rule did not finish in time.
+	Allocations    uint64        `json:"allocations" yaml:"allocations"`       // heap objects allocated.
+	AllocatedBytes uint64        `json:"allocatedBytes" yaml:"allocatedBytes"` // heap bytes allocated.
+}
+
+// SortRuleProfiles sorts profiles by total time, slowest first.
+func SortRuleProfiles(profiles []RuleProfile) {
+	sort.SliceStable(profiles, func(i, j int) bool {
+		if profiles[i].Total == profiles[j].Total {
+			return profiles[i].RuleId < profiles[j].RuleId
+		}
+		return profiles[i].Total > profiles[j].Total
+	})
+}
+
+// FileProfile is the profile of every rule run against a file.
+type FileProfile struct {
+	File  string        `json:"file" yaml:"file"`
+	Rules []RuleProfile `json:"rules" yaml:"rules"`
+}
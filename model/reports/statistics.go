@@ -30,6 +30,7 @@ type ReportStatistics struct {
 	TotalWarnings      int                  `json:"totalWarnings,omitempty" yaml:"totalWarnings,omitempty"`
 	TotalInfo          int                  `json:"totalInfo,omitempty" yaml:"totalInfo,omitempty"`
 	TotalHints         int                  `json:"totalHints,omitempty" yaml:"totalHints,omitempty"`
+	TotalSuppressed    int                  `json:"totalSuppressed,omitempty" yaml:"totalSuppressed,omitempty"`
 	CategoryStatistics []*CategoryStatistic `gorm:"foreignKey:ID" json:"categoryStatistics,omitempty" yaml:"categoryStatistics,omitempty"`
 }
 
@@ -0,0 +1,20 @@
+package reports
+
+// Suppression describes why a result was waived, and how the waiver was declared.
+type Suppression struct {
+	Kind   string `json:"kind" yaml:"kind"`                         // how the result was suppressed, 'comment' or 'extension'
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"` // the justification supplied with the suppression.
+}
+
+// SuppressedReport lists the results that were waived, in the same shape as a spectral report. It's kept apart
+// from the spectral report itself, spectral consumers would count suppressed results as issues.
+type SuppressedReport struct {
+	Count   int                    `json:"count" yaml:"count"`     // how many results were suppressed.
+	Results []SuppressedResultItem `json:"results" yaml:"results"` // the suppressed results, with the reason for each.
+}
+
+// SuppressedResultItem is a suppressed result, and why it was suppressed.
+type SuppressedResultItem struct {
+	SpectralReport `yaml:",inline"`
+	Suppression    *Suppression `json:"suppression" yaml:"suppression"`
+}
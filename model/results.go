@@ -137,6 +137,20 @@ func (rr *RuleResultSet) GenerateSpectralReport(source string) []reports.Spectra
 	return report
 }
 
+// GenerateSuppressedReport will return the results as a report of suppressed results, each one carries the
+// reason it was waived. It's used with a set of suppressed results, alongside a spectral report.
+func (rr *RuleResultSet) GenerateSuppressedReport(source string) *reports.SuppressedReport {
+	report := &reports.SuppressedReport{Results: []reports.SuppressedResultItem{}}
+	for i, item := range rr.GenerateSpectralReport(source) {
+		report.Results = append(report.Results, reports.SuppressedResultItem{
+			SpectralReport: item,
+			Suppression:    rr.Results[i].Suppression,
+		})
+	}
+	report.Count = len(report.Results)
+	return report
+}
+
 // GetErrorCount will return the number of errors returned by the rule results.
 func (rr *RuleResultSet) GetErrorCount() int {
 	if rr.ErrorCount > 0 {
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	_ "embed" // embedding is not supported by golint,
 	"encoding/json"
 	"github.com/daveshanley/vacuum/model/reports"
@@ -31,6 +32,14 @@ const (
 	CategoryAll          = "all"
 )
 
+// A rule's scope describes what its results depend on, it's used to decide when cached results are still valid.
+// A document scoped rule only looks at the root document, a global rule (the default) can look at every file
+// referenced by it, rules like 'oas3-unused-component' need to see everything.
+const (
+	RuleScopeDocument = "document"
+	RuleScopeGlobal   = "global"
+)
+
 type RuleCategory struct {
 	Id          string `json:"id" yaml:"id"`                   // The category ID
 	Name        string `json:"name" yaml:"name"`               // The name of the category
@@ -48,20 +57,24 @@ type RuleFunctionContext struct {
 	Document   libopenapi.Document `json:"-" yaml:"-"`                                       // A reference to the document being parsed
 	DrDocument *model.DrDocument   `json:"-" yaml:"-"`                                       // A high level, more powerful representation of the document being parsed. Powered by the doctor.
 	Logger     *slog.Logger        `json:"-" yaml:"-"`                                       // Custom logger
+	Context    context.Context     `json:"-" yaml:"-"`                                       // Done when the rule times out, long-running functions should stop.
 }
 
 // RuleFunctionResult describes a failure with linting after being run through a rule
 type RuleFunctionResult struct {
-	Message      string            `json:"message" yaml:"message"`                   // What failed and why?
-	Range        reports.Range     `json:"range" yaml:"range"`                       // Where did it happen?
-	Path         string            `json:"path" yaml:"path"`                         // the JSONPath to where it can be found
-	RuleId       string            `json:"ruleId" yaml:"ruleId"`                     // The ID of the rule
-	RuleSeverity string            `json:"ruleSeverity" yaml:"ruleSeverity"`         // the severity of the rule used
-	Origin       *index.NodeOrigin `json:"origin,omitempty" yaml:"origin,omitempty"` // Where did the result come from?
-	Rule         *Rule             `json:"-" yaml:"-"`                               // The rule used
-	StartNode    *yaml.Node        `json:"-" yaml:"-"`                               // Start of the violation
-	EndNode      *yaml.Node        `json:"-" yaml:"-"`                               // end of the violation
-	Timestamp    *time.Time        `json:"-" yaml:"-"`                               // When the result was created.
+	Message      string               `json:"message" yaml:"message"`                             // What failed and why?
+	Range        reports.Range        `json:"range" yaml:"range"`                                 // Where did it happen?
+	Path         string               `json:"path" yaml:"path"`                                   // the JSONPath to where it can be found
+	RuleId       string               `json:"ruleId" yaml:"ruleId"`                               // The ID of the rule
+	RuleSeverity string               `json:"ruleSeverity" yaml:"ruleSeverity"`                   // the severity of the rule used
+	Origin       *index.NodeOrigin    `json:"origin,omitempty" yaml:"origin,omitempty"`           // Where did the result come from?
+	Suppression  *reports.Suppression `json:"suppression,omitempty" yaml:"suppression,omitempty"` // Why was the result waived?
+	Fix          *Fix                 `json:"fix,omitempty" yaml:"fix,omitempty"`                 // How can it be fixed automatically?
+	Error        string               `json:"-" yaml:"-"`                                         // What the function found wrong, without the rule's description
+	Rule         *Rule                `json:"-" yaml:"-"`                                         // The rule used
+	StartNode    *yaml.Node           `json:"-" yaml:"-"`                                         // Start of the violation
+	EndNode      *yaml.Node           `json:"-" yaml:"-"`                                         // end of the violation
+	Timestamp    *time.Time           `json:"-" yaml:"-"`                                         // When the result was created.
 
 	// ModelContext may or may nor be populated, depending on the rule used and the context of the rule. If it is
 	// populated, then this is a reference to the model that fired the rule. (not currently used yet)
@@ -93,20 +106,22 @@ type RuleAction struct {
 
 // Rule is a structure that represents a rule as part of a ruleset.
 type Rule struct {
-	Id                 string         `json:"id,omitempty" yaml:"id,omitempty"`
-	Description        string         `json:"description,omitempty" yaml:"description,omitempty"`
-	Message            string         `json:"message,omitempty" yaml:"message,omitempty"`
-	Given              interface{}    `json:"given,omitempty" yaml:"given,omitempty"`
-	Formats            []string       `json:"formats,omitempty" yaml:"formats,omitempty"`
-	Resolved           bool           `json:"resolved,omitempty" yaml:"resolved,omitempty"`
-	Recommended        bool           `json:"recommended,omitempty" yaml:"recommended,omitempty"`
-	Type               string         `json:"type,omitempty" yaml:"type,omitempty"`
-	Severity           string         `json:"severity,omitempty" yaml:"severity,omitempty"`
-	Then               interface{}    `json:"then,omitempty" yaml:"then,omitempty"`
-	PrecompiledPattern *regexp.Regexp `json:"-" yaml:"-"` // regex is slow.
-	RuleCategory       *RuleCategory  `json:"category,omitempty" yaml:"category,omitempty"`
-	Name               string         `json:"-" yaml:"-"`
-	HowToFix           string         `json:"howToFix,omitempty" yaml:"howToFix,omitempty"`
+	Id                 string          `json:"id,omitempty" yaml:"id,omitempty"`
+	Description        string          `json:"description,omitempty" yaml:"description,omitempty"`
+	Message            string          `json:"message,omitempty" yaml:"message,omitempty"`
+	Given              interface{}     `json:"given,omitempty" yaml:"given,omitempty"`
+	Formats            []string        `json:"formats,omitempty" yaml:"formats,omitempty"`
+	Resolved           bool            `json:"resolved,omitempty" yaml:"resolved,omitempty"`
+	Recommended        bool            `json:"recommended,omitempty" yaml:"recommended,omitempty"`
+	Type               string          `json:"type,omitempty" yaml:"type,omitempty"`
+	Severity           string          `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Then               interface{}     `json:"then,omitempty" yaml:"then,omitempty"`
+	PrecompiledPattern *regexp.Regexp  `json:"-" yaml:"-"` // regex is slow.
+	RuleCategory       *RuleCategory   `json:"category,omitempty" yaml:"category,omitempty"`
+	Name               string          `json:"-" yaml:"-"`
+	HowToFix           string          `json:"howToFix,omitempty" yaml:"howToFix,omitempty"`
+	Scope              string          `json:"scope,omitempty" yaml:"scope,omitempty"`
+	AutoFix            AutoFixFunction `json:"-" yaml:"-"` // builds fixes for results of generic functions.
 }
 
 // RuleFunctionProperty is used by RuleFunctionSchema to describe the functionOptions a Rule accepts
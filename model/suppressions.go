@@ -0,0 +1,188 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package model
+
+import (
+	"strings"
+
+	"github.com/daveshanley/vacuum/model/reports"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SuppressionKindComment   = "comment"
+	SuppressionKindExtension = "extension"
+	SuppressionComment       = "vacuum-ignore"
+	SuppressionExtension     = "x-lint-ignore"
+)
+
+type suppressionScope map[string]*reports.Suppression
+
+// SuppressionIndex maps every node inside a suppressed part of a document to the rules that are suppressed
+// for it. Suppressions are declared using a `# vacuum-ignore rule-id: reason` comment attached to a node, or
+// by adding an `x-lint-ignore` extension (a rule id, a list of rule ids, or a map of rule ids to reasons) to
+// an object. Both apply to the node they are declared against, and everything underneath it.
+type SuppressionIndex struct {
+	nodes   map[*yaml.Node]suppressionScope
+	visited map[*yaml.Node]bool
+}
+
+// BuildSuppressionIndex walks all the supplied root nodes and indexes any suppressions found.
+func BuildSuppressionIndex(roots ...*yaml.Node) *SuppressionIndex {
+	si := &SuppressionIndex{
+		nodes:   make(map[*yaml.Node]suppressionScope),
+		visited: make(map[*yaml.Node]bool),
+	}
+	for _, root := range roots {
+		if root != nil {
+			si.walk(root, nil, make(map[*yaml.Node]bool))
+		}
+	}
+	return si
+}
+
+// Len returns the number of nodes that are covered by a suppression.
+func (si *SuppressionIndex) Len() int {
+	return len(si.nodes)
+}
+
+// FindSuppression returns the suppression that applies to a result, or nil if the result is not suppressed.
+func (si *SuppressionIndex) FindSuppression(result *RuleFunctionResult) *reports.Suppression {
+	if result == nil || result.StartNode == nil {
+		return nil
+	}
+	scope := si.nodes[result.StartNode]
+	if scope == nil {
+		return nil
+	}
+	ruleId := result.RuleId
+	if ruleId == "" && result.Rule != nil {
+		ruleId = result.Rule.Id
+	}
+	return scope[ruleId]
+}
+
+func (si *SuppressionIndex) walk(node *yaml.Node, scope suppressionScope, ancestors map[*yaml.Node]bool) {
+	if ancestors[node] {
+		return // circular, we have been here before.
+	}
+
+	// the resolved document shares nodes everywhere a reference was used, there is no need to walk
+	// the same nodes over and over again, unless there is something to suppress.
+	if si.visited[node] && len(scope) == 0 {
+		return
+	}
+	si.visited[node] = true
+
+	scope = scope.with(parseSuppressionComments(node))
+	if node.Kind == yaml.MappingNode {
+		scope = scope.with(parseSuppressionExtension(node))
+	}
+	si.record(node, scope)
+
+	ancestors[node] = true
+	defer delete(ancestors, node)
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			// suppressions on a value apply to its key too, so rules reporting on the key are covered.
+			pairScope := scope.with(parseSuppressionComments(key)).with(parseSuppressionComments(value))
+			if value.Kind == yaml.MappingNode {
+				pairScope = pairScope.with(parseSuppressionExtension(value))
+			}
+			si.record(key, pairScope)
+			si.walk(value, pairScope, ancestors)
+		}
+	default:
+		for _, n := range node.Content {
+			si.walk(n, scope, ancestors)
+		}
+	}
+}
+
+func (si *SuppressionIndex) record(node *yaml.Node, scope suppressionScope) {
+	if len(scope) == 0 {
+		return
+	}
+	si.nodes[node] = si.nodes[node].with(scope)
+}
+
+// with returns a scope containing both sets of suppressions, the receiver is never modified.
+func (s suppressionScope) with(other suppressionScope) suppressionScope {
+	if len(other) == 0 {
+		return s
+	}
+	if len(s) == 0 {
+		return other
+	}
+	merged := make(suppressionScope, len(s)+len(other))
+	for k, v := range s {
+		merged[k] = v
+	}
+	for k, v := range other {
+		merged[k] = v
+	}
+	return merged
+}
+
+// parseSuppressionComments looks for `vacuum-ignore` comments in the head and line comments of a node.
+// multiple rules can be suppressed by separating them with commas, the reason is optional.
+func parseSuppressionComments(node *yaml.Node) suppressionScope {
+	if node.HeadComment == "" && node.LineComment == "" {
+		return nil
+	}
+	var scope suppressionScope
+	for _, comment := range []string{node.HeadComment, node.LineComment} {
+		for _, line := range strings.Split(comment, "\n") {
+			line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+			if !strings.HasPrefix(line, SuppressionComment) {
+				continue
+			}
+			ids, reason, _ := strings.Cut(strings.TrimPrefix(line, SuppressionComment), ":")
+			for _, id := range strings.FieldsFunc(ids, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+				if scope == nil {
+					scope = make(suppressionScope)
+				}
+				scope[id] = &reports.Suppression{Kind: SuppressionKindComment, Reason: strings.TrimSpace(reason)}
+			}
+		}
+	}
+	return scope
+}
+
+// parseSuppressionExtension looks for an `x-lint-ignore` extension in a mapping node.
+func parseSuppressionExtension(node *yaml.Node) suppressionScope {
+	var scope suppressionScope
+	add := func(id, reason string) {
+		if id == "" {
+			return
+		}
+		if scope == nil {
+			scope = make(suppressionScope)
+		}
+		scope[id] = &reports.Suppression{Kind: SuppressionKindExtension, Reason: reason}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != SuppressionExtension {
+			continue
+		}
+		value := node.Content[i+1]
+		switch value.Kind {
+		case yaml.ScalarNode:
+			add(value.Value, "")
+		case yaml.SequenceNode:
+			for _, item := range value.Content {
+				add(item.Value, "")
+			}
+		case yaml.MappingNode:
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				add(value.Content[j].Value, value.Content[j+1].Value)
+			}
+		}
+	}
+	return scope
+}
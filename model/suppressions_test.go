@@ -0,0 +1,93 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestBuildSuppressionIndex_Comments(t *testing.T) {
+
+	spec := `openapi: 3.1.0
+paths:
+  # vacuum-ignore path-keys-no-trailing-slash: legacy routes, fixed in v2
+  /v1/pets/:
+    get:
+      description: pets # vacuum-ignore description-duplication, no-eval-in-markdown
+  /v2/pets:
+    get:
+      description: pets`
+
+	var root yaml.Node
+	_ = yaml.Unmarshal([]byte(spec), &root)
+
+	si := BuildSuppressionIndex(&root)
+
+	paths := root.Content[0].Content[3]
+	v1Key := paths.Content[0]
+	v1Desc := paths.Content[1].Content[1].Content[1]
+	v2Key := paths.Content[2]
+
+	s := si.FindSuppression(&RuleFunctionResult{RuleId: "path-keys-no-trailing-slash", StartNode: v1Key})
+	assert.NotNil(t, s)
+	assert.Equal(t, SuppressionKindComment, s.Kind)
+	assert.Equal(t, "legacy routes, fixed in v2", s.Reason)
+
+	// the head comment covers everything underneath the key.
+	assert.NotNil(t, si.FindSuppression(&RuleFunctionResult{RuleId: "path-keys-no-trailing-slash", StartNode: v1Desc}))
+
+	// line comments, with multiple rules and no reason.
+	s = si.FindSuppression(&RuleFunctionResult{Rule: &Rule{Id: "no-eval-in-markdown"}, StartNode: v1Desc})
+	assert.NotNil(t, s)
+	assert.Empty(t, s.Reason)
+
+	assert.Nil(t, si.FindSuppression(&RuleFunctionResult{RuleId: "operation-tags", StartNode: v1Key}))
+	assert.Nil(t, si.FindSuppression(&RuleFunctionResult{RuleId: "path-keys-no-trailing-slash", StartNode: v2Key}))
+}
+
+func TestBuildSuppressionIndex_Extension(t *testing.T) {
+
+	spec := `openapi: 3.1.0
+paths:
+  /v1/pets/:
+    x-lint-ignore: [path-keys-no-trailing-slash]
+    get:
+      x-lint-ignore:
+        operation-description: generated by a tool
+      responses: {}
+  /v2/pets:
+    x-lint-ignore: operation-tags
+    get:
+      responses: {}`
+
+	var root yaml.Node
+	_ = yaml.Unmarshal([]byte(spec), &root)
+
+	si := BuildSuppressionIndex(&root)
+
+	paths := root.Content[0].Content[3]
+	v1Key := paths.Content[0]
+	v1Get := paths.Content[1].Content[3]
+	v2Get := paths.Content[3].Content[3]
+
+	// the extension in the path item covers the key of the path item.
+	s := si.FindSuppression(&RuleFunctionResult{RuleId: "path-keys-no-trailing-slash", StartNode: v1Key})
+	assert.NotNil(t, s)
+	assert.Equal(t, SuppressionKindExtension, s.Kind)
+
+	s = si.FindSuppression(&RuleFunctionResult{RuleId: "operation-description", StartNode: v1Get})
+	assert.NotNil(t, s)
+	assert.Equal(t, "generated by a tool", s.Reason)
+
+	assert.NotNil(t, si.FindSuppression(&RuleFunctionResult{RuleId: "operation-tags", StartNode: v2Get}))
+	assert.Nil(t, si.FindSuppression(&RuleFunctionResult{RuleId: "operation-description", StartNode: v2Get}))
+}
+
+func TestBuildSuppressionIndex_Empty(t *testing.T) {
+	var root yaml.Node
+	_ = yaml.Unmarshal([]byte("openapi: 3.1.0 # just a comment"), &root)
+	si := BuildSuppressionIndex(&root, nil)
+	assert.Equal(t, 0, si.Len())
+	assert.Nil(t, si.FindSuppression(&RuleFunctionResult{RuleId: "x"}))
+}
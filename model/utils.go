@@ -26,15 +26,19 @@ const GithubUrl = "https://github.com/daveshanley/vacuum"
 // BuildFunctionResult will create a RuleFunctionResult from a key, message and value.
 // Deprecated: use BuildFunctionResultWithDescription instead.
 func BuildFunctionResult(key, message string, value interface{}) RuleFunctionResult {
+	err := fmt.Sprintf("'%s' %s '%v'", key, message, value)
 	return RuleFunctionResult{
-		Message: fmt.Sprintf("'%s' %s '%v'", key, message, value),
+		Message: err,
+		Error:   err,
 	}
 }
 
 // BuildFunctionResultWithDescription will create a RuleFunctionResult from a description, key, message and value.
 func BuildFunctionResultWithDescription(desc, key, message string, value interface{}) RuleFunctionResult {
+	err := fmt.Sprintf("'%s' %s '%v'", key, message, value)
 	return RuleFunctionResult{
-		Message: fmt.Sprintf("%s: '%s' %s '%v'", desc, key, message, value),
+		Message: fmt.Sprintf("%s: %s", desc, err),
+		Error:   err,
 	}
 }
 
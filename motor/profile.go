@@ -0,0 +1,67 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package motor
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/daveshanley/vacuum/model"
+)
+
+// ruleProfiler records the profile of a single rule. A rule that times out keeps running in the background, so the
+// profile is locked, and a copy is taken once the rule is done. All methods are safe to call on a nil profiler,
+// which records nothing.
+type ruleProfiler struct {
+	lock    sync.Mutex
+	profile model.RuleProfile
+	start   time.Time
+	memory  runtime.MemStats
+}
+
+func newRuleProfiler(id string) *ruleProfiler {
+	p := &ruleProfiler{profile: model.RuleProfile{RuleId: id}}
+	runtime.ReadMemStats(&p.memory)
+	p.start = time.Now()
+	return p
+}
+
+// lookup records the time taken to find nodes with a given path, and the nodes found.
+func (p *ruleProfiler) lookup(since time.Time, nodes int) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	p.profile.Lookup += time.Since(since)
+	p.profile.Nodes += nodes
+	p.lock.Unlock()
+}
+
+// function records the time taken to run a rule function, and the results it returned.
+func (p *ruleProfiler) function(since time.Time, results int) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	p.profile.Function += time.Since(since)
+	p.profile.Results += results
+	p.lock.Unlock()
+}
+
+// finish returns the profile of the rule, once it is done (or has timed out).
+func (p *ruleProfiler) finish(timedOut bool) model.RuleProfile {
+	total := time.Since(p.start)
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	profile := p.profile
+	profile.Total = total
+	profile.TimedOut = timedOut
+	profile.Allocations = memory.Mallocs - p.memory.Mallocs
+	profile.AllocatedBytes = memory.TotalAlloc - p.memory.TotalAlloc
+	return profile
+}
@@ -15,10 +15,12 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/daveshanley/vacuum/cache"
 	"github.com/daveshanley/vacuum/functions"
 	"github.com/daveshanley/vacuum/model"
 	"github.com/daveshanley/vacuum/rulesets"
@@ -34,6 +36,7 @@ import (
 
 type ruleContext struct {
 	rule               *model.Rule
+	ruleSet            *rulesets.RuleSet
 	specNode           *yaml.Node
 	specNodeUnresolved *yaml.Node
 	builtinFunctions   functions.Functions
@@ -48,6 +51,8 @@ type ruleContext struct {
 	drDocument         *doctor.DrDocument
 	skipDocumentCheck  bool
 	logger             *slog.Logger
+	runContext         context.Context
+	profiler           *ruleProfiler
 }
 
 // RuleSetExecution is an instruction set for executing a ruleset. It's a convenience structure to allow the signature
@@ -58,6 +63,7 @@ type RuleSetExecution struct {
 	Spec              []byte                        // The raw bytes of the OpenAPI specification.
 	SpecInfo          *datamodel.SpecInfo           // Pre-parsed spec-info.
 	CustomFunctions   map[string]model.RuleFunction // custom functions loaded from plugin.
+	FunctionLoader    rulesets.FunctionLoader       // Loads the functions declared by the ruleset, like plugin.LoadFunctionSource.
 	PanicFunction     func(p any)                   // In case of emergency, do this thing here.
 	SilenceLogs       bool                          // Prevent any warnings about rules/rule-sets being printed.
 	Base              string                        // The base path or URL of the specification, used for resolving relative or remote paths.
@@ -67,6 +73,9 @@ type RuleSetExecution struct {
 	SkipDocumentCheck bool                          // Skip the document check, useful for fragments and non openapi specs.
 	Logger            *slog.Logger                  // A custom logger.
 	Timeout           time.Duration                 // The timeout for each rule to run, prevents run-away rules, default is five seconds.
+	Cache             *cache.Cache                  // Replay and record rule results, rules are only run if their results are not cached.
+	Profile           bool                          // Profile each rule, rules are run one at a time so they can be measured alone.
+	Context           context.Context               // Cancels the execution, rules that have not started are skipped (optional).
 
 	// https://pb33f.io/libopenapi/circular-references/#circular-reference-results
 	IgnoreCircularArrayRef       bool // Ignore array circular references
@@ -77,11 +86,16 @@ type RuleSetExecution struct {
 type RuleSetExecutionResult struct {
 	RuleSetExecution *RuleSetExecution          // The execution struct that was used invoking the result.
 	Results          []model.RuleFunctionResult // The results of the execution.
+	Suppressed       []model.RuleFunctionResult // Results waived by vacuum-ignore comments or x-lint-ignore extensions.
 	Index            *index.SpecIndex           // The index that was created from the specification, used by the rules.
+	DrDocument       *doctor.DrDocument         // The doctor model of the specification (OpenAPI 3+ only), used by the rules.
 	SpecInfo         *datamodel.SpecInfo        // A reference to the SpecInfo object, used by all the rules.
 	Errors           []error                    // Any errors that were returned.
 	FilesProcessed   int                        // number of files extracted by the rolodex
 	FileSize         int64                      // total filesize loaded by the rolodex
+	Files            []string                   // absolute paths of the local files loaded by the rolodex
+	CachedRules      int                        // number of rules that were replayed from the cache, instead of run
+	Profiles         []model.RuleProfile        // how long each rule took and what it did, slowest first (if profiled).
 }
 
 // todo: move copy into virtual file system or some kind of map.
@@ -526,6 +540,16 @@ func ApplyRulesToRuleSet(execution *RuleSetExecution) *RuleSetExecutionResult {
 
 	// run all rules.
 	var errs []error
+	var recorded []recordedRule
+	var profiles []model.RuleProfile
+	cachedRules := 0
+
+	// functions declared by the ruleset are used alongside any that were supplied.
+	errs = append(errs, mergeRuleSetFunctions(execution)...)
+
+	// rules that cannot run properly are reported and skipped, rather than quietly doing nothing.
+	invalid, ruleSetErrs := validateRuleSet(execution, builtinFunctions, indexConfig.Logger)
+	errs = append(errs, ruleSetErrs...)
 
 	// add dr document build errors to the results.
 	if drDocument != nil {
@@ -561,9 +585,43 @@ func ApplyRulesToRuleSet(execution *RuleSetExecution) *RuleSetExecutionResult {
 		done := make(chan bool)
 		indexConfig.Logger.Debug("running rules", "total", totalRules)
 		now = time.Now()
-		for _, rule := range execution.RuleSet.Rules {
 
-			go func(rule *model.Rule, done chan bool) {
+		var rc *ruleCache
+		if execution.Cache != nil {
+			rc = newRuleCache(execution.Cache, execution, specUnresolved, specResolved, rolodexResolved)
+		}
+
+		runCtx := execution.Context
+		if runCtx == nil {
+			runCtx = context.Background()
+		}
+
+		completed := 0
+		for id, rule := range execution.RuleSet.Rules {
+
+			go func(id string, rule *model.Rule, done chan bool) {
+
+				if invalid[id] || runCtx.Err() != nil {
+					done <- true
+					return
+				}
+
+				if rc != nil {
+					if cached, ok := rc.replay(rule); ok {
+						lock.Lock()
+						ruleResults = append(ruleResults, cached...)
+						cachedRules++
+						lock.Unlock()
+						done <- true
+						return
+					}
+				}
+
+				// cached rules get their own results, so they can be recorded once the rule is done.
+				results := &ruleResults
+				if rc != nil {
+					results = &[]model.RuleFunctionResult{}
+				}
 
 				ruleSpec := specResolved
 				ruleIndex := indexResolved
@@ -576,10 +634,11 @@ func ApplyRulesToRuleSet(execution *RuleSetExecution) *RuleSetExecutionResult {
 				// this list of things is most likely going to grow a bit, so we use a nice clean message design.
 				ctx := ruleContext{
 					rule:               rule,
+					ruleSet:            execution.RuleSet,
 					specNode:           ruleSpec,
 					specNodeUnresolved: specUnresolved,
 					builtinFunctions:   builtinFunctions,
-					ruleResults:        &ruleResults,
+					ruleResults:        results,
 					errors:             &errs,
 					specInfo:           info,
 					index:              ruleIndex,
@@ -598,53 +657,137 @@ func ApplyRulesToRuleSet(execution *RuleSetExecution) *RuleSetExecutionResult {
 					execution.Timeout = time.Second * 5 // default
 				}
 
-				timeoutCtx, ruleCancel := context.WithTimeout(context.Background(), execution.Timeout)
+				timeoutCtx, ruleCancel := context.WithTimeout(runCtx, execution.Timeout)
 				defer ruleCancel()
+				ctx.runContext = timeoutCtx
 				doneChan := make(chan bool)
 
+				if execution.Profile {
+					ctx.profiler = newRuleProfiler(id)
+				}
+
 				go runRule(ctx, doneChan)
 
+				completed := false
 				select {
 				case <-timeoutCtx.Done():
+					if runCtx.Err() != nil {
+						ctx.logger.Debug("Rule cancelled", "rule", rule.Id)
+						break
+					}
 					ctx.logger.Error("Rule timed out, skipping", "rule", rule.Id, "timeout", execution.Timeout)
 					break
 				case <-doneChan:
+					completed = true
 					break
 				}
+
+				if ctx.profiler != nil {
+					profile := ctx.profiler.finish(!completed)
+					lock.Lock()
+					profiles = append(profiles, profile)
+					lock.Unlock()
+				}
+
+				// results of rules that timed out are incomplete, they are used but never cached.
+				if rc != nil {
+					lock.Lock()
+					ruleResults = append(ruleResults, *results...)
+					if completed {
+						recorded = append(recorded, recordedRule{rule: rule,
+							results: append([]model.RuleFunctionResult(nil), *results...)})
+					}
+					lock.Unlock()
+				}
 				done <- true
-			}(rule, done)
+			}(id, rule, done)
+
+			// profiled rules run one at a time, so they don't share the time (or allocations) of others.
+			if execution.Profile {
+				<-done
+				completed++
+			}
 		}
 
-		completed := 0
 		for completed < totalRules {
 			<-done
 			completed++
 		}
+
+		// the results of a cancelled execution are incomplete, the caller is told so.
+		if err := runCtx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("rules were cancelled: %w", err))
+		}
+
+		// an error may have stopped a rule part of the way through, only clean runs are cached.
+		if rc != nil && len(errs) == 0 {
+			for _, r := range recorded {
+				if err := rc.record(r.rule, r.results); err != nil {
+					indexConfig.Logger.Warn("unable to cache rule results", "rule", r.rule.Id, "error", err)
+				}
+			}
+		}
 		then = time.Since(now).Milliseconds()
 		indexConfig.Logger.Debug("rules completed", "totalRules", totalRules, "ms", then)
 	}
 
 	filesProcessed := 0
 	fileSize := int64(0)
+	var files []string
 
 	if indexResolved != nil && rolodexResolved != nil {
 		filesProcessed = rolodexResolved.RolodexTotalFiles()
 		fileSize = rolodexResolved.RolodexFileSize()
+		files = localRolodexFiles(rolodexResolved)
 		ruleResults = *removeDuplicates(&ruleResults, execution, indexResolved)
 	}
 
+	// separate out any results that have been suppressed inline.
+	var suppressedResults []model.RuleFunctionResult
+	ruleResults, suppressedResults = applySuppressions(ruleResults, []*yaml.Node{specUnresolved, specResolved},
+		rolodexUnresolved, rolodexResolved)
+
+	// apply any overrides defined by the ruleset, per file and per path.
+	if execution.RuleSet != nil && len(execution.RuleSet.Overrides) > 0 {
+		ruleResults = applyOverrides(ruleResults, execution)
+	}
+
 	then = time.Since(now).Milliseconds()
 	indexConfig.Logger.Debug("applied all rules and completed", "ms", then)
+	model.SortRuleProfiles(profiles)
 
 	return &RuleSetExecutionResult{
 		RuleSetExecution: execution,
 		Results:          ruleResults,
+		Suppressed:       suppressedResults,
 		Index:            indexResolved,
+		DrDocument:       drDocument,
 		SpecInfo:         specInfo,
 		Errors:           errs,
 		FilesProcessed:   filesProcessed,
 		FileSize:         fileSize,
+		Files:            files,
+		CachedRules:      cachedRules,
+		Profiles:         profiles,
+	}
+}
+
+// localRolodexFiles returns the absolute paths of every local file indexed by the rolodex, remote documents
+// are left out.
+func localRolodexFiles(rolodex *index.Rolodex) []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, idx := range rolodex.GetIndexes() {
+		location := idx.GetSpecAbsolutePath()
+		if location == "" || seen[location] || strings.HasPrefix(location, "http://") ||
+			strings.HasPrefix(location, "https://") {
+			continue
+		}
+		seen[location] = true
+		files = append(files, location)
 	}
+	sort.Strings(files)
+	return files
 }
 
 func runRule(ctx ruleContext, doneChan chan bool) {
@@ -674,6 +817,24 @@ func runRule(ctx ruleContext, doneChan chan bool) {
 		}
 	}
 
+	// expand any aliases (e.g. #OperationObject) into JSON Path expressions.
+	if ctx.ruleSet != nil {
+		specFormat := ""
+		if ctx.specInfo != nil {
+			specFormat = ctx.specInfo.SpecFormat
+		}
+		expanded, aErr := ctx.ruleSet.ExpandGivenPaths(givenPaths, specFormat)
+		if aErr != nil {
+			ctx.logger.Error("unable to expand rule aliases", "rule", ctx.rule.Id, "error", aErr)
+			lock.Lock()
+			*ctx.errors = append(*ctx.errors, fmt.Errorf("ruleset error in rule '%s': %w", ctx.rule.Id, aErr))
+			lock.Unlock()
+			doneChan <- true
+			return
+		}
+		givenPaths = expanded
+	}
+
 	findNodes := func(node *yaml.Node, path string, errChan chan error, nodesChan chan []*yaml.Node) {
 		nodes, err := utils.FindNodesWithoutDeserializing(node, path)
 		if err != nil {
@@ -687,6 +848,7 @@ func runRule(ctx ruleContext, doneChan chan bool) {
 
 	for _, givenPath := range givenPaths {
 
+		lookupStart := time.Now()
 		if givenPath != "$" {
 
 			// create a timeout on this, if we can't get a result within 2s, then
@@ -730,6 +892,7 @@ func runRule(ctx ruleContext, doneChan chan bool) {
 			// if we're looking for the root, don't bother looking, we already have it.
 			nodes = []*yaml.Node{ctx.specNode}
 		}
+		ctx.profiler.lookup(lookupStart, len(nodes))
 
 		if err != nil {
 			*ctx.errors = append(*ctx.errors, err)
@@ -766,6 +929,73 @@ func runRule(ctx ruleContext, doneChan chan bool) {
 
 var lock sync.Mutex
 
+// validateRuleSet checks the rules of the ruleset can run, with the builtin and custom functions of the execution.
+// A rule that cannot run is marked as invalid, so it's skipped, and the problem is logged as a warning: one broken
+// rule does not stop the rest of the ruleset from running ('vacuum ruleset check' reports these as errors). Given
+// paths that cannot be used, and problems with the ruleset itself, are returned as errors, as they always have been.
+func validateRuleSet(execution *RuleSetExecution, builtinFunctions functions.Functions,
+	logger *slog.Logger) (map[string]bool, []error) {
+	if execution.RuleSet == nil {
+		return nil, nil
+	}
+	lookup := func(name string) model.RuleFunction {
+		if f := builtinFunctions.FindFunction(name); f != nil {
+			return f
+		}
+		return execution.CustomFunctions[name]
+	}
+
+	invalid := make(map[string]bool)
+	var errs []error
+	for _, d := range execution.RuleSet.Validate(lookup) {
+		if d.Severity != model.SeverityError {
+			logger.Warn("ruleset problem", "rule", d.RuleId, "problem", d.Message)
+			continue
+		}
+		if d.RuleId == "" {
+			errs = append(errs, fmt.Errorf("ruleset error: %s", d.Message))
+			continue
+		}
+		invalid[d.RuleId] = true
+		if len(d.Path) > 2 && d.Path[2] == "given" {
+			errs = append(errs, fmt.Errorf("ruleset error in rule '%s': %s", d.RuleId, d.Message))
+			continue
+		}
+		logger.Warn("rule cannot run and has been skipped", "rule", d.RuleId, "problem", d.Message)
+	}
+	return invalid, errs
+}
+
+// mergeRuleSetFunctions loads the functions declared by the ruleset (and the rulesets it extends), and adds them to
+// the custom functions of the execution. The supplied map is never changed.
+func mergeRuleSetFunctions(execution *RuleSetExecution) []error {
+	if execution.RuleSet == nil || len(execution.RuleSet.FunctionSources) == 0 {
+		return nil
+	}
+	var errs []error
+	merged := make(map[string]model.RuleFunction, len(execution.CustomFunctions))
+	for name, function := range execution.CustomFunctions {
+		merged[name] = function
+	}
+	for _, fs := range execution.RuleSet.FunctionSources {
+		if execution.FunctionLoader == nil {
+			errs = append(errs, fmt.Errorf("functions of ruleset '%s' cannot be loaded, no function loader was supplied",
+				fs.Namespace))
+			continue
+		}
+		loaded, err := fs.Load(execution.FunctionLoader)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for name, function := range loaded {
+			merged[name] = function
+		}
+	}
+	execution.CustomFunctions = merged
+	return errs
+}
+
 func buildResults(ctx ruleContext, ruleAction model.RuleAction, nodes []*yaml.Node) *[]model.RuleFunctionResult {
 
 	ruleFunction := ctx.builtinFunctions.FindFunction(ruleAction.Function)
@@ -790,6 +1020,16 @@ func buildResults(ctx ruleContext, ruleAction model.RuleAction, nodes []*yaml.No
 			Document:   ctx.document,
 			DrDocument: ctx.drDocument,
 			Logger:     ctx.logger,
+			Context:    ctx.runContext,
+		}
+
+		// a message template replaces whatever the function says, so the function runs without the template, and
+		// its own message becomes the {{error}} of the template.
+		templated := model.IsMessageTemplate(ctx.rule.Message)
+		if templated {
+			untemplated := *ctx.rule
+			untemplated.Message = ""
+			rfc.Rule = &untemplated
 		}
 
 		if !ctx.skipDocumentCheck && ctx.specInfo.SpecFormat == "" && ctx.specInfo.Version == "" {
@@ -804,7 +1044,11 @@ func buildResults(ctx ruleContext, ruleAction model.RuleAction, nodes []*yaml.No
 		if !res {
 			for _, e := range errs {
 				lock.Lock()
-				*ctx.ruleResults = append(*ctx.ruleResults, model.RuleFunctionResult{Message: e})
+				*ctx.ruleResults = append(*ctx.ruleResults, model.RuleFunctionResult{
+					Message: e,
+					RuleId:  ctx.rule.Id,
+					Rule:    ctx.rule,
+				})
 				lock.Unlock()
 			}
 		} else {
@@ -824,7 +1068,27 @@ func buildResults(ctx ruleContext, ruleAction model.RuleAction, nodes []*yaml.No
 					}
 				}
 
+				functionStart := time.Now()
 				runRuleResults := ruleFunction.RunRule([]*yaml.Node{node}, rfc)
+				ctx.profiler.function(functionStart, len(runRuleResults))
+
+				// generic functions don't know how to fix anything, the rule might.
+				if ctx.rule.AutoFix != nil {
+					for i := range runRuleResults {
+						if runRuleResults[i].Fix == nil {
+							runRuleResults[i].Fix = ctx.rule.AutoFix(&runRuleResults[i], rfc)
+						}
+					}
+				}
+
+				if templated {
+					for i := range runRuleResults {
+						if runRuleResults[i].Rule == rfc.Rule {
+							runRuleResults[i].Rule = ctx.rule
+						}
+						runRuleResults[i].Message = model.InterpolateMessage(ctx.rule.Message, &runRuleResults[i], ctx.rule)
+					}
+				}
 
 				// because this function is running in multiple threads, we need to sync access to the final result
 				// list, otherwise things can get a bit random.
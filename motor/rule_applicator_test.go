@@ -1,9 +1,14 @@
 package motor
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"log/slog"
 	"github.com/daveshanley/vacuum/plugin"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -640,12 +645,18 @@ func TestApplyRules_Length_Description_BadConfig(t *testing.T) {
 
 	burgershop, _ := os.ReadFile("../model/test_files/burgershop.openapi.yaml")
 
+	var logs bytes.Buffer
 	rse := &RuleSetExecution{
 		RuleSet: rs,
 		Spec:    burgershop,
+		Logger:  slog.New(slog.NewTextHandler(&logs, nil)),
 	}
 	results := ApplyRulesToRuleSet(rse)
-	assert.Len(t, results.Errors, 0)
+
+	// the rule is skipped, it does not stop the lint.
+	assert.Empty(t, results.Errors)
+	assert.Contains(t, logs.String(), "rule=length-test-description")
+	assert.Contains(t, logs.String(), "invalid options for function 'length'")
 	assert.Len(t, results.Results, 0)
 
 }
@@ -2279,3 +2290,554 @@ func Benchmark_StripeSpecAgainstDefaultRuleSet(b *testing.B) {
 		assert.NotNil(b, results)
 	}
 }
+
+func TestApplyRules_Overrides(t *testing.T) {
+
+	yml := `extends: [[spectral:oas, off]]
+overrides:
+  - files:
+      - "*.yaml#/paths/~1v1~1*"
+    rules:
+      operation-description: off
+  - files:
+      - "legacy/**/*.yaml"
+    rules:
+      path-keys-no-trailing-slash: warn`
+
+	defaultRuleSets := rulesets.BuildDefaultRuleSets()
+	userRS, err := rulesets.CreateRuleSetFromData([]byte(yml))
+	assert.NoError(t, err)
+
+	rs := defaultRuleSets.GenerateRuleSetFromSuppliedRuleSet(userRS)
+	rs.Rules[rulesets.OperationDescription] = rulesets.GetOperationDescriptionRule()
+	rs.Rules[rulesets.PathKeysNoTrailingSlash] = rulesets.GetPathNoTrailingSlashRule()
+	rs.Rules[rulesets.PathKeysNoTrailingSlash].Severity = model.SeverityError
+
+	spec := `openapi: 3.1.0
+paths:
+  /v1/pets/:
+    get:
+      operationId: getPets
+  /v2/pets:
+    get:
+      operationId: getPetsV2`
+
+	rse := &RuleSetExecution{
+		RuleSet:      rs,
+		Spec:         []byte(spec),
+		SpecFileName: "spec.yaml",
+	}
+	results := ApplyRulesToRuleSet(rse)
+	assert.Len(t, results.Results, 2)
+	for _, r := range results.Results {
+		switch r.RuleId {
+		case rulesets.OperationDescription:
+			assert.Contains(t, r.Path, "/v2/pets")
+		case rulesets.PathKeysNoTrailingSlash:
+			assert.Equal(t, model.SeverityError, r.Rule.Severity)
+		}
+	}
+
+	// same spec, but now in the legacy directory, the trailing slash rule is downgraded.
+	rse.SpecFileName = "legacy/v1/spec.yaml"
+	results = ApplyRulesToRuleSet(rse)
+	assert.Len(t, results.Results, 3)
+	for _, r := range results.Results {
+		if r.RuleId == rulesets.PathKeysNoTrailingSlash {
+			assert.Equal(t, model.SeverityWarn, r.Rule.Severity)
+		}
+	}
+
+	// the original rule must remain untouched.
+	assert.Equal(t, model.SeverityError, rs.Rules[rulesets.PathKeysNoTrailingSlash].Severity)
+}
+
+func TestApplyRules_Overrides_RuleSetDirectory(t *testing.T) {
+
+	yml := `extends: [[spectral:oas, off]]
+overrides:
+  - files:
+      - "../legacy/*.yaml"
+    rules:
+      operation-description: off`
+
+	defaultRuleSets := rulesets.BuildDefaultRuleSets()
+	userRS, err := rulesets.CreateRuleSetFromData([]byte(yml))
+	assert.NoError(t, err)
+	userRS.Location = filepath.Join("config", "ruleset.yaml")
+
+	rs := defaultRuleSets.GenerateRuleSetFromSuppliedRuleSet(userRS)
+	rs.Rules[rulesets.OperationDescription] = rulesets.GetOperationDescriptionRule()
+
+	spec := `openapi: 3.1.0
+paths:
+  /pets:
+    get:
+      operationId: getPets`
+
+	// globs are relative to the ruleset, not to where vacuum is run.
+	rse := &RuleSetExecution{RuleSet: rs, Spec: []byte(spec), SpecFileName: filepath.Join("legacy", "a.yaml")}
+	assert.Empty(t, ApplyRulesToRuleSet(rse).Results)
+
+	abs, _ := filepath.Abs(filepath.Join("legacy", "a.yaml"))
+	rse.SpecFileName = abs
+	assert.Empty(t, ApplyRulesToRuleSet(rse).Results)
+
+	rse.SpecFileName = filepath.Join("config", "legacy", "a.yaml")
+	assert.Len(t, ApplyRulesToRuleSet(rse).Results, 1)
+}
+
+func TestApplyRules_Aliases(t *testing.T) {
+
+	yml := `aliases:
+  PathItem: "$.paths[*]"
+  OperationObject:
+    - "#PathItem['get','post']"
+rules:
+  operation-summary:
+    given: "#OperationObject"
+    then:
+      field: summary
+      function: truthy`
+
+	rc := CreateRuleComposer()
+	rs, err := rc.ComposeRuleSet([]byte(yml))
+	assert.NoError(t, err)
+
+	spec := `openapi: 3.1.0
+paths:
+  /pets:
+    get:
+      summary: get pets
+    post:
+      operationId: postPets`
+
+	rse := &RuleSetExecution{
+		RuleSet: rs,
+		Spec:    []byte(spec),
+	}
+	results := ApplyRulesToRuleSet(rse)
+	assert.Len(t, results.Errors, 0)
+	assert.Len(t, results.Results, 1)
+	assert.Equal(t, "operation-summary", results.Results[0].RuleId)
+}
+
+func TestApplyRules_UnknownFunction_Skipped(t *testing.T) {
+	yml := `extends: [[spectral:oas, recommended]]
+rules:
+  broken-rule:
+    given: $.info
+    then:
+      function: notAFunction`
+
+	userRS, err := rulesets.CreateRuleSetFromData([]byte(yml))
+	assert.NoError(t, err)
+	rs := rulesets.BuildDefaultRuleSets().GenerateRuleSetFromSuppliedRuleSet(userRS)
+
+	burgershop, _ := os.ReadFile("../model/test_files/burgershop.openapi.yaml")
+	var logs bytes.Buffer
+	results := ApplyRulesToRuleSet(&RuleSetExecution{
+		RuleSet: rs,
+		Spec:    burgershop,
+		Logger:  slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+
+	// the broken rule is skipped, every other rule runs.
+	assert.Empty(t, results.Errors)
+	assert.NotEmpty(t, results.Results)
+	assert.Contains(t, logs.String(), "rule=broken-rule")
+	for _, r := range results.Results {
+		assert.NotEqual(t, "broken-rule", r.RuleId)
+	}
+}
+
+func TestApplyRules_Aliases_Undefined(t *testing.T) {
+
+	yml := `rules:
+  operation-summary:
+    given: "#OperationObject"
+    then:
+      field: summary
+      function: truthy`
+
+	rc := CreateRuleComposer()
+	rs, err := rc.ComposeRuleSet([]byte(yml))
+	assert.NoError(t, err)
+
+	rse := &RuleSetExecution{
+		RuleSet: rs,
+		Spec:    []byte("openapi: 3.1.0"),
+	}
+	results := ApplyRulesToRuleSet(rse)
+	assert.Len(t, results.Errors, 1)
+	assert.Contains(t, results.Errors[0].Error(), "'OperationObject' is not defined")
+}
+
+func TestApplyRules_Suppressions(t *testing.T) {
+
+	spec := `openapi: 3.1.0
+paths:
+  # vacuum-ignore path-keys-no-trailing-slash: legacy route
+  /v1/pets/:
+    get:
+      operationId: getPets
+  /v2/pets/:
+    x-lint-ignore: [path-keys-no-trailing-slash]
+    get:
+      operationId: getPetsV2
+  /v3/pets/:
+    get:
+      operationId: getPetsV3`
+
+	rules := make(map[string]*model.Rule)
+	rules[rulesets.PathKeysNoTrailingSlash] = rulesets.GetPathNoTrailingSlashRule()
+
+	rse := &RuleSetExecution{
+		RuleSet: &rulesets.RuleSet{Rules: rules},
+		Spec:    []byte(spec),
+	}
+	results := ApplyRulesToRuleSet(rse)
+	assert.Len(t, results.Results, 1)
+	assert.Contains(t, results.Results[0].Path, "/v3/pets/")
+	assert.Len(t, results.Suppressed, 2)
+	for _, s := range results.Suppressed {
+		assert.NotNil(t, s.Suppression)
+		if s.Suppression.Kind == model.SuppressionKindComment {
+			assert.Equal(t, "legacy route", s.Suppression.Reason)
+		}
+	}
+}
+
+func TestApplyRules_AutoFix(t *testing.T) {
+
+	spec := `openapi: 3.1.0
+info:
+  title: pets
+paths:
+  /v1/pets/:
+    get:
+      operationId: getPets
+  /v1/pets:
+    get:
+      operationId: getPetsAgain
+  /v2/pets/:
+    get:
+      operationId: getPetsV2`
+
+	rules := make(map[string]*model.Rule)
+	rules[rulesets.PathKeysNoTrailingSlash] = rulesets.GetPathNoTrailingSlashRule()
+	rules[rulesets.InfoContact] = rulesets.GetInfoContactRule()
+
+	rse := &RuleSetExecution{
+		RuleSet: &rulesets.RuleSet{Rules: rules},
+		Spec:    []byte(spec),
+	}
+	results := ApplyRulesToRuleSet(rse)
+	assert.Len(t, results.Results, 3)
+	for _, r := range results.Results {
+		switch {
+		case r.RuleId == rulesets.InfoContact:
+			assert.NotNil(t, r.Fix)
+			assert.Equal(t, model.FixInsert, r.Fix.Edits[0].Kind)
+			assert.Equal(t, "contact", r.Fix.Edits[0].Key)
+		case strings.Contains(r.Path, "/v1/pets/"):
+			assert.Nil(t, r.Fix) // '/v1/pets' already exists.
+		default:
+			assert.NotNil(t, r.Fix)
+			assert.Equal(t, "/v2/pets", r.Fix.Edits[0].NewValue)
+		}
+	}
+}
+
+func TestApplyRules_Files(t *testing.T) {
+
+	dir := t.TempDir()
+	spec := `openapi: 3.1.0
+paths:
+  /pets:
+    get:
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: './pet.yaml'`
+	pet := `type: object
+properties:
+  name:
+    type: string`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "spec.yaml"), []byte(spec), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pet.yaml"), []byte(pet), 0644))
+
+	rse := &RuleSetExecution{
+		RuleSet:      rulesets.BuildDefaultRuleSets().GenerateOpenAPIRecommendedRuleSet(),
+		Spec:         []byte(spec),
+		SpecFileName: filepath.Join(dir, "spec.yaml"),
+		Base:         dir,
+		AllowLookup:  true,
+	}
+	results := ApplyRulesToRuleSet(rse)
+	assert.Empty(t, results.Errors)
+	assert.Contains(t, results.Files, filepath.Join(dir, "pet.yaml"))
+}
+
+func TestApplyRules_MessageTemplate(t *testing.T) {
+
+	ruleset := `rules:
+  lowercase-properties:
+    description: Property names must be lowercase
+    message: "{{property}} is not allowed: {{error}}"
+    given: $.components.schemas.Pet.properties[*]~
+    then:
+      function: pattern
+      functionOptions:
+        match: "^[a-z]+$"
+  info-contact:
+    description: Info must have a contact
+    message: "{{description}}, check {{path}} ({{value}})"
+    given: $.info
+    then:
+      field: contact
+      function: truthy`
+
+	spec := `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+components:
+  schemas:
+    Pet:
+      properties:
+        petName:
+          type: string`
+
+	userRS, err := rulesets.CreateRuleSetFromData([]byte(ruleset))
+	assert.NoError(t, err)
+	rs := rulesets.BuildDefaultRuleSets().GenerateRuleSetFromSuppliedRuleSet(userRS)
+
+	results := ApplyRulesToRuleSet(&RuleSetExecution{RuleSet: rs, Spec: []byte(spec)})
+	assert.Empty(t, results.Errors)
+
+	messages := make(map[string]string)
+	for _, r := range results.Results {
+		messages[r.Rule.Id] = r.Message
+	}
+	assert.Equal(t, "petName is not allowed: `petName` does not match the expression `^[a-z]+$`",
+		messages["lowercase-properties"])
+	assert.Equal(t, `Info must have a contact, check $.info ({"title":"pets","version":"1.0.0"})`,
+		messages["info-contact"])
+}
+
+func TestApplyRules_TestRules_Custom_Spectral_Function(t *testing.T) {
+
+	yamlBytes := `rules:
+  operation-summary:
+    description: "operations need a good summary"
+    given: $.paths[*][*]
+    severity: warn
+    then:
+      function: spectral_operation_summary
+      functionOptions:
+        minLength: 10
+`
+
+	defaultRuleSets := rulesets.BuildDefaultRuleSets()
+	userRS, userErr := rulesets.CreateRuleSetFromData([]byte(yamlBytes))
+	assert.NoError(t, userErr)
+	rs := defaultRuleSets.GenerateRuleSetFromSuppliedRuleSet(userRS)
+
+	spec := `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      summary: Pets
+      responses: {}
+    post:
+      summary: Add a pet to the store
+      responses: {}
+`
+	pm, err := plugin.LoadFunctions("../plugin/sample/js", true)
+	assert.NoError(t, err)
+
+	results := ApplyRulesToRuleSet(&RuleSetExecution{
+		RuleSet:         rs,
+		Spec:            []byte(spec),
+		CustomFunctions: pm.GetCustomFunctions(),
+	})
+
+	assert.Len(t, results.Results, 1)
+	assert.Equal(t, "summary 'Pets' is shorter than 10 characters", results.Results[0].Message)
+	assert.Equal(t, "$.paths['/pets'].get.summary", results.Results[0].Path)
+	assert.Equal(t, "operation-summary", results.Results[0].RuleId)
+	assert.Equal(t, 8, results.Results[0].Range.Start.Line)
+}
+
+func TestApplyRules_RuleSet_Functions(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		location := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(location), 0o755))
+		assert.NoError(t, os.WriteFile(location, []byte(content), 0o644))
+		return location
+	}
+
+	// two rulesets, each with their own 'checkTitle' function.
+	write("functions/checkTitle.js", `function runRule(input) {
+	return [{ message: 'root checked ' + input.title }];
+}`)
+	write("shared/fns/checkTitle.js", `export default (input) => [{ message: 'shared checked ' + input }];`)
+	shared := write("shared/shared.yaml", `functionsDir: fns
+functions: [checkTitle]
+rules:
+  shared-title:
+    given: $.info
+    then:
+      field: title
+      function: checkTitle
+`)
+	root := write("ruleset.yaml", `extends: [[`+shared+`, off]]
+functions: [checkTitle]
+rules:
+  root-title:
+    given: $.info
+    then:
+      field: title
+      function: checkTitle
+`)
+
+	rsBytes, _ := os.ReadFile(root)
+	userRS, err := rulesets.CreateRuleSetFromData(rsBytes)
+	assert.NoError(t, err)
+	userRS.Location = root
+	rs := rulesets.BuildDefaultRuleSets().GenerateRuleSetFromSuppliedRuleSet(userRS)
+
+	spec := `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+paths: {}
+`
+	supplied := map[string]model.RuleFunction{}
+	execution := &RuleSetExecution{RuleSet: rs, Spec: []byte(spec), CustomFunctions: supplied,
+		FunctionLoader: plugin.LoadFunctionSource}
+	results := ApplyRulesToRuleSet(execution)
+
+	assert.Empty(t, results.Errors)
+	messages := make(map[string]string)
+	for _, r := range results.Results {
+		messages[r.RuleId] = r.Message
+	}
+	assert.Equal(t, map[string]string{
+		"root-title":   "root checked pets",
+		"shared-title": "shared checked pets",
+	}, messages)
+	assert.Len(t, execution.CustomFunctions, 2)
+	assert.Empty(t, supplied)
+}
+
+func TestApplyRules_RuleSet_Functions_Missing(t *testing.T) {
+	userRS, err := rulesets.CreateRuleSetFromData([]byte(`functions: [nothing]
+rules:
+  nothing-rule:
+    given: $.info
+    then:
+      function: nothing
+`))
+	assert.NoError(t, err)
+	userRS.Location = filepath.Join(t.TempDir(), "ruleset.yaml")
+	rs := rulesets.BuildDefaultRuleSets().GenerateRuleSetFromSuppliedRuleSet(userRS)
+
+	spec := []byte("openapi: 3.1.0\ninfo:\n  title: a\n")
+	results := ApplyRulesToRuleSet(&RuleSetExecution{RuleSet: rs, Spec: spec, FunctionLoader: plugin.LoadFunctionSource})
+	assert.Len(t, results.Errors, 1)
+	assert.Contains(t, results.Errors[0].Error(), "unable to load functions of ruleset")
+
+	// without a loader, the functions of the ruleset cannot be loaded.
+	results = ApplyRulesToRuleSet(&RuleSetExecution{RuleSet: rs, Spec: spec})
+	assert.Contains(t, results.Errors[0].Error(), "no function loader was supplied")
+}
+
+type sleepyFunction struct{}
+
+func (s sleepyFunction) GetSchema() model.RuleFunctionSchema {
+	return model.RuleFunctionSchema{Name: "sleepy"}
+}
+
+func (s sleepyFunction) RunRule(_ []*yaml.Node, _ model.RuleFunctionContext) []model.RuleFunctionResult {
+	time.Sleep(500 * time.Millisecond)
+	return nil
+}
+
+func TestApplyRulesToRuleSet_Profile(t *testing.T) {
+	rs, err := rulesets.CreateRuleSetFromData([]byte(`rules:
+  title-truthy:
+    given: $.info
+    then:
+      field: summary
+      function: truthy
+  paths-truthy:
+    given: $.paths[*]
+    then:
+      field: description
+      function: truthy
+  sleepy:
+    given: $
+    then:
+      function: sleepy
+`))
+	assert.NoError(t, err)
+	rs = rulesets.BuildDefaultRuleSets().GenerateRuleSetFromSuppliedRuleSet(rs)
+
+	burgershop, _ := os.ReadFile("../model/test_files/burgershop.openapi.yaml")
+	results := ApplyRulesToRuleSet(&RuleSetExecution{
+		RuleSet:         rs,
+		Spec:            burgershop,
+		CustomFunctions: map[string]model.RuleFunction{"sleepy": sleepyFunction{}},
+		Timeout:         100 * time.Millisecond,
+		Profile:         true,
+	})
+	assert.Empty(t, results.Errors)
+	assert.Len(t, results.Profiles, 3)
+
+	profiles := make(map[string]model.RuleProfile)
+	for _, p := range results.Profiles {
+		profiles[p.RuleId] = p
+	}
+	assert.Equal(t, "sleepy", results.Profiles[0].RuleId)
+	assert.True(t, profiles["sleepy"].TimedOut)
+	assert.False(t, profiles["title-truthy"].TimedOut)
+	assert.Equal(t, 1, profiles["title-truthy"].Nodes)
+	assert.Equal(t, 1, profiles["title-truthy"].Results)
+	assert.Equal(t, 5, profiles["paths-truthy"].Nodes)
+	assert.Greater(t, profiles["paths-truthy"].Lookup, time.Duration(0))
+	assert.Greater(t, profiles["paths-truthy"].Allocations, uint64(0))
+}
+
+func TestApplyRulesToRuleSet_NoProfile(t *testing.T) {
+	burgershop, _ := os.ReadFile("../model/test_files/burgershop.openapi.yaml")
+	results := ApplyRulesToRuleSet(&RuleSetExecution{
+		RuleSet: rulesets.BuildDefaultRuleSets().GenerateOpenAPIRecommendedRuleSet(),
+		Spec:    burgershop,
+	})
+	assert.Nil(t, results.Profiles)
+}
+
+func TestApplyRulesToRuleSet_Cancelled(t *testing.T) {
+	rs := rulesets.BuildDefaultRuleSets().GenerateOpenAPIRecommendedRuleSet()
+	burgershop, _ := os.ReadFile("../model/test_files/burgershop.openapi.yaml")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := ApplyRulesToRuleSet(&RuleSetExecution{
+		RuleSet: rs,
+		Spec:    burgershop,
+		Context: ctx,
+	})
+	assert.Len(t, results.Errors, 1)
+	assert.ErrorIs(t, results.Errors[0], context.Canceled)
+	assert.Empty(t, results.Results)
+}
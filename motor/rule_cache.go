@@ -0,0 +1,213 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package motor
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"sort"
+
+	"github.com/daveshanley/vacuum/cache"
+	"github.com/daveshanley/vacuum/model"
+	"github.com/pb33f/libopenapi/index"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	treeUnresolved = "unresolved"
+	treeResolved   = "resolved"
+)
+
+// ruleCache replays and records the results of rules, for a single execution. A result is keyed by the rule,
+// the execution options and the bytes of every file the rule can see. Document scoped rules only see the root
+// document, so changing a referenced file does not invalidate them.
+//
+// Nodes are cached by their position in a walk of the parsed trees, the same bytes always parse into the same
+// trees, so the nodes of replayed results are the real nodes of the document, not copies. Suppressions, origins
+// and everything else that needs real nodes keeps working.
+type ruleCache struct {
+	cache    *cache.Cache
+	options  []byte
+	aliases  []byte
+	document []byte // hash of the root document.
+	global   []byte // hash of every file in the rolodex, nil if a file could not be read.
+	refs     map[*yaml.Node]cache.NodeRef
+	trees    map[string][]*yaml.Node
+}
+
+type recordedRule struct {
+	rule    *model.Rule
+	results []model.RuleFunctionResult
+}
+
+func newRuleCache(c *cache.Cache, execution *RuleSetExecution, specUnresolved, specResolved *yaml.Node,
+	rolodex *index.Rolodex) *ruleCache {
+
+	rc := &ruleCache{
+		cache: c,
+		refs:  make(map[*yaml.Node]cache.NodeRef),
+		trees: make(map[string][]*yaml.Node),
+	}
+	rc.options, _ = json.Marshal([]any{execution.SpecFileName, execution.Base, execution.AllowLookup,
+		execution.SkipDocumentCheck, execution.IgnoreCircularArrayRef, execution.IgnoreCircularPolymorphicRef})
+	if execution.RuleSet != nil && len(execution.RuleSet.Aliases) > 0 {
+		rc.aliases, _ = json.Marshal(execution.RuleSet.Aliases)
+	}
+
+	doc := sha256.Sum256(execution.Spec)
+	rc.document = doc[:]
+
+	// the unresolved tree is walked first, it only depends on the root document.
+	visited := make(map[*yaml.Node]bool)
+	rc.walk(treeUnresolved, specUnresolved, visited)
+	rc.walk(treeResolved, specResolved, visited)
+
+	if rolodex == nil {
+		rc.global = rc.document
+		return rc
+	}
+
+	var indexes []*index.SpecIndex
+	for _, idx := range rolodex.GetIndexes() {
+		if idx != nil && idx != rolodex.GetRootIndex() {
+			indexes = append(indexes, idx)
+		}
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i].GetSpecAbsolutePath() < indexes[j].GetSpecAbsolutePath()
+	})
+
+	global := sha256.New()
+	global.Write(execution.Spec)
+	for _, idx := range indexes {
+		location := idx.GetSpecAbsolutePath()
+		f, err := rolodex.Open(location)
+		if err != nil || f == nil {
+			return rc // can't tell if this file changed, so global rules are not cached.
+		}
+		global.Write([]byte(location))
+		global.Write([]byte(f.GetContent()))
+		rc.walk(location, idx.GetRootNode(), visited)
+	}
+	rc.global = global.Sum(nil)
+	return rc
+}
+
+// walk numbers every node of a tree that has not been seen before, depth first. Resolved trees share nodes
+// between files (and can loop), a node belongs to the first tree it was found in.
+func (rc *ruleCache) walk(tree string, node *yaml.Node, visited map[*yaml.Node]bool) {
+	if node == nil || visited[node] {
+		return
+	}
+	visited[node] = true
+	rc.refs[node] = cache.NodeRef{Tree: tree, Ordinal: len(rc.trees[tree]), Line: node.Line, Column: node.Column}
+	rc.trees[tree] = append(rc.trees[tree], node)
+	for _, n := range node.Content {
+		rc.walk(tree, n, visited)
+	}
+}
+
+// key returns the cache key of a rule, or an empty string if the rule can't be cached.
+func (rc *ruleCache) key(rule *model.Rule) string {
+	definition, err := json.Marshal(rule)
+	if err != nil {
+		return ""
+	}
+	files := rc.global
+	if rule.Scope == model.RuleScopeDocument && !rule.Resolved {
+		files = rc.document
+	}
+	if files == nil {
+		return ""
+	}
+	return rc.cache.Key(definition, rc.aliases, rc.options, files)
+}
+
+// replay returns the cached results of a rule. If the rule is not cached, or a node can't be found, the rule
+// has to run again.
+func (rc *ruleCache) replay(rule *model.Rule) ([]model.RuleFunctionResult, bool) {
+	key := rc.key(rule)
+	if key == "" {
+		return nil, false
+	}
+	entry, ok := rc.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	results := make([]model.RuleFunctionResult, 0, len(entry.Results))
+	for _, cached := range entry.Results {
+		start, sOk := rc.node(cached.StartNode)
+		end, eOk := rc.node(cached.EndNode)
+		if !sOk || !eOk {
+			return nil, false
+		}
+		result := model.RuleFunctionResult{
+			Message:      cached.Message,
+			Range:        cached.Range,
+			Path:         cached.Path,
+			RuleId:       cached.RuleId,
+			RuleSeverity: cached.RuleSeverity,
+			StartNode:    start,
+			EndNode:      end,
+		}
+		if cached.HasRule {
+			result.Rule = rule
+		}
+		results = append(results, result)
+	}
+	return results, true
+}
+
+// record stores the results of a rule.
+func (rc *ruleCache) record(rule *model.Rule, results []model.RuleFunctionResult) error {
+	key := rc.key(rule)
+	if key == "" {
+		return nil
+	}
+	entry := &cache.Entry{RuleId: rule.Id, Results: make([]cache.Result, 0, len(results))}
+	for _, r := range results {
+		entry.Results = append(entry.Results, cache.Result{
+			Message:      r.Message,
+			Range:        r.Range,
+			Path:         r.Path,
+			RuleId:       r.RuleId,
+			RuleSeverity: r.RuleSeverity,
+			HasRule:      r.Rule != nil,
+			StartNode:    rc.ref(r.StartNode),
+			EndNode:      rc.ref(r.EndNode),
+		})
+	}
+	return rc.cache.Put(key, entry)
+}
+
+func (rc *ruleCache) ref(node *yaml.Node) *cache.NodeRef {
+	if node == nil {
+		return nil
+	}
+	if ref, ok := rc.refs[node]; ok {
+		return &ref
+	}
+
+	// created by the rule, not part of the document.
+	return &cache.NodeRef{Ordinal: -1, Line: node.Line, Column: node.Column, Kind: node.Kind, Tag: node.Tag,
+		Value: node.Value}
+}
+
+func (rc *ruleCache) node(ref *cache.NodeRef) (*yaml.Node, bool) {
+	if ref == nil {
+		return nil, true
+	}
+	if ref.Ordinal < 0 {
+		return &yaml.Node{Kind: ref.Kind, Tag: ref.Tag, Value: ref.Value, Line: ref.Line, Column: ref.Column}, true
+	}
+	nodes := rc.trees[ref.Tree]
+	if ref.Ordinal >= len(nodes) {
+		return nil, false
+	}
+	node := nodes[ref.Ordinal]
+	if node.Line != ref.Line || node.Column != ref.Column {
+		return nil, false // the document is not the one the result came from.
+	}
+	return node, true
+}
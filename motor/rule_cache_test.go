@@ -0,0 +1,131 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package motor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/daveshanley/vacuum/cache"
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func summarizeResults(results []model.RuleFunctionResult) []string {
+	var summary []string
+	for _, r := range results {
+		line, col := 0, 0
+		if r.StartNode != nil {
+			line, col = r.StartNode.Line, r.StartNode.Column
+		}
+		summary = append(summary, fmt.Sprintf("%s:%d:%d:%s:%s", r.RuleId, line, col, r.Path, r.Message))
+	}
+	sort.Strings(summary)
+	return summary
+}
+
+func TestApplyRules_Cache(t *testing.T) {
+
+	dir := t.TempDir()
+	spec := `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+servers:
+  - url: https://pets.example.com/
+paths:
+  /pets:
+    # vacuum-ignore operation-description
+    get:
+      operationId: getPets
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: './pet.yaml'`
+	pet := `type: object
+properties:
+  name:
+    type: string`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "spec.yaml"), []byte(spec), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pet.yaml"), []byte(pet), 0644))
+
+	c, err := cache.NewCache(filepath.Join(dir, "cache"), "test")
+	assert.NoError(t, err)
+
+	rs := rulesets.BuildDefaultRuleSets().GenerateOpenAPIRecommendedRuleSet()
+	lint := func() *RuleSetExecutionResult {
+		return ApplyRulesToRuleSet(&RuleSetExecution{
+			RuleSet:      rs,
+			Spec:         []byte(spec),
+			SpecFileName: filepath.Join(dir, "spec.yaml"),
+			Base:         dir,
+			AllowLookup:  true,
+			Cache:        c,
+		})
+	}
+
+	first := lint()
+	assert.Empty(t, first.Errors)
+	assert.Equal(t, 0, first.CachedRules)
+	assert.NotEmpty(t, first.Results)
+	assert.Len(t, first.Suppressed, 1)
+
+	// nothing changed, every rule is replayed, and nodes are real enough to be suppressed.
+	second := lint()
+	assert.Empty(t, second.Errors)
+	assert.Equal(t, len(rs.Rules), second.CachedRules)
+	assert.Equal(t, summarizeResults(first.Results), summarizeResults(second.Results))
+	assert.Len(t, second.Suppressed, 1)
+
+	// a referenced file changed, only rules scoped to the root document are still cached.
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pet.yaml"), []byte(pet+"\n  age:\n    type: integer"), 0644))
+	documentRules := 0
+	for _, r := range rs.Rules {
+		if r.Scope == model.RuleScopeDocument && !r.Resolved {
+			documentRules++
+		}
+	}
+	third := lint()
+	assert.Empty(t, third.Errors)
+	assert.Greater(t, documentRules, 0)
+	assert.Equal(t, documentRules, third.CachedRules)
+}
+
+func TestRuleCache_Nodes(t *testing.T) {
+
+	var root yaml.Node
+	_ = yaml.Unmarshal([]byte("a:\n  b: c"), &root)
+
+	rc := &ruleCache{refs: make(map[*yaml.Node]cache.NodeRef), trees: make(map[string][]*yaml.Node)}
+	rc.walk(treeUnresolved, &root, make(map[*yaml.Node]bool))
+
+	c := root.Content[0].Content[1].Content[1]
+	ref := rc.ref(c)
+	node, ok := rc.node(ref)
+	assert.True(t, ok)
+	assert.Same(t, c, node)
+
+	// nodes that are not part of the document are re-created.
+	synthetic := &yaml.Node{Kind: yaml.ScalarNode, Value: "nope", Line: 9, Column: 3}
+	ref = rc.ref(synthetic)
+	assert.Equal(t, -1, ref.Ordinal)
+	node, ok = rc.node(ref)
+	assert.True(t, ok)
+	assert.Equal(t, "nope", node.Value)
+	assert.Equal(t, 9, node.Line)
+
+	// a node that moved means a different document.
+	_, ok = rc.node(&cache.NodeRef{Tree: treeUnresolved, Ordinal: 1, Line: 5, Column: 1})
+	assert.False(t, ok)
+	_, ok = rc.node(&cache.NodeRef{Tree: treeUnresolved, Ordinal: 100})
+	assert.False(t, ok)
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package motor
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/rulesets"
+)
+
+// applyOverrides runs through all results and applies any ruleset overrides that match the file and path the
+// result was found in. Results for rules switched 'off' are removed, results that have a new severity are
+// re-pointed at a copy of the rule with the overridden severity, so the original rule is never mutated.
+// Overrides are evaluated in order, the last matching override wins. Like Spectral, file globs are relative to the
+// ruleset, when its location is known, and to the working directory when it's not.
+func applyOverrides(results []model.RuleFunctionResult, execution *RuleSetExecution) []model.RuleFunctionResult {
+	overrides := execution.RuleSet.Overrides
+	var dir string
+	if location := execution.RuleSet.Location; location != "" && !strings.Contains(location, "://") {
+		dir = filepath.Dir(location)
+	}
+	overriddenRules := make(map[string]*model.Rule)
+	var filtered []model.RuleFunctionResult
+
+	for _, result := range results {
+		if result.Rule == nil {
+			filtered = append(filtered, result)
+			continue
+		}
+
+		fileName := execution.SpecFileName
+		if result.Origin != nil && result.Origin.AbsoluteLocation != "" {
+			fileName = result.Origin.AbsoluteLocation
+		}
+
+		severity := ""
+		for _, override := range overrides {
+			sev, ok := override.GetSeverityForRule(result.Rule.Id)
+			if !ok || !override.MatchesFrom(dir, fileName, result.Path) {
+				continue
+			}
+			severity = sev
+		}
+
+		switch severity {
+		case "", result.Rule.Severity:
+			filtered = append(filtered, result)
+		case rulesets.SpectralOff:
+			continue
+		default:
+			key := result.Rule.Id + ":" + severity
+			rule := overriddenRules[key]
+			if rule == nil {
+				copied := *result.Rule
+				copied.Severity = severity
+				rule = &copied
+				overriddenRules[key] = rule
+			}
+			result.Rule = rule
+			result.RuleSeverity = severity
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
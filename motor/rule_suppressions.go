@@ -0,0 +1,45 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package motor
+
+import (
+	"github.com/daveshanley/vacuum/model"
+	"github.com/pb33f/libopenapi/index"
+	"gopkg.in/yaml.v3"
+)
+
+// applySuppressions separates results that have been waived using `vacuum-ignore` comments or `x-lint-ignore`
+// extensions from the rest of the results. Suppressed results are returned separately, with the suppression
+// attached, so they can be reported on.
+func applySuppressions(results []model.RuleFunctionResult, roots []*yaml.Node,
+	rolodexes ...*index.Rolodex) ([]model.RuleFunctionResult, []model.RuleFunctionResult) {
+
+	// every file in the rolodex can declare suppressions, not just the root document.
+	for _, rolodex := range rolodexes {
+		if rolodex == nil {
+			continue
+		}
+		for _, idx := range rolodex.GetIndexes() {
+			if idx != nil {
+				roots = append(roots, idx.GetRootNode())
+			}
+		}
+	}
+
+	suppressionIndex := model.BuildSuppressionIndex(roots...)
+	if suppressionIndex.Len() == 0 {
+		return results, nil
+	}
+
+	var kept, suppressed []model.RuleFunctionResult
+	for _, result := range results {
+		if s := suppressionIndex.FindSuppression(&result); s != nil {
+			result.Suppression = s
+			suppressed = append(suppressed, result)
+			continue
+		}
+		kept = append(kept, result)
+	}
+	return kept, suppressed
+}
@@ -0,0 +1,502 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package javascript
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/dop251/goja"
+	drv3 "github.com/pb33f/doctor/model/high/v3"
+	"github.com/pb33f/libopenapi/index"
+	"github.com/pb33f/libopenapi/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// maxRefDepth is how many references resolve follows before giving up, references can be circular.
+const maxRefDepth = 32
+
+var operationMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// documentAPI is the read-only 'vacuum' global available to JavaScript functions. It lets functions look at the
+// whole document, not just the node they are given.
+//
+//	vacuum.input                       the node the function is checking.
+//	vacuum.node(path)                  the node at a JSONPath, or null.
+//	vacuum.resolve(nodeOrRef)          the node a reference points to, or the node itself if it's not a reference.
+//	vacuum.document.paths()            every path, with its operations.
+//	vacuum.document.operations()       every operation.
+//	vacuum.document.schemas()          every schema.
+//	vacuum.index.findComponent(ref)    the node of a component, or null.
+//	vacuum.index.components(type)      every component of a type, e.g. 'schemas'.
+//	vacuum.index.references()          every reference, and the node it points to.
+//
+// Nodes are objects with a line, column, kind and (for references) a ref. value() decodes a node, get(key) returns
+// the child node with a key or index, keys() returns the keys of a map and path() returns the JSONPath of the node.
+// A result returned with a node (or a path) is reported at that node, instead of the node being checked.
+type documentAPI struct {
+	vm      *goja.Runtime
+	context model.RuleFunctionContext
+	root    *yaml.Node
+	paths   *nodePaths
+	nodes   []*yaml.Node
+	global  *goja.Object
+}
+
+// resultLocation is where a result returned by a function points, start is nil if it points nowhere.
+type resultLocation struct {
+	start, end *yaml.Node
+	path       string
+}
+
+// nodePaths remembers the path to every node of the last document seen, every rule using a function looks at
+// the same document.
+type nodePaths struct {
+	lock  sync.Mutex
+	root  *yaml.Node
+	paths map[*yaml.Node][]any
+}
+
+func (n *nodePaths) of(root *yaml.Node) map[*yaml.Node][]any {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.paths == nil || n.root != root {
+		n.root = root
+		n.paths = make(map[*yaml.Node][]any)
+		if root != nil {
+			mapPaths(root, []any{}, n.paths)
+		}
+	}
+	return n.paths
+}
+
+// documentRoot returns the root node of an indexed document.
+func documentRoot(idx *index.SpecIndex) *yaml.Node {
+	if idx == nil {
+		return nil
+	}
+	root := idx.GetRootNode()
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	return root
+}
+
+func newDocumentAPI(vm *goja.Runtime, context model.RuleFunctionContext, paths *nodePaths) *documentAPI {
+	return &documentAPI{vm: vm, context: context, root: documentRoot(context.Index), paths: paths}
+}
+
+// install sets the 'vacuum' global.
+func (a *documentAPI) install() error {
+	api := a.vm.NewObject()
+	a.global = api
+	_ = api.Set("node", func(path string) goja.Value {
+		if a.root == nil {
+			return goja.Null()
+		}
+		found, err := utils.FindNodesWithoutDeserializing(a.root, path)
+		if err != nil || len(found) == 0 {
+			return goja.Null()
+		}
+		return a.node(found[0])
+	})
+	_ = api.Set("resolve", a.resolve)
+
+	document := a.vm.NewObject()
+	_ = document.Set("paths", a.documentPaths)
+	_ = document.Set("operations", a.operations)
+	_ = document.Set("schemas", a.schemas)
+	_ = api.Set("document", document)
+
+	idx := a.vm.NewObject()
+	_ = idx.Set("findComponent", func(ref string) goja.Value {
+		return a.node(a.findRef(ref))
+	})
+	_ = idx.Set("components", a.components)
+	_ = idx.Set("references", a.references)
+	_ = api.Set("index", idx)
+
+	return a.vm.Set("vacuum", api)
+}
+
+// setInput sets the node the function is checking.
+func (a *documentAPI) setInput(input *yaml.Node) {
+	_ = a.global.Set("input", a.node(input))
+}
+
+// locate returns where a result points, from the node or the path (a JSONPath, or a list of segments) it was
+// returned with.
+func (a *documentAPI) locate(node, path any) resultLocation {
+	if n := a.lookup(node); n != nil {
+		loc := resultLocation{start: n, end: n}
+		if p, ok := a.pathOf(n); ok {
+			loc.path = JSONPath(p)
+		} else if s, isString := path.(string); isString {
+			loc.path = s
+		}
+		return loc
+	}
+	switch p := path.(type) {
+	case string:
+		if a.root != nil && p != "" {
+			if found, err := utils.FindNodesWithoutDeserializing(a.root, p); err == nil && len(found) > 0 {
+				return resultLocation{start: found[0], end: found[0], path: p}
+			}
+		}
+	case []any:
+		if start, end := locate(a.root, p); start != nil {
+			return resultLocation{start: start, end: end, path: JSONPath(p)}
+		}
+	}
+	return resultLocation{}
+}
+
+// node wraps a node for JavaScript, nil is null.
+func (a *documentAPI) node(n *yaml.Node) goja.Value {
+	if n == nil {
+		return goja.Null()
+	}
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	id := len(a.nodes)
+	a.nodes = append(a.nodes, n)
+
+	o := a.vm.NewObject()
+	_ = o.Set("__node", id)
+	_ = o.Set("line", n.Line)
+	_ = o.Set("column", n.Column)
+	_ = o.Set("kind", kindName(n.Kind))
+	if ref := refOf(n); ref != "" {
+		_ = o.Set("ref", ref)
+	}
+	_ = o.Set("value", func() any {
+		var v any
+		_ = n.Decode(&v)
+		return v
+	})
+	_ = o.Set("get", func(key goja.Value) goja.Value {
+		_, value := child(n, key.Export())
+		return a.node(value)
+	})
+	_ = o.Set("keys", func() []string {
+		keys := []string{}
+		if n.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				keys = append(keys, n.Content[i].Value)
+			}
+		}
+		return keys
+	})
+	_ = o.Set("path", func() goja.Value {
+		if path, ok := a.pathOf(n); ok {
+			return a.vm.ToValue(JSONPath(path))
+		}
+		return goja.Null()
+	})
+	return o
+}
+
+// lookup returns the node a JavaScript node object wraps.
+func (a *documentAPI) lookup(v any) *yaml.Node {
+	o, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+	var id int
+	switch n := o["__node"].(type) {
+	case int64:
+		id = int(n)
+	case float64:
+		id = int(n)
+	default:
+		return nil
+	}
+	if id < 0 || id >= len(a.nodes) {
+		return nil
+	}
+	return a.nodes[id]
+}
+
+// pathOf returns the path to a node in the document, if it's in the document.
+func (a *documentAPI) pathOf(n *yaml.Node) ([]any, bool) {
+	if a.root == nil {
+		return nil, false
+	}
+	path, ok := a.paths.of(a.root)[n]
+	return path, ok
+}
+
+// resolve follows references, from a node, a reference string or a decoded {$ref} value.
+func (a *documentAPI) resolve(v goja.Value) goja.Value {
+	var n *yaml.Node
+	ref := ""
+	switch x := v.Export().(type) {
+	case string:
+		ref = x
+	case map[string]any:
+		if n = a.lookup(x); n == nil {
+			ref, _ = x["$ref"].(string)
+		}
+	}
+	if n == nil && ref != "" {
+		n = a.findRef(ref)
+	}
+	for i := 0; n != nil && i < maxRefDepth; i++ {
+		r := refOf(n)
+		if r == "" {
+			break
+		}
+		next := a.findRef(r)
+		if next == nil {
+			break
+		}
+		n = next
+	}
+	return a.node(n)
+}
+
+func (a *documentAPI) findRef(ref string) *yaml.Node {
+	idx := a.context.Index
+	if idx == nil || ref == "" {
+		return nil
+	}
+	if r := idx.FindComponent(ref); r != nil && r.Node != nil {
+		return r.Node
+	}
+	if r, _ := idx.SearchIndexForReference(ref); r != nil {
+		return r.Node
+	}
+	return nil
+}
+
+// documentPaths returns every path of the document, and its operations.
+func (a *documentAPI) documentPaths() []any {
+	var paths []any
+	var current map[string]any
+	for _, op := range a.collectOperations() {
+		if current == nil || current["path"] != op["path"] {
+			current = map[string]any{"path": op["path"], "node": op["pathItem"], "operations": []any{}}
+			paths = append(paths, current)
+		}
+		if op["method"] != nil {
+			current["operations"] = append(current["operations"].([]any), op)
+		}
+	}
+	if paths == nil {
+		return []any{}
+	}
+	return paths
+}
+
+// operations returns every operation of the document.
+func (a *documentAPI) operations() []any {
+	ops := []any{}
+	for _, op := range a.collectOperations() {
+		if op["method"] != nil {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// collectOperations reads operations from the doctor model when there is one, otherwise from the paths of the
+// document. Path items without operations are included, with no method.
+func (a *documentAPI) collectOperations() []map[string]any {
+	var ops []map[string]any
+	add := func(path string, pathItem *yaml.Node, method string, op *yaml.Node) {
+		o := map[string]any{"path": path, "pathItem": a.node(pathItem)}
+		if op != nil {
+			o["method"] = method
+			o["node"] = a.node(op)
+			var decoded struct {
+				OperationId string   `yaml:"operationId"`
+				Summary     string   `yaml:"summary"`
+				Tags        []string `yaml:"tags"`
+				Deprecated  bool     `yaml:"deprecated"`
+			}
+			_ = op.Decode(&decoded)
+			o["operationId"] = decoded.OperationId
+			o["summary"] = decoded.Summary
+			o["tags"] = decoded.Tags
+			o["deprecated"] = decoded.Deprecated
+		}
+		ops = append(ops, o)
+	}
+
+	if dr := a.context.DrDocument; dr != nil && dr.V3Document != nil && dr.V3Document.Paths != nil &&
+		dr.V3Document.Paths.PathItems != nil {
+		for pair := dr.V3Document.Paths.PathItems.First(); pair != nil; pair = pair.Next() {
+			pi := pair.Value()
+			var piNode *yaml.Node
+			if pi.Value != nil && pi.Value.GoLow() != nil {
+				piNode = pi.Value.GoLow().RootNode
+			}
+			found := false
+			for _, m := range operationMethods {
+				op := drOperation(pi, m)
+				if op == nil || op.Value == nil || op.Value.GoLow() == nil {
+					continue
+				}
+				found = true
+				add(pair.Key(), piNode, m, op.Value.GoLow().RootNode)
+			}
+			if !found {
+				add(pair.Key(), piNode, "", nil)
+			}
+		}
+		return ops
+	}
+
+	if a.root == nil {
+		return ops
+	}
+	_, paths := child(a.root, "paths")
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return ops
+	}
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		path, pathItem := paths.Content[i].Value, paths.Content[i+1]
+		found := false
+		for _, m := range operationMethods {
+			if _, op := child(pathItem, m); op != nil {
+				found = true
+				add(path, pathItem, m, op)
+			}
+		}
+		if !found {
+			add(path, pathItem, "", nil)
+		}
+	}
+	return ops
+}
+
+// schemas returns every schema, from the doctor model when there is one, otherwise from the index.
+func (a *documentAPI) schemas() []any {
+	schemas := []any{}
+	if dr := a.context.DrDocument; dr != nil {
+		for _, s := range dr.Schemas {
+			if s == nil || s.Value == nil || s.Value.GoLow() == nil || s.Value.GoLow().ParentProxy == nil {
+				continue
+			}
+			schemas = append(schemas, map[string]any{
+				"path": s.GenerateJSONPath(),
+				"node": a.node(s.Value.GoLow().ParentProxy.GetValueNode()),
+			})
+		}
+		return schemas
+	}
+	if a.context.Index != nil {
+		for _, r := range a.context.Index.GetAllSchemas() {
+			schemas = append(schemas, map[string]any{"path": r.Path, "node": a.node(r.Node)})
+		}
+	}
+	return schemas
+}
+
+// components returns every component of a type, sorted by reference.
+func (a *documentAPI) components(kind string) []any {
+	components := []any{}
+	idx := a.context.Index
+	if idx == nil {
+		return components
+	}
+	var refs map[string]*index.Reference
+	switch strings.ToLower(kind) {
+	case "schemas", "definitions":
+		refs = idx.GetAllComponentSchemas()
+	case "parameters":
+		refs = idx.GetAllParameters()
+	case "responses":
+		refs = idx.GetAllResponses()
+	case "requestbodies":
+		refs = idx.GetAllRequestBodies()
+	case "headers":
+		refs = idx.GetAllHeaders()
+	case "examples":
+		refs = idx.GetAllExamples()
+	case "links":
+		refs = idx.GetAllLinks()
+	case "callbacks":
+		refs = idx.GetAllCallbacks()
+	case "securityschemes", "securitydefinitions":
+		refs = idx.GetAllSecuritySchemes()
+	}
+	return a.referenceList(refs)
+}
+
+// references returns every reference in the document, and the node it points to.
+func (a *documentAPI) references() []any {
+	if a.context.Index == nil {
+		return []any{}
+	}
+	return a.referenceList(a.context.Index.GetMappedReferences())
+}
+
+// referenceList returns references with the nodes they point to, sorted by reference.
+func (a *documentAPI) referenceList(all map[string]*index.Reference) []any {
+	list := []any{}
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		r := all[k]
+		if r == nil {
+			continue
+		}
+		list = append(list, map[string]any{"ref": k, "name": r.Name, "node": a.node(r.Node)})
+	}
+	return list
+}
+
+func drOperation(pi *drv3.PathItem, method string) *drv3.Operation {
+	switch method {
+	case "get":
+		return pi.Get
+	case "put":
+		return pi.Put
+	case "post":
+		return pi.Post
+	case "delete":
+		return pi.Delete
+	case "options":
+		return pi.Options
+	case "head":
+		return pi.Head
+	case "patch":
+		return pi.Patch
+	case "trace":
+		return pi.Trace
+	}
+	return nil
+}
+
+// refOf returns the reference a node is, if it's a reference.
+func refOf(n *yaml.Node) string {
+	if n.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == "$ref" {
+			return n.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+func kindName(kind yaml.Kind) string {
+	switch kind {
+	case yaml.MappingNode:
+		return "map"
+	case yaml.SequenceNode:
+		return "array"
+	case yaml.AliasNode:
+		return "alias"
+	}
+	return "scalar"
+}
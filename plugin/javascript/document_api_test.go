@@ -0,0 +1,158 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package javascript
+
+import (
+	"testing"
+
+	"github.com/daveshanley/vacuum/model"
+	doctor "github.com/pb33f/doctor/model"
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+const documentAPISpec = `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [pets]
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pets'
+    post:
+      operationId: createPet
+      responses: {}
+  /owners: {}
+components:
+  schemas:
+    Pets:
+      type: array
+      items:
+        $ref: '#/components/schemas/Pet'
+    Pet:
+      type: object
+      properties:
+        name:
+          type: string
+`
+
+func documentAPIContext(t *testing.T, doctored bool) model.RuleFunctionContext {
+	doc, err := libopenapi.NewDocument([]byte(documentAPISpec))
+	assert.NoError(t, err)
+	m, errs := doc.BuildV3Model()
+	assert.Empty(t, errs)
+	ctx := model.RuleFunctionContext{Index: m.Index, Document: doc, Given: "$"}
+	if doctored {
+		ctx.DrDocument = doctor.NewDrDocument(m)
+	}
+	return ctx
+}
+
+func runDocumentAPI(t *testing.T, script string, ctx model.RuleFunctionContext) []model.RuleFunctionResult {
+	f := NewJSRuleFunction("document-api", script)
+	assert.NoError(t, f.CheckScript())
+	root := documentRoot(ctx.Index)
+	return f.RunRule([]*yaml.Node{root}, ctx)
+}
+
+func Test_DocumentAPI_Operations(t *testing.T) {
+	script := `function runRule(input) {
+	const results = [];
+	for (const op of vacuum.document.operations()) {
+		results.push({ message: op.method + ' ' + op.path + ' ' + op.operationId + ' ' + op.tags, node: op.node });
+	}
+	for (const p of vacuum.document.paths()) {
+		results.push({ message: p.path + ' has ' + p.operations.length + ' operations', node: p.node });
+	}
+	return results;
+}`
+
+	for _, doctored := range []bool{true, false} {
+		results := runDocumentAPI(t, script, documentAPIContext(t, doctored))
+		assert.Len(t, results, 4)
+		assert.Equal(t, "get /pets listPets pets", results[0].Message)
+		assert.Equal(t, "$.paths['/pets'].get", results[0].Path)
+		assert.Equal(t, 8, results[0].StartNode.Line)
+		assert.Equal(t, 8, results[0].Range.Start.Line)
+		assert.Equal(t, "post /pets createPet ", results[1].Message)
+		assert.Equal(t, "/pets has 2 operations", results[2].Message)
+		assert.Equal(t, "/owners has 0 operations", results[3].Message)
+		assert.Equal(t, "$.paths['/owners']", results[3].Path)
+	}
+}
+
+func Test_DocumentAPI_Resolve(t *testing.T) {
+	script := `function runRule(input) {
+	const schema = vacuum.node("$.paths['/pets'].get.responses['200'].content['application/json'].schema");
+	const pets = vacuum.resolve(schema);
+	const pet = vacuum.resolve(pets.get('items'));
+	const name = pet.get('properties').get('name');
+	return [
+		{ message: schema.ref + ' is ' + pets.value().type + ' at ' + pets.path() },
+		{ message: 'pet has ' + pet.get('properties').keys().join(','), node: name },
+		{ message: 'by path', path: ['components', 'schemas', 'Pet', 'type'] },
+		{ message: 'by JSONPath', path: '$.info.title' },
+	];
+}`
+
+	results := runDocumentAPI(t, script, documentAPIContext(t, true))
+	assert.Len(t, results, 4)
+	assert.Equal(t, "#/components/schemas/Pets is array at $.components.schemas.Pets", results[0].Message)
+	assert.Equal(t, "$", results[0].Path)
+
+	assert.Equal(t, "pet has name", results[1].Message)
+	assert.Equal(t, "$.components.schemas.Pet.properties.name", results[1].Path)
+	assert.Equal(t, 30, results[1].StartNode.Line)
+	assert.Equal(t, 11, results[1].StartNode.Column)
+
+	assert.Equal(t, "$.components.schemas.Pet.type", results[2].Path)
+	assert.Equal(t, "type", results[2].StartNode.Value)
+	assert.Equal(t, "object", results[2].EndNode.Value)
+
+	assert.Equal(t, "$.info.title", results[3].Path)
+	assert.Equal(t, "pets", results[3].StartNode.Value)
+}
+
+func Test_DocumentAPI_Index(t *testing.T) {
+	script := `function runRule(input) {
+	const pet = vacuum.index.findComponent('#/components/schemas/Pet');
+	const names = vacuum.index.components('schemas').map(c => c.name);
+	const refs = vacuum.index.references().map(r => r.ref);
+	return [
+		{ message: 'Pet is at line ' + pet.line + ', ' + pet.kind },
+		{ message: names.join(',') },
+		{ message: refs.join(',') },
+		{ message: 'missing is ' + vacuum.index.findComponent('#/components/schemas/Nope') },
+		{ message: vacuum.document.schemas().length > 0 ? 'has schemas' : 'no schemas' },
+	];
+}`
+
+	results := runDocumentAPI(t, script, documentAPIContext(t, false))
+	assert.Len(t, results, 5)
+	assert.Equal(t, "Pet is at line 27, map", results[0].Message)
+	assert.Equal(t, "Pet,Pets", results[1].Message)
+	assert.Equal(t, "#/components/schemas/Pet,#/components/schemas/Pets", results[2].Message)
+	assert.Equal(t, "missing is null", results[3].Message)
+	assert.Equal(t, "has schemas", results[4].Message)
+}
+
+func Test_DocumentAPI_Input(t *testing.T) {
+	script := `function runRule(input) {
+	return [{ message: vacuum.input.keys().join(','), node: vacuum.input.get('info') }];
+}`
+
+	results := runDocumentAPI(t, script, documentAPIContext(t, false))
+	assert.Len(t, results, 1)
+	assert.Equal(t, "openapi,info,paths,components", results[0].Message)
+	assert.Equal(t, "$.info", results[0].Path)
+	assert.Equal(t, 3, results[0].StartNode.Line)
+}
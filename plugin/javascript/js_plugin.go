@@ -4,12 +4,12 @@
 package javascript
 
 import (
+	gocontext "context"
+	"errors"
 	"fmt"
 	"github.com/daveshanley/vacuum/model"
 	"github.com/daveshanley/vacuum/model/reports"
 	"github.com/dop251/goja"
-	"github.com/dop251/goja_nodejs/console"
-	"github.com/dop251/goja_nodejs/require"
 	"github.com/mitchellh/mapstructure"
 	"gopkg.in/yaml.v3"
 )
@@ -24,41 +24,40 @@ type JSEnabledRuleFunction interface {
 	RegisterCoreFunction(name string, function CoreFunction)
 }
 
+// JSRuleFunction runs a rule function written in JavaScript. Every call runs in a runtime of its own, taken from a
+// pool of runtimes that have already run the script, so the same function can be used by many rules at once.
 type JSRuleFunction struct {
-	ruleName      string
-	script        string
-	scriptParsed  bool
-	runtime       *goja.Runtime
-	coreFunctions map[string]interface{}
+	ruleName string
+	script   string
+	pool     *runtimePool
+	paths    nodePaths
 }
 
+// NewJSRuleFunction creates a JavaScript rule function, using the default limits.
 func NewJSRuleFunction(ruleName, script string) JSEnabledRuleFunction {
-	rt := goja.New()
-	rt.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
-	reg := new(require.Registry)
-	reg.Enable(rt)
-	console.Enable(rt)
+	return NewJSRuleFunctionWithLimits(ruleName, script, DefaultLimits())
+}
 
+// NewJSRuleFunctionWithLimits creates a JavaScript rule function, that is stopped if it goes past the supplied limits.
+func NewJSRuleFunctionWithLimits(ruleName, script string, limits Limits) JSEnabledRuleFunction {
 	return &JSRuleFunction{
 		ruleName: ruleName,
 		script:   script,
-		runtime:  rt,
+		pool:     newRuntimePool(script, limits),
 	}
 }
 
 func (j *JSRuleFunction) RegisterCoreFunction(name string, function CoreFunction) {
-	if j.coreFunctions == nil {
-		j.coreFunctions = make(map[string]interface{})
-	}
-	j.coreFunctions[name] = function
+	j.pool.register(name, function)
 }
 
+// RunScript runs the script in a new runtime, to check it can be run.
 func (j *JSRuleFunction) RunScript() error {
-	_, err := j.runtime.RunString(j.script)
+	rt, err := j.pool.get(gocontext.Background())
 	if err != nil {
 		return err
 	}
-	j.scriptParsed = true
+	j.pool.put(rt, true)
 	return nil
 }
 
@@ -69,24 +68,30 @@ func (j *JSRuleFunction) GetSchema() model.RuleFunctionSchema {
 		Name: j.ruleName,
 	}
 
-	if !j.scriptParsed {
-		err := j.RunScript()
-		if err != nil {
-
-			return basic
-		}
+	rt, err := j.pool.get(gocontext.Background())
+	if err != nil {
+		return basic
 	}
 
-	if schemaFunc, ok = goja.AssertFunction(j.runtime.Get("getSchema")); !ok {
+	if schemaFunc, ok = goja.AssertFunction(rt.vm.Get("getSchema")); !ok {
+		j.pool.put(rt, true)
 		return basic
 	}
-	schema, sErr := schemaFunc(goja.Undefined())
+	var schema goja.Value
+	loadCtx, cancel := j.pool.loadContext(gocontext.Background())
+	defer cancel()
+	reusable, sErr := j.pool.run(loadCtx, rt.vm, func() error {
+		var cErr error
+		schema, cErr = schemaFunc(goja.Undefined())
+		return cErr
+	})
+	j.pool.put(rt, reusable)
 	if sErr != nil {
 		return basic
 	}
 
 	var decoded model.RuleFunctionSchema
-	err := mapstructure.Decode(schema.Export(), &decoded)
+	err = mapstructure.Decode(schema.Export(), &decoded)
 	if err != nil {
 		return basic
 	}
@@ -94,13 +99,12 @@ func (j *JSRuleFunction) GetSchema() model.RuleFunctionSchema {
 }
 
 func (j *JSRuleFunction) CheckScript() error {
-	if !j.scriptParsed {
-		err := j.RunScript()
-		if err != nil {
-			return err
-		}
+	rt, err := j.pool.get(gocontext.Background())
+	if err != nil {
+		return err
 	}
-	if _, ok := goja.AssertFunction(j.runtime.Get("runRule")); !ok {
+	defer j.pool.put(rt, true)
+	if _, ok := goja.AssertFunction(rt.vm.Get("runRule")); !ok {
 		return fmt.Errorf("runRule function not found")
 	}
 	return nil
@@ -110,107 +114,131 @@ func (j *JSRuleFunction) RunRule(nodes []*yaml.Node, context model.RuleFunctionC
 
 	var results []model.RuleFunctionResult
 
+	runCtx := context.Context
+	if runCtx == nil {
+		runCtx = gocontext.Background()
+	}
+
+	if len(nodes) == 0 {
+		return results
+	}
+
+	failed := func(node *yaml.Node, message string) []model.RuleFunctionResult {
+		return append(results, model.RuleFunctionResult{
+			Message:   message,
+			StartNode: node,
+			EndNode:   node,
+			Path:      fmt.Sprint(context.Given),
+			Rule:      context.Rule,
+		})
+	}
+
+	rt, err := j.pool.get(runCtx)
+	if err != nil {
+		return failed(nodes[0], fmt.Sprintf("Unable to run JavaScript function: '%s': %s", j.ruleName, err.Error()))
+	}
+	reusable := true
+	defer func() { j.pool.put(rt, reusable) }()
+
+	api := newDocumentAPI(rt.vm, context, &j.paths)
+	if apiErr := api.install(); apiErr != nil {
+		return failed(nodes[0], fmt.Sprintf("Unable to set vacuum API in JavaScript function: '%s': %s ",
+			j.ruleName, apiErr.Error()))
+	}
+
 	for _, node := range nodes {
 
 		var enc interface{}
 		_ = node.Decode(&enc)
+		api.setInput(node)
 
-		runtimeErr := j.runtime.Set("context", context)
+		runtimeErr := rt.vm.Set("context", context)
 		if runtimeErr != nil {
-			return []model.RuleFunctionResult{
-				{
-					Message: fmt.Sprintf("Unable to set context in JavaScript function: '%s': %s ",
-						j.ruleName, runtimeErr.Error()),
-					StartNode: node,
-					EndNode:   node,
-					Path:      fmt.Sprint(context.Given),
-					Rule:      context.Rule,
-				},
-			}
-		}
-
-		// register core functions
-		for name, function := range j.coreFunctions {
-			coreErr := j.runtime.Set(fmt.Sprintf("vacuum_%s", name), function)
-			if coreErr != nil {
-				return []model.RuleFunctionResult{
-					{
-						Message: fmt.Sprintf("Unable to set core vacuum function '%s': '%s': %s ",
-							name, j.ruleName, coreErr.Error()),
-						StartNode: node,
-						EndNode:   node,
-						Path:      fmt.Sprint(context.Given),
-						Rule:      context.Rule,
-					},
-				}
-			}
+			return failed(node, fmt.Sprintf("Unable to set context in JavaScript function: '%s': %s ",
+				j.ruleName, runtimeErr.Error()))
 		}
 
-		runRule, ok := goja.AssertFunction(j.runtime.Get("runRule"))
+		runRule, ok := goja.AssertFunction(rt.vm.Get("runRule"))
 		if !ok {
-			return []model.RuleFunctionResult{
-				{
-					Message: fmt.Sprintf("'runRule' is not defined as a JavaScript function: '%s': %s ",
-						j.ruleName, runtimeErr.Error()),
-					StartNode: node,
-					EndNode:   node,
-					Path:      fmt.Sprint(context.Given),
-					Rule:      context.Rule,
-				},
-			}
+			return failed(node, fmt.Sprintf("'runRule' is not defined as a JavaScript function: '%s'", j.ruleName))
 		}
 		var functionResults []model.RuleFunctionResult
 
 		// run JS rule!
-		runtimeValue := j.runtime.ToValue(enc)
-		ruleOutput, rErr := runRule(goja.Undefined(), runtimeValue)
-		if rErr != nil {
-			if jserr, okE := rErr.(*goja.Exception); okE {
-				return []model.RuleFunctionResult{
-					{
-						Message: fmt.Sprintf("Unable to execute JavaScript function: '%s': %s",
-							j.ruleName, jserr.Value().String()),
-						StartNode: node,
-						EndNode:   node,
-						Path:      fmt.Sprint(context.Given),
-						Rule:      context.Rule,
-					},
-				}
+		var ruleOutput goja.Value
+		runtimeValue := rt.vm.ToValue(enc)
+		reusable, runtimeErr = j.pool.run(runCtx, rt.vm, func() error {
+			var rErr error
+			ruleOutput, rErr = runRule(goja.Undefined(), runtimeValue)
+			return rErr
+		})
+		if runtimeErr != nil {
+			if message, isJS := describeError(j.ruleName, runtimeErr); isJS {
+				return failed(node, message)
 			}
-			panic(rErr) // not an exception
+			panic(runtimeErr) // not an exception
 		}
+		// results can point at any node, the rest is decoded as it always has been.
 		op := ruleOutput.Export()
-		rErr = mapstructure.Decode(op, &functionResults)
-		if rErr != nil {
-			return []model.RuleFunctionResult{
-				{
-					Message: fmt.Sprintf("Unable to decode results from JavaScript function: '%s': %s ",
-						j.ruleName, runtimeErr.Error()),
-					StartNode: node,
-					EndNode:   node,
-					Path:      fmt.Sprint(context.Given),
-					Rule:      context.Rule,
-				},
+		var locations []resultLocation
+		if items, isList := op.([]interface{}); isList {
+			locations = make([]resultLocation, len(items))
+			for i, item := range items {
+				if m, isMap := item.(map[string]interface{}); isMap {
+					locations[i] = api.locate(m["node"], m["path"])
+					delete(m, "node")
+					delete(m, "path")
+				}
 			}
 		}
+		rErr := mapstructure.Decode(op, &functionResults)
+		if rErr != nil {
+			return failed(node, fmt.Sprintf("Unable to decode results from JavaScript function: '%s': %s ",
+				j.ruleName, rErr.Error()))
+		}
 
 		for i := range functionResults {
-			functionResults[i].StartNode = node
-			functionResults[i].EndNode = node
+			start, end, path := node, node, fmt.Sprint(context.Given)
+			if i < len(locations) && locations[i].start != nil {
+				start, end = locations[i].start, locations[i].end
+				if locations[i].path != "" {
+					path = locations[i].path
+				}
+			}
+			functionResults[i].StartNode = start
+			functionResults[i].EndNode = end
 			functionResults[i].Range = reports.Range{
 				Start: reports.RangeItem{
-					Line: node.Line,
-					Char: node.Column,
+					Line: start.Line,
+					Char: start.Column,
 				},
 				End: reports.RangeItem{
-					Line: node.Line,
-					Char: node.Column,
+					Line: end.Line,
+					Char: end.Column,
 				},
 			}
-			functionResults[i].Path = fmt.Sprint(context.Given)
+			functionResults[i].Path = path
 			functionResults[i].Rule = context.Rule
 		}
 		results = append(results, functionResults...)
 	}
 	return results
 }
+
+// describeError returns a message for an error raised while running a function, or false if it was not raised by
+// JavaScript.
+func describeError(name string, err error) (string, bool) {
+	var interrupted *goja.InterruptedError
+	var overflow *goja.StackOverflowError
+	var jserr *goja.Exception
+	switch {
+	case errors.As(err, &interrupted):
+		return fmt.Sprintf("JavaScript function was interrupted: '%s': %v", name, interrupted.Value()), true
+	case errors.As(err, &overflow):
+		return fmt.Sprintf("Unable to execute JavaScript function: '%s': %s", name,
+			"maximum call stack size exceeded"), true
+	case errors.As(err, &jserr):
+		return fmt.Sprintf("Unable to execute JavaScript function: '%s': %s", name, jserr.Value().String()), true
+	}
+	return "", false
+}
@@ -4,13 +4,16 @@
 package javascript
 
 import (
+	"context"
 	"fmt"
 	"github.com/daveshanley/vacuum/functions/core"
 	"github.com/daveshanley/vacuum/model"
 	"github.com/pterm/pterm"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
+	"sync"
 	"testing"
+	"time"
 )
 
 func Test_JSPlugin_Basic_Fail(t *testing.T) {
@@ -226,3 +229,97 @@ func Test_JSPlugin_Fail_NoRunRuleFunc(t *testing.T) {
 	assert.Equal(t, "runRule function not found", err.Error())
 
 }
+
+func Test_JSPlugin_Concurrent(t *testing.T) {
+
+	script := `function runRule(input) {
+	return [{ message: input + ' from ' + context.given }];
+}`
+
+	f := NewJSRuleFunctionWithLimits("test", script, Limits{MaxRuntimes: 4})
+	assert.NoError(t, f.CheckScript())
+
+	var wg sync.WaitGroup
+	messages := make([]string, 100)
+	for i := range messages {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var y yaml.Node
+			_ = yaml.Unmarshal([]byte(fmt.Sprintf("input-%d", i)), &y)
+			results := f.RunRule([]*yaml.Node{y.Content[0]}, model.RuleFunctionContext{Given: fmt.Sprintf("rule-%d", i)})
+			if len(results) == 1 {
+				messages[i] = results[0].Message
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, m := range messages {
+		assert.Equal(t, fmt.Sprintf("input-%d from rule-%d", i, i), m)
+	}
+}
+
+func Test_JSPlugin_Timeout(t *testing.T) {
+
+	script := `function runRule(input) {
+	while (input === "spin") {}
+	return [{ message: "done" }];
+}`
+
+	f := NewJSRuleFunction("test", script)
+	assert.NoError(t, f.CheckScript())
+
+	var spin, done yaml.Node
+	_ = yaml.Unmarshal([]byte("spin"), &spin)
+	_ = yaml.Unmarshal([]byte("done"), &done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	results := f.RunRule([]*yaml.Node{spin.Content[0]}, model.RuleFunctionContext{Context: ctx})
+	assert.Len(t, results, 1)
+	assert.Equal(t, "JavaScript function was interrupted: 'test': context deadline exceeded", results[0].Message)
+
+	// the interrupted runtime is not reused.
+	results = f.RunRule([]*yaml.Node{done.Content[0]}, model.RuleFunctionContext{})
+	assert.Len(t, results, 1)
+	assert.Equal(t, "done", results[0].Message)
+}
+
+func Test_JSPlugin_LoadNeverFinishes(t *testing.T) {
+
+	script := `while (true) {}
+function runRule(input) { return []; }`
+
+	f := NewJSRuleFunctionWithLimits("test", script, Limits{LoadTimeout: 50 * time.Millisecond})
+	assert.ErrorContains(t, f.CheckScript(), "did not finish loading")
+	assert.ErrorContains(t, f.RunScript(), "did not finish loading")
+
+	// a rule creating a runtime is stopped by its own context.
+	f = NewJSRuleFunctionWithLimits("test", script, Limits{LoadTimeout: time.Minute})
+	var y yaml.Node
+	_ = yaml.Unmarshal([]byte("beep"), &y)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	results := f.RunRule([]*yaml.Node{y.Content[0]}, model.RuleFunctionContext{Context: ctx})
+	assert.Len(t, results, 1)
+	assert.Contains(t, results[0].Message, "Unable to run JavaScript function: 'test'")
+}
+
+func Test_JSPlugin_StackOverflow(t *testing.T) {
+
+	script := `function recurse(n) { return recurse(n + 1); }
+function runRule(input) {
+	return recurse(0);
+}`
+
+	f := NewJSRuleFunctionWithLimits("test", script, Limits{MaxCallStackSize: 100})
+	assert.NoError(t, f.CheckScript())
+
+	var y yaml.Node
+	_ = yaml.Unmarshal([]byte("beep"), &y)
+
+	results := f.RunRule([]*yaml.Node{y.Content[0]}, model.RuleFunctionContext{})
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Unable to execute JavaScript function: 'test': maximum call stack size exceeded", results[0].Message)
+}
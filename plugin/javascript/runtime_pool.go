@@ -0,0 +1,268 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package javascript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja_nodejs/console"
+	"github.com/dop251/goja_nodejs/require"
+)
+
+// ErrMemoryLimit is the reason a function is interrupted when the heap of the process grows past Limits.MaxHeap.
+var ErrMemoryLimit = errors.New("memory limit exceeded")
+
+// heapMetric is the memory occupied by live (and not yet swept) heap objects.
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// heapCheckInterval is how often the heap is checked while a function is running.
+const heapCheckInterval = 10 * time.Millisecond
+
+// defaultLoadTimeout is how long a script can run for when a runtime is created, if the limits don't say.
+const defaultLoadTimeout = 5 * time.Second
+
+// Limits stop runaway functions from hanging or exhausting the linter. Functions are also interrupted when the
+// context of the rule running them is done, which is how rule timeouts are enforced.
+//
+// MaxHeap is a limit for the whole process, not for a function: the Go heap is shared by everything vacuum is
+// doing, so a large specification or another lint running at the same time counts towards it. When the heap
+// grows past it, every JavaScript function running at that moment is interrupted. It stops the linter from
+// being taken down by a function allocating without end, it does not measure what a single function uses.
+type Limits struct {
+	MaxRuntimes      int           // runtimes per function, so how many rules can run it at once. Defaults to GOMAXPROCS.
+	MaxCallStackSize int           // deepest call stack allowed, stops unbounded recursion.
+	MaxHeap          uint64        // process heap size in bytes running functions are interrupted at, zero turns the check off.
+	LoadTimeout      time.Duration // how long the top level of a script can run for when a runtime is created.
+}
+
+// DefaultLimits returns the limits used when none are supplied.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxRuntimes:      runtime.GOMAXPROCS(0),
+		MaxCallStackSize: 1024,
+		MaxHeap:          4 << 30,
+		LoadTimeout:      defaultLoadTimeout,
+	}
+}
+
+// pooledRuntime is a runtime that has already run the script. generation tracks which core functions have been
+// registered with it.
+type pooledRuntime struct {
+	vm         *goja.Runtime
+	generation int
+}
+
+// runtimePool holds runtimes ready to run a function. goja runtimes are not safe for concurrent use, so each call
+// takes a runtime out of the pool, and puts it back when it's done. Runtimes are created as they are needed, up to
+// MaxRuntimes, after which callers wait for a runtime to be returned, or for a runtime that was dropped to make
+// room for a new one.
+type runtimePool struct {
+	script     string
+	name       string               // where the script was read from, relative requires are resolved from its directory.
+	loader     require.SourceLoader // reads required modules, the default loader reads them from disk as they are.
+	limits     Limits
+	lock       sync.Mutex
+	idle       chan *pooledRuntime
+	room       chan struct{} // one token for every runtime that can still be created.
+	generation int
+	core       map[string]interface{}
+	prepare    func(vm *goja.Runtime, reg *require.Registry) // called before the script is run in a new runtime.
+}
+
+func newRuntimePool(script string, limits Limits) *runtimePool {
+	if limits.MaxRuntimes <= 0 {
+		limits.MaxRuntimes = runtime.GOMAXPROCS(0)
+	}
+	if limits.LoadTimeout <= 0 {
+		limits.LoadTimeout = defaultLoadTimeout
+	}
+	room := make(chan struct{}, limits.MaxRuntimes)
+	for i := 0; i < limits.MaxRuntimes; i++ {
+		room <- struct{}{}
+	}
+	return &runtimePool{
+		script: script,
+		limits: limits,
+		idle:   make(chan *pooledRuntime, limits.MaxRuntimes),
+		room:   room,
+		core:   make(map[string]interface{}),
+	}
+}
+
+// register adds a core function, runtimes pick it up the next time they are taken from the pool.
+func (p *runtimePool) register(name string, function interface{}) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.core[name] = function
+	p.generation++
+}
+
+// get returns a runtime, an idle one if there is one, or a new one if the pool has room. If the pool is exhausted,
+// it waits for a runtime to be returned, for room to be made by a runtime being dropped, or for the context to be
+// done. A new runtime runs the script, which is interrupted if the context is done, or if it runs for longer than
+// the load timeout.
+func (p *runtimePool) get(ctx context.Context) (*pooledRuntime, error) {
+	var rt *pooledRuntime
+	var err error
+	select {
+	case rt = <-p.idle:
+	default:
+		select {
+		case <-p.room:
+			rt, err = p.fill(ctx)
+		default:
+			select {
+			case rt = <-p.idle:
+			case <-p.room:
+				rt, err = p.fill(ctx)
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if rt.generation != p.generation {
+		for name, function := range p.core {
+			if err = rt.vm.Set("vacuum_"+name, function); err != nil {
+				p.room <- struct{}{}
+				return nil, err
+			}
+		}
+		rt.generation = p.generation
+	}
+	return rt, nil
+}
+
+// fill creates a runtime in the room taken from the pool, the room is given back if it can't be created.
+func (p *runtimePool) fill(ctx context.Context) (*pooledRuntime, error) {
+	vm, err := p.create(ctx)
+	if err != nil {
+		p.room <- struct{}{}
+		return nil, err
+	}
+	return &pooledRuntime{vm: vm}, nil
+}
+
+// put returns a runtime to the pool. Runtimes that may still be interrupted are dropped, which makes room for a
+// new runtime, waking up a caller waiting for one.
+func (p *runtimePool) put(rt *pooledRuntime, reusable bool) {
+	if !reusable {
+		p.room <- struct{}{}
+		return
+	}
+	rt.vm.ClearInterrupt()
+	p.idle <- rt
+}
+
+// loadContext bounds loading a function (running the top level of its script, or asking it for its schema) by the
+// load timeout, so a script that never finishes can't hang whatever is loading it.
+func (p *runtimePool) loadContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, p.limits.LoadTimeout)
+}
+
+// create builds a runtime and runs the script in it.
+func (p *runtimePool) create(ctx context.Context) (*goja.Runtime, error) {
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	if p.limits.MaxCallStackSize > 0 {
+		vm.SetMaxCallStackSize(p.limits.MaxCallStackSize)
+	}
+	var options []require.Option
+	if p.loader != nil {
+		options = append(options, require.WithLoader(p.loader))
+	}
+	reg := require.NewRegistry(options...)
+	if p.prepare != nil {
+		p.prepare(vm, reg)
+	}
+	reg.Enable(vm)
+	console.Enable(vm)
+
+	loadCtx, cancel := p.loadContext(ctx)
+	defer cancel()
+	_, err := p.run(loadCtx, vm, func() error {
+		_, rErr := vm.RunScript(p.name, p.script)
+		return rErr
+	})
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(loadCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("script did not finish loading within %s", p.limits.LoadTimeout)
+		}
+		return nil, err
+	}
+	return vm, nil
+}
+
+// run calls fn, interrupting the runtime if the context is done or the heap grows too big. It returns false if
+// the runtime can't be reused, because an interrupt may still arrive after fn has returned.
+func (p *runtimePool) run(ctx context.Context, vm *goja.Runtime, fn func() error) (bool, error) {
+	stopContext := context.AfterFunc(ctx, func() {
+		vm.Interrupt(ctx.Err())
+	})
+
+	var stopHeap func() bool
+	if p.limits.MaxHeap > 0 {
+		stopHeap = watchHeap(p.limits.MaxHeap, func() {
+			vm.Interrupt(ErrMemoryLimit)
+		})
+	}
+
+	err := fn()
+
+	reusable := stopContext()
+	if stopHeap != nil && !stopHeap() {
+		reusable = false
+	}
+	return reusable, err
+}
+
+// watchHeap calls interrupt if the heap grows past max before the returned stop function is called. stop returns
+// false if interrupt was called, interrupt is never called after stop has returned.
+func watchHeap(max uint64, interrupt func()) func() bool {
+	var lock sync.Mutex
+	stopped, fired := false, false
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(heapCheckInterval)
+		defer ticker.Stop()
+		sample := []metrics.Sample{{Name: heapMetric}}
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				metrics.Read(sample)
+				if sample[0].Value.Kind() != metrics.KindUint64 || sample[0].Value.Uint64() <= max {
+					continue
+				}
+				lock.Lock()
+				if !stopped {
+					fired = true
+					interrupt()
+				}
+				lock.Unlock()
+				return
+			}
+		}
+	}()
+	return func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		stopped = true
+		close(done)
+		return !fired
+	}
+}
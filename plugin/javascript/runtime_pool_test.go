@@ -0,0 +1,108 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package javascript
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntimePool_Exhausted(t *testing.T) {
+	p := newRuntimePool(`var x = 1;`, Limits{MaxRuntimes: 1})
+
+	rt, err := p.get(context.Background())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = p.get(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	p.put(rt, true)
+	again, err := p.get(context.Background())
+	assert.NoError(t, err)
+	assert.Same(t, rt, again)
+
+	// a runtime that can't be reused makes room for a new one.
+	p.put(again, false)
+	fresh, err := p.get(context.Background())
+	assert.NoError(t, err)
+	assert.NotSame(t, rt, fresh)
+}
+
+func TestRuntimePool_DroppedWakesWaiters(t *testing.T) {
+	p := newRuntimePool(`var x = 1;`, Limits{MaxRuntimes: 1})
+	rt, err := p.get(context.Background())
+	assert.NoError(t, err)
+
+	// a caller waiting on an exhausted pool gets a new runtime, once the one in use is dropped.
+	got := make(chan error)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		fresh, gErr := p.get(ctx)
+		if gErr == nil {
+			p.put(fresh, true)
+		}
+		got <- gErr
+	}()
+	time.Sleep(20 * time.Millisecond)
+	p.put(rt, false)
+	assert.NoError(t, <-got)
+}
+
+func TestRuntimePool_RegisterCoreFunction(t *testing.T) {
+	p := newRuntimePool(`function runRule() { return vacuum_hello(); }`, Limits{MaxRuntimes: 1})
+
+	rt, err := p.get(context.Background())
+	assert.NoError(t, err)
+	p.put(rt, true)
+
+	p.register("hello", func() string { return "hello" })
+	rt, err = p.get(context.Background())
+	assert.NoError(t, err)
+	v, err := rt.vm.RunString("runRule()")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", v.String())
+}
+
+func TestRuntimePool_BadScript(t *testing.T) {
+	p := newRuntimePool(`function (`, Limits{MaxRuntimes: 1})
+	_, err := p.get(context.Background())
+	assert.Error(t, err)
+	assert.Len(t, p.room, 1)
+}
+
+func TestRuntimePool_ScriptNeverLoads(t *testing.T) {
+	p := newRuntimePool(`while (true) {}`, Limits{MaxRuntimes: 1, LoadTimeout: 50 * time.Millisecond})
+
+	// loading gives up after the load timeout.
+	_, err := p.get(context.Background())
+	assert.ErrorContains(t, err, "did not finish loading within 50ms")
+	assert.Len(t, p.room, 1)
+
+	// and sooner, if the caller is done first.
+	p.limits.LoadTimeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = p.get(ctx)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestWatchHeap(t *testing.T) {
+	interrupted := make(chan bool, 1)
+	stop := watchHeap(1, func() { interrupted <- true })
+	<-interrupted
+	assert.False(t, stop())
+
+	stop = watchHeap(1<<62, func() { interrupted <- true })
+	time.Sleep(3 * heapCheckInterval)
+	assert.True(t, stop())
+	assert.Empty(t, interrupted)
+}
@@ -0,0 +1,708 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package javascript
+
+import (
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/model/reports"
+	"github.com/dop251/goja"
+	"github.com/dop251/goja_nodejs/require"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// spectralCore is the '@stoplight/spectral-core' module made available to Spectral functions. The schemas passed
+// to createRulesetFunction are kept with the function, vacuum validates input and options itself.
+const spectralCore = `(function() {
+	return {
+		createRulesetFunction: function(meta, fn) {
+			var f = function(targetVal, opts, context) {
+				return fn(targetVal, opts, context);
+			};
+			f.__spectral = meta || {};
+			return f;
+		}
+	};
+})()`
+
+// spectralFunction is the global the default export of a Spectral function module is assigned to.
+const spectralFunction = "__spectral_function"
+
+var (
+	spectralExportDefault = regexp.MustCompile(`(?m)^(\s*)export\s+default\s+`)
+	spectralExportList    = regexp.MustCompile(`(?m)^(\s*)export\s*{([^}]*)}\s*;?[ \t]*$`)
+	spectralExportNamed   = regexp.MustCompile(`(?m)^(\s*)export\s+((?:async\s+)?function\*?|class|const|let|var)\s+([\w$]+)`)
+	spectralImport        = regexp.MustCompile(`(?m)^\s*import\s+(?:(.+?)\s+from\s+)?['"]([^'"]+)['"]\s*;?[ \t]*$`)
+	spectralModule        = regexp.MustCompile(`(?m)^\s*export\s|module\.exports|createRulesetFunction`)
+	spectralRunRule       = regexp.MustCompile(`function\s+runRule\s*\(`)
+	spectralIdentifier    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+)
+
+// IsSpectralFunction returns true if the script is a Spectral custom function, written as an ES or CommonJS module,
+// rather than a vacuum function that defines a global runRule function.
+func IsSpectralFunction(script string) bool {
+	return spectralModule.MatchString(script) && !spectralRunRule.MatchString(script)
+}
+
+// SpectralRuleFunction runs a Spectral custom function, like those written with createRulesetFunction.
+//
+//	export default createRulesetFunction({ input: null, options: null }, (targetVal, opts, context) => {
+//	  return [{ message: 'something is wrong', path: [...context.path, 'summary'] }];
+//	});
+//
+// The function gets the path of the value it's checking, the document and the document inventory in its
+// context, and the paths of the results it returns are used to find the nodes (and ranges) they belong to.
+type SpectralRuleFunction struct {
+	ruleName string
+	pool     *runtimePool
+	metaOnce sync.Once
+	meta     *spectralMeta
+	metaErr  error
+	docLock  sync.Mutex
+	doc      *spectralDocument
+}
+
+// spectralMeta holds the schemas a function was created with.
+type spectralMeta struct {
+	options    map[string]any
+	input      *jsonschema.Schema
+	optsSchema *jsonschema.Schema
+}
+
+// spectralDocument is everything about a document a function can see, it's built once per root node.
+type spectralDocument struct {
+	root       *yaml.Node
+	data       any
+	unresolved any
+	paths      map[*yaml.Node][]any
+}
+
+// NewSpectralRuleFunction creates a function from a Spectral function module, using the default limits.
+func NewSpectralRuleFunction(ruleName, script string) JSEnabledRuleFunction {
+	return NewSpectralRuleFunctionWithLimits(ruleName, script, DefaultLimits())
+}
+
+// NewSpectralRuleFunctionWithLimits creates a function from a Spectral function module, that is stopped if it goes
+// past the supplied limits.
+func NewSpectralRuleFunctionWithLimits(ruleName, script string, limits Limits) JSEnabledRuleFunction {
+	return newSpectralRuleFunction(ruleName, "", script, limits)
+}
+
+// NewSpectralRuleFunctionFromFile creates a function from a Spectral function module read from location, using the
+// default limits. Modules it imports with a relative path are resolved from the directory of location.
+func NewSpectralRuleFunctionFromFile(ruleName, location, script string) JSEnabledRuleFunction {
+	return newSpectralRuleFunction(ruleName, location, script, DefaultLimits())
+}
+
+func newSpectralRuleFunction(ruleName, location, script string, limits Limits) JSEnabledRuleFunction {
+	pool := newRuntimePool(TranslateSpectralModule(script), limits)
+	if location != "" {
+		if abs, err := filepath.Abs(location); err == nil {
+			location = abs
+		}
+		pool.name = filepath.ToSlash(location)
+	}
+	pool.loader = loadSpectralModule
+	pool.prepare = func(vm *goja.Runtime, reg *require.Registry) {
+		reg.RegisterNativeModule("@stoplight/spectral-core", func(vm *goja.Runtime, module *goja.Object) {
+			exports, _ := vm.RunString(spectralCore)
+			_ = module.Set("exports", exports)
+		})
+	}
+	return &SpectralRuleFunction{ruleName: ruleName, pool: pool}
+}
+
+// loadSpectralModule reads a module imported by a Spectral function, helpers written as ES modules are rewritten
+// as CommonJS modules, the same way as the function itself.
+func loadSpectralModule(location string) ([]byte, error) {
+	src, err := require.DefaultSourceLoader(location)
+	if err != nil || strings.HasSuffix(location, ".json") {
+		return src, err
+	}
+	return []byte(translateModule(string(src))), nil
+}
+
+// TranslateSpectralModule rewrites a Spectral function module as a script, imports become calls to require, and
+// the default export is assigned to a global.
+func TranslateSpectralModule(script string) string {
+	var sb strings.Builder
+	sb.WriteString("var __spectral_module;\n")
+	sb.WriteString("var " + spectralFunction + " = (function(module, exports) {\n")
+	sb.WriteString(translateModule(script))
+	sb.WriteString("\n;return module.exports && module.exports.default ? module.exports.default : module.exports;\n")
+	sb.WriteString("})(__spectral_module = {exports: {}}, __spectral_module.exports);\n")
+	return sb.String()
+}
+
+// translateModule rewrites the imports and exports of an ES module as those of a CommonJS module. Named exports
+// are added to module.exports once the module has run, so they can be declared after they are exported.
+func translateModule(script string) string {
+	script = spectralImport.ReplaceAllStringFunc(script, func(s string) string {
+		m := spectralImport.FindStringSubmatch(s)
+		return translateImport(m[1], m[2])
+	})
+
+	var exports []string
+	script = spectralExportList.ReplaceAllStringFunc(script, func(s string) string {
+		m := spectralExportList.FindStringSubmatch(s)
+		for _, n := range strings.Split(m[2], ",") {
+			local, exported, _ := strings.Cut(strings.TrimSpace(n), " as ")
+			if local = strings.TrimSpace(local); local == "" {
+				continue
+			}
+			if exported = strings.TrimSpace(exported); exported == "" {
+				exported = local
+			}
+			exports = append(exports, fmt.Sprintf("module.exports[%q] = %s;", exported, local))
+		}
+		return m[1]
+	})
+	script = spectralExportDefault.ReplaceAllString(script, "${1}module.exports.default = ")
+	script = spectralExportNamed.ReplaceAllStringFunc(script, func(s string) string {
+		m := spectralExportNamed.FindStringSubmatch(s)
+		exports = append(exports, fmt.Sprintf("module.exports[%q] = %s;", m[3], m[3]))
+		return m[1] + m[2] + " " + m[3]
+	})
+	if len(exports) > 0 {
+		script += "\n" + strings.Join(exports, "\n")
+	}
+	return script
+}
+
+// translateImport turns an import into a require, clause is empty for imports that are only there for side effects.
+func translateImport(clause, module string) string {
+	req := fmt.Sprintf("require(%q)", module)
+	clause = strings.TrimSpace(clause)
+	if clause == "" {
+		return req + ";"
+	}
+	var lines []string
+	if strings.HasPrefix(clause, "* as ") {
+		return fmt.Sprintf("const %s = %s;", strings.TrimSpace(clause[5:]), req)
+	}
+	if i := strings.Index(clause, "{"); i >= 0 {
+		named := strings.TrimSuffix(strings.TrimSpace(clause[i+1:]), "}")
+		var parts []string
+		for _, n := range strings.Split(named, ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				parts = append(parts, strings.Replace(n, " as ", ": ", 1))
+			}
+		}
+		lines = append(lines, fmt.Sprintf("const { %s } = %s;", strings.Join(parts, ", "), req))
+		clause = strings.TrimSuffix(strings.TrimSpace(clause[:i]), ",")
+	}
+	if clause = strings.TrimSpace(clause); clause != "" {
+		lines = append([]string{fmt.Sprintf("const %s = (function(m) { return m && m.default ? m.default : m; })(%s);",
+			clause, req)}, lines...)
+	}
+	return strings.Join(lines, " ")
+}
+
+func (s *SpectralRuleFunction) RegisterCoreFunction(name string, function CoreFunction) {
+	s.pool.register(name, function)
+}
+
+// RunScript runs the module in a new runtime, to check it can be run.
+func (s *SpectralRuleFunction) RunScript() error {
+	rt, err := s.pool.get(gocontext.Background())
+	if err != nil {
+		return err
+	}
+	s.pool.put(rt, true)
+	return nil
+}
+
+func (s *SpectralRuleFunction) CheckScript() error {
+	rt, err := s.pool.get(gocontext.Background())
+	if err != nil {
+		return err
+	}
+	defer s.pool.put(rt, true)
+	if _, ok := goja.AssertFunction(rt.vm.Get(spectralFunction)); !ok {
+		return fmt.Errorf("spectral function module does not export a function")
+	}
+	return nil
+}
+
+// GetSchema translates the options schema of the function.
+func (s *SpectralRuleFunction) GetSchema() model.RuleFunctionSchema {
+	schema := model.RuleFunctionSchema{Name: s.ruleName}
+	meta, err := s.loadMeta()
+	if err != nil || meta.options == nil {
+		return schema
+	}
+	if properties, ok := meta.options["properties"].(map[string]any); ok {
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop := model.RuleFunctionProperty{Name: name}
+			if def, isMap := properties[name].(map[string]any); isMap {
+				if d, isString := def["description"].(string); isString {
+					prop.Description = d
+				} else if t, isType := def["type"].(string); isType {
+					prop.Description = t
+				}
+			}
+			schema.Properties = append(schema.Properties, prop)
+		}
+	}
+	if required, ok := meta.options["required"].([]any); ok {
+		for _, r := range required {
+			schema.Required = append(schema.Required, fmt.Sprint(r))
+		}
+	}
+	if v, ok := meta.options["minProperties"].(float64); ok {
+		schema.MinProperties = int(v)
+	}
+	if v, ok := meta.options["maxProperties"].(float64); ok {
+		schema.MaxProperties = int(v)
+	}
+	if v, ok := meta.options["errorMessage"].(string); ok {
+		schema.ErrorMessage = v
+	} else {
+		schema.ErrorMessage = fmt.Sprintf("'%s' function has invalid options", s.ruleName)
+	}
+	return schema
+}
+
+// loadMeta reads and compiles the schemas of the function, once.
+func (s *SpectralRuleFunction) loadMeta() (*spectralMeta, error) {
+	s.metaOnce.Do(func() {
+		rt, err := s.pool.get(gocontext.Background())
+		if err != nil {
+			s.metaErr = err
+			return
+		}
+		defer s.pool.put(rt, true)
+
+		meta := &spectralMeta{}
+		s.meta = meta
+		fn := rt.vm.Get(spectralFunction)
+		if fn == nil || goja.IsUndefined(fn) || goja.IsNull(fn) {
+			return
+		}
+		raw := fn.ToObject(rt.vm).Get("__spectral")
+		if raw == nil || goja.IsUndefined(raw) {
+			return
+		}
+		// round trip through JSON, so numbers are decoded the same way as in the schema compiler.
+		b, err := json.Marshal(raw.Export())
+		if err != nil {
+			s.metaErr = err
+			return
+		}
+		var decoded map[string]json.RawMessage
+		_ = json.Unmarshal(b, &decoded)
+		if meta.input, err = compileSchema(s.ruleName+"-input", decoded["input"]); err != nil {
+			s.metaErr = err
+			return
+		}
+		if meta.optsSchema, err = compileSchema(s.ruleName+"-options", decoded["options"]); err != nil {
+			s.metaErr = err
+			return
+		}
+		if len(decoded["options"]) > 0 {
+			_ = json.Unmarshal(decoded["options"], &meta.options)
+		}
+	})
+	return s.meta, s.metaErr
+}
+
+// compileSchema compiles a JSON schema, a missing or null schema is nil.
+func compileSchema(name string, raw json.RawMessage) (*jsonschema.Schema, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	compiler := jsonschema.NewCompiler()
+	url := "vacuum://" + name + ".json"
+	if err := compiler.AddResource(url, bytes.NewReader(raw)); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(url)
+}
+
+// toJSON converts a value to what JSON would decode it to, which is what schemas validate.
+func toJSON(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var out any
+	err = d.Decode(&out)
+	return out, err
+}
+
+func (s *SpectralRuleFunction) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext) []model.RuleFunctionResult {
+
+	var results []model.RuleFunctionResult
+	if len(nodes) == 0 {
+		return results
+	}
+
+	runCtx := context.Context
+	if runCtx == nil {
+		runCtx = gocontext.Background()
+	}
+
+	failed := func(node *yaml.Node, message string) []model.RuleFunctionResult {
+		return append(results, model.RuleFunctionResult{
+			Message:   message,
+			StartNode: node,
+			EndNode:   node,
+			Path:      fmt.Sprint(context.Given),
+			Rule:      context.Rule,
+		})
+	}
+
+	meta, err := s.loadMeta()
+	if err != nil {
+		return failed(nodes[0], fmt.Sprintf("Unable to load spectral function: '%s': %s", s.ruleName, err.Error()))
+	}
+
+	opts := context.Options
+	if meta.optsSchema != nil {
+		o, _ := toJSON(opts)
+		if o == nil {
+			o = map[string]any{}
+		}
+		if vErr := meta.optsSchema.Validate(o); vErr != nil {
+			return failed(nodes[0], fmt.Sprintf("'%s' function has invalid options: %s", s.ruleName, vErr.Error()))
+		}
+	}
+
+	doc := s.document(context)
+
+	rt, err := s.pool.get(runCtx)
+	if err != nil {
+		return failed(nodes[0], fmt.Sprintf("Unable to run JavaScript function: '%s': %s", s.ruleName, err.Error()))
+	}
+	reusable := true
+	defer func() { s.pool.put(rt, reusable) }()
+
+	fn, ok := goja.AssertFunction(rt.vm.Get(spectralFunction))
+	if !ok {
+		return failed(nodes[0], fmt.Sprintf("spectral function module does not export a function: '%s'", s.ruleName))
+	}
+
+	jsContext := s.buildContext(rt.vm, doc, context)
+
+	for _, node := range nodes {
+		for _, target := range s.targets(doc, node, context.RuleAction) {
+
+			var targetVal any
+			if target.node != nil {
+				_ = target.node.Decode(&targetVal)
+			}
+			if target.key {
+				targetVal = target.node.Value
+			}
+
+			// functions with an input schema are only called with input that matches it.
+			if meta.input != nil {
+				in, _ := toJSON(targetVal)
+				if meta.input.Validate(in) != nil {
+					continue
+				}
+			}
+
+			_ = jsContext.Set("path", target.path)
+
+			var output goja.Value
+			var runErr error
+			reusable, runErr = s.pool.run(runCtx, rt.vm, func() error {
+				var cErr error
+				output, cErr = fn(goja.Undefined(), rt.vm.ToValue(targetVal), rt.vm.ToValue(opts), jsContext)
+				return cErr
+			})
+			if runErr == nil {
+				output, runErr = settle(output)
+			}
+			if runErr != nil {
+				if message, isJS := describeError(s.ruleName, runErr); isJS {
+					return failed(node, message)
+				}
+				return failed(node, fmt.Sprintf("Unable to execute JavaScript function: '%s': %s",
+					s.ruleName, runErr.Error()))
+			}
+			if output == nil || goja.IsUndefined(output) || goja.IsNull(output) {
+				continue
+			}
+
+			var functionResults []struct {
+				Message string `json:"message"`
+				Path    []any  `json:"path"`
+			}
+			b, mErr := json.Marshal(output.Export())
+			if mErr == nil {
+				mErr = json.Unmarshal(b, &functionResults)
+			}
+			if mErr != nil {
+				return failed(node, fmt.Sprintf("Unable to decode results from JavaScript function: '%s': %s ",
+					s.ruleName, mErr.Error()))
+			}
+
+			for _, fr := range functionResults {
+				path := fr.Path
+				if path == nil {
+					path = target.path
+				}
+				start, end := locate(doc.root, path)
+				if start == nil {
+					start, end = node, node
+				}
+				message := fr.Message
+				if context.Rule != nil && context.Rule.Message != "" {
+					message = context.Rule.Message
+				}
+				results = append(results, model.RuleFunctionResult{
+					Message:   message,
+					Error:     fr.Message,
+					StartNode: start,
+					EndNode:   end,
+					Range: reports.Range{
+						Start: reports.RangeItem{Line: start.Line, Char: start.Column},
+						End:   reports.RangeItem{Line: end.Line, Char: end.Column},
+					},
+					Path: JSONPath(path),
+					Rule: context.Rule,
+				})
+			}
+		}
+	}
+	return results
+}
+
+// settle returns the value a promise was resolved with. Promises are settled once the function has returned,
+// unless they are waiting on something that never happens.
+func settle(v goja.Value) (goja.Value, error) {
+	if v == nil {
+		return v, nil
+	}
+	p, ok := v.Export().(*goja.Promise)
+	if !ok {
+		return v, nil
+	}
+	switch p.State() {
+	case goja.PromiseStateFulfilled:
+		return p.Result(), nil
+	case goja.PromiseStateRejected:
+		return nil, fmt.Errorf("%s", p.Result().String())
+	}
+	return nil, fmt.Errorf("asynchronous function never completed")
+}
+
+// spectralTarget is a value a function is called with.
+type spectralTarget struct {
+	node *yaml.Node // nil if the field does not exist.
+	path []any
+	key  bool // the value is the key of node.
+}
+
+// targets returns the values the function is called with for a node, which are the node itself, or the field
+// of the rule action. A field of '@key' calls the function with every key of the node.
+func (s *SpectralRuleFunction) targets(doc *spectralDocument, node *yaml.Node, action *model.RuleAction) []spectralTarget {
+	path := append([]any{}, doc.paths[node]...)
+	if action == nil || action.Field == "" {
+		return []spectralTarget{{node: node, path: path}}
+	}
+	if action.Field == "@key" {
+		var t []spectralTarget
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				t = append(t, spectralTarget{node: node.Content[i], key: true,
+					path: append(append([]any{}, path...), node.Content[i].Value)})
+			}
+		}
+		return t
+	}
+	field := node
+	for _, seg := range strings.Split(action.Field, ".") {
+		path = append(path, seg)
+		if field != nil {
+			_, field = child(field, seg)
+		}
+	}
+	return []spectralTarget{{node: field, path: path}}
+}
+
+// buildContext creates the context object passed to the function.
+func (s *SpectralRuleFunction) buildContext(vm *goja.Runtime, doc *spectralDocument, context model.RuleFunctionContext) *goja.Object {
+	ctx := vm.NewObject()
+
+	source := ""
+	if context.Index != nil {
+		source = context.Index.GetSpecAbsolutePath()
+	}
+	document := vm.NewObject()
+	_ = document.Set("data", doc.data)
+	_ = document.Set("source", source)
+	_ = ctx.Set("document", document)
+
+	inventory := vm.NewObject()
+	_ = inventory.Set("resolved", doc.data)
+	_ = inventory.Set("unresolved", doc.unresolved)
+	_ = inventory.Set("findAssociatedItemForPath", func(path []any, resolved bool) any {
+		start, _ := locate(doc.root, path)
+		if start == nil {
+			return nil
+		}
+		location := source
+		if context.Index != nil {
+			if origin := context.Index.FindNodeOrigin(start); origin != nil {
+				location = origin.AbsoluteLocation
+			}
+		}
+		return map[string]any{
+			"document":            map[string]any{"source": location},
+			"path":                path,
+			"missingPropertyPath": []any{},
+		}
+	})
+	_ = ctx.Set("documentInventory", inventory)
+
+	if context.Rule != nil {
+		_ = ctx.Set("rule", map[string]any{
+			"name":        context.Rule.Id,
+			"description": context.Rule.Description,
+			"message":     context.Rule.Message,
+			"severity":    context.Rule.Severity,
+			"given":       context.Rule.Given,
+		})
+	}
+	return ctx
+}
+
+// document returns what the function can see of the document being linted. The last document is kept, as every
+// rule using the function lints the same one.
+func (s *SpectralRuleFunction) document(context model.RuleFunctionContext) *spectralDocument {
+	root := documentRoot(context.Index)
+
+	s.docLock.Lock()
+	defer s.docLock.Unlock()
+	if s.doc != nil && s.doc.root == root {
+		return s.doc
+	}
+	doc := &spectralDocument{root: root, paths: make(map[*yaml.Node][]any)}
+	if root != nil {
+		_ = root.Decode(&doc.data)
+		mapPaths(root, []any{}, doc.paths)
+	}
+	doc.unresolved = doc.data
+	if context.Document != nil && context.Document.GetSpecInfo() != nil && context.Document.GetSpecInfo().RootNode != nil {
+		var unresolved any
+		if context.Document.GetSpecInfo().RootNode.Decode(&unresolved) == nil {
+			doc.unresolved = unresolved
+		}
+	}
+	s.doc = doc
+	return doc
+}
+
+// mapPaths records the path to every node under node, nodes reachable by more than one path keep the first.
+func mapPaths(node *yaml.Node, path []any, paths map[*yaml.Node][]any) {
+	if _, seen := paths[node]; seen {
+		return
+	}
+	paths[node] = path
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			mapPaths(node.Content[i+1], append(append([]any{}, path...), node.Content[i].Value), paths)
+		}
+	case yaml.SequenceNode:
+		for i, n := range node.Content {
+			mapPaths(n, append(append([]any{}, path...), i), paths)
+		}
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			mapPaths(node.Alias, path, paths)
+		}
+	}
+}
+
+// locate finds the nodes a path points to. Properties start at their key, and end at their value if it's a scalar.
+// A path that does not exist is located at the closest node that does.
+func locate(root *yaml.Node, path []any) (*yaml.Node, *yaml.Node) {
+	if root == nil {
+		return nil, nil
+	}
+	start, end := root, root
+	node := root
+	for _, seg := range path {
+		key, value := child(node, seg)
+		if value == nil {
+			break
+		}
+		node = value
+		start, end = value, value
+		if key != nil {
+			start = key
+			if value.Kind != yaml.ScalarNode {
+				end = key
+			}
+		}
+	}
+	return start, end
+}
+
+// child returns the key and value of a property of a mapping, or an item of a sequence (which has no key).
+func child(node *yaml.Node, seg any) (*yaml.Node, *yaml.Node) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	name := fmt.Sprint(seg)
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				return node.Content[i], node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(node.Content) {
+			return nil, node.Content[i]
+		}
+	}
+	return nil, nil
+}
+
+// JSONPath renders path segments the way vacuum renders paths, e.g. $.paths['/pets'].get.tags[0]
+func JSONPath(path []any) string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, seg := range path {
+		switch v := seg.(type) {
+		case int:
+			sb.WriteString(fmt.Sprintf("[%d]", v))
+		case int64:
+			sb.WriteString(fmt.Sprintf("[%d]", v))
+		case float64:
+			sb.WriteString(fmt.Sprintf("[%d]", int(v)))
+		default:
+			name := fmt.Sprint(v)
+			if spectralIdentifier.MatchString(name) {
+				sb.WriteString("." + name)
+			} else {
+				sb.WriteString("['" + strings.ReplaceAll(name, "'", "\\'") + "']")
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package javascript
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/pb33f/libopenapi/index"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+const spectralSpec = `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      summary: Pets
+    post:
+      description: make a pet
+`
+
+func spectralIndex(t *testing.T) (*index.SpecIndex, *yaml.Node) {
+	var root yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(spectralSpec), &root))
+	return index.NewSpecIndexWithConfig(&root, index.CreateOpenAPIIndexConfig()), &root
+}
+
+func operations(root *yaml.Node) []*yaml.Node {
+	pets := root.Content[0].Content[5].Content[1]
+	return []*yaml.Node{pets.Content[1], pets.Content[3]}
+}
+
+func Test_Spectral_IsSpectralFunction(t *testing.T) {
+	assert.True(t, IsSpectralFunction(`export default (input) => {}`))
+	assert.True(t, IsSpectralFunction(`module.exports = function(input) {}`))
+	assert.True(t, IsSpectralFunction("const { createRulesetFunction } = require('@stoplight/spectral-core');"))
+	assert.False(t, IsSpectralFunction(`function runRule(input) {}`))
+	assert.False(t, IsSpectralFunction(`// export default is not used here
+function runRule(input) {}`))
+}
+
+func Test_Spectral_TranslateImport(t *testing.T) {
+	assert.Equal(t, `const { createRulesetFunction } = require("@stoplight/spectral-core");`,
+		translateImport("{ createRulesetFunction }", "@stoplight/spectral-core"))
+	assert.Equal(t, `const { a, b: c } = require("m");`, translateImport("{ a, b as c }", "m"))
+	assert.Equal(t, `const ns = require("m");`, translateImport("* as ns", "m"))
+	assert.Equal(t, `require("m");`, translateImport("", "m"))
+	assert.Equal(t, `const d = (function(m) { return m && m.default ? m.default : m; })(require("m")); `+
+		`const { x } = require("m");`, translateImport("d, { x }", "m"))
+}
+
+func Test_Spectral_RunRule(t *testing.T) {
+	script, err := os.ReadFile("../sample/js/spectral_operation_summary.js")
+	assert.NoError(t, err)
+
+	f := NewSpectralRuleFunction("operation-summary", string(script))
+	assert.NoError(t, f.CheckScript())
+
+	idx, root := spectralIndex(t)
+	results := f.RunRule(operations(root), model.RuleFunctionContext{
+		Rule:    &model.Rule{Id: "operation-summary"},
+		Options: map[string]interface{}{"minLength": 10},
+		Index:   idx,
+	})
+
+	assert.Len(t, results, 2)
+	assert.Equal(t, "summary 'Pets' is shorter than 10 characters", results[0].Message)
+	assert.Equal(t, "$.paths['/pets'].get.summary", results[0].Path)
+	assert.Equal(t, 8, results[0].StartNode.Line)
+	assert.Equal(t, "summary", results[0].StartNode.Value)
+	assert.Equal(t, "Pets", results[0].EndNode.Value)
+	assert.Equal(t, 8, results[0].Range.Start.Line)
+	assert.Equal(t, 7, results[0].Range.Start.Char)
+	assert.Equal(t, 16, results[0].Range.End.Char)
+
+	assert.Equal(t, "operation has no summary", results[1].Message)
+	assert.Equal(t, "$.paths['/pets'].post", results[1].Path)
+	assert.Equal(t, 9, results[1].StartNode.Line)
+
+	// the options schema is enforced.
+	results = f.RunRule(operations(root), model.RuleFunctionContext{
+		Options: map[string]interface{}{"minLength": "ten"},
+		Index:   idx,
+	})
+	assert.Len(t, results, 1)
+	assert.Contains(t, results[0].Message, "'operation-summary' function has invalid options")
+
+	// only objects are checked.
+	results = f.RunRule([]*yaml.Node{root.Content[0].Content[1]}, model.RuleFunctionContext{
+		Options: map[string]interface{}{"minLength": 10},
+		Index:   idx,
+	})
+	assert.Empty(t, results)
+}
+
+func Test_Spectral_RunRule_CommonJS_Key(t *testing.T) {
+	script := `module.exports = async function(targetVal, opts, context) {
+	if (targetVal !== targetVal.toLowerCase()) {
+		return [{ message: context.rule.name + ': ' + targetVal + ' in ' + context.document.data.info.title }];
+	}
+};`
+
+	f := NewSpectralRuleFunction("lower-case", script)
+	assert.NoError(t, f.CheckScript())
+
+	spec := `openapi: 3.1.0
+info:
+  title: pets
+paths:
+  /pets: {}
+  /Owners: {}
+`
+	var root yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(spec), &root))
+	idx := index.NewSpecIndexWithConfig(&root, index.CreateOpenAPIIndexConfig())
+
+	results := f.RunRule([]*yaml.Node{root.Content[0].Content[5]}, model.RuleFunctionContext{
+		Rule:       &model.Rule{Id: "lower-case", Message: ""},
+		RuleAction: &model.RuleAction{Field: "@key"},
+		Index:      idx,
+	})
+	assert.Len(t, results, 1)
+	assert.Equal(t, "lower-case: /Owners in pets", results[0].Message)
+	assert.Equal(t, "$.paths['/Owners']", results[0].Path)
+	assert.Equal(t, 6, results[0].StartNode.Line)
+}
+
+func Test_Spectral_RunRule_DocumentInventory(t *testing.T) {
+	script := `export default function (targetVal, opts, context) {
+	const item = context.documentInventory.findAssociatedItemForPath(['paths', '/pets', 'get'], true);
+	return [{ message: item.document.source + ' ' + context.documentInventory.resolved.openapi }];
+}`
+
+	f := NewSpectralRuleFunction("inventory", script)
+	assert.NoError(t, f.CheckScript())
+
+	idx, root := spectralIndex(t)
+	results := f.RunRule([]*yaml.Node{root.Content[0]}, model.RuleFunctionContext{Index: idx})
+	assert.Len(t, results, 1)
+	assert.Equal(t, idx.GetSpecAbsolutePath()+" 3.1.0", results[0].Message)
+	assert.Equal(t, "$", results[0].Path)
+}
+
+func Test_Spectral_RunRule_Throws(t *testing.T) {
+	script := `export default () => { throw new Error("oops"); }`
+
+	f := NewSpectralRuleFunction("throws", script)
+	idx, root := spectralIndex(t)
+	results := f.RunRule([]*yaml.Node{root.Content[0]}, model.RuleFunctionContext{Index: idx})
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Unable to execute JavaScript function: 'throws': Error: oops", results[0].Message)
+}
+
+func Test_Spectral_RunRule_RelativeImport(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "helpers.js"), []byte(`import { prefix } from './prefix';
+export function describe(title) {
+	return prefix + title;
+}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "prefix.js"),
+		[]byte(`module.exports.prefix = 'title is ';`), 0644))
+	script := `import { describe } from './lib/helpers';
+export default (targetVal) => [{ message: describe(targetVal.title) }];`
+
+	// imports are resolved from the directory of the function, not the working directory.
+	f := NewSpectralRuleFunctionFromFile("relative", filepath.Join(dir, "relative.js"), script)
+	assert.NoError(t, f.CheckScript())
+
+	idx, root := spectralIndex(t)
+	results := f.RunRule([]*yaml.Node{root.Content[0].Content[3]}, model.RuleFunctionContext{Index: idx})
+	assert.Len(t, results, 1)
+	assert.Equal(t, "title is pets", results[0].Message)
+
+	// without a location, there is nothing to resolve them from.
+	assert.Error(t, NewSpectralRuleFunction("relative", script).CheckScript())
+}
+
+func Test_Spectral_TranslateModule_Exports(t *testing.T) {
+	translated := translateModule("export const a = 1;\nfunction b() {}\nexport { b, a as c };")
+	assert.Equal(t, "const a = 1;\nfunction b() {}\n\n"+
+		`module.exports["b"] = b;`+"\n"+`module.exports["c"] = a;`+"\n"+`module.exports["a"] = a;`, translated)
+}
+
+func Test_Spectral_CheckScript_NoExport(t *testing.T) {
+	f := NewSpectralRuleFunction("nothing", `export const a = 1;`)
+	assert.Error(t, f.CheckScript())
+}
+
+func Test_Spectral_JSONPath(t *testing.T) {
+	assert.Equal(t, "$", JSONPath(nil))
+	assert.Equal(t, "$.paths['/pets'].get.tags[0]", JSONPath([]any{"paths", "/pets", "get", "tags", float64(0)}))
+	assert.Equal(t, "$.info['it\\'s']", JSONPath([]any{"info", "it's"}))
+}
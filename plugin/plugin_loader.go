@@ -5,6 +5,8 @@ import (
 	"github.com/daveshanley/vacuum/functions/core"
 	"github.com/daveshanley/vacuum/model"
 	"github.com/daveshanley/vacuum/plugin/javascript"
+	"github.com/daveshanley/vacuum/plugin/rpc"
+	"github.com/daveshanley/vacuum/plugin/wasm"
 	"github.com/pterm/pterm"
 	"gopkg.in/yaml.v3"
 	"os"
@@ -14,6 +16,9 @@ import (
 	"strings"
 )
 
+// VacuumVersion is sent to out-of-process plugins when they are started.
+var VacuumVersion string
+
 // LoadFunctions will load custom functions found in the supplied path
 func LoadFunctions(path string, silence bool) (*Manager, error) {
 
@@ -35,6 +40,7 @@ func LoadFunctions(path string, silence bool) (*Manager, error) {
 			// let's try and open it.
 			p, e := plugin.Open(fPath)
 			if e != nil {
+				pm.Close()
 				return nil, e
 			}
 
@@ -42,6 +48,7 @@ func LoadFunctions(path string, silence bool) (*Manager, error) {
 			var bootFunc plugin.Symbol
 			bootFunc, err = p.Lookup("Boot")
 			if err != nil {
+				pm.Close()
 				return nil, err
 			}
 
@@ -60,32 +67,94 @@ func LoadFunctions(path string, silence bool) (*Manager, error) {
 			// let's try and read the file
 			p, e := os.ReadFile(fPath)
 			if e != nil {
+				pm.Close()
 				return nil, e
 			}
 
-			function := javascript.NewJSRuleFunction(fName, string(p))
+			// register this function with the plugin manager
+			pm.RegisterFunction(fName, newJavaScriptFunction(fName, fPath, string(p), silence))
+		}
+
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".wasm") {
+			fPath := filepath.Join(path, entry.Name())
+			fName := strings.TrimSuffix(entry.Name(), ".wasm")
+
+			// let's try and read the file, a function that can't be loaded is skipped, like a broken script.
+			b, e := os.ReadFile(fPath)
+			if e != nil {
+				pterm.Error.Printf("Failed to load function '%s': %s\n", fName, e.Error())
+				continue
+			}
+
+			function, e := wasm.NewWasmRuleFunction(fName, b)
+			if e != nil {
+				pterm.Error.Printf("Failed to load function '%s': %s\n", fName, e.Error())
+				continue
+			}
+			pm.closers = append(pm.closers, function)
 
 			// found something
 			if !silence {
-				pterm.Info.Printf("Located custom javascript function: '%s'\n", function.GetSchema().Name)
+				pterm.Info.Printf("Located custom WebAssembly function: '%s'\n", function.GetSchema().Name)
 			}
-			// check if the function is valid
-			sErr := function.CheckScript()
+			pm.RegisterFunction(fName, function)
+		}
+
+		if !entry.IsDir() && rpc.IsManifest(entry.Name()) {
+			fPath := filepath.Join(path, entry.Name())
 
-			if sErr != nil {
-				pterm.Error.Printf("Failed to load function '%s': %s\n", fName, sErr.Error())
+			// a plugin that can't be started is skipped, the other functions are still loaded.
+			manifest, e := rpc.LoadManifest(fPath)
+			if e != nil {
+				pterm.Error.Printf("Failed to load plugin '%s': %s\n", fPath, e.Error())
+				continue
 			}
 
-			// register core functions with this custom function.
-			RegisterCoreFunctions(function)
+			// start it up, and find out what it can do.
+			p, e := rpc.Start(manifest, VacuumVersion)
+			if e != nil {
+				pterm.Error.Printf("Failed to load plugin '%s': %s\n", fPath, e.Error())
+				continue
+			}
+			pm.plugins = append(pm.plugins, p)
 
-			// register this function with the plugin manager
-			pm.RegisterFunction(fName, function)
+			for _, function := range p.Functions() {
+				if !silence {
+					pterm.Info.Printf("Located custom plugin function: '%s' (%s)\n", function.Name(), p.Name())
+				}
+				pm.RegisterFunction(function.Name(), function)
+			}
 		}
 	}
 	return pm, nil
 }
 
+// newJavaScriptFunction creates a JavaScript function, written for vacuum or for Spectral. location is the file the
+// script was read from, Spectral functions import modules relative to it, it's empty if the script was downloaded.
+func newJavaScriptFunction(name, location, script string, silence bool) javascript.JSEnabledRuleFunction {
+	var function javascript.JSEnabledRuleFunction
+	if javascript.IsSpectralFunction(script) {
+		function = javascript.NewSpectralRuleFunctionFromFile(name, location, script)
+	} else {
+		function = javascript.NewJSRuleFunction(name, script)
+	}
+
+	// found something
+	if !silence {
+		pterm.Info.Printf("Located custom javascript function: '%s'\n", function.GetSchema().Name)
+	}
+	// check if the function is valid
+	sErr := function.CheckScript()
+
+	if sErr != nil {
+		pterm.Error.Printf("Failed to load function '%s': %s\n", name, sErr.Error())
+	}
+
+	// register core functions with this custom function.
+	RegisterCoreFunctions(function)
+	return function
+}
+
 var extractInput = func(input any) *yaml.Node {
 	var y yaml.Node
 	switch reflect.TypeOf(input).Kind() {
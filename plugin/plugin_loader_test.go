@@ -2,6 +2,8 @@ package plugin
 
 import (
 	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 )
@@ -23,7 +25,7 @@ func TestLoadFunctions_JavaScript_OK(t *testing.T) {
 	pm, err := LoadFunctions("sample/js", false)
 	assert.NotNil(t, pm)
 	assert.NoError(t, err)
-	assert.Equal(t, 5, pm.LoadedFunctionCount())
+	assert.Equal(t, 6, pm.LoadedFunctionCount())
 	assert.Equal(t, "useless_func_modified_name",
 		pm.GetCustomFunctions()["useless_func"].GetSchema().Name)
 	assert.Equal(t, "check_for_name_and_id",
@@ -47,3 +49,59 @@ func TestLoadFunctions_TestCompile(t *testing.T) {
 		assert.Equal(t, 0, pm.LoadedFunctionCount())
 	}
 }
+
+func TestLoadFunctions_JavaScript_Spectral(t *testing.T) {
+	pm, err := LoadFunctions("sample/js", true)
+	assert.NoError(t, err)
+
+	schema := pm.GetCustomFunctions()["spectral_operation_summary"].GetSchema()
+	assert.Equal(t, "spectral_operation_summary", schema.Name)
+	assert.Equal(t, []string{"minLength"}, schema.Required)
+	assert.Equal(t, "the shortest summary allowed", schema.GetPropertyDescription("minLength"))
+}
+
+func TestLoadFunctions_WebAssembly(t *testing.T) {
+	pm, err := LoadFunctions("sample/wasm", false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pm.LoadedFunctionCount())
+	assert.Equal(t, "no-todo", pm.GetCustomFunctions()["no_todo"].GetSchema().Name)
+
+	// the manager releases the runtimes of its WebAssembly functions.
+	assert.Len(t, pm.closers, 1)
+	assert.NoError(t, pm.Close())
+	assert.Empty(t, pm.closers)
+}
+
+func TestLoadFunctions_WebAssembly_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"sample/wasm/no_todo.wasm", "wasm/test_files/no_exports.wasm"} {
+		b, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, filepath.Base(file)), b, 0644))
+	}
+
+	// the broken function is skipped, the others are still loaded.
+	pm, err := LoadFunctions(dir, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pm.LoadedFunctionCount())
+	assert.NotNil(t, pm.GetCustomFunctions()["no_todo"])
+	assert.Nil(t, pm.GetCustomFunctions()["no_exports"])
+	assert.NoError(t, pm.Close())
+}
+
+func TestLoadFunctions_Plugin_Broken(t *testing.T) {
+	dir := t.TempDir()
+	b, err := os.ReadFile("sample/js/useless_func.js")
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "useless_func.js"), b, 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "missing.plugin.yaml"), []byte("command: ./does-not-exist\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.plugin.yaml"), []byte("args: [nothing to run]\n"), 0644))
+
+	// plugins that can't be started are skipped, the other functions are still loaded.
+	pm, err := LoadFunctions(dir, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pm.LoadedFunctionCount())
+	assert.NotNil(t, pm.GetCustomFunctions()["useless_func"])
+	assert.Empty(t, pm.plugins)
+	assert.NoError(t, pm.Close())
+}
@@ -1,7 +1,10 @@
 package plugin
 
 import (
+	"io"
+
 	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/plugin/rpc"
 	"gopkg.in/yaml.v3"
 )
 
@@ -10,6 +13,8 @@ type FunctionHook func(nodes []*yaml.Node, context model.RuleFunctionContext) []
 
 type Manager struct {
 	customFunctions map[string]model.RuleFunction
+	plugins         []*rpc.Plugin
+	closers         []io.Closer // functions holding resources, like WebAssembly runtimes.
 }
 
 func CreatePluginManager() *Manager {
@@ -31,3 +36,19 @@ func (pm *Manager) LoadedFunctionCount() int {
 func (pm *Manager) GetCustomFunctions() map[string]model.RuleFunction {
 	return pm.customFunctions
 }
+
+// Close stops any out-of-process plugins started by the manager, and releases the resources held by its functions.
+// The functions of the manager cannot be used once it's closed. Closing a nil manager does nothing.
+func (pm *Manager) Close() error {
+	if pm == nil {
+		return nil
+	}
+	for _, p := range pm.plugins {
+		_ = p.Close()
+	}
+	for _, c := range pm.closers {
+		_ = c.Close()
+	}
+	pm.plugins, pm.closers = nil, nil
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package rpc
+
+import (
+	gocontext "context"
+	"fmt"
+	"sync"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/model/reports"
+	"github.com/pb33f/libopenapi/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Function is a function provided by a plugin.
+type Function struct {
+	plugin     *Plugin
+	name       string
+	schemaOnce sync.Once
+	schema     model.RuleFunctionSchema
+}
+
+// Name returns the name the plugin gave the function.
+func (f *Function) Name() string {
+	return f.name
+}
+
+// GetSchema returns the schema of the function, as told by the plugin.
+func (f *Function) GetSchema() model.RuleFunctionSchema {
+	f.schemaOnce.Do(func() {
+		if err := f.plugin.call(gocontext.Background(), MethodGetSchema,
+			GetSchemaParams{Function: f.name}, &f.schema); err != nil {
+			f.schema = model.RuleFunctionSchema{}
+		}
+		if f.schema.Name == "" {
+			f.schema.Name = f.name
+		}
+	})
+	return f.schema
+}
+
+// RunRule sends each node to the plugin, and reports what it finds.
+func (f *Function) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext) []model.RuleFunctionResult {
+	var results []model.RuleFunctionResult
+	if len(nodes) == 0 {
+		return results
+	}
+
+	ctx := context.Context
+	if ctx == nil {
+		ctx = gocontext.Background()
+	}
+
+	for _, node := range nodes {
+		var found []Result
+		err := f.plugin.call(ctx, MethodRunRule, NewRunRuleParams(f.name, node, context), &found)
+		if err != nil {
+			return append(results, Failure(node,
+				fmt.Sprintf("Unable to run plugin function '%s': %s", f.name, err.Error()), context))
+		}
+		results = append(results, Report(found, node, context)...)
+	}
+	return results
+}
+
+// NewRunRuleParams describes a node being checked by a function, plugins that are not processes (like WebAssembly
+// modules) are sent the same thing.
+func NewRunRuleParams(function string, node *yaml.Node, context model.RuleFunctionContext) RunRuleParams {
+	var value any
+	_ = node.Decode(&value)
+	var field string
+	if context.RuleAction != nil {
+		field = context.RuleAction.Field
+	}
+	return RunRuleParams{
+		Function: function,
+		Node:     Node{Value: value, Line: node.Line, Column: node.Column},
+		Path:     context.Given,
+		Field:    field,
+		Options:  context.Options,
+		Rule:     ruleOf(context.Rule),
+	}
+}
+
+// Report turns what a function found in a node into results, located by their paths when they have one.
+func Report(found []Result, node *yaml.Node, context model.RuleFunctionContext) []model.RuleFunctionResult {
+	results := make([]model.RuleFunctionResult, 0, len(found))
+	root := documentRoot(context)
+	for _, r := range found {
+		start, path := node, fmt.Sprint(context.Given)
+		if r.Path != "" && root != nil {
+			if located, _ := utils.FindNodesWithoutDeserializing(root, r.Path); len(located) > 0 {
+				start, path = located[0], r.Path
+			}
+		}
+		results = append(results, result(start, path, r.Message, context))
+	}
+	return results
+}
+
+// Failure reports that a function could not check a node.
+func Failure(node *yaml.Node, message string, context model.RuleFunctionContext) model.RuleFunctionResult {
+	return result(node, fmt.Sprint(context.Given), message, context)
+}
+
+func result(node *yaml.Node, path, message string, context model.RuleFunctionContext) model.RuleFunctionResult {
+	return model.RuleFunctionResult{
+		Message:   message,
+		StartNode: node,
+		EndNode:   node,
+		Range: reports.Range{
+			Start: reports.RangeItem{Line: node.Line, Char: node.Column},
+			End:   reports.RangeItem{Line: node.Line, Char: node.Column},
+		},
+		Path: path,
+		Rule: context.Rule,
+	}
+}
+
+func documentRoot(context model.RuleFunctionContext) *yaml.Node {
+	if context.Index == nil {
+		return nil
+	}
+	root := context.Index.GetRootNode()
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	return root
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package rpc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultTimeout is how long a call can take, if the manifest does not say.
+const DefaultTimeout = 10 * time.Second
+
+// DefaultMaxRestarts is how many crashes of a plugin are restarted within the restart window, if the manifest
+// does not say.
+const DefaultMaxRestarts = 3
+
+// Manifest declares a plugin.
+type Manifest struct {
+	Command     string            `json:"command" yaml:"command"`
+	Args        []string          `json:"args,omitempty" yaml:"args,omitempty"`
+	Env         map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+	Timeout     string            `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	MaxRestarts *int              `json:"maxRestarts,omitempty" yaml:"maxRestarts,omitempty"`
+	Location    string            `json:"-" yaml:"-"` // where the manifest was loaded from.
+}
+
+// IsManifest returns true if the file name is that of a plugin manifest.
+func IsManifest(name string) bool {
+	for _, ext := range []string{".plugin.yaml", ".plugin.yml", ".plugin.json"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadManifest reads and checks a plugin manifest (JSON is YAML, so both are read the same way).
+func LoadManifest(location string) (*Manifest, error) {
+	b, err := os.ReadFile(location)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err = yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("unable to parse plugin manifest '%s': %w", location, err)
+	}
+	m.Location = location
+	if m.Command == "" {
+		return nil, fmt.Errorf("plugin manifest '%s' has no command", location)
+	}
+	if _, err = m.timeout(); err != nil {
+		return nil, fmt.Errorf("plugin manifest '%s' has an invalid timeout: %w", location, err)
+	}
+	return &m, nil
+}
+
+// Name is the name of the plugin, which is the name of the manifest.
+func (m *Manifest) Name() string {
+	name := filepath.Base(m.Location)
+	if i := strings.Index(name, ".plugin."); i > 0 {
+		return name[:i]
+	}
+	return m.Command
+}
+
+// command returns the path of the executable, commands that look like paths are relative to the manifest.
+func (m *Manifest) command() string {
+	if filepath.IsAbs(m.Command) || !strings.ContainsAny(m.Command, `/\`) || m.Location == "" {
+		return m.Command
+	}
+	return filepath.Join(filepath.Dir(m.Location), m.Command)
+}
+
+func (m *Manifest) timeout() (time.Duration, error) {
+	if m.Timeout == "" {
+		return DefaultTimeout, nil
+	}
+	d, err := time.ParseDuration(m.Timeout)
+	if err == nil && d <= 0 {
+		err = fmt.Errorf("timeout must be positive")
+	}
+	return d, err
+}
+
+func (m *Manifest) maxRestarts() int {
+	if m.MaxRestarts == nil {
+		return DefaultMaxRestarts
+	}
+	return *m.MaxRestarts
+}
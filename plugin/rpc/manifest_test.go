@@ -0,0 +1,68 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package rpc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsManifest(t *testing.T) {
+	assert.True(t, IsManifest("naming.plugin.yaml"))
+	assert.True(t, IsManifest("naming.plugin.yml"))
+	assert.True(t, IsManifest("naming.plugin.json"))
+	assert.False(t, IsManifest("naming.yaml"))
+	assert.False(t, IsManifest("plugin.js"))
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	location := filepath.Join(dir, "naming.plugin.yaml")
+	assert.NoError(t, os.WriteFile(location, []byte("command: ./bin/naming\nargs: [--strict]\n"), 0o644))
+
+	m, err := LoadManifest(location)
+	assert.NoError(t, err)
+	assert.Equal(t, "naming", m.Name())
+	assert.Equal(t, filepath.Join(dir, "bin", "naming"), m.command())
+	assert.Equal(t, []string{"--strict"}, m.Args)
+	timeout, _ := m.timeout()
+	assert.Equal(t, DefaultTimeout, timeout)
+	assert.Equal(t, DefaultMaxRestarts, m.maxRestarts())
+
+	// commands that are not paths are looked up in the PATH.
+	m.Command = "python3"
+	assert.Equal(t, "python3", m.command())
+}
+
+func TestLoadManifest_JSON(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "naming.plugin.json")
+	assert.NoError(t, os.WriteFile(location,
+		[]byte(`{"command": "naming", "timeout": "2s", "maxRestarts": 0}`), 0o644))
+
+	m, err := LoadManifest(location)
+	assert.NoError(t, err)
+	timeout, _ := m.timeout()
+	assert.Equal(t, "2s", timeout.String())
+	assert.Equal(t, 0, m.maxRestarts())
+}
+
+func TestLoadManifest_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"no-command.plugin.yaml": "args: [a]",
+		"timeout.plugin.yaml":    "command: naming\ntimeout: soon",
+		"negative.plugin.yaml":   "command: naming\ntimeout: -1s",
+		"broken.plugin.yaml":     "command: [",
+	} {
+		location := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(location, []byte(content), 0o644))
+		_, err := LoadManifest(location)
+		assert.Error(t, err, name)
+	}
+	_, err := LoadManifest(filepath.Join(dir, "missing.plugin.yaml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,345 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxMessageSize is the largest message a plugin can send.
+const maxMessageSize = 64 << 20
+
+// stderrTail is how much of the stderr of a plugin is kept, to explain why it died.
+const stderrTail = 4096
+
+// restartWindow is the period the restart limit of a manifest applies to. A plugin that crashes now and then is
+// always restarted, a plugin that keeps crashing gives up until the window is over.
+var restartWindow = 10 * time.Minute
+
+// Plugin is a running plugin, it is restarted if it dies or stops answering. Crashes are limited by the restart
+// limit of the manifest within restartWindow, plugins killed for not answering in time are always restarted.
+type Plugin struct {
+	manifest      *Manifest
+	version       string
+	timeout       time.Duration
+	lock          sync.Mutex
+	proc          *process
+	restarts      int       // crashes restarted since restartsSince.
+	restartsSince time.Time // when the restart window started.
+	closed        bool
+	functions     []string
+}
+
+// Start starts the plugin declared by a manifest, and asks it which functions it provides.
+func Start(manifest *Manifest, vacuumVersion string) (*Plugin, error) {
+	timeout, err := manifest.timeout()
+	if err != nil {
+		return nil, err
+	}
+	p := &Plugin{manifest: manifest, version: vacuumVersion, timeout: timeout}
+	if p.proc, err = p.spawn(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Name returns the name of the plugin.
+func (p *Plugin) Name() string {
+	return p.manifest.Name()
+}
+
+// Functions returns the functions provided by the plugin.
+func (p *Plugin) Functions() []*Function {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	functions := make([]*Function, len(p.functions))
+	for i, name := range p.functions {
+		functions[i] = &Function{plugin: p, name: name}
+	}
+	return functions
+}
+
+// Close stops the plugin, it is asked to exit by closing its stdin, and killed if it does not.
+func (p *Plugin) Close() error {
+	p.lock.Lock()
+	p.closed = true
+	proc := p.proc
+	p.lock.Unlock()
+	if proc != nil {
+		proc.stop(time.Second)
+	}
+	return nil
+}
+
+// call sends a request to the plugin, and decodes the result. The plugin is restarted first if it has died, and
+// killed if it does not answer in time.
+func (p *Plugin) call(ctx context.Context, method string, params, result any) error {
+	proc, err := p.running()
+	if err != nil {
+		return err
+	}
+	callCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	raw, err := proc.call(callCtx, method, params)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			proc.timedOut.Store(true)
+			proc.kill()
+			<-proc.done // so the next call restarts it.
+			return fmt.Errorf("plugin '%s' did not answer '%s' within %s", p.Name(), method, p.timeout)
+		}
+		return err
+	}
+	if result != nil {
+		if err = json.Unmarshal(raw, result); err != nil {
+			return fmt.Errorf("plugin '%s' sent an invalid '%s' result: %w", p.Name(), method, err)
+		}
+	}
+	return nil
+}
+
+// running returns the running process, restarting it if it has died.
+func (p *Plugin) running() (*process, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed {
+		return nil, fmt.Errorf("plugin '%s' has been closed", p.Name())
+	}
+	if !p.proc.exited() {
+		return p.proc, nil
+	}
+	// a plugin that was too slow has not crashed, it's restarted without counting against the limit.
+	if !p.proc.timedOut.Load() {
+		if time.Since(p.restartsSince) > restartWindow {
+			p.restarts, p.restartsSince = 0, time.Now()
+		}
+		if p.restarts >= p.manifest.maxRestarts() {
+			return nil, fmt.Errorf("plugin '%s' has been restarted %d times in %s, and is not restarted again for now: %w",
+				p.Name(), p.restarts, restartWindow, p.proc.err)
+		}
+		p.restarts++
+	}
+	proc, err := p.spawn()
+	if err != nil {
+		return nil, err
+	}
+	p.proc = proc
+	return proc, nil
+}
+
+// spawn starts the plugin process and initializes it.
+func (p *Plugin) spawn() (*process, error) {
+	cmd := exec.Command(p.manifest.command(), p.manifest.Args...)
+	cmd.Env = os.Environ()
+	for k, v := range p.manifest.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	proc, err := startProcess(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start plugin '%s': %w", p.Name(), err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	var init InitializeResult
+	raw, err := proc.call(ctx, MethodInitialize, InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		VacuumVersion:   p.version,
+	})
+	if err == nil {
+		err = json.Unmarshal(raw, &init)
+	}
+	if err == nil && init.ProtocolVersion != ProtocolVersion {
+		err = fmt.Errorf("plugin speaks protocol version %d, vacuum speaks version %d",
+			init.ProtocolVersion, ProtocolVersion)
+	}
+	if err != nil {
+		proc.kill()
+		return nil, fmt.Errorf("unable to initialize plugin '%s': %w", p.Name(), err)
+	}
+	p.functions = init.Functions
+	return proc, nil
+}
+
+// process is a single run of a plugin.
+type process struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stderr    *tail
+	writeLock sync.Mutex
+	lock      sync.Mutex
+	pending   map[int64]chan *Response
+	nextID    atomic.Int64
+	done      chan struct{}
+	err       error       // why the process exited, set before done is closed.
+	timedOut  atomic.Bool // the process was killed because a call took too long.
+}
+
+func startProcess(cmd *exec.Cmd) (*process, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	proc := &process{
+		cmd:     cmd,
+		stdin:   stdin,
+		stderr:  &tail{},
+		pending: make(map[int64]chan *Response),
+		done:    make(chan struct{}),
+	}
+	cmd.Stderr = proc.stderr
+	cmd.WaitDelay = time.Second
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	go proc.read(stdout)
+	return proc, nil
+}
+
+// read hands responses to the calls waiting for them, until the process exits.
+func (p *process) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var resp Response
+		if json.Unmarshal(scanner.Bytes(), &resp) != nil {
+			continue // not a response, so not ours.
+		}
+		p.lock.Lock()
+		ch := p.pending[resp.ID]
+		delete(p.pending, resp.ID)
+		p.lock.Unlock()
+		if ch != nil {
+			ch <- &resp
+		}
+	}
+	p.kill() // the plugin can't be talked to anymore, if it's still running.
+	err := p.cmd.Wait()
+	if scanErr := scanner.Err(); scanErr != nil {
+		err = scanErr
+	}
+	if err == nil {
+		err = errors.New("exited")
+	}
+	if stderr := p.stderr.String(); stderr != "" {
+		err = fmt.Errorf("%w: %s", err, stderr)
+	}
+
+	p.lock.Lock()
+	p.err = fmt.Errorf("plugin process stopped: %w", err)
+	p.pending = nil
+	p.lock.Unlock()
+	close(p.done)
+}
+
+func (p *process) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	id := p.nextID.Add(1)
+	ch := make(chan *Response, 1)
+
+	p.lock.Lock()
+	if p.pending == nil {
+		p.lock.Unlock()
+		return nil, p.err
+	}
+	p.pending[id] = ch
+	p.lock.Unlock()
+
+	forget := func() {
+		p.lock.Lock()
+		delete(p.pending, id)
+		p.lock.Unlock()
+	}
+
+	msg, _ := json.Marshal(Request{JSONRPC: "2.0", ID: id, Method: method, Params: b})
+	p.writeLock.Lock()
+	_, err = p.stdin.Write(append(msg, '\n'))
+	p.writeLock.Unlock()
+	if err != nil {
+		forget()
+		select {
+		case <-p.done:
+			return nil, p.err
+		case <-time.After(100 * time.Millisecond):
+			return nil, fmt.Errorf("unable to write to plugin: %w", err)
+		}
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	case <-p.done:
+		return nil, p.err
+	case <-ctx.Done():
+		forget()
+		return nil, ctx.Err()
+	}
+}
+
+func (p *process) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *process) kill() {
+	_ = p.cmd.Process.Kill()
+}
+
+// stop closes stdin, and kills the process if it has not exited after the grace period.
+func (p *process) stop(grace time.Duration) {
+	_ = p.stdin.Close()
+	select {
+	case <-p.done:
+	case <-time.After(grace):
+		p.kill()
+		<-p.done
+	}
+}
+
+// tail keeps the end of what is written to it.
+type tail struct {
+	lock sync.Mutex
+	buf  []byte
+}
+
+func (t *tail) Write(b []byte) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.buf = append(t.buf, b...)
+	if len(t.buf) > stderrTail {
+		t.buf = t.buf[len(t.buf)-stderrTail:]
+	}
+	return len(b), nil
+}
+
+func (t *tail) String() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return strings.TrimSpace(string(t.buf))
+}
@@ -0,0 +1,250 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/pb33f/libopenapi/index"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// the test binary is the plugin, when this is set.
+const pluginEnv = "VACUUM_RPC_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(pluginEnv) {
+	case "serve":
+		_ = Serve(os.Stdin, os.Stdout, testFunctions)
+		os.Exit(0)
+	case "old":
+		// answers initialize with a protocol vacuum does not speak.
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			fmt.Println(`{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":99,"functions":[]}}`)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+var testFunctions = map[string]Handler{
+	"no-todo": {
+		Schema: model.RuleFunctionSchema{
+			Name:       "no-todo",
+			Properties: []model.RuleFunctionProperty{{Name: "word", Description: "the word to look for"}},
+		},
+		Run: func(params *RunRuleParams) ([]Result, error) {
+			word := "TODO"
+			if opts, ok := params.Options.(map[string]any); ok && opts["word"] != nil {
+				word = fmt.Sprint(opts["word"])
+			}
+			info, _ := params.Node.Value.(map[string]any)
+			if desc, ok := info["description"].(string); ok && strings.Contains(desc, word) {
+				return []Result{{
+					Message: fmt.Sprintf("%s: description contains '%s' (line %d)", params.Rule.Id, word, params.Node.Line),
+					Path:    fmt.Sprint(params.Path) + ".description",
+				}}, nil
+			}
+			return nil, nil
+		},
+	},
+	"slow": {
+		Run: func(params *RunRuleParams) ([]Result, error) {
+			time.Sleep(10 * time.Second)
+			return nil, nil
+		},
+	},
+	"crash": {
+		Run: func(params *RunRuleParams) ([]Result, error) {
+			fmt.Fprintln(os.Stderr, "boom")
+			os.Exit(3)
+			return nil, nil
+		},
+	},
+	"fails": {
+		Run: func(params *RunRuleParams) ([]Result, error) {
+			return nil, fmt.Errorf("cannot do that")
+		},
+	},
+}
+
+const testSpec = `openapi: 3.1.0
+info:
+  title: pets
+  description: TODO write this
+  version: 1.0.0
+`
+
+func testManifest(t *testing.T, mode string, timeout string, restarts int) *Manifest {
+	exe, err := os.Executable()
+	assert.NoError(t, err)
+	return &Manifest{
+		Command:     exe,
+		Env:         map[string]string{pluginEnv: mode},
+		Timeout:     timeout,
+		MaxRestarts: &restarts,
+		Location:    filepath.Join(t.TempDir(), "test.plugin.yaml"),
+	}
+}
+
+func testContext(t *testing.T) (model.RuleFunctionContext, []*yaml.Node) {
+	var root yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(testSpec), &root))
+	idx := index.NewSpecIndexWithConfig(&root, index.CreateOpenAPIIndexConfig())
+	return model.RuleFunctionContext{
+		Rule:    &model.Rule{Id: "no-todo-rule"},
+		Given:   "$.info",
+		Index:   idx,
+		Context: context.Background(),
+	}, []*yaml.Node{root.Content[0].Content[3]}
+}
+
+func function(p *Plugin, name string) *Function {
+	for _, f := range p.Functions() {
+		if f.Name() == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func TestPlugin_RunRule(t *testing.T) {
+	p, err := Start(testManifest(t, "serve", "", 0), "1.2.3")
+	assert.NoError(t, err)
+	defer p.Close()
+
+	assert.Equal(t, "test", p.Name())
+	assert.Len(t, p.Functions(), 4)
+
+	f := function(p, "no-todo")
+	assert.Equal(t, "no-todo", f.GetSchema().Name)
+	assert.Equal(t, "word", f.GetSchema().Properties[0].Name)
+	assert.Equal(t, "slow", function(p, "slow").GetSchema().Name)
+
+	ctx, nodes := testContext(t)
+	results := f.RunRule(nodes, ctx)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "no-todo-rule: description contains 'TODO' (line 3)", results[0].Message)
+	assert.Equal(t, "$.info.description", results[0].Path)
+	assert.Equal(t, 4, results[0].StartNode.Line)
+	assert.Equal(t, 4, results[0].Range.Start.Line)
+	assert.Equal(t, ctx.Rule, results[0].Rule)
+
+	ctx.Options = map[string]any{"word": "nothing"}
+	assert.Empty(t, f.RunRule(nodes, ctx))
+
+	results = function(p, "fails").RunRule(nodes, ctx)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Unable to run plugin function 'fails': cannot do that (-32603)", results[0].Message)
+	assert.Equal(t, "$.info", results[0].Path)
+}
+
+func TestPlugin_Timeout_Restarts(t *testing.T) {
+	p, err := Start(testManifest(t, "serve", "250ms", 1), "")
+	assert.NoError(t, err)
+	defer p.Close()
+
+	ctx, nodes := testContext(t)
+	results := function(p, "slow").RunRule(nodes, ctx)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Unable to run plugin function 'slow': plugin 'test' did not answer 'runRule' within 250ms",
+		results[0].Message)
+
+	// restarted.
+	results = function(p, "no-todo").RunRule(nodes, ctx)
+	assert.Len(t, results, 1)
+	assert.Contains(t, results[0].Message, "contains 'TODO'")
+
+	// slow calls are not crashes, the plugin is restarted however many times it's killed.
+	for i := 0; i < 2; i++ {
+		function(p, "slow").RunRule(nodes, ctx)
+	}
+	results = function(p, "no-todo").RunRule(nodes, ctx)
+	assert.Len(t, results, 1)
+	assert.Contains(t, results[0].Message, "contains 'TODO'")
+}
+
+func TestPlugin_Crash_RestartWindow(t *testing.T) {
+	window := restartWindow
+	restartWindow = 500 * time.Millisecond
+	defer func() { restartWindow = window }()
+
+	p, err := Start(testManifest(t, "serve", "", 1), "")
+	assert.NoError(t, err)
+	defer p.Close()
+
+	// restarted once, and not after crashing again.
+	ctx, nodes := testContext(t)
+	function(p, "crash").RunRule(nodes, ctx)
+	function(p, "crash").RunRule(nodes, ctx)
+	results := function(p, "no-todo").RunRule(nodes, ctx)
+	assert.Len(t, results, 1)
+	assert.Contains(t, results[0].Message, "has been restarted 1 times in 500ms")
+
+	// the limit applies to the window, once it's over the plugin is restarted again.
+	time.Sleep(restartWindow)
+	results = function(p, "no-todo").RunRule(nodes, ctx)
+	assert.Len(t, results, 1)
+	assert.Contains(t, results[0].Message, "contains 'TODO'")
+}
+
+func TestPlugin_Crash(t *testing.T) {
+	p, err := Start(testManifest(t, "serve", "", 0), "")
+	assert.NoError(t, err)
+	defer p.Close()
+
+	ctx, nodes := testContext(t)
+	results := function(p, "crash").RunRule(nodes, ctx)
+	assert.Len(t, results, 1)
+	assert.Contains(t, results[0].Message, "plugin process stopped: exit status 3: boom")
+
+	results = function(p, "no-todo").RunRule(nodes, ctx)
+	assert.Len(t, results, 1)
+	assert.Contains(t, results[0].Message, "has been restarted 0 times")
+}
+
+func TestPlugin_RuleContextCancelled(t *testing.T) {
+	p, err := Start(testManifest(t, "serve", "", 0), "")
+	assert.NoError(t, err)
+	defer p.Close()
+
+	ctx, nodes := testContext(t)
+	cancelled, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	ctx.Context = cancelled
+	results := function(p, "slow").RunRule(nodes, ctx)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Unable to run plugin function 'slow': context deadline exceeded", results[0].Message)
+}
+
+func TestStart_ProtocolVersion(t *testing.T) {
+	_, err := Start(testManifest(t, "old", "", 0), "")
+	assert.ErrorContains(t, err,
+		"unable to initialize plugin 'test': plugin speaks protocol version 99, vacuum speaks version 1")
+}
+
+func TestStart_NoCommand(t *testing.T) {
+	_, err := Start(&Manifest{Command: "./does-not-exist", Location: "nowhere/bad.plugin.yaml"}, "")
+	assert.ErrorContains(t, err, "unable to start plugin 'bad'")
+}
+
+func TestPlugin_Close(t *testing.T) {
+	p, err := Start(testManifest(t, "serve", "", 0), "")
+	assert.NoError(t, err)
+	assert.NoError(t, p.Close())
+
+	ctx, nodes := testContext(t)
+	results := function(p, "no-todo").RunRule(nodes, ctx)
+	assert.Equal(t, "Unable to run plugin function 'no-todo': plugin 'test' has been closed", results[0].Message)
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+// Package rpc runs custom functions in a separate process, so they can be written in any language, and don't need
+// to be built with the same toolchain as vacuum (like Go plugins do).
+//
+// A plugin is an executable declared by a manifest (a file named *.plugin.yaml, *.plugin.yml or *.plugin.json) in
+// the functions directory:
+//
+//	command: ./bin/naming-functions   # relative to the manifest, or looked up in the PATH.
+//	args: [--strict]
+//	env:
+//	  NAMING_STYLE: kebab
+//	timeout: 10s                      # how long a single call can take, before the plugin is restarted.
+//	maxRestarts: 3                    # how many crashes within 10 minutes are restarted, before its functions give up.
+//
+// vacuum starts the plugin once, and talks to it with JSON-RPC 2.0 over stdio, one message per line. Requests can
+// be sent before earlier requests have been answered, responses are matched to requests by id. A plugin should
+// exit when its stdin is closed.
+//
+//	initialize {protocolVersion, vacuumVersion}               -> {protocolVersion, functions: [name]}
+//	getSchema  {function}                                     -> RuleFunctionSchema
+//	runRule    {function, node, path, field, options, rule}   -> [{message, path}]
+//
+// The node of a runRule call is the decoded value being checked, with its line and column. A result is reported
+// at the node being checked, unless it has a JSONPath of its own.
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/daveshanley/vacuum/model"
+)
+
+// ProtocolVersion is the version of the protocol spoken by vacuum, a plugin has to speak the same version.
+const ProtocolVersion = 1
+
+// Methods of the protocol.
+const (
+	MethodInitialize = "initialize"
+	MethodGetSchema  = "getSchema"
+	MethodRunRule    = "runRule"
+)
+
+// JSON-RPC error codes used by plugins.
+const (
+	CodeParseError     = -32700
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Request is a JSON-RPC request.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC response, with a result or an error.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// InitializeParams are sent when a plugin is started.
+type InitializeParams struct {
+	ProtocolVersion int    `json:"protocolVersion"`
+	VacuumVersion   string `json:"vacuumVersion,omitempty"`
+}
+
+// InitializeResult lists the functions a plugin provides.
+type InitializeResult struct {
+	ProtocolVersion int      `json:"protocolVersion"`
+	Functions       []string `json:"functions"`
+}
+
+// GetSchemaParams asks for the schema of a function.
+type GetSchemaParams struct {
+	Function string `json:"function"`
+}
+
+// RunRuleParams asks a function to check a node.
+type RunRuleParams struct {
+	Function string `json:"function"`
+	Node     Node   `json:"node"`
+	Path     any    `json:"path,omitempty"`  // the given path of the rule.
+	Field    string `json:"field,omitempty"` // the field of the rule action, if there is one.
+	Options  any    `json:"options,omitempty"`
+	Rule     Rule   `json:"rule"`
+}
+
+// Node is the value being checked, and where it is.
+type Node struct {
+	Value  any `json:"value"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Rule describes the rule running the function.
+type Rule struct {
+	Id          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	Message     string `json:"message,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+}
+
+// Result is something a function found, Path is a JSONPath to where it was found.
+type Result struct {
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+}
+
+// ruleOf describes a rule for a plugin.
+func ruleOf(r *model.Rule) Rule {
+	if r == nil {
+		return Rule{}
+	}
+	return Rule{Id: r.Id, Description: r.Description, Message: r.Message, Severity: r.Severity}
+}
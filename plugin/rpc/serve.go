@@ -0,0 +1,117 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/daveshanley/vacuum/model"
+)
+
+// Handler is a function served by a plugin written in Go.
+type Handler struct {
+	Schema model.RuleFunctionSchema
+	Run    func(params *RunRuleParams) ([]Result, error)
+}
+
+// Serve answers requests read from in, until in is closed. It is all a plugin written in Go needs, plugins written
+// in other languages speak the same protocol:
+//
+//	func main() {
+//		_ = rpc.Serve(os.Stdin, os.Stdout, map[string]rpc.Handler{"no-todo": {Run: noTodo}})
+//	}
+//
+// Requests are answered concurrently.
+func Serve(in io.Reader, out io.Writer, functions map[string]Handler) error {
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var writeLock sync.Mutex
+	var running sync.WaitGroup
+	defer running.Wait()
+	write := func(resp Response) {
+		resp.JSONRPC = "2.0"
+		b, _ := json.Marshal(resp)
+		writeLock.Lock()
+		defer writeLock.Unlock()
+		_, _ = out.Write(append(b, '\n'))
+	}
+	fail := func(id int64, code int, err error) {
+		write(Response{ID: id, Error: &Error{Code: code, Message: err.Error()}})
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			fail(0, CodeParseError, err)
+			continue
+		}
+		running.Add(1)
+		go func(req Request) {
+			defer running.Done()
+			result, code, err := answer(req, names, functions)
+			if err != nil {
+				fail(req.ID, code, err)
+				return
+			}
+			b, err := json.Marshal(result)
+			if err != nil {
+				fail(req.ID, CodeInternalError, err)
+				return
+			}
+			write(Response{ID: req.ID, Result: b})
+		}(req)
+	}
+	return scanner.Err()
+}
+
+// answer runs a request, returning the result, or an error and its code.
+func answer(req Request, names []string, functions map[string]Handler) (any, int, error) {
+	switch req.Method {
+	case MethodInitialize:
+		return InitializeResult{ProtocolVersion: ProtocolVersion, Functions: names}, 0, nil
+	case MethodGetSchema:
+		var params GetSchemaParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, CodeInvalidParams, err
+		}
+		h, ok := functions[params.Function]
+		if !ok {
+			return nil, CodeInvalidParams, fmt.Errorf("unknown function '%s'", params.Function)
+		}
+		schema := h.Schema
+		if schema.Name == "" {
+			schema.Name = params.Function
+		}
+		return schema, 0, nil
+	case MethodRunRule:
+		var params RunRuleParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, CodeInvalidParams, err
+		}
+		h, ok := functions[params.Function]
+		if !ok || h.Run == nil {
+			return nil, CodeInvalidParams, fmt.Errorf("unknown function '%s'", params.Function)
+		}
+		results, err := h.Run(&params)
+		if err != nil {
+			return nil, CodeInternalError, err
+		}
+		if results == nil {
+			results = []Result{}
+		}
+		return results, 0, nil
+	}
+	return nil, CodeMethodNotFound, fmt.Errorf("unknown method '%s'", req.Method)
+}
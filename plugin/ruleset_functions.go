@@ -0,0 +1,68 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/rulesets"
+)
+
+// LoadFunctionSource loads the functions declared by a ruleset. Local functions can be anything the functions
+// directory can hold, remote functions are JavaScript (like Spectral), downloaded from the functions directory.
+// The returned closer releases what the functions hold (like plugin processes), once they are no longer needed.
+func LoadFunctionSource(fs *rulesets.FunctionSource) (map[string]model.RuleFunction, io.Closer, error) {
+	loaded := make(map[string]model.RuleFunction, len(fs.Functions))
+
+	if fs.Remote {
+		client := &http.Client{Timeout: 10 * time.Second}
+		for _, name := range fs.Functions {
+			script, err := download(client, fs.Location, name+".js")
+			if err != nil {
+				return nil, nil, fmt.Errorf("unable to download function '%s' of ruleset '%s': %w",
+					name, fs.Namespace, err)
+			}
+			loaded[name] = newJavaScriptFunction(name, "", script, true)
+		}
+		return loaded, CreatePluginManager(), nil
+	}
+
+	pm, err := LoadFunctions(fs.Location, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to load functions of ruleset '%s': %w", fs.Namespace, err)
+	}
+	for _, name := range fs.Functions {
+		function := pm.GetCustomFunctions()[name]
+		if function == nil {
+			pm.Close()
+			return nil, nil, fmt.Errorf("function '%s' of ruleset '%s' cannot be found in '%s'",
+				name, fs.Namespace, fs.Location)
+		}
+		loaded[name] = function
+	}
+	return loaded, pm, nil
+}
+
+func download(client *http.Client, base, file string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	location := u.ResolveReference(&url.URL{Path: file}).String()
+	resp, err := client.Get(location)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("'%s' returned %s", location, resp.Status)
+	}
+	b, err := io.ReadAll(resp.Body)
+	return string(b), err
+}
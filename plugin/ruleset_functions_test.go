@@ -0,0 +1,59 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package plugin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/daveshanley/vacuum/rulesets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFunctionSource(t *testing.T) {
+	loaded, closer, err := LoadFunctionSource(&rulesets.FunctionSource{
+		Location:  "sample/js",
+		Functions: []string{"check_single_path", "useless_func"},
+	})
+	assert.NoError(t, err)
+	defer closer.Close()
+	assert.Len(t, loaded, 2)
+	assert.Equal(t, "useless_func_modified_name", loaded["useless_func"].GetSchema().Name)
+
+	_, _, err = LoadFunctionSource(&rulesets.FunctionSource{
+		Namespace: "ruleset.yaml",
+		Location:  "sample/js",
+		Functions: []string{"nope"},
+	})
+	assert.EqualError(t, err, "function 'nope' of ruleset 'ruleset.yaml' cannot be found in 'sample/js'")
+}
+
+func TestLoadFunctionSource_Remote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/functions/checkTitle.js" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = rw.Write([]byte(`export default (input) => [{ message: 'checked ' + input }];`))
+	}))
+	defer server.Close()
+
+	loaded, _, err := LoadFunctionSource(&rulesets.FunctionSource{
+		Location:  server.URL + "/functions/",
+		Functions: []string{"checkTitle"},
+		Remote:    true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "checkTitle", loaded["checkTitle"].GetSchema().Name)
+
+	_, _, err = LoadFunctionSource(&rulesets.FunctionSource{
+		Namespace: "remote.yaml",
+		Location:  server.URL + "/functions/",
+		Functions: []string{"missing"},
+		Remote:    true,
+	})
+	assert.ErrorContains(t, err, "unable to download function 'missing' of ruleset 'remote.yaml'")
+	assert.ErrorContains(t, err, "404 Not Found")
+}
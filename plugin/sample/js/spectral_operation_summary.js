@@ -0,0 +1,36 @@
+// Copyright 2024 Princess Beef Heavy Industries / Dave Shanley.
+// SPDX-License-Identifier: MIT
+
+// A Spectral custom function, loaded as-is by vacuum.
+import { createRulesetFunction } from '@stoplight/spectral-core';
+
+export default createRulesetFunction(
+    {
+        input: {
+            type: 'object',
+        },
+        options: {
+            type: 'object',
+            properties: {
+                minLength: {
+                    type: 'integer',
+                    description: 'the shortest summary allowed',
+                },
+            },
+            required: ['minLength'],
+            additionalProperties: false,
+        },
+    },
+    (targetVal, opts, context) => {
+        const summary = targetVal.summary;
+        if (summary === undefined) {
+            return [{ message: 'operation has no summary', path: [...context.path] }];
+        }
+        if (summary.length < opts.minLength) {
+            return [{
+                message: `summary '${summary}' is shorter than ${opts.minLength} characters`,
+                path: [...context.path, 'summary'],
+            }];
+        }
+    },
+);
@@ -0,0 +1,91 @@
+;; Reports a description containing TODO, built with: wat2wasm no_todo.wat -o no_todo.wasm
+(module
+  (memory 1)
+  (global $heap (mut i32) (i32.const 1024))
+
+  (data (i32.const 0) "{\"name\":\"no-todo\",\"properties\":[{\"name\":\"word\",\"description\":\"not used\"}]}")
+  (data (i32.const 256) "[{\"message\":\"description contains TODO\",\"path\":\"$.info.description\"}]")
+  (data (i32.const 512) "[]")
+
+  ;; pack returns a pointer and a length as a single value.
+  (func $pack (param $ptr i32) (param $len i32) (result i64)
+    local.get $ptr
+    i64.extend_i32_u
+    i64.const 32
+    i64.shl
+    local.get $len
+    i64.extend_i32_u
+    i64.or)
+
+  ;; alloc is a bump allocator, memory is thrown away after every run.
+  (func $alloc (param $size i32) (result i32)
+    (local $ptr i32)
+    (local $pages i32)
+    global.get $heap
+    local.set $ptr
+    global.get $heap
+    local.get $size
+    i32.add
+    global.set $heap
+    global.get $heap
+    i32.const 65535
+    i32.add
+    i32.const 16
+    i32.shr_u
+    memory.size
+    i32.sub
+    local.tee $pages
+    i32.const 0
+    i32.gt_s
+    if
+      local.get $pages
+      memory.grow
+      drop
+    end
+    local.get $ptr)
+
+  (func $get_schema (result i64)
+    i32.const 0
+    i32.const 74
+    call $pack)
+
+  (func $run_rule (param $ptr i32) (param $len i32) (result i64)
+    (local $end i32)
+    local.get $ptr
+    local.get $len
+    i32.add
+    i32.const 4
+    i32.sub
+    local.set $end
+    block $done
+      loop $search
+        local.get $ptr
+        local.get $end
+        i32.gt_s
+        br_if $done
+        local.get $ptr
+        i32.load
+        i32.const 0x4f444f54 ;; TODO
+        i32.eq
+        if
+          i32.const 256
+          i32.const 69
+          call $pack
+          return
+        end
+        local.get $ptr
+        i32.const 1
+        i32.add
+        local.set $ptr
+        br $search
+      end
+    end
+    i32.const 512
+    i32.const 2
+    call $pack)
+
+  (export "memory" (memory 0))
+  (export "alloc" (func $alloc))
+  (export "get_schema" (func $get_schema))
+  (export "run_rule" (func $run_rule))
+)
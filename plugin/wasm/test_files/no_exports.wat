@@ -0,0 +1,6 @@
+;; Has nothing vacuum can call, built with: wat2wasm no_exports.wat -o no_exports.wasm
+(module
+  (memory 1)
+
+  (export "memory" (memory 0))
+)
@@ -0,0 +1,17 @@
+;; Never returns, built with: wat2wasm spin.wat -o spin.wasm
+(module
+  (memory 1)
+
+  (func $alloc (param $size i32) (result i32)
+    i32.const 1024)
+
+  (func $run_rule (param $ptr i32) (param $len i32) (result i64)
+    loop $spin
+      br $spin
+    end
+    i64.const 0)
+
+  (export "memory" (memory 0))
+  (export "alloc" (func $alloc))
+  (export "run_rule" (func $run_rule))
+)
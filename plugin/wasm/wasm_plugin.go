@@ -0,0 +1,191 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+// Package wasm runs custom functions compiled to WebAssembly, in a sandbox, with a pure Go runtime. Modules can be
+// written in anything that compiles to WebAssembly, and run anywhere vacuum does.
+//
+// A module provides one function, named after its file (like JavaScript functions), and exports:
+//
+//	memory                          the memory of the module.
+//	alloc(size i32) i32             returns somewhere vacuum can write size bytes.
+//	run_rule(ptr i32, len i32) i64  checks the JSON input at ptr, and returns JSON results.
+//	get_schema() i64                optional, returns the JSON RuleFunctionSchema of the function.
+//
+// Returned JSON is located by a single i64, the pointer in the high 32 bits, and the length in the low 32 bits.
+// The input of run_rule is the same as that sent to out-of-process plugins ({node, path, field, options, rule}),
+// and so are the results ([{message, path}]).
+//
+// Modules can import WASI, but are given no files, environment or network. A module is instantiated for each run of
+// a rule, so it is never shared between goroutines, and is stopped when the rule times out.
+package wasm
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/plugin/rpc"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"gopkg.in/yaml.v3"
+)
+
+// MaxMemoryPages limits how much memory a module can use (64KiB a page), 256MiB.
+const MaxMemoryPages = 4096
+
+// WasmRuleFunction is a function provided by a WebAssembly module.
+type WasmRuleFunction struct {
+	ruleName   string
+	runtime    wazero.Runtime
+	module     wazero.CompiledModule
+	schemaOnce sync.Once
+	schema     model.RuleFunctionSchema
+}
+
+// NewWasmRuleFunction compiles a module, and checks it exports what vacuum needs.
+func NewWasmRuleFunction(name string, binary []byte) (*WasmRuleFunction, error) {
+	ctx := gocontext.Background()
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(MaxMemoryPages))
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		_ = rt.Close(ctx)
+		return nil, err
+	}
+	compiled, err := rt.CompileModule(ctx, binary)
+	if err != nil {
+		_ = rt.Close(ctx)
+		return nil, fmt.Errorf("unable to compile WebAssembly function '%s': %w", name, err)
+	}
+	exports := compiled.ExportedFunctions()
+	for _, export := range []string{"alloc", "run_rule"} {
+		if exports[export] == nil {
+			_ = rt.Close(ctx)
+			return nil, fmt.Errorf("WebAssembly function '%s' does not export '%s'", name, export)
+		}
+	}
+	if compiled.ExportedMemories()["memory"] == nil {
+		_ = rt.Close(ctx)
+		return nil, fmt.Errorf("WebAssembly function '%s' does not export 'memory'", name)
+	}
+	return &WasmRuleFunction{ruleName: name, runtime: rt, module: compiled}, nil
+}
+
+// Close releases the compiled module.
+func (w *WasmRuleFunction) Close() error {
+	return w.runtime.Close(gocontext.Background())
+}
+
+// GetSchema returns the schema exported by the module, or just the name of the function if it has none.
+func (w *WasmRuleFunction) GetSchema() model.RuleFunctionSchema {
+	w.schemaOnce.Do(func() {
+		if w.module.ExportedFunctions()["get_schema"] != nil {
+			ctx := gocontext.Background()
+			if mod, err := w.instantiate(ctx); err == nil {
+				if out, cErr := call(ctx, mod, "get_schema"); cErr == nil {
+					_ = json.Unmarshal(out, &w.schema)
+				}
+				_ = mod.Close(ctx)
+			}
+		}
+		if w.schema.Name == "" {
+			w.schema.Name = w.ruleName
+		}
+	})
+	return w.schema
+}
+
+// RunRule instantiates the module, and runs it against each node.
+func (w *WasmRuleFunction) RunRule(nodes []*yaml.Node, context model.RuleFunctionContext) []model.RuleFunctionResult {
+	var results []model.RuleFunctionResult
+	if len(nodes) == 0 {
+		return results
+	}
+
+	ctx := context.Context
+	if ctx == nil {
+		ctx = gocontext.Background()
+	}
+
+	mod, err := w.instantiate(ctx)
+	if err != nil {
+		return append(results, rpc.Failure(nodes[0], w.describe(err), context))
+	}
+	defer mod.Close(gocontext.Background())
+
+	for _, node := range nodes {
+		in, _ := json.Marshal(rpc.NewRunRuleParams(w.ruleName, node, context))
+		out, cErr := call(ctx, mod, "run_rule", in)
+		if cErr != nil {
+			return append(results, rpc.Failure(node, w.describe(cErr), context))
+		}
+		var found []rpc.Result
+		if dErr := json.Unmarshal(out, &found); dErr != nil {
+			return append(results, rpc.Failure(node,
+				fmt.Sprintf("Unable to decode results from WebAssembly function: '%s': %s", w.ruleName, dErr.Error()),
+				context))
+		}
+		results = append(results, rpc.Report(found, node, context)...)
+	}
+	return results
+}
+
+func (w *WasmRuleFunction) instantiate(ctx gocontext.Context) (api.Module, error) {
+	// anonymous, so any number can run at once.
+	return w.runtime.InstantiateModule(ctx, w.module, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize"))
+}
+
+func (w *WasmRuleFunction) describe(err error) string {
+	if errors.Is(err, gocontext.DeadlineExceeded) || errors.Is(err, gocontext.Canceled) {
+		return fmt.Sprintf("WebAssembly function was interrupted: '%s': %v", w.ruleName, err)
+	}
+	return fmt.Sprintf("Unable to execute WebAssembly function: '%s': %s", w.ruleName, err.Error())
+}
+
+// call runs an exported function, copying input into the module first when there is some, and returns the output.
+func call(ctx gocontext.Context, mod api.Module, name string, input ...[]byte) ([]byte, error) {
+	var params []uint64
+	for _, in := range input {
+		ptr, err := write(ctx, mod, in)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, uint64(ptr), uint64(len(in)))
+	}
+	ret, err := mod.ExportedFunction(name).Call(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+	if len(ret) != 1 {
+		return nil, fmt.Errorf("'%s' returned %d values, not 1", name, len(ret))
+	}
+	ptr, size := uint32(ret[0]>>32), uint32(ret[0])
+	out, ok := mod.Memory().Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("'%s' returned %d bytes at %d, which is out of memory", name, size, ptr)
+	}
+	// the memory goes away with the module.
+	return append([]byte(nil), out...), nil
+}
+
+func write(ctx gocontext.Context, mod api.Module, in []byte) (uint32, error) {
+	ret, err := mod.ExportedFunction("alloc").Call(ctx, uint64(len(in)))
+	if err != nil {
+		return 0, err
+	}
+	if len(ret) != 1 {
+		return 0, fmt.Errorf("'alloc' returned %d values, not 1", len(ret))
+	}
+	ptr := uint32(ret[0])
+	if !mod.Memory().Write(ptr, in) {
+		return 0, fmt.Errorf("'alloc' returned %d, which has no room for %d bytes", ptr, len(in))
+	}
+	return ptr, nil
+}
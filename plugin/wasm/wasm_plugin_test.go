@@ -0,0 +1,105 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package wasm
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/pb33f/libopenapi/index"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+const testSpec = `openapi: 3.1.0
+info:
+  title: pets
+  description: TODO write this
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      description: list pets
+`
+
+func load(t *testing.T, location, name string) *WasmRuleFunction {
+	b, err := os.ReadFile(location)
+	assert.NoError(t, err)
+	f, err := NewWasmRuleFunction(name, b)
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+	return f
+}
+
+func testContext(t *testing.T) (model.RuleFunctionContext, *yaml.Node) {
+	var root yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(testSpec), &root))
+	return model.RuleFunctionContext{
+		Rule:  &model.Rule{Id: "no-todo"},
+		Given: "$.info",
+		Index: index.NewSpecIndexWithConfig(&root, index.CreateOpenAPIIndexConfig()),
+	}, root.Content[0]
+}
+
+func TestWasmRuleFunction_RunRule(t *testing.T) {
+	f := load(t, "../sample/wasm/no_todo.wasm", "no_todo")
+	assert.Equal(t, "no-todo", f.GetSchema().Name)
+	assert.Equal(t, "word", f.GetSchema().Properties[0].Name)
+
+	ctx, root := testContext(t)
+	info, get := root.Content[3], root.Content[5].Content[1].Content[1]
+
+	results := f.RunRule([]*yaml.Node{info, get}, ctx)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "description contains TODO", results[0].Message)
+	assert.Equal(t, "$.info.description", results[0].Path)
+	assert.Equal(t, 4, results[0].StartNode.Line)
+	assert.Equal(t, 4, results[0].Range.Start.Line)
+	assert.Equal(t, ctx.Rule, results[0].Rule)
+}
+
+func TestWasmRuleFunction_RunRule_Concurrent(t *testing.T) {
+	f := load(t, "../sample/wasm/no_todo.wasm", "no_todo")
+	ctx, root := testContext(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results := f.RunRule([]*yaml.Node{root.Content[3]}, ctx)
+			assert.Len(t, results, 1)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWasmRuleFunction_RunRule_Timeout(t *testing.T) {
+	f := load(t, "test_files/spin.wasm", "spin")
+	assert.Equal(t, "spin", f.GetSchema().Name)
+
+	ctx, root := testContext(t)
+	timeout, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	ctx.Context = timeout
+
+	results := f.RunRule([]*yaml.Node{root.Content[3]}, ctx)
+	assert.Len(t, results, 1)
+	assert.Contains(t, results[0].Message, "WebAssembly function was interrupted: 'spin'")
+	assert.Equal(t, "$.info", results[0].Path)
+}
+
+func TestNewWasmRuleFunction_Invalid(t *testing.T) {
+	b, err := os.ReadFile("test_files/no_exports.wasm")
+	assert.NoError(t, err)
+	_, err = NewWasmRuleFunction("no_exports", b)
+	assert.EqualError(t, err, "WebAssembly function 'no_exports' does not export 'alloc'")
+
+	_, err = NewWasmRuleFunction("garbage", []byte("not a module"))
+	assert.ErrorContains(t, err, "unable to compile WebAssembly function 'garbage'")
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package rulesets
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pb33f/libopenapi/datamodel"
+)
+
+var aliasRegex = regexp.MustCompile(`^#([A-Za-z][A-Za-z\d_-]*)(.*)$`)
+
+// RuleSetAliasTarget is a format scoped alias definition. The given paths only apply when linting a
+// specification that matches one of the formats.
+type RuleSetAliasTarget struct {
+	Formats []string    `json:"formats" yaml:"formats" mapstructure:"formats"`
+	Given   interface{} `json:"given" yaml:"given" mapstructure:"given"`
+}
+
+// RuleSetAlias is the long form of an alias, with a description and format scoped targets.
+type RuleSetAlias struct {
+	Description string               `json:"description,omitempty" yaml:"description,omitempty" mapstructure:"description"`
+	Targets     []RuleSetAliasTarget `json:"targets" yaml:"targets" mapstructure:"targets"`
+}
+
+// ExpandGivenPaths will take a slice of given paths and expand any aliases (e.g. '#OperationObject.responses')
+// into regular JSON Path expressions, ready to be evaluated. Aliases can reference other aliases, they are
+// expanded recursively. An error is returned if an alias is not defined or if aliases reference each other
+// in a loop. The spec format is used to select the correct targets of format scoped aliases.
+func (rs *RuleSet) ExpandGivenPaths(given []string, specFormat string) ([]string, error) {
+	var expanded []string
+	for _, g := range given {
+		paths, err := rs.expandAlias(g, specFormat, nil)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, paths...)
+	}
+	return expanded, nil
+}
+
+func (rs *RuleSet) expandAlias(given, specFormat string, visited []string) ([]string, error) {
+	match := aliasRegex.FindStringSubmatch(given)
+	if match == nil {
+		return []string{given}, nil
+	}
+	name, remainder := match[1], match[2]
+
+	if slices.Contains(visited, name) {
+		return nil, fmt.Errorf("alias '%s' is circular: %s -> %s", name, strings.Join(visited, " -> "), name)
+	}
+
+	alias, ok := rs.Aliases[name]
+	if !ok {
+		return nil, fmt.Errorf("alias '%s' is not defined in the ruleset", name)
+	}
+
+	targets, err := resolveAliasTargets(name, alias, specFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	visited = append(visited, name)
+	var expanded []string
+	for _, target := range targets {
+		paths, eErr := rs.expandAlias(target, specFormat, visited)
+		if eErr != nil {
+			return nil, eErr
+		}
+		for _, p := range paths {
+			expanded = append(expanded, p+remainder)
+		}
+	}
+	return expanded, nil
+}
+
+// resolveAliasTargets extracts the given paths of an alias definition, which can be a string, an array of strings,
+// or an object containing format scoped targets.
+func resolveAliasTargets(name string, alias interface{}, specFormat string) ([]string, error) {
+	switch a := alias.(type) {
+	case string, []interface{}, []string:
+		return extractGivenStrings(a), nil
+	case map[string]interface{}:
+		var def RuleSetAlias
+		if err := mapstructure.Decode(a, &def); err != nil {
+			return nil, fmt.Errorf("alias '%s' cannot be decoded: %s", name, err.Error())
+		}
+		var paths []string
+		for _, target := range def.Targets {
+			if specFormat == "" || aliasFormatMatches(target.Formats, specFormat) {
+				paths = append(paths, extractGivenStrings(target.Given)...)
+			}
+		}
+		return paths, nil
+	}
+	return nil, fmt.Errorf("alias '%s' has an invalid definition", name)
+}
+
+func extractGivenStrings(given interface{}) []string {
+	var paths []string
+	switch g := given.(type) {
+	case string:
+		paths = append(paths, g)
+	case []string:
+		paths = append(paths, g...)
+	case []interface{}:
+		for _, gi := range g {
+			if s, ok := gi.(string); ok {
+				paths = append(paths, s)
+			}
+		}
+	}
+	return paths
+}
+
+// aliasFormatMatches checks the formats of an alias target against the format of the spec. The generic 'oas3'
+// format matches all versions of OpenAPI 3, the spectral names for specific versions are also supported.
+func aliasFormatMatches(formats []string, specFormat string) bool {
+	for _, f := range formats {
+		switch f {
+		case specFormat:
+			return true
+		case datamodel.OAS3:
+			if strings.HasPrefix(specFormat, datamodel.OAS3) {
+				return true
+			}
+		case "oas3_0", "oas3.0":
+			if specFormat == datamodel.OAS3 {
+				return true
+			}
+		case "oas3.1":
+			if specFormat == datamodel.OAS31 {
+				return true
+			}
+		}
+	}
+	return false
+}
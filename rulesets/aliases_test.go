@@ -0,0 +1,75 @@
+package rulesets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleSet_ExpandGivenPaths(t *testing.T) {
+
+	yaml := `aliases:
+  PathItem: "$.paths[*]"
+  OperationObject:
+    - "#PathItem['get','put']"
+  SchemaObject:
+    description: all schemas
+    targets:
+      - formats: [oas2]
+        given: "$.definitions[*]"
+      - formats: [oas3]
+        given: ["$.components.schemas[*]"]
+rules:
+  fish-cakes:
+    given: "#OperationObject.responses"
+    then:
+      function: truthy`
+
+	rs, err := CreateRuleSetFromData([]byte(yaml))
+	assert.NoError(t, err)
+	assert.Len(t, rs.Aliases, 3)
+
+	paths, err := rs.ExpandGivenPaths([]string{"#OperationObject.responses", "$.info"}, "oas3")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"$.paths[*]['get','put'].responses", "$.info"}, paths)
+
+	paths, err = rs.ExpandGivenPaths([]string{"#SchemaObject.properties"}, "oas2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"$.definitions[*].properties"}, paths)
+
+	paths, err = rs.ExpandGivenPaths([]string{"#SchemaObject"}, "oas3_1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"$.components.schemas[*]"}, paths)
+}
+
+func TestRuleSet_ExpandGivenPaths_Undefined(t *testing.T) {
+
+	rs := &RuleSet{Aliases: map[string]interface{}{"PathItem": "$.paths[*]"}}
+	_, err := rs.ExpandGivenPaths([]string{"#Nope.get"}, "oas3")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "'Nope' is not defined")
+}
+
+func TestRuleSet_ExpandGivenPaths_Circular(t *testing.T) {
+
+	rs := &RuleSet{Aliases: map[string]interface{}{
+		"A": "#B.a",
+		"B": []interface{}{"#C"},
+		"C": "#A",
+	}}
+	_, err := rs.ExpandGivenPaths([]string{"#A"}, "oas3")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "circular")
+}
+
+func TestGenerateRuleSetFromSuppliedRuleSet_Aliases(t *testing.T) {
+
+	yaml := `extends: [[spectral:oas, off]]
+aliases:
+  Info: "$.info"`
+
+	rs, err := CreateRuleSetFromData([]byte(yaml))
+	assert.NoError(t, err)
+	gen := BuildDefaultRuleSets().GenerateRuleSetFromSuppliedRuleSet(rs)
+	assert.Equal(t, "$.info", gen.Aliases["Info"])
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package rulesets
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/daveshanley/vacuum/model"
+)
+
+// DefaultFunctionsDir is where a ruleset keeps its functions, if it does not set a functionsDir (same as Spectral).
+const DefaultFunctionsDir = "functions"
+
+// FunctionSource is a directory of custom functions, declared by a ruleset with 'functions' and 'functionsDir'.
+//
+// Functions are namespaced by the ruleset that declares them, so two rulesets can each have a function with the same
+// name. The rules of the ruleset are changed to use the namespaced names, when it is loaded.
+type FunctionSource struct {
+	Namespace string   // the location of the ruleset declaring the functions, empty when it is not known.
+	Location  string   // the directory (or URL) holding the functions.
+	Functions []string // the names of the functions, as the ruleset uses them.
+	Remote    bool     // the functions are downloaded.
+
+	lock   sync.Mutex
+	done   bool
+	loaded map[string]model.RuleFunction
+	closer io.Closer
+	err    error
+}
+
+// FunctionName returns the name a function is registered as.
+func (fs *FunctionSource) FunctionName(name string) string {
+	if fs.Namespace == "" {
+		return name
+	}
+	return fs.Namespace + "#" + name
+}
+
+// FunctionLoader loads the functions of a source, the closer it returns (which can be nil) releases what the
+// functions hold, once they are no longer needed.
+type FunctionLoader func(fs *FunctionSource) (map[string]model.RuleFunction, io.Closer, error)
+
+// Load loads the functions the first time it is called, using the supplied loader, and returns them by their
+// registered names. Later calls return the same functions (or error), until the source is closed.
+func (fs *FunctionSource) Load(loader FunctionLoader) (map[string]model.RuleFunction, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	if !fs.done {
+		fs.done = true
+		var loaded map[string]model.RuleFunction
+		loaded, fs.closer, fs.err = loader(fs)
+		if fs.err == nil {
+			fs.loaded = make(map[string]model.RuleFunction, len(loaded))
+			for name, function := range loaded {
+				fs.loaded[fs.FunctionName(name)] = function
+			}
+		}
+	}
+	return fs.loaded, fs.err
+}
+
+// Close releases the loaded functions, they are loaded again the next time Load is called.
+func (fs *FunctionSource) Close() error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	var err error
+	if fs.closer != nil {
+		err = fs.closer.Close()
+	}
+	fs.done, fs.loaded, fs.closer, fs.err = false, nil, nil, nil
+	return err
+}
+
+// CloseFunctions releases the functions loaded for the ruleset, and the rulesets it extends.
+func (rs *RuleSet) CloseFunctions() {
+	if rs == nil {
+		return
+	}
+	for _, fs := range rs.FunctionSources {
+		_ = fs.Close()
+	}
+}
+
+// functionSource returns the functions declared by a ruleset, or nil if there are none. The rules of the ruleset
+// are changed to use the namespaced names of the functions.
+func (rs *RuleSet) functionSource(remote bool) (*FunctionSource, error) {
+	if len(rs.Functions) == 0 {
+		return nil, nil
+	}
+	dir := rs.FunctionsDir
+	if dir == "" {
+		dir = DefaultFunctionsDir
+	}
+
+	fs := &FunctionSource{Namespace: rs.Location, Functions: rs.Functions, Remote: remote}
+	switch {
+	case remote:
+		base, err := url.Parse(rs.Location)
+		if err != nil {
+			return nil, fmt.Errorf("cannot locate functions of ruleset '%s': %w", rs.Location, err)
+		}
+		fs.Location = base.ResolveReference(&url.URL{Path: strings.TrimSuffix(dir, "/") + "/"}).String()
+	case filepath.IsAbs(dir) || rs.Location == "":
+		fs.Location = dir
+	default:
+		fs.Location = filepath.Join(filepath.Dir(rs.Location), dir)
+	}
+
+	renamed := make(map[string]string, len(rs.Functions))
+	for _, name := range rs.Functions {
+		renamed[name] = fs.FunctionName(name)
+	}
+	for _, def := range rs.RuleDefinitions {
+		if rule, ok := def.(map[string]interface{}); ok {
+			renameFunctions(rule["then"], renamed)
+		}
+	}
+	for _, rule := range rs.Rules {
+		renameFunctions(rule.Then, renamed)
+	}
+	return fs, nil
+}
+
+// renameFunctions changes the functions used by the actions of a rule.
+func renameFunctions(then interface{}, renamed map[string]string) {
+	switch t := then.(type) {
+	case map[string]interface{}:
+		if name, ok := t["function"].(string); ok && renamed[name] != "" {
+			t["function"] = renamed[name]
+		}
+	case []interface{}:
+		for _, action := range t {
+			renameFunctions(action, renamed)
+		}
+	case *model.RuleAction:
+		if renamed[t.Function] != "" {
+			t.Function = renamed[t.Function]
+		}
+	case []*model.RuleAction:
+		for _, action := range t {
+			renameFunctions(action, renamed)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package rulesets
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const sharedFunctionsRuleSet = `functionsDir: ./shared-functions
+functions: [checkTitle]
+rules:
+  shared-title:
+    given: $.info
+    then:
+      field: title
+      function: checkTitle
+`
+
+func ruleFunction(rule interface{}) string {
+	return rule.(map[string]interface{})["then"].(map[string]interface{})["function"].(string)
+}
+
+func TestRuleSet_Functions(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.yaml")
+	assert.NoError(t, os.WriteFile(shared, []byte(sharedFunctionsRuleSet), 0o644))
+
+	root := filepath.Join(dir, "ruleset.yaml")
+	userRS, err := CreateRuleSetFromData([]byte(`extends: [[` + shared + `, all]]
+functions: [checkTitle]
+rules:
+  own-title:
+    given: $.info
+    then:
+      - field: title
+        function: checkTitle
+      - field: title
+        function: truthy
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"checkTitle"}, userRS.Functions)
+	userRS.Location = root
+
+	rs := BuildDefaultRuleSets().GenerateRuleSetFromSuppliedRuleSet(userRS)
+	assert.Len(t, rs.FunctionSources, 2)
+	assert.Equal(t, root, rs.Location)
+
+	own, ext := rs.FunctionSources[0], rs.FunctionSources[1]
+	assert.Equal(t, root, own.Namespace)
+	assert.Equal(t, filepath.Join(dir, DefaultFunctionsDir), own.Location)
+	assert.Equal(t, shared, ext.Namespace)
+	assert.Equal(t, filepath.Join(dir, "shared-functions"), ext.Location)
+
+	// each ruleset uses its own function, even though they have the same name.
+	then := rs.Rules["own-title"].Then.([]interface{})
+	assert.Equal(t, root+"#checkTitle", then[0].(map[string]interface{})["function"])
+	assert.Equal(t, "truthy", then[1].(map[string]interface{})["function"])
+	assert.Equal(t, shared+"#checkTitle", ruleFunction(rs.RuleDefinitions["shared-title"]))
+}
+
+func TestRuleSet_Functions_Remote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(sharedFunctionsRuleSet))
+	}))
+	defer server.Close()
+
+	userRS, err := CreateRuleSetFromData([]byte(`extends: [[` + server.URL + `/rulesets/shared.yaml, all]]
+rules: {}
+`))
+	assert.NoError(t, err)
+
+	rs := BuildDefaultRuleSets().GenerateRuleSetFromSuppliedRuleSet(userRS)
+	assert.Len(t, rs.FunctionSources, 1)
+	assert.True(t, rs.FunctionSources[0].Remote)
+	assert.Equal(t, server.URL+"/rulesets/shared-functions/", rs.FunctionSources[0].Location)
+	assert.Equal(t, server.URL+"/rulesets/shared.yaml#checkTitle", ruleFunction(rs.RuleDefinitions["shared-title"]))
+}
+
+func TestRuleSet_Functions_None(t *testing.T) {
+	userRS, err := CreateRuleSetFromData([]byte(`functionsDir: ./functions
+rules: {}`))
+	assert.NoError(t, err)
+	rs := BuildDefaultRuleSets().GenerateRuleSetFromSuppliedRuleSet(userRS)
+	assert.Empty(t, rs.FunctionSources)
+}
+
+func TestFunctionSource_Load(t *testing.T) {
+	fs := &FunctionSource{Namespace: "shared.yaml", Functions: []string{"a"}}
+	calls, closed := 0, 0
+	loader := func(fs *FunctionSource) (map[string]model.RuleFunction, io.Closer, error) {
+		calls++
+		return map[string]model.RuleFunction{"a": nil}, closeFunc(func() error { closed++; return nil }), nil
+	}
+	loaded, err := fs.Load(loader)
+	assert.NoError(t, err)
+	assert.Contains(t, loaded, "shared.yaml#a")
+	_, _ = fs.Load(loader)
+	assert.Equal(t, 1, calls)
+
+	// once closed, the functions are loaded again.
+	rs := &RuleSet{FunctionSources: []*FunctionSource{fs}}
+	rs.CloseFunctions()
+	assert.Equal(t, 1, closed)
+	_, _ = fs.Load(loader)
+	assert.Equal(t, 2, calls)
+
+	assert.Equal(t, "a", (&FunctionSource{}).FunctionName("a"))
+}
+
+type closeFunc func() error
+
+func (c closeFunc) Close() error { return c() }
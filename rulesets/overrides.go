@@ -0,0 +1,278 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package rulesets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/daveshanley/vacuum/model"
+)
+
+// RuleSetOverride represents a single entry of the spectral `overrides` block. Each override is scoped by a set of
+// file globs, optionally suffixed with a JSON Pointer (e.g. `legacy/**/*.yaml#/paths/~1v1~1*`), and contains
+// a map of rule IDs to severities (or 'off') that apply to any result that lands inside that scope.
+type RuleSetOverride struct {
+	Files      []string               `json:"files" yaml:"files"`
+	Rules      map[string]interface{} `json:"rules,omitempty" yaml:"rules,omitempty"`
+	scopes     []overrideScope
+	scopesOnce sync.Once
+}
+
+// overrideScope is a pre-compiled files entry, separating the glob from the JSON Pointer.
+type overrideScope struct {
+	glob     *regexp.Regexp
+	pointer  []string
+	patterns []*regexp.Regexp // nil entries are exact segment matches.
+}
+
+// GetSeverityForRule returns the severity the override defines for a rule. The second return value
+// is false if the override does not mention the rule. A severity of 'off' means the result should be dropped.
+func (o *RuleSetOverride) GetSeverityForRule(ruleId string) (string, bool) {
+	if o.Rules == nil {
+		return "", false
+	}
+	v, ok := o.Rules[ruleId]
+	if !ok {
+		return "", false
+	}
+	return normalizeOverrideSeverity(v)
+}
+
+// Matches checks if a file name and JSON Path sit inside the scope of this override. An override matches if
+// any of its files entries match both the file glob and (if supplied) the JSON Pointer. File globs are relative
+// to the working directory, use MatchesFrom when the location of the ruleset is known.
+func (o *RuleSetOverride) Matches(fileName, jsonPath string) bool {
+	return o.MatchesFrom("", fileName, jsonPath)
+}
+
+// MatchesFrom is Matches, with file globs relative to a directory (the directory of the ruleset, like Spectral)
+// rather than the working directory. An empty directory is the working directory.
+func (o *RuleSetOverride) MatchesFrom(dir, fileName, jsonPath string) bool {
+	o.scopesOnce.Do(func() {
+		for _, f := range o.Files {
+			o.scopes = append(o.scopes, buildOverrideScope(f))
+		}
+	})
+	for _, scope := range o.scopes {
+		if scope.glob != nil && !matchOverrideFile(scope.glob, dir, fileName) {
+			continue
+		}
+		if len(scope.pointer) > 0 && !matchOverridePointer(scope, jsonPath) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// normalizeOverrideSeverity converts a severity from an override (which can be a human-readable string,
+// a spectral diagnostic number or a boolean) into a vacuum severity.
+func normalizeOverrideSeverity(v interface{}) (string, bool) {
+	switch sev := v.(type) {
+	case string:
+		switch sev {
+		case model.SeverityError, model.SeverityWarn, model.SeverityInfo, model.SeverityHint, SpectralOff:
+			return sev, true
+		}
+	case float64:
+		switch int(sev) {
+		case -1:
+			return SpectralOff, true
+		case 0:
+			return model.SeverityError, true
+		case 1:
+			return model.SeverityWarn, true
+		case 2:
+			return model.SeverityInfo, true
+		case 3:
+			return model.SeverityHint, true
+		}
+	case int:
+		return normalizeOverrideSeverity(float64(sev))
+	case bool:
+		if !sev {
+			return SpectralOff, true
+		}
+	}
+	return "", false
+}
+
+func buildOverrideScope(entry string) overrideScope {
+	var scope overrideScope
+	glob, pointer, hasPointer := strings.Cut(entry, "#")
+	if glob != "" {
+		scope.glob = compileGlob(glob)
+	}
+	if hasPointer {
+		for _, seg := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+			if seg == "" {
+				continue
+			}
+			seg = strings.ReplaceAll(seg, "~1", "/")
+			seg = strings.ReplaceAll(seg, "~0", "~")
+			scope.pointer = append(scope.pointer, seg)
+			var pattern *regexp.Regexp
+			if strings.ContainsAny(seg, "*?") {
+				quoted := strings.ReplaceAll(strings.ReplaceAll(regexp.QuoteMeta(seg), `\*`, ".*"), `\?`, ".")
+				pattern = regexp.MustCompile(fmt.Sprintf("^%s$", quoted))
+			}
+			scope.patterns = append(scope.patterns, pattern)
+		}
+	}
+	return scope
+}
+
+// compileGlob converts a file glob into a regular expression. '**' matches across directories,
+// '*' and '?' match within a single path segment and '{a,b}' matches either alternative.
+func compileGlob(glob string) *regexp.Regexp {
+	glob = strings.TrimPrefix(filepath.ToSlash(glob), "./")
+	var sb strings.Builder
+	sb.WriteString("^")
+	inGroup := false
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '{':
+			inGroup = true
+			sb.WriteString("(?:")
+		case '}':
+			if inGroup {
+				inGroup = false
+				sb.WriteString(")")
+			} else {
+				sb.WriteString(`\}`)
+			}
+		case ',':
+			if inGroup {
+				sb.WriteString("|")
+			} else {
+				sb.WriteString(",")
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(glob)))
+	}
+	return re
+}
+
+// matchOverrideFile checks the glob against the path of the file relative to the directory globs are relative to.
+// Without a directory, the glob is checked against the file name as supplied, and if the file is absolute, against
+// the path relative to the working directory.
+func matchOverrideFile(glob *regexp.Regexp, dir, fileName string) bool {
+	if fileName == "" {
+		return false
+	}
+	if dir != "" {
+		absDir, dErr := filepath.Abs(dir)
+		absFile, fErr := filepath.Abs(fileName)
+		if dErr == nil && fErr == nil {
+			if rel, rErr := filepath.Rel(absDir, absFile); rErr == nil {
+				return glob.MatchString(filepath.ToSlash(rel))
+			}
+		}
+	}
+	name := strings.TrimPrefix(filepath.ToSlash(fileName), "./")
+	if glob.MatchString(name) {
+		return true
+	}
+	if filepath.IsAbs(fileName) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, rErr := filepath.Rel(wd, fileName); rErr == nil {
+				return glob.MatchString(filepath.ToSlash(rel))
+			}
+		}
+	}
+	return false
+}
+
+// matchOverridePointer checks if the JSON Path of a result sits at, or underneath the JSON Pointer
+// of an override. Pointer segments may contain '*' wildcards.
+func matchOverridePointer(scope overrideScope, jsonPath string) bool {
+	segments := SplitJSONPath(jsonPath)
+	if len(segments) < len(scope.pointer) {
+		return false
+	}
+	for i, p := range scope.pointer {
+		if p == segments[i] {
+			continue
+		}
+		if scope.patterns[i] == nil || !scope.patterns[i].MatchString(segments[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitJSONPath breaks a normalized JSON Path (as generated by vacuum rules) such as
+// `$.paths['/pets'].get.responses[0]` into its segments: [paths /pets get responses 0].
+func SplitJSONPath(jsonPath string) []string {
+	var segments []string
+	p := strings.TrimPrefix(strings.TrimSpace(jsonPath), "$")
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			if end > 0 {
+				segments = append(segments, p[:end])
+			}
+			p = p[end:]
+		case '[':
+			if len(p) > 1 && (p[1] == '\'' || p[1] == '"') {
+				quote := p[1]
+				end := strings.IndexByte(p[2:], quote)
+				if end < 0 {
+					segments = append(segments, p[2:])
+					return segments
+				}
+				segments = append(segments, p[2:2+end])
+				p = p[2+end+1:]
+				p = strings.TrimPrefix(p, "]")
+			} else {
+				end := strings.IndexByte(p, ']')
+				if end < 0 {
+					segments = append(segments, p[1:])
+					return segments
+				}
+				segments = append(segments, p[1:end])
+				p = p[end+1:]
+			}
+		default:
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			segments = append(segments, p[:end])
+			p = p[end:]
+		}
+	}
+	return segments
+}
@@ -0,0 +1,86 @@
+package rulesets
+
+import (
+	"testing"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateRuleSetFromData_Overrides(t *testing.T) {
+
+	yaml := `extends: spectral:oas
+overrides:
+  - files:
+      - "legacy/**/*.yaml"
+    rules:
+      info-contact: off
+      operation-description: hint
+  - files:
+      - "*.yaml#/paths/~1v1~1*"
+    rules:
+      operation-tags: 1`
+
+	rs, err := CreateRuleSetFromData([]byte(yaml))
+	assert.NoError(t, err)
+	assert.Len(t, rs.Overrides, 2)
+
+	gen := BuildDefaultRuleSets().GenerateRuleSetFromSuppliedRuleSet(rs)
+	assert.Len(t, gen.Overrides, 2)
+
+	sev, ok := gen.Overrides[0].GetSeverityForRule(InfoContact)
+	assert.True(t, ok)
+	assert.Equal(t, SpectralOff, sev)
+
+	sev, ok = gen.Overrides[0].GetSeverityForRule(OperationDescription)
+	assert.True(t, ok)
+	assert.Equal(t, model.SeverityHint, sev)
+
+	sev, ok = gen.Overrides[1].GetSeverityForRule(OperationTags)
+	assert.True(t, ok)
+	assert.Equal(t, model.SeverityWarn, sev)
+
+	_, ok = gen.Overrides[1].GetSeverityForRule(InfoContact)
+	assert.False(t, ok)
+}
+
+func TestRuleSetOverride_Matches_Files(t *testing.T) {
+
+	o := &RuleSetOverride{Files: []string{"legacy/**/*.yaml", "specs/{a,b}.json"}}
+
+	assert.True(t, o.Matches("legacy/one.yaml", "$.info"))
+	assert.True(t, o.Matches("./legacy/v1/deep/one.yaml", "$.info"))
+	assert.True(t, o.Matches("specs/a.json", "$"))
+	assert.False(t, o.Matches("specs/c.json", "$"))
+	assert.False(t, o.Matches("legacy/one.json", "$.info"))
+	assert.False(t, o.Matches("modern/one.yaml", "$.info"))
+	assert.False(t, o.Matches("", "$.info"))
+}
+
+func TestRuleSetOverride_Matches_Pointer(t *testing.T) {
+
+	o := &RuleSetOverride{Files: []string{"#/paths/~1v1~1*"}}
+
+	assert.True(t, o.Matches("spec.yaml", "$.paths['/v1/pets'].get"))
+	assert.True(t, o.Matches("", "$.paths['/v1/pets/{id}']"))
+	assert.False(t, o.Matches("spec.yaml", "$.paths['/v2/pets'].get"))
+	assert.False(t, o.Matches("spec.yaml", "$.paths"))
+	assert.False(t, o.Matches("spec.yaml", "$.info.contact"))
+}
+
+func TestRuleSetOverride_Matches_FileAndPointer(t *testing.T) {
+
+	o := &RuleSetOverride{Files: []string{"legacy/*.yaml#/info"}}
+
+	assert.True(t, o.Matches("legacy/api.yaml", "$.info.contact"))
+	assert.False(t, o.Matches("legacy/api.yaml", "$.paths"))
+	assert.False(t, o.Matches("api.yaml", "$.info.contact"))
+}
+
+func TestSplitJSONPath(t *testing.T) {
+	assert.Equal(t, []string{"paths", "/pets", "get", "responses", "200"},
+		SplitJSONPath("$.paths['/pets'].get.responses['200']"))
+	assert.Equal(t, []string{"tags", "0", "name"}, SplitJSONPath("$.tags[0].name"))
+	assert.Equal(t, []string{"components", "schemas", "a.b"}, SplitJSONPath(`$.components.schemas["a.b"]`))
+	assert.Nil(t, SplitJSONPath("$"))
+}
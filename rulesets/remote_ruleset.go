@@ -119,6 +119,14 @@ func SniffOutAllExternalRules(
 		return
 	}
 
+	// functions come along with the rules that use them.
+	drs.Location = location
+	if fs, fErr := drs.functionSource(remote); fErr != nil {
+		rsm.logger.Error("cannot load ruleset functions", "location", location, "error", fErr.Error())
+	} else if fs != nil {
+		rs.FunctionSources = append(rs.FunctionSources, fs)
+	}
+
 	// iterate over the remote ruleset and add the rules in
 	for ruleName, ruleValue := range drs.Rules {
 		rs.Rules[ruleName] = ruleValue
@@ -127,6 +135,21 @@ func SniffOutAllExternalRules(
 		rs.RuleDefinitions[ruleName] = ruleValue
 	}
 
+	// aliases are inherited, but never replace an alias defined by the extending ruleset.
+	if rs.Aliases == nil && len(drs.Aliases) > 0 {
+		rs.Aliases = make(map[string]interface{})
+	}
+	for aliasName, aliasValue := range drs.Aliases {
+		if _, ok := rs.Aliases[aliasName]; !ok {
+			rs.Aliases[aliasName] = aliasValue
+		}
+	}
+
+	// overrides from an extended ruleset are applied first, so the extending ruleset always wins.
+	if len(drs.Overrides) > 0 {
+		rs.Overrides = append(append([]*RuleSetOverride{}, drs.Overrides...), rs.Overrides...)
+	}
+
 	visited = append(visited, location)
 
 	// iterate over the extends and extract everything
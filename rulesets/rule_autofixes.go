@@ -0,0 +1,61 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package rulesets
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/pb33f/libopenapi/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// autoFixInfoContact adds a contact skeleton to the info object, ready to be filled in.
+func autoFixInfoContact(result *model.RuleFunctionResult, _ model.RuleFunctionContext) *model.Fix {
+	info := result.StartNode
+	if info == nil || !utils.IsNodeMap(info) {
+		return nil
+	}
+	if k, _ := utils.FindKeyNodeTop("contact", info.Content); k != nil {
+		return nil // it's there, but empty. we don't know what the author meant.
+	}
+	emptyString := func() *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: ""}
+	}
+	contact := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "name"}, emptyString(),
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "url"}, emptyString(),
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "email"}, emptyString(),
+	}}
+	return &model.Fix{
+		Description: "add a `contact` object to `info`, with `name`, `url` and `email` to complete",
+		Edits:       []model.FixEdit{model.NewInsertEdit(info, "contact", contact)},
+	}
+}
+
+// autoFixPathTrailingSlash removes the trailing slash from a path, as long as that path doesn't already exist.
+func autoFixPathTrailingSlash(result *model.RuleFunctionResult, context model.RuleFunctionContext) *model.Fix {
+	key := result.StartNode
+	if key == nil || key.Kind != yaml.ScalarNode {
+		return nil
+	}
+	fixed := strings.TrimRight(key.Value, "/")
+	if fixed == "" || fixed == key.Value {
+		return nil
+	}
+	if context.Index != nil {
+		if paths := context.Index.GetPathsNode(); paths != nil {
+			for i := 0; i < len(paths.Content); i += 2 {
+				if paths.Content[i].Value == fixed {
+					return nil
+				}
+			}
+		}
+	}
+	return &model.Fix{
+		Description: fmt.Sprintf("rename path to `%s`", fixed),
+		Edits:       []model.FixEdit{model.NewReplaceEdit(key, fixed)},
+	}
+}
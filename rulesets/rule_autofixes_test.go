@@ -0,0 +1,34 @@
+package rulesets
+
+import (
+	"testing"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAutoFixInfoContact(t *testing.T) {
+	var root yaml.Node
+	_ = yaml.Unmarshal([]byte("title: test"), &root)
+
+	fix := autoFixInfoContact(&model.RuleFunctionResult{StartNode: root.Content[0]}, model.RuleFunctionContext{})
+	assert.NotNil(t, fix)
+	assert.Equal(t, model.FixInsert, fix.Edits[0].Kind)
+	assert.Equal(t, "contact", fix.Edits[0].Key)
+	assert.Len(t, fix.Edits[0].Value.Content, 6)
+
+	// an empty contact is there, we don't guess.
+	_ = yaml.Unmarshal([]byte("title: test\ncontact: false"), &root)
+	assert.Nil(t, autoFixInfoContact(&model.RuleFunctionResult{StartNode: root.Content[0]}, model.RuleFunctionContext{}))
+}
+
+func TestAutoFixPathTrailingSlash(t *testing.T) {
+	key := &yaml.Node{Kind: yaml.ScalarNode, Value: "/pets//"}
+	fix := autoFixPathTrailingSlash(&model.RuleFunctionResult{StartNode: key}, model.RuleFunctionContext{})
+	assert.NotNil(t, fix)
+	assert.Equal(t, "/pets", fix.Edits[0].NewValue)
+
+	key.Value = "/pets"
+	assert.Nil(t, autoFixPathTrailingSlash(&model.RuleFunctionResult{StartNode: key}, model.RuleFunctionContext{}))
+}
@@ -60,6 +60,7 @@ func GetInfoContactRule() *model.Rule {
 			Function: "truthy",
 		},
 		HowToFix: contactFix,
+		AutoFix:  autoFixInfoContact,
 	}
 }
 
@@ -269,6 +270,7 @@ uniqueItems: true`
 		Description:  "OpenAPI host `schemes` must be present and non-empty array",
 		Given:        "$",
 		Resolved:     false,
+		Scope:        model.RuleScopeDocument,
 		RuleCategory: model.RuleCategories[model.CategoryInfo],
 		Recommended:  true,
 		Type:         Validation,
@@ -295,6 +297,7 @@ func GetOAS2HostNotExampleRule() *model.Rule {
 		Description:  "Host URL should not point at example.com",
 		Given:        "$.host",
 		Resolved:     false,
+		Scope:        model.RuleScopeDocument,
 		RuleCategory: model.RuleCategories[model.CategoryInfo],
 		Recommended:  true,
 		Type:         Style,
@@ -321,6 +324,7 @@ func GetOAS3HostNotExampleRule() *model.Rule {
 		Description:  "Server URL should not point at example.com",
 		Given:        "$.servers[*].url",
 		Resolved:     false,
+		Scope:        model.RuleScopeDocument,
 		RuleCategory: model.RuleCategories[model.CategoryInfo],
 		Recommended:  false,
 		Type:         Style,
@@ -346,6 +350,7 @@ func GetOAS2HostTrailingSlashRule() *model.Rule {
 		Description:  "Host URL should not contain a trailing slash",
 		Given:        "$.host",
 		Resolved:     false,
+		Scope:        model.RuleScopeDocument,
 		RuleCategory: model.RuleCategories[model.CategoryInfo],
 		Recommended:  true,
 		Type:         Style,
@@ -371,6 +376,7 @@ func GetOAS3HostTrailingSlashRule() *model.Rule {
 		Description:  "server URL should not contain a trailing slash",
 		Given:        "$.servers[*]",
 		Resolved:     false,
+		Scope:        model.RuleScopeDocument,
 		RuleCategory: model.RuleCategories[model.CategoryInfo],
 		Recommended:  false,
 		Type:         Style,
@@ -499,6 +505,7 @@ func GetAPIServersRule() *model.Rule {
 		Description:  "Check for valid API servers definition",
 		Given:        "$",
 		Resolved:     false,
+		Scope:        model.RuleScopeDocument,
 		Recommended:  true,
 		RuleCategory: model.RuleCategories[model.CategoryValidation],
 		Type:         Validation,
@@ -605,6 +612,7 @@ func GetPathNoTrailingSlashRule() *model.Rule {
 		},
 		PrecompiledPattern: comp,
 		HowToFix:           pathNoTrailingSlashFix,
+		AutoFix:            autoFixPathTrailingSlash,
 	}
 }
 
@@ -888,6 +896,7 @@ func GetOAS2APIHostRule() *model.Rule {
 		Description:  "OpenAPI `host` must be present and a non-empty string",
 		Given:        "$",
 		Resolved:     false,
+		Scope:        model.RuleScopeDocument,
 		Recommended:  true,
 		RuleCategory: model.RuleCategories[model.CategoryInfo],
 		Type:         Style,
@@ -990,6 +999,7 @@ func GetOAS3UnusedComponentRule() *model.Rule {
 		Description:  "Check for unused components and bad references",
 		Given:        "$",
 		Resolved:     false,
+		Scope:        model.RuleScopeGlobal,
 		Recommended:  true,
 		RuleCategory: model.RuleCategories[model.CategorySchemas],
 		Type:         Validation,
@@ -1010,6 +1020,7 @@ func GetOAS2UnusedComponentRule() *model.Rule {
 		Description:  "Check for unused definitions and bad references",
 		Given:        "$",
 		Resolved:     false,
+		Scope:        model.RuleScopeGlobal,
 		Recommended:  true,
 		RuleCategory: model.RuleCategories[model.CategorySchemas],
 		Type:         Validation,
@@ -1192,6 +1203,7 @@ func GetNoVerbsInPathRule() *model.Rule {
 		Description:  "Path segments must not contain an HTTP verb",
 		Given:        "$",
 		Resolved:     false,
+		Scope:        model.RuleScopeDocument,
 		Recommended:  true,
 		RuleCategory: model.RuleCategories[model.CategoryOperations],
 		Type:         Style,
@@ -1212,6 +1224,7 @@ func GetPathsKebabCaseRule() *model.Rule {
 		Description:  "Path segments must only use kebab-case (no underscores or uppercase)",
 		Given:        "$",
 		Resolved:     false,
+		Scope:        model.RuleScopeDocument,
 		Recommended:  true,
 		RuleCategory: model.RuleCategories[model.CategoryOperations],
 		Type:         Validation,
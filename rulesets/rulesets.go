@@ -188,11 +188,14 @@ func (rsm ruleSetsModel) GenerateRuleSetFromSuppliedRuleSet(ruleset *RuleSet) *R
 	// default and explicitly recommended
 	if extends[SpectralOpenAPI] == SpectralRecommended || extends[SpectralOpenAPI] == SpectralOpenAPI {
 		rs = rsm.GenerateOpenAPIRecommendedRuleSet()
+		rs.Rules = copyRules(rs.Rules)
 	}
 
 	// all rules
 	if extends[SpectralOpenAPI] == SpectralAll {
-		rs = rsm.openAPIRuleSet
+		allRS := *rsm.openAPIRuleSet
+		allRS.Rules = copyRules(allRS.Rules)
+		rs = &allRS
 	}
 
 	// no rules!
@@ -227,13 +230,27 @@ func (rsm ruleSetsModel) GenerateRuleSetFromSuppliedRuleSet(ruleset *RuleSet) *R
 		}
 	}
 
-	// add definitions.
+	// add definitions, overrides and aliases.
 	rs.RuleDefinitions = ruleset.RuleDefinitions
+	rs.Overrides = ruleset.Overrides
+	rs.Aliases = make(map[string]interface{})
+	for k, v := range ruleset.Aliases {
+		rs.Aliases[k] = v
+	}
 
 	if rs.RuleDefinitions == nil {
 		rs.RuleDefinitions = make(map[string]any)
 	}
 
+	// functions declared by the ruleset, extended rulesets add their own.
+	rs.Location = ruleset.Location
+	rs.FunctionSources = nil
+	if fs, fErr := ruleset.functionSource(false); fErr != nil {
+		rsm.logger.Error("cannot load ruleset functions", "error", fErr.Error())
+	} else if fs != nil {
+		rs.FunctionSources = append(rs.FunctionSources, fs)
+	}
+
 	// download remote rulesets
 	if CheckForRemoteExtends(extends) || CheckForLocalExtends(extends) {
 
@@ -304,7 +321,8 @@ func (rsm ruleSetsModel) GenerateRuleSetFromSuppliedRuleSet(ruleset *RuleSet) *R
 					rsm.logger.Warn("Rule does not exist, ignoring it", "rule", k)
 					continue
 				}
-				rs.Rules[k] = rsm.openAPIRuleSet.Rules[k]
+				rule := *rsm.openAPIRuleSet.Rules[k]
+				rs.Rules[k] = &rule
 			} else {
 				delete(rs.Rules, k) // remove it completely
 			}
@@ -348,6 +366,20 @@ func (rsm ruleSetsModel) GenerateRuleSetFromSuppliedRuleSet(ruleset *RuleSet) *R
 	return rs
 }
 
+// copyRules copies a map of rules, and the rules in it. The built-in rules are shared by every ruleset built on
+// top of them, so they are copied before definitions turn them off or change their severity.
+func copyRules(rules map[string]*model.Rule) map[string]*model.Rule {
+	copied := make(map[string]*model.Rule, len(rules))
+	for name, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		r := *rule
+		copied[name] = &r
+	}
+	return copied
+}
+
 // CreateRuleSetFromRuleMap creates a RuleSet from a map of rules. Built-in rules can can be exposed by using
 // the GetAllBuiltInRules() function.
 func CreateRuleSetFromRuleMap(rules map[string]*model.Rule) *RuleSet {
@@ -491,6 +523,12 @@ type RuleSet struct {
 	RuleDefinitions  map[string]interface{} `json:"rules" yaml:"rules"` // this can be either a string, or an entire rule (super annoying, stoplight).
 	Rules            map[string]*model.Rule `json:"-" yaml:"-"`
 	Extends          interface{}            `json:"extends,omitempty" yaml:"extends,omitempty"` // can be string or tuple (again... why stoplight?)
+	Overrides        []*RuleSetOverride     `json:"overrides,omitempty" yaml:"overrides,omitempty"`
+	Aliases          map[string]interface{} `json:"aliases,omitempty" yaml:"aliases,omitempty"`           // can be a given string, array, or format scoped targets.
+	Functions        []string               `json:"functions,omitempty" yaml:"functions,omitempty"`       // custom functions the ruleset brings with it.
+	FunctionsDir     string                 `json:"functionsDir,omitempty" yaml:"functionsDir,omitempty"` // where the functions are, relative to the ruleset.
+	FunctionSources  []*FunctionSource      `json:"-" yaml:"-"`                                           // functions declared by this ruleset, and those it extends.
+	Location         string                 `json:"-" yaml:"-"`                                           // where the ruleset was loaded from, if known.
 	extendsMeta      map[string]string
 }
 
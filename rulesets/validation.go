@@ -0,0 +1,200 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package rulesets
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pb33f/libopenapi/utils"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+// Diagnostic is a problem with a ruleset, found before it is used.
+type Diagnostic struct {
+	RuleId   string   `json:"ruleId,omitempty" yaml:"ruleId,omitempty"` // the rule with the problem, if it's a rule.
+	Severity string   `json:"severity" yaml:"severity"`                 // error (the rule can't run properly) or warn.
+	Message  string   `json:"message" yaml:"message"`
+	Path     []string `json:"path" yaml:"path"`                         // where the problem is in the ruleset.
+	Line     int      `json:"line,omitempty" yaml:"line,omitempty"`     // set by Locate.
+	Column   int      `json:"column,omitempty" yaml:"column,omitempty"` // set by Locate.
+}
+
+// FunctionLookup returns the function with the supplied name, or nil if there is no such function.
+type FunctionLookup func(name string) model.RuleFunction
+
+// Validate checks the rules of a ruleset can run: their functions exist and are given valid options, their given
+// paths are valid JSONPath (once aliases are expanded), and their formats and categories are known. Diagnostics
+// are sorted by rule.
+func (rs *RuleSet) Validate(lookup FunctionLookup) []*Diagnostic {
+	var diagnostics []*Diagnostic
+
+	for i, format := range rs.Formats {
+		if !slices.Contains(model.AllFormats, format) {
+			diagnostics = append(diagnostics, &Diagnostic{
+				Severity: model.SeverityWarn,
+				Message:  fmt.Sprintf("unknown format '%s', formats are: %v", format, model.AllFormats),
+				Path:     []string{"formats", strconv.Itoa(i)},
+			})
+		}
+	}
+
+	ids := make([]string, 0, len(rs.Rules))
+	for id := range rs.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		diagnostics = append(diagnostics, rs.validateRule(id, rs.Rules[id], lookup)...)
+	}
+	return diagnostics
+}
+
+func (rs *RuleSet) validateRule(id string, rule *model.Rule, lookup FunctionLookup) []*Diagnostic {
+	var diagnostics []*Diagnostic
+	report := func(severity, message string, path ...string) {
+		diagnostics = append(diagnostics, &Diagnostic{
+			RuleId:   id,
+			Severity: severity,
+			Message:  message,
+			Path:     append([]string{"rules", id}, path...),
+		})
+	}
+
+	// given paths.
+	given := extractGivenStrings(rule.Given)
+	if len(given) == 0 {
+		report(model.SeverityError, "rule has no 'given' path", "given")
+	}
+	for i, g := range given {
+		at := []string{"given"}
+		if _, isString := rule.Given.(string); !isString {
+			at = append(at, strconv.Itoa(i))
+		}
+		paths, err := rs.ExpandGivenPaths([]string{g}, "")
+		if err != nil {
+			report(model.SeverityError, err.Error(), at...)
+			continue
+		}
+		for _, p := range paths {
+			if p == "$" {
+				continue
+			}
+			if _, pErr := yamlpath.NewPath(utils.FixContext(p)); pErr != nil {
+				report(model.SeverityError, fmt.Sprintf("invalid JSONPath '%s': %s", p, pErr.Error()), at...)
+			}
+		}
+	}
+
+	// formats.
+	for i, format := range rule.Formats {
+		if !slices.Contains(model.AllFormats, format) {
+			report(model.SeverityWarn, fmt.Sprintf("unknown format '%s', formats are: %v", format, model.AllFormats),
+				"formats", strconv.Itoa(i))
+		}
+	}
+
+	// categories, rules with a category that does not exist don't have one.
+	if def, ok := rs.RuleDefinitions[id].(map[string]interface{}); ok {
+		if category, isMap := def["category"].(map[string]interface{}); isMap {
+			if cid, _ := category["id"].(string); cid != "" && model.RuleCategories[cid] == nil {
+				report(model.SeverityWarn, fmt.Sprintf("unknown category '%s'", cid), "category", "id")
+			}
+		}
+	}
+
+	// functions and their options.
+	var actions []model.RuleAction
+	var single model.RuleAction
+	list := false
+	if err := mapstructure.Decode(rule.Then, &single); err == nil {
+		actions = []model.RuleAction{single}
+	} else if err = mapstructure.Decode(rule.Then, &actions); err == nil {
+		list = true
+	} else {
+		report(model.SeverityError, "'then' must be an action, or a list of actions", "then")
+	}
+	for i := range actions {
+		action := actions[i]
+		at := []string{"then"}
+		if list {
+			at = append(at, strconv.Itoa(i))
+		}
+		if action.Function == "" {
+			report(model.SeverityError, "action has no function", at...)
+			continue
+		}
+		function := lookup(action.Function)
+		if function == nil {
+			report(model.SeverityError, fmt.Sprintf("unknown function '%s'", action.Function),
+				append(at, "function")...)
+			continue
+		}
+		_, errs := model.ValidateRuleFunctionContextAgainstSchema(function, model.RuleFunctionContext{
+			Options:    action.FunctionOptions,
+			RuleAction: &action,
+			Rule:       rule,
+			Given:      rule.Given,
+		})
+		for _, e := range errs {
+			report(model.SeverityError, fmt.Sprintf("invalid options for function '%s': %s", action.Function, e),
+				append(at, "functionOptions")...)
+		}
+	}
+	return diagnostics
+}
+
+// Locate sets the line and column of diagnostics, from the ruleset they were found in. A diagnostic is placed at
+// the closest thing to its path that exists, as long as its rule exists (problems in rules that come from an
+// extended ruleset are not placed at all).
+func Locate(root *yaml.Node, diagnostics []*Diagnostic) {
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	for _, d := range diagnostics {
+		if node := locatePath(root, d.Path); node != nil {
+			d.Line, d.Column = node.Line, node.Column
+		}
+	}
+}
+
+// locatePath walks a path as far as it can, returning the last node it found (the key, for keys of maps). Nothing
+// is returned if the first two segments (the rule) can't be found.
+func locatePath(node *yaml.Node, path []string) *yaml.Node {
+	var found *yaml.Node
+	depth := 0
+	for _, seg := range path {
+		if node == nil {
+			break
+		}
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == seg {
+					found, next = node.Content[i], node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(seg); err == nil && i >= 0 && i < len(node.Content) {
+				found, next = node.Content[i], node.Content[i]
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+		depth++
+	}
+	if depth < min(len(path), 2) {
+		return nil
+	}
+	return found
+}
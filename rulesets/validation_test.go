@@ -0,0 +1,124 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package rulesets
+
+import (
+	"testing"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+type lengthStub struct{}
+
+func (l lengthStub) GetSchema() model.RuleFunctionSchema {
+	return model.RuleFunctionSchema{
+		Name:          "length",
+		MinProperties: 1,
+		ErrorMessage:  "'length' needs 'min' or 'max'",
+		Properties:    []model.RuleFunctionProperty{{Name: "min"}, {Name: "max"}},
+	}
+}
+
+func (l lengthStub) RunRule(_ []*yaml.Node, _ model.RuleFunctionContext) []model.RuleFunctionResult {
+	return nil
+}
+
+func stubLookup(name string) model.RuleFunction {
+	if name == "length" {
+		return lengthStub{}
+	}
+	return nil
+}
+
+const invalidRuleSet = `formats: [oas3, oas9]
+rules:
+  bad-function:
+    given: $.info
+    then:
+      function: nothing
+  bad-options:
+    given: $.info
+    then:
+      - function: length
+        functionOptions:
+          max: 10
+      - function: length
+  bad-path:
+    given:
+      - $.info
+      - $.paths[?(@.x ==
+    then:
+      function: length
+      functionOptions:
+        min: 1
+  bad-format:
+    given: $.info
+    formats: [oas3, swagger]
+    category:
+      id: nope
+    then:
+      function: length
+      functionOptions:
+        min: 1
+  fine:
+    given: $.info
+    then:
+      function: length
+      functionOptions:
+        min: 1
+`
+
+func TestRuleSet_Validate(t *testing.T) {
+	rs, err := CreateRuleSetFromData([]byte(invalidRuleSet))
+	assert.NoError(t, err)
+	rs = BuildDefaultRuleSets().GenerateRuleSetFromSuppliedRuleSet(rs)
+	rs.Rules = map[string]*model.Rule{
+		"bad-function": rs.Rules["bad-function"],
+		"bad-options":  rs.Rules["bad-options"],
+		"bad-path":     rs.Rules["bad-path"],
+		"bad-format":   rs.Rules["bad-format"],
+		"fine":         rs.Rules["fine"],
+	}
+
+	diagnostics := rs.Validate(stubLookup)
+
+	var root yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(invalidRuleSet), &root))
+	Locate(&root, diagnostics)
+
+	type found struct {
+		rule, severity string
+		path           []string
+		line, column   int
+	}
+	var got []found
+	for _, d := range diagnostics {
+		got = append(got, found{d.RuleId, d.Severity, d.Path, d.Line, d.Column})
+	}
+	assert.Equal(t, []found{
+		{"", model.SeverityWarn, []string{"formats", "1"}, 1, 17},
+		{"bad-format", model.SeverityWarn, []string{"rules", "bad-format", "formats", "1"}, 24, 21},
+		{"bad-format", model.SeverityWarn, []string{"rules", "bad-format", "category", "id"}, 26, 7},
+		{"bad-function", model.SeverityError, []string{"rules", "bad-function", "then", "function"}, 6, 7},
+		{"bad-options", model.SeverityError, []string{"rules", "bad-options", "then", "1", "functionOptions"}, 13, 9},
+		{"bad-path", model.SeverityError, []string{"rules", "bad-path", "given", "1"}, 17, 9},
+	}, got)
+	assert.Equal(t, "unknown function 'nothing'", diagnostics[3].Message)
+	assert.Contains(t, diagnostics[4].Message, "invalid options for function 'length'")
+	assert.Contains(t, diagnostics[5].Message, "invalid JSONPath '$.paths[?(@.x =='")
+}
+
+func TestLocate_ExtendedRule(t *testing.T) {
+	var root yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte("rules:\n  mine:\n    given: $\n"), &root))
+	diagnostics := []*Diagnostic{
+		{Path: []string{"rules", "extended", "then", "function"}},
+		{Path: []string{"rules", "mine", "then", "function"}},
+	}
+	Locate(&root, diagnostics)
+	assert.Zero(t, diagnostics[0].Line)
+	assert.Equal(t, 2, diagnostics[1].Line)
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package ruletest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	vacuum_report "github.com/daveshanley/vacuum/vacuum-report"
+)
+
+// BuildJUnitReport renders the results of test suites as a JUnit XML report, each manifest is a test suite.
+func BuildJUnitReport(suites []*SuiteResult) []byte {
+	all := &vacuum_report.TestSuites{}
+	for _, s := range suites {
+		ts := &vacuum_report.TestSuite{
+			Name: s.Manifest,
+			Time: s.Duration.Seconds(),
+		}
+		if s.Error != nil {
+			ts.TestCases = append(ts.TestCases, &vacuum_report.TestCase{
+				Name:      "load ruleset",
+				ClassName: s.Manifest,
+				Failure: &vacuum_report.Failure{
+					Message:  s.Error.Error(),
+					Type:     "ERROR",
+					Contents: xmlText(s.Error.Error()),
+				},
+			})
+		}
+		for _, t := range s.Tests {
+			tc := &vacuum_report.TestCase{
+				Name:      t.Name,
+				ClassName: s.Manifest,
+				Time:      t.Duration.Seconds(),
+			}
+			if !t.Passed() {
+				tc.Failure = &vacuum_report.Failure{
+					Message:  Summary(t),
+					Type:     "FAILURE",
+					Contents: xmlText(Diff(t)),
+				}
+			}
+			ts.TestCases = append(ts.TestCases, tc)
+		}
+		ts.Tests = len(ts.TestCases)
+		ts.Failures = s.Failures()
+		all.TestSuites = append(all.TestSuites, ts)
+		all.Tests += ts.Tests
+		all.Failures += ts.Failures
+		all.Time += ts.Time
+	}
+	b, _ := xml.MarshalIndent(all, "", " ")
+	return append([]byte(xml.Header), b...)
+}
+
+// Summary describes why a test failed, in a single line.
+func Summary(t *TestResult) string {
+	var parts []string
+	if len(t.Errors) > 0 {
+		parts = append(parts, fmt.Sprintf("%d errors", len(t.Errors)))
+	}
+	if len(t.Missing) > 0 {
+		parts = append(parts, fmt.Sprintf("%d expected results missing", len(t.Missing)))
+	}
+	if len(t.Unexpected) > 0 {
+		parts = append(parts, fmt.Sprintf("%d unexpected results", len(t.Unexpected)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Diff describes why a test failed, missing results are prefixed with '-' and unexpected results with '+'.
+func Diff(t *TestResult) string {
+	var sb strings.Builder
+	for _, e := range t.Errors {
+		sb.WriteString("! " + e.Error() + "\n")
+	}
+	for _, e := range t.Missing {
+		sb.WriteString("- " + e.String() + "\n")
+	}
+	for _, e := range t.Unexpected {
+		sb.WriteString("+ " + e.String() + "\n")
+	}
+	return sb.String()
+}
+
+// xmlText escapes text, Failure contents are written as inner XML.
+func xmlText(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+// Package ruletest runs rules against fixture specifications, and checks the results are the ones expected. It
+// powers the test-rules command, so rule authors can test custom rules (and custom functions) the same way they
+// test code.
+package ruletest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest describes a ruleset and the tests it has to pass. All paths are relative to the manifest.
+//
+//	ruleset: ruleset.yaml
+//	functions: functions
+//	rules: [operation-must-have-tags]
+//	tests:
+//	  - name: operations without tags are flagged
+//	    spec: fixtures/no-tags.yaml
+//	    expect:
+//	      - rule: operation-must-have-tags
+//	        path: $.paths['/pets'].get
+//	        severity: error
+type Manifest struct {
+	RuleSet   string   `json:"ruleset" yaml:"ruleset"`                         // the ruleset being tested.
+	Functions string   `json:"functions,omitempty" yaml:"functions,omitempty"` // custom functions used by the ruleset.
+	Rules     []string `json:"rules,omitempty" yaml:"rules,omitempty"`         // only check results of these rules.
+	Tests     []*Test  `json:"tests" yaml:"tests"`
+	Location  string   `json:"-" yaml:"-"` // where the manifest was loaded from.
+}
+
+// Test is a fixture specification, and the results the ruleset is expected to find in it. A test with no
+// expectations checks that nothing is found.
+type Test struct {
+	Name   string         `json:"name" yaml:"name"`
+	Spec   string         `json:"spec" yaml:"spec"`
+	Rules  []string       `json:"rules,omitempty" yaml:"rules,omitempty"` // overrides the rules of the manifest.
+	Expect []*Expectation `json:"expect,omitempty" yaml:"expect,omitempty"`
+}
+
+// Expectation is a result a test expects to find. Only the rule is required, anything else that is set has to
+// match as well. Message matches if it's contained in the message of the result.
+type Expectation struct {
+	Rule     string `json:"rule" yaml:"rule"`
+	Path     string `json:"path,omitempty" yaml:"path,omitempty"`
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Message  string `json:"message,omitempty" yaml:"message,omitempty"`
+	Line     int    `json:"line,omitempty" yaml:"line,omitempty"`
+}
+
+// LoadManifest reads and checks a manifest.
+func LoadManifest(location string) (*Manifest, error) {
+	b, err := os.ReadFile(location)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err = yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("unable to parse test manifest '%s': %w", location, err)
+	}
+	m.Location = location
+
+	if m.RuleSet == "" {
+		return nil, fmt.Errorf("test manifest '%s' has no ruleset", location)
+	}
+	if len(m.Tests) == 0 {
+		return nil, fmt.Errorf("test manifest '%s' has no tests", location)
+	}
+	for i, t := range m.Tests {
+		if t == nil || t.Spec == "" {
+			return nil, fmt.Errorf("test %d of manifest '%s' has no spec", i+1, location)
+		}
+		if t.Name == "" {
+			t.Name = t.Spec
+		}
+		for _, e := range t.Expect {
+			if e == nil || e.Rule == "" {
+				return nil, fmt.Errorf("test '%s' of manifest '%s' expects a result without a rule", t.Name, location)
+			}
+		}
+	}
+	return &m, nil
+}
+
+// resolve returns a path relative to the manifest.
+func (m *Manifest) resolve(path string) string {
+	if path == "" || filepath.IsAbs(path) || m.Location == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(m.Location), path)
+}
+
+func (e *Expectation) String() string {
+	s := e.Rule
+	if e.Severity != "" {
+		s += " [" + e.Severity + "]"
+	}
+	if e.Path != "" {
+		s += " " + e.Path
+	}
+	if e.Line > 0 {
+		s += fmt.Sprintf(" (line %d)", e.Line)
+	}
+	if e.Message != "" {
+		s += fmt.Sprintf(" %q", e.Message)
+	}
+	return s
+}
@@ -0,0 +1,231 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package ruletest
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/motor"
+	"github.com/daveshanley/vacuum/plugin"
+	"github.com/daveshanley/vacuum/rulesets"
+)
+
+// Options configure how tests are run.
+type Options struct {
+	Functions map[string]model.RuleFunction // custom functions, used if the manifest does not load its own.
+	Timeout   time.Duration                 // timeout for each rule, the motor default is used if not set.
+	Logger    *slog.Logger                  // logger for the motor, nothing is logged if not set.
+}
+
+// SuiteResult is the outcome of running every test in a manifest.
+type SuiteResult struct {
+	Manifest string
+	Tests    []*TestResult
+	Duration time.Duration
+	Error    error // the ruleset or functions could not be loaded, no tests were run.
+}
+
+// TestResult is the outcome of a single test. Missing are results that were expected but not found, Unexpected
+// are results that were found but not expected.
+type TestResult struct {
+	Name       string
+	Spec       string
+	Missing    []*Expectation
+	Unexpected []*Expectation
+	Errors     []error
+	Duration   time.Duration
+}
+
+// Passed returns true if the test found exactly what it expected.
+func (t *TestResult) Passed() bool {
+	return len(t.Missing) == 0 && len(t.Unexpected) == 0 && len(t.Errors) == 0
+}
+
+// Failures returns the number of tests that did not pass, a suite that could not run is a single failure.
+func (s *SuiteResult) Failures() int {
+	if s.Error != nil {
+		return 1
+	}
+	f := 0
+	for _, t := range s.Tests {
+		if !t.Passed() {
+			f++
+		}
+	}
+	return f
+}
+
+// Run runs every test in the manifest.
+func Run(m *Manifest, opts Options) *SuiteResult {
+	start := time.Now()
+	suite := &SuiteResult{Manifest: m.Location}
+
+	rs, functions, pm, err := loadRules(m, opts)
+	if err != nil {
+		suite.Error = err
+		suite.Duration = time.Since(start)
+		return suite
+	}
+	defer func() {
+		pm.Close()
+		rs.CloseFunctions()
+	}()
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError}))
+	}
+
+	for _, t := range m.Tests {
+		suite.Tests = append(suite.Tests, runTest(m, t, rs, functions, opts.Timeout, logger))
+	}
+	suite.Duration = time.Since(start)
+	return suite
+}
+
+// loadRules loads the ruleset of the manifest, and its functions. The manager holds the functions loaded for the
+// manifest, it's nil if the manifest uses the functions of the options.
+func loadRules(m *Manifest, opts Options) (*rulesets.RuleSet, map[string]model.RuleFunction, *plugin.Manager, error) {
+	location := m.resolve(m.RuleSet)
+	rsBytes, err := os.ReadFile(location)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("unable to read ruleset '%s': %w", location, err)
+	}
+	userRS, err := rulesets.CreateRuleSetFromData(rsBytes)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("unable to parse ruleset '%s': %w", location, err)
+	}
+	userRS.Location = location
+	rs := rulesets.BuildDefaultRuleSets().GenerateRuleSetFromSuppliedRuleSet(userRS)
+
+	functions := opts.Functions
+	var pm *plugin.Manager
+	if m.Functions != "" {
+		var pErr error
+		pm, pErr = plugin.LoadFunctions(m.resolve(m.Functions), true)
+		if pErr != nil {
+			return nil, nil, nil, fmt.Errorf("unable to load functions '%s': %w", m.resolve(m.Functions), pErr)
+		}
+		functions = pm.GetCustomFunctions()
+	}
+	return rs, functions, pm, nil
+}
+
+func runTest(m *Manifest, t *Test, rs *rulesets.RuleSet, functions map[string]model.RuleFunction,
+	timeout time.Duration, logger *slog.Logger) *TestResult {
+
+	start := time.Now()
+	result := &TestResult{Name: t.Name, Spec: t.Spec}
+	defer func() { result.Duration = time.Since(start) }()
+
+	location := m.resolve(t.Spec)
+	spec, err := os.ReadFile(location)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("unable to read spec '%s': %w", location, err))
+		return result
+	}
+
+	execution := motor.ApplyRulesToRuleSet(&motor.RuleSetExecution{
+		RuleSet:         rs,
+		Spec:            spec,
+		SpecFileName:    location,
+		CustomFunctions: functions,
+		FunctionLoader:  plugin.LoadFunctionSource,
+		Base:            filepath.Dir(location),
+		Timeout:         timeout,
+		Logger:          logger,
+	})
+	result.Errors = append(result.Errors, execution.Errors...)
+
+	checked := t.Rules
+	if len(checked) == 0 {
+		checked = m.Rules
+	}
+	var actual []*Expectation
+	for i := range execution.Results {
+		a := actualResult(&execution.Results[i])
+		if len(checked) == 0 || contains(checked, a.Rule) {
+			actual = append(actual, a)
+		}
+	}
+	result.Missing, result.Unexpected = compare(t.Expect, actual)
+	return result
+}
+
+// compare matches every expectation with a result, expectations with no result are missing, results with no
+// expectation are unexpected. As many expectations as possible are matched, so a loose expectation never takes
+// the result a stricter one needs, whatever order they are written in.
+func compare(expected, actual []*Expectation) (missing, unexpected []*Expectation) {
+	owner := make([]int, len(actual)) // the expectation each result is matched with, -1 if it's not matched.
+	for i := range owner {
+		owner[i] = -1
+	}
+
+	// assign finds a result for an expectation, moving the expectations matched with results it could use to
+	// other results when they can be moved.
+	var assign func(e int, seen []bool) bool
+	assign = func(e int, seen []bool) bool {
+		for i, a := range actual {
+			if seen[i] || !matches(expected[e], a) {
+				continue
+			}
+			seen[i] = true
+			if owner[i] == -1 || assign(owner[i], seen) {
+				owner[i] = e
+				return true
+			}
+		}
+		return false
+	}
+
+	for e := range expected {
+		if !assign(e, make([]bool, len(actual))) {
+			missing = append(missing, expected[e])
+		}
+	}
+	for i, a := range actual {
+		if owner[i] == -1 {
+			unexpected = append(unexpected, a)
+		}
+	}
+	return missing, unexpected
+}
+
+func matches(e, a *Expectation) bool {
+	return e.Rule == a.Rule &&
+		(e.Path == "" || e.Path == a.Path) &&
+		(e.Severity == "" || e.Severity == a.Severity) &&
+		(e.Line == 0 || e.Line == a.Line) &&
+		(e.Message == "" || strings.Contains(a.Message, e.Message))
+}
+
+func actualResult(r *model.RuleFunctionResult) *Expectation {
+	a := &Expectation{Rule: r.RuleId, Path: r.Path, Severity: r.RuleSeverity, Message: r.Message}
+	if r.Rule != nil {
+		if a.Rule == "" {
+			a.Rule = r.Rule.Id
+		}
+		a.Severity = r.Rule.Severity
+	}
+	if r.StartNode != nil {
+		a.Line = r.StartNode.Line
+	}
+	return a
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package ruletest
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	vacuum_report "github.com/daveshanley/vacuum/vacuum-report"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRuleSet = `extends: [[vacuum:oas, off]]
+rules:
+  title-capitalized:
+    description: Titles must start with a capital letter
+    severity: warn
+    given: $.info
+    then:
+      field: title
+      function: pattern
+      functionOptions:
+        match: "^[A-Z]"
+  single-path:
+    description: Only one path is allowed
+    severity: error
+    given: $.paths
+    then:
+      function: check_single_path`
+
+const testSpec = `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      responses: {}
+  /Owners:
+    get:
+      responses: {}`
+
+func writeManifest(t *testing.T, manifest string) *Manifest {
+	dir := t.TempDir()
+	functions, _ := filepath.Abs("../plugin/sample/js")
+	manifest = strings.ReplaceAll(manifest, "FUNCTIONS", functions)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ruleset.yaml"), []byte(testRuleSet), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "spec.yaml"), []byte(testSpec), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "tests.yaml"), []byte(manifest), 0644))
+	m, err := LoadManifest(filepath.Join(dir, "tests.yaml"))
+	assert.NoError(t, err)
+	return m
+}
+
+func TestRun_Pass(t *testing.T) {
+	m := writeManifest(t, `ruleset: ruleset.yaml
+functions: FUNCTIONS
+tests:
+  - name: lower case title and too many paths
+    spec: spec.yaml
+    expect:
+      - rule: title-capitalized
+        severity: warn
+        line: 3
+        message: does not match
+      - rule: single-path
+        severity: error
+        message: there are 2
+  - name: only the JS function
+    spec: spec.yaml
+    rules: [single-path]
+    expect:
+      - rule: single-path`)
+
+	suite := Run(m, Options{})
+	assert.NoError(t, suite.Error)
+	assert.Len(t, suite.Tests, 2)
+	for _, tr := range suite.Tests {
+		assert.True(t, tr.Passed(), Diff(tr))
+	}
+	assert.Equal(t, 0, suite.Failures())
+}
+
+func TestRun_Fail(t *testing.T) {
+	m := writeManifest(t, `ruleset: ruleset.yaml
+functions: FUNCTIONS
+rules: [title-capitalized]
+tests:
+  - name: wrong severity
+    spec: spec.yaml
+    expect:
+      - rule: title-capitalized
+        severity: error
+  - name: missing fixture
+    spec: nope.yaml`)
+
+	suite := Run(m, Options{})
+	assert.Equal(t, 2, suite.Failures())
+
+	wrong := suite.Tests[0]
+	assert.Len(t, wrong.Missing, 1)
+	assert.Len(t, wrong.Unexpected, 1)
+	assert.Equal(t, "warn", wrong.Unexpected[0].Severity)
+	assert.Contains(t, Diff(wrong), "- title-capitalized [error]")
+	assert.Contains(t, Diff(wrong), "+ title-capitalized [warn]")
+
+	assert.NotEmpty(t, suite.Tests[1].Errors)
+
+	var report vacuum_report.TestSuites
+	assert.NoError(t, xml.Unmarshal(BuildJUnitReport([]*SuiteResult{suite}), &report))
+	assert.Equal(t, 2, report.Tests)
+	assert.Equal(t, 2, report.Failures)
+	assert.NotNil(t, report.TestSuites[0].TestCases[0].Failure)
+}
+
+func TestCompare_LooseExpectationFirst(t *testing.T) {
+	actual := []*Expectation{
+		{Rule: "x", Path: "$.info"},
+		{Rule: "x", Path: "$.paths"},
+	}
+
+	// the loose expectation does not take the result the stricter one needs.
+	missing, unexpected := compare([]*Expectation{{Rule: "x"}, {Rule: "x", Path: "$.info"}}, actual)
+	assert.Empty(t, missing)
+	assert.Empty(t, unexpected)
+
+	missing, unexpected = compare([]*Expectation{{Rule: "x", Path: "$.info"}, {Rule: "x"}}, actual)
+	assert.Empty(t, missing)
+	assert.Empty(t, unexpected)
+
+	missing, unexpected = compare([]*Expectation{{Rule: "x"}, {Rule: "x", Path: "$.info"}, {Rule: "x", Path: "$.info"}}, actual)
+	assert.Equal(t, []*Expectation{{Rule: "x", Path: "$.info"}}, missing)
+	assert.Empty(t, unexpected)
+}
+
+func TestRun_BadRuleSet(t *testing.T) {
+	suite := Run(&Manifest{RuleSet: "nope.yaml", Tests: []*Test{{Spec: "spec.yaml"}}}, Options{})
+	assert.Error(t, suite.Error)
+	assert.Equal(t, 1, suite.Failures())
+}
+
+func TestLoadManifest_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	location := filepath.Join(dir, "tests.yaml")
+
+	_, err := LoadManifest(location)
+	assert.Error(t, err)
+
+	assert.NoError(t, os.WriteFile(location, []byte("tests: []"), 0644))
+	_, err = LoadManifest(location)
+	assert.ErrorContains(t, err, "no ruleset")
+
+	assert.NoError(t, os.WriteFile(location, []byte("ruleset: r.yaml\ntests: [{spec: s.yaml, expect: [{path: $}]}]"), 0644))
+	_, err = LoadManifest(location)
+	assert.ErrorContains(t, err, "without a rule")
+}
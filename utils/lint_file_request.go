@@ -4,8 +4,10 @@
 package utils
 
 import (
+	"github.com/daveshanley/vacuum/cache"
 	"github.com/daveshanley/vacuum/model"
 	"github.com/daveshanley/vacuum/rulesets"
+	vacuum_report "github.com/daveshanley/vacuum/vacuum-report"
 	"log/slog"
 	"sync"
 )
@@ -30,6 +32,13 @@ type LintFileRequest struct {
 	TimeoutFlag              int
 	IgnoreArrayCircleRef     bool
 	IgnorePolymorphCircleRef bool
+	UpdateBaselineFlag       bool
+	FixFlag                  bool
+	FixDryRunFlag            bool
+	Sarif                    *vacuum_report.SarifReport
+	Baseline                 *vacuum_report.Baseline
+	Cache                    *cache.Cache
+	Profiles                 *[]model.FileProfile
 	DefaultRuleSets          rulesets.RuleSets
 	SelectedRS               *rulesets.RuleSet
 	Functions                map[string]model.RuleFunction
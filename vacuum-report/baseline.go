@@ -0,0 +1,230 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package vacuum_report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daveshanley/vacuum/model"
+)
+
+// Baseline is a set of known results, recorded from a previous run. Results that exist in the baseline are
+// considered accepted, only new results count towards failures. A baseline is stored as a regular VacuumReport,
+// so any existing report can be used as a baseline. Baselines written by vacuum also record the fingerprints of
+// each specification, so a result is only known for the specification it was found in. Reports that don't
+// record them apply to every specification.
+//
+// Specifications are recorded relative to the directory of the baseline file, so a baseline works the same
+// wherever vacuum is run from.
+type Baseline struct {
+	dir          string // the directory of the baseline file, empty if it's not known.
+	fingerprints map[string]int
+	specs        map[string][]string
+	results      map[string][]*model.RuleFunctionResult // results of each specification, "" for unknown specifications.
+	replaced     map[string]bool                        // specifications whose recorded results Add has replaced.
+	lock         sync.Mutex
+}
+
+// Fingerprint generates a stable identifier for a result found in a specification, using the specification file
+// name, the rule ID, the JSON path and a hash of the message. Line and column numbers are deliberately left out,
+// so shifting content around does not create new results.
+func Fingerprint(specFileName string, result *model.RuleFunctionResult) string {
+	ruleId := result.RuleId
+	if ruleId == "" && result.Rule != nil {
+		ruleId = result.Rule.Id
+	}
+	if specFileName != "" {
+		specFileName = filepath.ToSlash(filepath.Clean(specFileName))
+	}
+	msg := sha256.Sum256([]byte(result.Message))
+	fp := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%s", specFileName, ruleId, result.Path, hex.EncodeToString(msg[:]))))
+	return hex.EncodeToString(fp[:16])
+}
+
+// NewBaseline creates a new empty Baseline, specifications are recorded by the names they are added with.
+func NewBaseline() *Baseline {
+	return &Baseline{
+		fingerprints: make(map[string]int),
+		specs:        make(map[string][]string),
+		results:      make(map[string][]*model.RuleFunctionResult),
+		replaced:     make(map[string]bool),
+	}
+}
+
+// LoadBaseline reads a vacuum report (compressed or not) from disk and creates a Baseline from its results.
+// If the file does not exist and allowMissing is true, an empty Baseline is returned.
+func LoadBaseline(filePath string, allowMissing bool) (*Baseline, error) {
+	b := NewBaseline()
+	b.dir = filepath.Dir(filePath)
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if allowMissing && errors.Is(err, fs.ErrNotExist) {
+			return b, nil
+		}
+		return nil, err
+	}
+	vr, err := CheckFileForVacuumReport(data)
+	if err != nil {
+		return nil, fmt.Errorf("baseline '%s' is not a vacuum report: %w", filePath, err)
+	}
+	if vr == nil {
+		return nil, fmt.Errorf("baseline '%s' does not contain any results", filePath)
+	}
+	if vr.Baseline == nil {
+		// a report that doesn't know which specification its results came from, applies to all of them.
+		for _, r := range vr.ResultSet.Results {
+			fp := Fingerprint("", r)
+			b.fingerprints[fp]++
+			b.specs[""] = append(b.specs[""], fp)
+		}
+		b.results[""] = vr.ResultSet.Results
+		return b, nil
+	}
+
+	// results are given back to the specification they were recorded for, so they can be replaced.
+	pending := make(map[string]map[string]int, len(vr.Baseline))
+	for spec, fingerprints := range vr.Baseline {
+		b.specs[spec] = fingerprints
+		pending[spec] = make(map[string]int, len(fingerprints))
+		for _, fp := range fingerprints {
+			b.fingerprints[fp]++
+			pending[spec][fp]++
+		}
+	}
+	for _, r := range vr.ResultSet.Results {
+		owner := ""
+		for spec := range pending {
+			if fp := Fingerprint(spec, r); pending[spec][fp] > 0 {
+				pending[spec][fp]--
+				owner = spec
+				break
+			}
+		}
+		b.results[owner] = append(b.results[owner], r)
+	}
+	return b, nil
+}
+
+// specName returns the name a specification is recorded under, the path relative to the directory of the
+// baseline when it's known.
+func (b *Baseline) specName(specFileName string) string {
+	if specFileName == "" || b.dir == "" || strings.Contains(specFileName, "://") {
+		return specFileName
+	}
+	dir, dErr := filepath.Abs(b.dir)
+	spec, sErr := filepath.Abs(specFileName)
+	if dErr != nil || sErr != nil {
+		return specFileName
+	}
+	if rel, err := filepath.Rel(dir, spec); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return specFileName
+}
+
+// Add records the results of a specification in the baseline. The results replace those recorded for the
+// specification before, the first time it's added, results of other specifications are kept. Results of a report
+// that does not record specifications can't be told apart, they are all replaced.
+func (b *Baseline) Add(specFileName string, results []*model.RuleFunctionResult) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	name := b.specName(specFileName)
+	for _, spec := range []string{name, ""} {
+		if b.replaced[spec] {
+			continue
+		}
+		b.replaced[spec] = true
+		for _, fp := range b.specs[spec] {
+			b.fingerprints[fp]--
+		}
+		delete(b.specs, spec)
+		delete(b.results, spec)
+	}
+	for _, r := range results {
+		fp := Fingerprint(name, r)
+		b.fingerprints[fp]++
+		b.specs[name] = append(b.specs[name], fp)
+		b.results[name] = append(b.results[name], r)
+	}
+}
+
+// Len returns the number of results recorded in the baseline.
+func (b *Baseline) Len() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	total := 0
+	for _, results := range b.results {
+		total += len(results)
+	}
+	return total
+}
+
+// Filter separates the results of a specification that are new, from results that already exist in the baseline.
+// If a fingerprint was recorded N times, then only N matching results are considered known, any more than that
+// are new.
+func (b *Baseline) Filter(specFileName string, results []*model.RuleFunctionResult) (newResults, knownResults []*model.RuleFunctionResult) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	seen := make(map[string]int)
+	names := []string{b.specName(specFileName)}
+	if names[0] != specFileName {
+		// baselines recorded specifications by the name they were linted with, before names were relative.
+		names = append(names, specFileName)
+	}
+	names = append(names, "")
+	for _, r := range results {
+		known := false
+		for _, name := range names {
+			fp := Fingerprint(name, r)
+			if seen[fp] < b.fingerprints[fp] {
+				seen[fp]++
+				known = true
+				break
+			}
+		}
+		if known {
+			knownResults = append(knownResults, r)
+			continue
+		}
+		newResults = append(newResults, r)
+	}
+	return newResults, knownResults
+}
+
+// Write serializes the baseline as a VacuumReport to the supplied path. Results must have been prepared
+// for serialization (see model.RuleResultSet.PrepareForSerialization) before being added.
+func (b *Baseline) Write(filePath string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	names := make([]string, 0, len(b.results))
+	for name := range b.results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var results []*model.RuleFunctionResult
+	for _, name := range names {
+		results = append(results, b.results[name]...)
+	}
+	vr := VacuumReport{
+		Generated: time.Now(),
+		ResultSet: model.NewRuleResultSetPointer(results),
+		Baseline:  b.specs,
+	}
+	data, err := json.MarshalIndent(vr, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0664)
+}
@@ -0,0 +1,162 @@
+package vacuum_report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	a := &model.RuleFunctionResult{RuleId: "rule", Path: "$.info", Message: "broken"}
+	b := &model.RuleFunctionResult{Rule: &model.Rule{Id: "rule"}, Path: "$.info", Message: "broken"}
+	c := &model.RuleFunctionResult{RuleId: "rule", Path: "$.info", Message: "different"}
+
+	// line numbers do not matter, rule ids can come from the rule.
+	a.Range.Start.Line = 10
+	b.Range.Start.Line = 20
+	assert.Equal(t, Fingerprint("a.yaml", a), Fingerprint("a.yaml", b))
+	assert.Equal(t, Fingerprint("specs/a.yaml", a), Fingerprint("./specs//a.yaml", a))
+	assert.NotEqual(t, Fingerprint("a.yaml", a), Fingerprint("a.yaml", c))
+
+	// the same result, in a different specification.
+	assert.NotEqual(t, Fingerprint("a.yaml", a), Fingerprint("b.yaml", a))
+}
+
+func TestBaseline_Filter(t *testing.T) {
+	known := &model.RuleFunctionResult{RuleId: "rule", Path: "$.info", Message: "broken"}
+	b := NewBaseline()
+	b.Add("a.yaml", []*model.RuleFunctionResult{known})
+
+	results := []*model.RuleFunctionResult{
+		{RuleId: "rule", Path: "$.info", Message: "broken"},
+		{RuleId: "rule", Path: "$.info", Message: "broken"}, // introduced a second time, this one is new.
+		{RuleId: "rule", Path: "$.paths", Message: "broken"},
+	}
+	newResults, knownResults := b.Filter("a.yaml", results)
+	assert.Len(t, knownResults, 1)
+	assert.Len(t, newResults, 2)
+}
+
+func TestBaseline_FilterSpecs(t *testing.T) {
+	b := NewBaseline()
+	b.Add("a.yaml", []*model.RuleFunctionResult{{RuleId: "rule", Path: "$.info", Message: "broken"}})
+
+	// the same result in another specification is new.
+	results := []*model.RuleFunctionResult{{RuleId: "rule", Path: "$.info", Message: "broken"}}
+	newResults, knownResults := b.Filter("b.yaml", results)
+	assert.Len(t, newResults, 1)
+	assert.Len(t, knownResults, 0)
+
+	newResults, _ = b.Filter("a.yaml", results)
+	assert.Len(t, newResults, 0)
+}
+
+func TestBaseline_WriteAndLoad(t *testing.T) {
+	tmp := filepath.Join(t.TempDir(), "baseline.json")
+
+	b := NewBaseline()
+	b.Add("a.yaml", []*model.RuleFunctionResult{
+		{RuleId: "rule", Path: "$.info", Message: "broken"},
+		{RuleId: "another-rule", Path: "$.paths", Message: "also broken"},
+	})
+	b.Add("b.yaml", []*model.RuleFunctionResult{{RuleId: "rule", Path: "$.paths", Message: "broken"}})
+	assert.NoError(t, b.Write(tmp))
+
+	loaded, err := LoadBaseline(tmp, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, loaded.Len())
+
+	newResults, _ := loaded.Filter("a.yaml", []*model.RuleFunctionResult{{RuleId: "rule", Path: "$.info", Message: "broken"}})
+	assert.Len(t, newResults, 0)
+	newResults, _ = loaded.Filter("b.yaml", []*model.RuleFunctionResult{{RuleId: "rule", Path: "$.info", Message: "broken"}})
+	assert.Len(t, newResults, 1)
+}
+
+func TestLoadBaseline_Report(t *testing.T) {
+	tmp := filepath.Join(t.TempDir(), "report.json")
+	data, _ := json.Marshal(VacuumReport{ResultSet: model.NewRuleResultSetPointer([]*model.RuleFunctionResult{
+		{RuleId: "rule", Path: "$.info", Message: "broken"},
+	})})
+	_ = os.WriteFile(tmp, data, 0664)
+
+	// a report has no fingerprints for each specification, so its results are known in any of them.
+	loaded, err := LoadBaseline(tmp, false)
+	assert.NoError(t, err)
+	for _, spec := range []string{"a.yaml", "b.yaml"} {
+		newResults, _ := loaded.Filter(spec, []*model.RuleFunctionResult{{RuleId: "rule", Path: "$.info", Message: "broken"}})
+		assert.Len(t, newResults, 0)
+	}
+}
+
+func TestLoadBaseline_Missing(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "nope.json")
+	_, err := LoadBaseline(missing, false)
+	assert.Error(t, err)
+
+	b, err := LoadBaseline(missing, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, b.Len())
+}
+
+func TestLoadBaseline_NotAReport(t *testing.T) {
+	tmp := filepath.Join(t.TempDir(), "baseline.json")
+	_ = os.WriteFile(tmp, []byte("not a report"), 0664)
+	_, err := LoadBaseline(tmp, false)
+	assert.Error(t, err)
+}
+
+func TestBaseline_RelativeToBaseline(t *testing.T) {
+	dir := t.TempDir()
+	tmp := filepath.Join(dir, "baseline.json")
+	result := []*model.RuleFunctionResult{{RuleId: "rule", Path: "$.info", Message: "broken"}}
+
+	b, err := LoadBaseline(tmp, true)
+	assert.NoError(t, err)
+	b.Add(filepath.Join(dir, "specs", "a.yaml"), result)
+	assert.NoError(t, b.Write(tmp))
+
+	// the same specification, spelled differently, is recorded under the same name.
+	wd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(wd) }()
+	assert.NoError(t, os.Chdir(dir))
+	loaded, err := LoadBaseline("baseline.json", false)
+	assert.NoError(t, err)
+	newResults, _ := loaded.Filter(filepath.Join("specs", "a.yaml"), result)
+	assert.Len(t, newResults, 0)
+	newResults, _ = loaded.Filter(filepath.Join(".", "specs", "..", "specs", "a.yaml"), result)
+	assert.Len(t, newResults, 0)
+	newResults, _ = loaded.Filter("a.yaml", result)
+	assert.Len(t, newResults, 1)
+}
+
+func TestBaseline_UpdateKeepsOtherSpecs(t *testing.T) {
+	tmp := filepath.Join(t.TempDir(), "baseline.json")
+	aResult := &model.RuleFunctionResult{RuleId: "rule", Path: "$.info", Message: "broken"}
+	bResult := &model.RuleFunctionResult{RuleId: "rule", Path: "$.paths", Message: "broken"}
+
+	b, _ := LoadBaseline(tmp, true)
+	b.Add(filepath.Join(filepath.Dir(tmp), "a.yaml"), []*model.RuleFunctionResult{aResult, bResult})
+	assert.NoError(t, b.Write(tmp))
+
+	// updating with only b.yaml keeps the results of a.yaml.
+	b, err := LoadBaseline(tmp, true)
+	assert.NoError(t, err)
+	b.Add(filepath.Join(filepath.Dir(tmp), "b.yaml"), []*model.RuleFunctionResult{bResult})
+	assert.NoError(t, b.Write(tmp))
+
+	loaded, err := LoadBaseline(tmp, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, loaded.Len())
+	newResults, _ := loaded.Filter(filepath.Join(filepath.Dir(tmp), "a.yaml"), []*model.RuleFunctionResult{aResult, bResult})
+	assert.Len(t, newResults, 0)
+
+	// linting a.yaml again replaces its results, instead of adding to them.
+	loaded.Add(filepath.Join(filepath.Dir(tmp), "a.yaml"), []*model.RuleFunctionResult{aResult})
+	assert.Equal(t, 2, loaded.Len())
+	newResults, _ = loaded.Filter(filepath.Join(filepath.Dir(tmp), "a.yaml"), []*model.RuleFunctionResult{aResult, bResult})
+	assert.Len(t, newResults, 1)
+}
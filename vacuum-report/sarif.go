@@ -0,0 +1,319 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package vacuum_report
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/daveshanley/vacuum/model"
+)
+
+const (
+	SarifVersion = "2.1.0"
+	SarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	SarifBaselineNew       = "new"
+	SarifBaselineUnchanged = "unchanged"
+)
+
+// SarifLog is the root of a SARIF 2.1.0 document. Only the parts of the specification vacuum has
+// something to say about are modelled.
+type SarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool    SarifTool      `json:"tool"`
+	Results []*SarifResult `json:"results"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name           string                      `json:"name"`
+	Version        string                      `json:"version,omitempty"`
+	InformationUri string                      `json:"informationUri"`
+	Rules          []*SarifReportingDescriptor `json:"rules"`
+}
+
+// SarifReportingDescriptor describes a rule.
+type SarifReportingDescriptor struct {
+	Id                   string                 `json:"id"`
+	Name                 string                 `json:"name,omitempty"`
+	ShortDescription     *SarifMessage          `json:"shortDescription,omitempty"`
+	Help                 *SarifMessage          `json:"help,omitempty"`
+	HelpUri              string                 `json:"helpUri,omitempty"`
+	DefaultConfiguration *SarifConfiguration    `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+type SarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifResult struct {
+	RuleId              string              `json:"ruleId"`
+	RuleIndex           int                 `json:"ruleIndex"`
+	Level               string              `json:"level"`
+	Message             SarifMessage        `json:"message"`
+	Locations           []*SarifLocation    `json:"locations,omitempty"`
+	PartialFingerprints map[string]string   `json:"partialFingerprints,omitempty"`
+	BaselineState       string              `json:"baselineState,omitempty"`
+	Suppressions        []*SarifSuppression `json:"suppressions,omitempty"`
+}
+
+type SarifLocation struct {
+	PhysicalLocation *SarifPhysicalLocation  `json:"physicalLocation,omitempty"`
+	LogicalLocations []*SarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Region           *SarifRegion          `json:"region,omitempty"`
+}
+
+type SarifArtifactLocation struct {
+	Uri string `json:"uri"`
+}
+
+type SarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type SarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind,omitempty"`
+}
+
+type SarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// SarifReport collects results from one or more linting runs and builds a single SARIF log. It is safe to add
+// results from multiple goroutines.
+type SarifReport struct {
+	rules       map[string]*model.Rule
+	fired       map[string]*model.Rule
+	toolVersion string
+	results     []*SarifResult
+	lock        sync.Mutex
+}
+
+// NewSarifReport creates a new SarifReport. All the rules in the supplied map are described in the log, even if
+// they did not produce any results.
+func NewSarifReport(rules map[string]*model.Rule, toolVersion string) *SarifReport {
+	return &SarifReport{rules: rules, fired: make(map[string]*model.Rule), toolVersion: toolVersion}
+}
+
+// BuildSarifReport is a convenience function that builds a SARIF log for the results of a single specification.
+func BuildSarifReport(resultSet *model.RuleResultSet, suppressed []*model.RuleFunctionResult,
+	rules map[string]*model.Rule, specFileName, toolVersion string) []byte {
+	sr := NewSarifReport(rules, toolVersion)
+	sr.AddResults(specFileName, resultSet.Results, "")
+	sr.AddResults(specFileName, suppressed, "")
+	return sr.Render()
+}
+
+// AddResults converts results for a specification into SARIF results. Results found in other files (via the
+// rolodex) point at the file they were found in. If baselineState is not empty, it is recorded against every result.
+func (s *SarifReport) AddResults(specFileName string, results []*model.RuleFunctionResult, baselineState string) {
+	var converted []*SarifResult
+	for _, r := range results {
+		if r == nil || r.Rule == nil {
+			continue
+		}
+		sr := &SarifResult{
+			RuleId:              r.Rule.Id,
+			Level:               sarifLevel(r.Rule.Severity),
+			Message:             SarifMessage{Text: r.Message},
+			Locations:           []*SarifLocation{sarifLocation(specFileName, r)},
+			PartialFingerprints: map[string]string{"vacuum/v1": Fingerprint(specFileName, r)},
+			BaselineState:       baselineState,
+		}
+		if r.Suppression != nil {
+			sr.Suppressions = []*SarifSuppression{{Kind: "inSource", Justification: r.Suppression.Reason}}
+		}
+		converted = append(converted, sr)
+	}
+	s.lock.Lock()
+	s.results = append(s.results, converted...)
+	for _, r := range results {
+		if r != nil && r.Rule != nil && s.fired[r.Rule.Id] == nil {
+			s.fired[r.Rule.Id] = r.Rule
+		}
+	}
+	s.lock.Unlock()
+}
+
+// Build creates the SARIF log, from everything added so far.
+func (s *SarifReport) Build() *SarifLog {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// rules that fired but are not part of the ruleset (e.g. from a replayed report) are described too.
+	rules := make(map[string]*model.Rule)
+	for id, r := range s.fired {
+		rules[id] = r
+	}
+	for id, r := range s.rules {
+		rules[id] = r
+	}
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	indexes := make(map[string]int)
+	descriptors := make([]*SarifReportingDescriptor, len(ids))
+	for i, id := range ids {
+		indexes[id] = i
+		descriptors[i] = sarifDescriptor(rules[id])
+	}
+	for _, r := range s.results {
+		r.RuleIndex = indexes[r.RuleId]
+	}
+
+	results := s.results
+	if results == nil {
+		results = []*SarifResult{}
+	}
+	return &SarifLog{
+		Schema:  SarifSchema,
+		Version: SarifVersion,
+		Runs: []*SarifRun{{
+			Tool: SarifTool{Driver: SarifDriver{
+				Name:           "vacuum",
+				Version:        s.toolVersion,
+				InformationUri: model.WebsiteUrl,
+				Rules:          descriptors,
+			}},
+			Results: results,
+		}},
+	}
+}
+
+// Render builds the SARIF log and renders it as JSON.
+func (s *SarifReport) Render() []byte {
+	data, _ := json.MarshalIndent(s.Build(), "", "  ")
+	return data
+}
+
+func sarifDescriptor(rule *model.Rule) *SarifReportingDescriptor {
+	d := &SarifReportingDescriptor{Id: rule.Id}
+	if rule.Name != "" {
+		d.Name = rule.Name
+	}
+	if rule.Description != "" {
+		d.ShortDescription = &SarifMessage{Text: rule.Description}
+	}
+	if rule.HowToFix != "" {
+		d.Help = &SarifMessage{Text: rule.HowToFix}
+	}
+	if rule.Severity != "" {
+		d.DefaultConfiguration = &SarifConfiguration{Level: sarifLevel(rule.Severity)}
+	}
+	if rule.RuleCategory != nil {
+		d.HelpUri = fmt.Sprintf("%s/rules/%s/%s", model.WebsiteUrl, rule.RuleCategory.Id,
+			strings.ReplaceAll(rule.Id, "$", ""))
+		d.Properties = map[string]interface{}{
+			"category": rule.RuleCategory.Name,
+			"tags":     []string{rule.RuleCategory.Id},
+		}
+	}
+	return d
+}
+
+func sarifLocation(specFileName string, r *model.RuleFunctionResult) *SarifLocation {
+	file := specFileName
+	if r.Origin != nil && r.Origin.AbsoluteLocation != "" && filepath.Base(r.Origin.AbsoluteLocation) != "root.yaml" {
+		file = r.Origin.AbsoluteLocation
+	}
+	loc := &SarifLocation{
+		PhysicalLocation: &SarifPhysicalLocation{
+			ArtifactLocation: SarifArtifactLocation{Uri: sarifUri(file)},
+		},
+	}
+
+	// prefer the nodes, a replayed report only has a range.
+	region := &SarifRegion{
+		StartLine:   r.Range.Start.Line,
+		StartColumn: r.Range.Start.Char,
+		EndLine:     r.Range.End.Line,
+		EndColumn:   r.Range.End.Char,
+	}
+	if r.StartNode != nil {
+		region.StartLine, region.StartColumn = r.StartNode.Line, r.StartNode.Column
+	}
+	if r.EndNode != nil {
+		region.EndLine, region.EndColumn = r.EndNode.Line, r.EndNode.Column+len(r.EndNode.Value)
+	}
+	if region.StartLine > 0 {
+		if region.EndLine < region.StartLine {
+			region.EndLine, region.EndColumn = region.StartLine, 0
+		}
+		loc.PhysicalLocation.Region = region
+	}
+
+	if r.Path != "" {
+		loc.LogicalLocations = []*SarifLogicalLocation{{FullyQualifiedName: r.Path, Kind: "object"}}
+	}
+	return loc
+}
+
+// sarifUri converts a file name into a URI. Relative paths stay relative (so they can be resolved against the
+// repository root by whoever reads the log), absolute paths become file URIs. Both are percent-encoded.
+func sarifUri(file string) string {
+	switch {
+	case file == "":
+		return "stdin"
+	case strings.Contains(file, "://"):
+		return file
+	}
+	p := filepath.ToSlash(file)
+	if isDrivePath(p) {
+		// a Windows path, the drive letter goes after the (empty) host.
+		return (&url.URL{Scheme: "file", Path: "/" + p}).String()
+	}
+	if filepath.IsAbs(file) {
+		return (&url.URL{Scheme: "file", Path: p}).String()
+	}
+	return (&url.URL{Path: strings.TrimPrefix(p, "./")}).String()
+}
+
+// isDrivePath returns true if a slash separated path starts with a Windows drive letter, like C:/specs.
+func isDrivePath(p string) bool {
+	return len(p) >= 3 && p[1] == ':' && p[2] == '/' &&
+		((p[0] >= 'a' && p[0] <= 'z') || (p[0] >= 'A' && p[0] <= 'Z'))
+}
+
+func sarifLevel(severity string) string {
+	switch severity {
+	case model.SeverityError:
+		return "error"
+	case model.SeverityWarn:
+		return "warning"
+	case model.SeverityInfo, model.SeverityHint:
+		return "note"
+	}
+	return "none"
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package vacuum_report
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/daveshanley/vacuum/model/reports"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSarifReport(t *testing.T) {
+	j := testhelp_generateReport()
+	j.ResultSet.Results[0].Message = "testing, 123"
+	j.ResultSet.Results[0].Path = "$.info.contact"
+
+	suppressed := &model.RuleFunctionResult{
+		Rule:        j.ResultSet.Results[0].Rule,
+		Message:     "ignored",
+		Suppression: &reports.Suppression{Reason: "we know"},
+	}
+	rules := map[string]*model.Rule{
+		"two": {Id: "two", Severity: model.SeverityWarn, Description: "two"},
+	}
+
+	data := BuildSarifReport(j.ResultSet, []*model.RuleFunctionResult{suppressed}, rules, "spec.yaml", "1.2.3")
+
+	var log SarifLog
+	assert.NoError(t, json.Unmarshal(data, &log))
+	assert.Equal(t, SarifVersion, log.Version)
+	assert.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	assert.Equal(t, "vacuum", run.Tool.Driver.Name)
+	assert.Equal(t, "1.2.3", run.Tool.Driver.Version)
+	assert.Len(t, run.Tool.Driver.Rules, 2)
+	assert.Equal(t, "one", run.Tool.Driver.Rules[0].Id)
+	assert.Equal(t, "two", run.Tool.Driver.Rules[1].Id)
+	assert.Equal(t, "warning", run.Tool.Driver.Rules[1].DefaultConfiguration.Level)
+
+	assert.Len(t, run.Results, 2)
+	res := run.Results[0]
+	assert.Equal(t, "one", res.RuleId)
+	assert.Equal(t, 0, res.RuleIndex)
+	assert.Equal(t, "error", res.Level)
+	assert.Equal(t, "testing, 123", res.Message.Text)
+	assert.Equal(t, "spec.yaml", res.Locations[0].PhysicalLocation.ArtifactLocation.Uri)
+	assert.Equal(t, 1, res.Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Equal(t, 10, res.Locations[0].PhysicalLocation.Region.StartColumn)
+	assert.Equal(t, 20, res.Locations[0].PhysicalLocation.Region.EndLine)
+	assert.Equal(t, "$.info.contact", res.Locations[0].LogicalLocations[0].FullyQualifiedName)
+	assert.Equal(t, Fingerprint("spec.yaml", j.ResultSet.Results[0]), res.PartialFingerprints["vacuum/v1"])
+	assert.Empty(t, res.Suppressions)
+
+	assert.Len(t, run.Results[1].Suppressions, 1)
+	assert.Equal(t, "inSource", run.Results[1].Suppressions[0].Kind)
+	assert.Equal(t, "we know", run.Results[1].Suppressions[0].Justification)
+}
+
+func TestSarifReport_NoResults(t *testing.T) {
+	data := NewSarifReport(nil, "").Render()
+	var raw map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	runs := raw["runs"].([]interface{})
+	assert.NotNil(t, runs[0].(map[string]interface{})["results"])
+}
+
+func TestSarifUri(t *testing.T) {
+	assert.Equal(t, "stdin", sarifUri(""))
+	assert.Equal(t, "https://example.com/spec.yaml", sarifUri("https://example.com/spec.yaml"))
+	assert.Equal(t, "file:///tmp/spec.yaml", sarifUri("/tmp/spec.yaml"))
+	assert.Equal(t, "specs/spec.yaml", sarifUri("./specs/spec.yaml"))
+	assert.Equal(t, "file:///tmp/my%20specs/a%23b.yaml", sarifUri("/tmp/my specs/a#b.yaml"))
+	assert.Equal(t, "my%20specs/spec.yaml", sarifUri("my specs/spec.yaml"))
+	assert.Equal(t, "file:///C:/specs/my%20spec.yaml", sarifUri("C:/specs/my spec.yaml"))
+}
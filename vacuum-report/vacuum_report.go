@@ -22,6 +22,12 @@ type VacuumReport struct {
 	SpecInfo   *datamodel.SpecInfo       `json:"specInfo" yaml:"specInfo"`
 	Statistics *reports.ReportStatistics `json:"statistics" yaml:"statistics"`
 	ResultSet  *model.RuleResultSet      `json:"resultSet" yaml:"resultSet"`
+
+	// Suppressed contains all results that were waived by comments or extensions, kept so they can be audited.
+	Suppressed *model.RuleResultSet `json:"suppressedResultSet,omitempty" yaml:"suppressedResultSet,omitempty"`
+
+	// Baseline contains the fingerprints of the results of each specification, when the report is a lint baseline.
+	Baseline map[string][]string `json:"baseline,omitempty" yaml:"baseline,omitempty"`
 }
 
 // BuildVacuumReportFromFile will attempt (at great speed) to read in a file as a Vacuum Report. If successful a pointer
@@ -64,6 +70,12 @@ func BuildVacuumReportFromFile(filePath string) (*VacuumReport, []byte, error) {
 		// go fast!
 		go rebuildNode(res, &wg, rs)
 	}
+	if vr.Suppressed != nil {
+		wg.Add(len(vr.Suppressed.Results))
+		for _, res := range vr.Suppressed.Results {
+			go rebuildNode(res, &wg, rs)
+		}
+	}
 	wg.Wait()
 	return vr, bytes, nil
 }
@@ -0,0 +1,37 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+package watch
+
+import (
+	"github.com/daveshanley/vacuum/model"
+	vacuum_report "github.com/daveshanley/vacuum/vacuum-report"
+)
+
+// Delta compares the results of two runs, and returns the results that are new in the current run, and the
+// results of the previous run that have been fixed. Results are matched using their baseline fingerprint, so a
+// result that only moved to a different line is neither new nor fixed.
+func Delta(previous, current []*model.RuleFunctionResult) (added, fixed []*model.RuleFunctionResult) {
+	seen := make(map[string]int, len(previous))
+	for _, r := range previous {
+		seen[vacuum_report.Fingerprint("", r)]++
+	}
+	for _, r := range current {
+		fp := vacuum_report.Fingerprint("", r)
+		if seen[fp] > 0 {
+			seen[fp]--
+			continue
+		}
+		added = append(added, r)
+	}
+
+	// whatever has not been matched by the current run, has been fixed.
+	for _, r := range previous {
+		fp := vacuum_report.Fingerprint("", r)
+		if seen[fp] > 0 {
+			seen[fp]--
+			fixed = append(fixed, r)
+		}
+	}
+	return added, fixed
+}
@@ -0,0 +1,45 @@
+package watch
+
+import (
+	"testing"
+
+	"github.com/daveshanley/vacuum/model"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func result(ruleId, path, message string, line int) *model.RuleFunctionResult {
+	return &model.RuleFunctionResult{
+		RuleId:    ruleId,
+		Path:      path,
+		Message:   message,
+		StartNode: &yaml.Node{Line: line},
+	}
+}
+
+func TestDelta(t *testing.T) {
+	previous := []*model.RuleFunctionResult{
+		result("a", "$.info", "no description", 2),
+		result("b", "$.paths", "no paths", 10),
+		result("c", "$.tags", "no tags", 20),
+		result("c", "$.tags", "no tags", 21),
+	}
+	current := []*model.RuleFunctionResult{
+		result("a", "$.info", "no description", 5), // moved, not new.
+		result("c", "$.tags", "no tags", 20),
+		result("d", "$.servers", "no servers", 30),
+	}
+
+	added, fixed := Delta(previous, current)
+	assert.Len(t, added, 1)
+	assert.Equal(t, "d", added[0].RuleId)
+	assert.Len(t, fixed, 2)
+	assert.Equal(t, "b", fixed[0].RuleId)
+	assert.Equal(t, "c", fixed[1].RuleId)
+}
+
+func TestDelta_FirstRun(t *testing.T) {
+	added, fixed := Delta(nil, []*model.RuleFunctionResult{result("a", "$", "oops", 1)})
+	assert.Len(t, added, 1)
+	assert.Empty(t, fixed)
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 Princess B33f Heavy Industries / Dave Shanley
+// SPDX-License-Identifier: MIT
+
+// Package watch re-runs work when files on disk change. It's used by the --watch mode of the lint, html-report
+// and dashboard commands, to re-lint a specification (and every file it references) as it's being edited.
+package watch
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is how long the watcher waits for things to settle after a change, editors often write several
+// files (or the same file several times) when saving.
+const DefaultDebounce = 300 * time.Millisecond
+
+// Watcher watches a set of files and directories, and reports changes to them in debounced batches.
+type Watcher struct {
+	debounce time.Duration
+	fsw      *fsnotify.Watcher
+	files    map[string]bool // files that trigger a change.
+	dirs     map[string]bool // directories where any file triggers a change.
+	watched  map[string]bool // directories registered with fsnotify.
+	lock     sync.Mutex
+}
+
+// NewWatcher creates a new Watcher, changes are reported once nothing has changed for the debounce duration.
+func NewWatcher(debounce time.Duration) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	return &Watcher{
+		debounce: debounce,
+		fsw:      fsw,
+		files:    make(map[string]bool),
+		dirs:     make(map[string]bool),
+		watched:  make(map[string]bool),
+	}, nil
+}
+
+// Watch replaces everything being watched with the supplied files and directories. It can be called at any
+// time, for example when a specification starts referencing new files. Files are watched through their
+// directory, so files replaced by editors (instead of written to) are still picked up.
+func (w *Watcher) Watch(files, dirs []string) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.files = make(map[string]bool, len(files))
+	w.dirs = make(map[string]bool, len(dirs))
+	needed := make(map[string]bool)
+	for _, f := range files {
+		if f == "" {
+			continue
+		}
+		abs := absolute(f)
+		w.files[abs] = true
+		needed[filepath.Dir(abs)] = true
+	}
+	for _, d := range dirs {
+		if d == "" {
+			continue
+		}
+		abs := absolute(d)
+		w.dirs[abs] = true
+		needed[abs] = true
+	}
+
+	for dir := range w.watched {
+		if !needed[dir] {
+			_ = w.fsw.Remove(dir)
+			delete(w.watched, dir)
+		}
+	}
+	for dir := range needed {
+		if w.watched[dir] {
+			continue
+		}
+		if err := w.fsw.Add(dir); err != nil {
+			return err
+		}
+		w.watched[dir] = true
+	}
+	return nil
+}
+
+// Run waits for changes and calls onChange with the (sorted) files that changed, until the context is done or
+// the watcher is closed. onChange is called synchronously, anything that changes while it runs is reported in
+// the next batch.
+func (w *Watcher) Run(ctx context.Context, onChange func(changed []string)) error {
+	pending := make(map[string]bool)
+	var timer *time.Timer
+	var fire <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil
+
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			if !w.matches(event) {
+				continue
+			}
+			pending[absolute(event.Name)] = true
+			if timer == nil {
+				timer = time.NewTimer(w.debounce)
+			} else {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(w.debounce)
+			}
+			fire = timer.C
+
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+
+		case <-fire:
+			fire = nil
+			changed := make([]string, 0, len(pending))
+			for f := range pending {
+				changed = append(changed, f)
+			}
+			sort.Strings(changed)
+			pending = make(map[string]bool)
+			onChange(changed)
+		}
+	}
+}
+
+// Close stops watching everything.
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
+
+func (w *Watcher) matches(event fsnotify.Event) bool {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) &&
+		!event.Has(fsnotify.Rename) && !event.Has(fsnotify.Remove) {
+		return false
+	}
+	abs := absolute(event.Name)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.files[abs] || w.dirs[filepath.Dir(abs)]
+}
+
+func absolute(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
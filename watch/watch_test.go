@@ -0,0 +1,65 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcher_Run(t *testing.T) {
+	dir := t.TempDir()
+	functionsDir := filepath.Join(dir, "functions")
+	assert.NoError(t, os.Mkdir(functionsDir, 0755))
+
+	spec := filepath.Join(dir, "spec.yaml")
+	ignored := filepath.Join(dir, "notes.txt")
+	assert.NoError(t, os.WriteFile(spec, []byte("openapi: 3.1.0"), 0644))
+
+	w, err := NewWatcher(50 * time.Millisecond)
+	assert.NoError(t, err)
+	defer w.Close()
+	assert.NoError(t, w.Watch([]string{spec}, []string{functionsDir}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	batches := make(chan []string, 10)
+	go func() {
+		_ = w.Run(ctx, func(changed []string) {
+			batches <- changed
+		})
+	}()
+
+	// several writes in a row are debounced into a single batch, files not watched are ignored.
+	assert.NoError(t, os.WriteFile(ignored, []byte("nope"), 0644))
+	assert.NoError(t, os.WriteFile(spec, []byte("openapi: 3.1.1"), 0644))
+	assert.NoError(t, os.WriteFile(spec, []byte("openapi: 3.1.2"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(functionsDir, "check.js"), []byte("//"), 0644))
+
+	select {
+	case changed := <-batches:
+		assert.Equal(t, []string{filepath.Join(functionsDir, "check.js"), spec}, changed)
+	case <-ctx.Done():
+		t.Fatal("no changes reported")
+	}
+
+	// the spec is no longer watched.
+	assert.NoError(t, w.Watch(nil, []string{functionsDir}))
+	assert.NoError(t, os.WriteFile(spec, []byte("openapi: 3.1.3"), 0644))
+	select {
+	case changed := <-batches:
+		t.Fatalf("unexpected changes: %v", changed)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestWatcher_Watch_Missing(t *testing.T) {
+	w, err := NewWatcher(0)
+	assert.NoError(t, err)
+	defer w.Close()
+	assert.Error(t, w.Watch(nil, []string{filepath.Join(t.TempDir(), "nope")}))
+}